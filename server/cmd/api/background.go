@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+// background runs fn in a goroutine tracked by app.wg. The context passed to
+// fn is cancelled when the server shuts down; serve() then waits for every
+// background task to return. Panics are logged instead of crashing the
+// process.
+func (app *application) background(name string, fn func(ctx context.Context)) {
+	app.bgOnce.Do(app.initBackground)
+
+	app.wg.Add(1)
+	go func() {
+		defer app.wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Errorj(tlog.JSON{"message": "background task panicked", "task": name, "error": fmt.Sprintf("%v", err)})
+			}
+		}()
+
+		fn(app.bgCtx)
+	}()
+}
+
+// every runs fn at each interval until shutdown. Errors are logged and the
+// next tick runs as usual.
+func (app *application) every(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	app.background(name, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					app.logger.Errorj(tlog.JSON{"message": "background task failed", "task": name, "error": err})
+				}
+			}
+		}
+	})
+}
+
+// stopBackground cancels the context of every background task.
+func (app *application) stopBackground() {
+	app.bgOnce.Do(app.initBackground)
+	app.bgCancel()
+}
+
+func (app *application) initBackground() {
+	app.bgCtx, app.bgCancel = context.WithCancel(context.Background())
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackground(t *testing.T) {
+	t.Run("stops tasks and waits for them", func(t *testing.T) {
+		app := newTestApplication(t)
+		var stopped atomic.Bool
+
+		app.background("wait", func(ctx context.Context) {
+			<-ctx.Done()
+			stopped.Store(true)
+		})
+		app.stopBackground()
+		app.wg.Wait()
+
+		assert.True(t, stopped.Load())
+	})
+
+	t.Run("recovers panics", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		app.background("panic", func(ctx context.Context) { panic("boom") })
+		app.wg.Wait()
+	})
+
+	t.Run("runs periodic tasks until shutdown", func(t *testing.T) {
+		app := newTestApplication(t)
+		var runs atomic.Int32
+
+		app.every("tick", time.Millisecond, func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+		assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+
+		app.stopBackground()
+		app.wg.Wait()
+	})
+}
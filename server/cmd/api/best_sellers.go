@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/featured"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+// salesSettleTime is how long a delivered order may take to commit before
+// the sales rollup reads past it.
+const salesSettleTime = time.Minute
+
+// BestSellerProduct is a product ranked by units delivered.
+type BestSellerProduct struct {
+	Rank    int           `json:"rank"`
+	Sold    int           `json:"sold" doc:"Units delivered in the window"`
+	Product PricedProduct `json:"product"`
+}
+
+// bestSellerWindows maps a window to the days it spans, counting today.
+// All time has none.
+var bestSellerWindows = map[string]int{
+	"7d":  7,
+	"30d": 30,
+	"all": 0,
+}
+
+// startSalesRollup adds newly delivered orders to the best-seller rollup
+// every minute until shutdown.
+func (app *application) startSalesRollup() {
+	app.every("sales rollup", time.Minute, func(ctx context.Context) error {
+		added, err := app.models.Sales.Refresh(time.Now().Add(-salesSettleTime))
+		if err != nil {
+			return err
+		}
+		if added > 0 {
+			app.logger.Debugj(tlog.JSON{"message": "rolled up delivered orders", "count": added})
+		}
+		return nil
+	})
+}
+
+// bestSellersFrom returns the first date of window in Asia/Jakarta, or an
+// empty string for all time.
+func bestSellersFrom(window string, now time.Time) (string, error) {
+	days := bestSellerWindows[window]
+	if days == 0 {
+		return "", nil
+	}
+	return featured.AddDays(featured.Day(now), 1-days)
+}
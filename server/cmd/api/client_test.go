@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/featured"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+	"github.com/ucok-man/mayobox-server/pkg/client"
+)
+
+// TestClient runs pkg/client against the real routes to catch drift
+// between the handlers and the client types.
+func TestClient(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.Compression.Enabled = true
+	srv := httptest.NewServer(app.routes())
+	defer srv.Close()
+
+	c, err := client.New(srv.URL, client.WithAdminToken(testAdminToken))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("healthcheck", func(t *testing.T) {
+		health, err := c.Healthcheck(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, "available", health.Status)
+		assert.Equal(t, VERSION, health.SystemInfo.Version)
+	})
+
+	t.Run("testimonies", func(t *testing.T) {
+		page, err := c.ListTestimonies(ctx, client.ListParams{Page: 1, PageSize: 2})
+		require.NoError(t, err)
+		assert.Len(t, page.Data, 2)
+		assert.Equal(t, 5, page.Metadata.TotalRecords)
+		assert.NotEmpty(t, page.Data[0].User.Username)
+
+		count := 0
+		for _, err := range c.Testimonies(ctx, 2) {
+			require.NoError(t, err)
+			count++
+		}
+		assert.Equal(t, 5, count)
+	})
+
+	t.Run("testimonies validation error", func(t *testing.T) {
+		_, err := c.ListTestimonies(ctx, client.ListParams{PageSize: 1000})
+
+		require.True(t, client.IsValidation(err), err)
+		assert.True(t, client.IsCode(err, string(apperror.CodeValidationFailed)))
+		var apiErr *client.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Contains(t, apiErr.Details, "pagination.pagesize")
+	})
+
+	t.Run("faqs", func(t *testing.T) {
+		faqs, err := c.ListFAQs(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, faqs, 5)
+		assert.Len(t, faqs[0].Answers, 2)
+	})
+
+	t.Run("pricing", func(t *testing.T) {
+		quote, err := c.Quote(ctx, 1053)
+		require.NoError(t, err)
+		assert.Equal(t, "Rp144.261", quote.Total.Display)
+		assert.Equal(t, 1505, quote.GamepassPrice)
+
+		_, err = c.Quote(ctx, 0)
+		assert.True(t, client.IsValidation(err), err)
+	})
+
+	t.Run("featured products", func(t *testing.T) {
+		entries, err := c.SetFeaturedCalendar(ctx, featured.Day(time.Now()), []string{"990e8400-e29b-41d4-a716-446655440006"})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		calendar, err := c.FeaturedCalendar(ctx, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, entries, calendar)
+
+		products, err := c.FeaturedProducts(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, products)
+		assert.Equal(t, "990e8400-e29b-41d4-a716-446655440006", products[0].Product.ID)
+		assert.Equal(t, "calendar", products[0].Source)
+		assert.Equal(t, products[0].Product.Robux, products[0].Product.Price.Robux)
+
+		_, err = c.SetFeaturedCalendar(ctx, "tomorrow", nil)
+		assert.True(t, client.IsValidation(err), err)
+	})
+
+	t.Run("best sellers", func(t *testing.T) {
+		sellers, err := c.BestSellers(ctx, "7d", 5)
+		require.NoError(t, err)
+		assert.Empty(t, sellers)
+
+		_, err = c.BestSellers(ctx, "1y", 0)
+		assert.True(t, client.IsValidation(err), err)
+	})
+
+	t.Run("recent orders", func(t *testing.T) {
+		orders, err := c.RecentOrders(ctx, 5)
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+
+		_, err = c.RecentOrders(ctx, 100)
+		assert.True(t, client.IsValidation(err), err)
+	})
+
+	t.Run("orders and payments", func(t *testing.T) {
+		order, err := c.CreateOrder(ctx, client.NewOrder{
+			ProductID:      "990e8400-e29b-41d4-a716-446655440003",
+			RobloxUsername: "builderman",
+			PaymentMethod:  "va_mandiri",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "pending_payment", order.Status)
+		require.NotNil(t, order.Payment)
+		assert.Equal(t, "mandiri", order.Payment.Instructions.Bank)
+
+		event, err := c.SimulatePayment(ctx, order.Payment.ChargeID, "paid")
+		require.NoError(t, err)
+		assert.True(t, event.Applied)
+
+		order, err = c.Order(ctx, order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "paid", order.Status)
+		assert.Equal(t, "1053 Robux", order.Product.Name)
+
+		_, err = c.SimulatePayment(ctx, order.Payment.ChargeID, "failed")
+		assert.True(t, client.IsValidation(err), err)
+
+		_, err = c.Order(ctx, "aa0e8400-e29b-41d4-a716-446655449999")
+		assert.True(t, client.IsNotFound(err), err)
+	})
+
+	t.Run("admin orders", func(t *testing.T) {
+		order, err := c.CreateOrder(ctx, client.NewOrder{
+			ProductID:      "990e8400-e29b-41d4-a716-446655440003",
+			RobloxUsername: "builderman",
+			PaymentMethod:  "qris",
+		})
+		require.NoError(t, err)
+		_, err = c.SimulatePayment(ctx, order.Payment.ChargeID, "paid")
+		require.NoError(t, err)
+
+		paid, err := c.AdminOrders(ctx, "paid", 100)
+		require.NoError(t, err)
+		assert.True(t, slices.ContainsFunc(paid, func(o client.AdminOrder) bool { return o.ID == order.ID }))
+
+		detail, err := c.AdminOrder(ctx, order.ID)
+		require.NoError(t, err)
+		require.Len(t, detail.Transitions, 2)
+		assert.Equal(t, "payment", detail.Transitions[1].Actor)
+		assert.Contains(t, detail.Next, "delivered")
+
+		detail, err = c.RetryOrder(ctx, order.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, "paid", detail.Status)
+
+		detail, err = c.ResolveOrder(ctx, order.ID, client.OrderResolution{Status: "delivered", Reason: "sent by hand", Reference: "gp-42"})
+		require.NoError(t, err)
+		assert.Equal(t, "delivered", detail.Status)
+		assert.Equal(t, "gp-42", *detail.Delivery.Reference)
+		assert.Empty(t, detail.Next)
+
+		_, err = c.ResolveOrder(ctx, order.ID, client.OrderResolution{Status: "refunded", Reason: "changed their mind"})
+		assert.True(t, client.IsCode(err, "invalid_order_transition"), err)
+	})
+
+	t.Run("supplier accounts", func(t *testing.T) {
+		account, err := c.CreateSupplierAccount(ctx, client.SupplierAccountChange{Name: "Backup", MaxConcurrency: utility.SetPtrValue(3)})
+		require.NoError(t, err)
+		assert.Equal(t, 3, account.MaxConcurrency)
+		assert.True(t, account.Active)
+
+		account, err = c.UpdateSupplierAccount(ctx, account.ID, client.SupplierAccountChange{Active: utility.SetPtrValue(false)})
+		require.NoError(t, err)
+		assert.Equal(t, "Backup", account.Name)
+		assert.False(t, account.Active)
+
+		accounts, err := c.SupplierAccounts(ctx)
+		require.NoError(t, err)
+		assert.True(t, slices.ContainsFunc(accounts, func(a client.SupplierAccount) bool { return a.ID == account.ID }))
+
+		_, err = c.UpdateSupplierAccount(ctx, "aa0e8400-e29b-41d4-a716-446655449999", client.SupplierAccountChange{Name: "Gone"})
+		assert.True(t, client.IsCode(err, "resource_not_found"), err)
+
+		letters, err := c.DeadLetters(ctx, true, 10)
+		require.NoError(t, err)
+		assert.Empty(t, letters)
+	})
+
+	t.Run("stock", func(t *testing.T) {
+		account, err := c.CreateSupplierAccount(ctx, client.SupplierAccountChange{Name: "Reserve"})
+		require.NoError(t, err)
+
+		entry, err := c.PurchaseStock(ctx, account.ID, 5000, "top up")
+		require.NoError(t, err)
+		assert.Equal(t, "purchase", entry.Kind)
+
+		rec, err := c.ReconcileStock(ctx, account.ID, 4800, "counted at Roblox")
+		require.NoError(t, err)
+		assert.Equal(t, -200, rec.Difference)
+		require.NotNil(t, rec.Entry)
+		assert.Equal(t, 4800, rec.Balance.Available)
+
+		entries, err := c.StockLedger(ctx, account.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "adjustment", entries[0].Kind)
+
+		balances, err := c.StockBalances(ctx)
+		require.NoError(t, err)
+		assert.True(t, slices.ContainsFunc(balances, func(b client.StockBalance) bool { return b.SupplierAccountID == account.ID && b.OnHand == 4800 }))
+	})
+
+	t.Run("vouchers", func(t *testing.T) {
+		voucher, err := c.CreateVoucher(ctx, client.VoucherChange{Code: "rp5k", AmountOffIDR: utility.SetPtrValue[int64](5000), UsageLimit: utility.SetPtrValue(1)})
+		require.NoError(t, err)
+		assert.Equal(t, "RP5K", voucher.Code)
+
+		quote, err := c.ValidateVoucher(ctx, client.VoucherCheck{Code: "RP5K", ProductID: "990e8400-e29b-41d4-a716-446655440003"})
+		require.NoError(t, err)
+		assert.Equal(t, "Rp139.261", quote.TotalIDR.Display)
+
+		order, err := c.CreateOrder(ctx, client.NewOrder{
+			ProductID:      "990e8400-e29b-41d4-a716-446655440003",
+			RobloxUsername: "builderman",
+			PaymentMethod:  "qris",
+			VoucherCode:    "rp5k",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Rp5.000", order.DiscountIDR.Display)
+		assert.Equal(t, voucher.ID, *order.VoucherID)
+
+		_, err = c.ValidateVoucher(ctx, client.VoucherCheck{Code: "RP5K", ProductID: "990e8400-e29b-41d4-a716-446655440003"})
+		assert.True(t, client.IsCode(err, string(apperror.CodeInvalidVoucher)), err)
+
+		voucher, err = c.UpdateVoucher(ctx, voucher.ID, client.VoucherChange{Code: "RP5K", AmountOffIDR: utility.SetPtrValue[int64](5000), Active: utility.SetPtrValue(false)})
+		require.NoError(t, err)
+		assert.False(t, voucher.Active)
+		assert.Nil(t, voucher.UsageLimit)
+
+		vouchers, err := c.Vouchers(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, vouchers)
+		assert.Equal(t, 1, vouchers[0].Redemptions)
+	})
+
+	t.Run("flash sales", func(t *testing.T) {
+		now := time.Now().In(utility.Jakarta)
+		change := client.FlashSaleChange{
+			Name:     "Payday",
+			StartsAt: now.Add(-time.Hour).Format("2006-01-02T15:04"),
+			EndsAt:   now.Add(time.Hour).Format("2006-01-02T15:04"),
+			Items:    []client.FlashSaleItemChange{{ProductID: "990e8400-e29b-41d4-a716-446655440007", PriceIDR: 50000, Quantity: 1}},
+		}
+		sale, err := c.CreateFlashSale(ctx, change)
+		require.NoError(t, err)
+		require.Len(t, sale.Items, 1)
+
+		active, err := c.ActiveFlashSales(ctx)
+		require.NoError(t, err)
+		require.Len(t, active.Sales, 1)
+		assert.Equal(t, "Rp50.000", active.Sales[0].Items[0].PriceIDR.Display)
+		assert.Equal(t, 1, active.Sales[0].Items[0].Remaining)
+
+		order, err := c.CreateOrder(ctx, client.NewOrder{
+			ProductID:      "990e8400-e29b-41d4-a716-446655440007",
+			RobloxUsername: "builderman",
+			PaymentMethod:  "qris",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Rp50.000", order.TotalIDR.Display)
+		assert.Equal(t, sale.Items[0].ID, *order.FlashSaleItemID)
+
+		change.Active = utility.SetPtrValue(false)
+		sale, err = c.UpdateFlashSale(ctx, sale.ID, change)
+		require.NoError(t, err)
+		assert.False(t, sale.Active)
+		assert.Equal(t, 1, sale.Items[0].Sold)
+
+		sales, err := c.FlashSales(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, sales)
+		assert.Equal(t, sale.ID, sales[0].ID)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		level, err := c.SetLogLevel(ctx, "warn")
+		require.NoError(t, err)
+		assert.Equal(t, "warn", level)
+
+		level, err = c.LogLevel(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "warn", level)
+
+		_, err = c.RecentLogs(ctx, 5)
+		require.NoError(t, err)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		var buf strings.Builder
+		err := c.ExportTestimonies(ctx, "csv", &buf)
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(buf.String(), strings.Join(testimoniCSVHeader, ",")+"\n"))
+		assert.Equal(t, 6, strings.Count(buf.String(), "\n"))
+
+		err = c.ExportTestimonies(ctx, "xml", &buf)
+		assert.True(t, client.IsValidation(err), err)
+	})
+
+	t.Run("admin without token", func(t *testing.T) {
+		anon, err := client.New(srv.URL)
+		require.NoError(t, err)
+
+		_, err = anon.LogLevel(ctx)
+
+		assert.True(t, client.IsUnauthorized(err))
+		assert.True(t, client.IsCode(err, string(apperror.CodeInvalidAuthToken)))
+	})
+}
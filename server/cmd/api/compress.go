@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+)
+
+// Content codings withCompression offers, preferred first when a client
+// weights them the same.
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+var supportedEncodings = []string{encodingBrotli, encodingGzip}
+
+// encoder is the part of gzip.Writer and brotli.Writer compressWriter uses.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// incompressibleTypes are already compressed, compressing would only cost
+// CPU.
+var incompressibleTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/zstd",
+}
+
+// withCompression compresses responses with brotli or gzip, whichever the
+// client weights higher in Accept-Encoding. Bodies are held back until they
+// reach the configured minimum size, smaller ones are sent as is. A handler
+// that flushes before reaching it (a streaming export) is compressed right
+// away.
+func (app *application) withCompression() echo.MiddlewareFunc {
+	cfg := app.config.Compression
+	pools := map[string]*sync.Pool{
+		encodingBrotli: {
+			New: func() any {
+				return brotli.NewWriterLevel(nil, cfg.BrotliLevel)
+			},
+		},
+		encodingGzip: {
+			New: func() any {
+				w, _ := gzip.NewWriterLevel(nil, cfg.Level)
+				return w
+			},
+		},
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if !cfg.Enabled {
+				return next(ctx)
+			}
+
+			res := ctx.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			encoding := negotiateEncoding(ctx.Request().Header.Get(echo.HeaderAcceptEncoding))
+			if ctx.Request().Method == http.MethodHead || encoding == "" {
+				return next(ctx)
+			}
+
+			writer := &compressWriter{ResponseWriter: res.Writer, minSize: cfg.MinSize, encoding: encoding, pool: pools[encoding]}
+			res.Writer = writer
+			// Not deferred: a handler aborting with a panic must not get a
+			// valid stream trailer appended to its truncated body.
+			err := next(ctx)
+			if err != nil {
+				// Write the error now so it is compressed as well.
+				ctx.Error(err)
+			}
+			res.Writer = writer.ResponseWriter
+			return writer.Close()
+		}
+	}
+}
+
+// negotiateEncoding returns the supported content coding the client
+// weights highest in acceptEncoding, empty when it accepts none of them. A
+// coding without a q-value weighs 1, one not listed takes the weight of
+// "*", and ties go to the order of supportedEncodings.
+func negotiateEncoding(acceptEncoding string) string {
+	weights := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		weight := 1.0
+		if _, q, found := strings.Cut(strings.ReplaceAll(params, " ", ""), "q="); found {
+			var err error
+			if weight, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		weights[coding] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, coding := range supportedEncodings {
+		weight, ok := weights[coding]
+		if !ok {
+			weight = weights["*"]
+		}
+		if weight > bestWeight {
+			best, bestWeight = coding, weight
+		}
+	}
+	return best
+}
+
+func isCompressible(header http.Header) bool {
+	if header.Get(echo.HeaderContentEncoding) != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get(echo.HeaderContentType))
+	if err != nil {
+		// Without a Content-Type net/http sniffs the body, which
+		// compression would defeat.
+		return false
+	}
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// compressWriter buffers the start of the body until it knows whether the
+// response is worth compressing.
+type compressWriter struct {
+	http.ResponseWriter
+	minSize  int
+	encoding string
+	pool     *sync.Pool
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	enc     encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() < w.minSize {
+			return len(b), nil
+		}
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered body, compressing it regardless of its size
+// since more is about to follow.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if err := w.start(true); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close sends what is still buffered and finishes the compressed stream.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 {
+			return nil
+		}
+		return w.start(false)
+	}
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	w.pool.Put(w.enc)
+	w.enc = nil
+	return err
+}
+
+// start writes the header and the buffered body, compressed when compress
+// is set and the response allows it.
+func (w *compressWriter) start(compress bool) error {
+	w.decided = true
+
+	header := w.Header()
+	bodyless := w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified
+	if compress && !bodyless && isCompressible(header) {
+		header.Set(echo.HeaderContentEncoding, w.encoding)
+		header.Del(echo.HeaderContentLength)
+		// The body differs from the uncompressed one the ETag was computed
+		// from, so the ETag can only be a weak validator.
+		if etag := header.Get(headerETag); strings.HasPrefix(etag, `"`) {
+			header.Set(headerETag, "W/"+etag)
+		}
+
+		w.enc = w.pool.Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
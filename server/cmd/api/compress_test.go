@@ -0,0 +1,165 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCompression(t *testing.T) {
+	newApp := func(t *testing.T, minSize int) *application {
+		app := newTestApplication(t)
+		app.config.Compression.Enabled = true
+		app.config.Compression.MinSize = minSize
+		app.config.Compression.Level = gzip.DefaultCompression
+		return app
+	}
+	gzipHeader := func() http.Header {
+		return http.Header{echo.HeaderAcceptEncoding: {"br;q=0.5, gzip;q=0.8"}}
+	}
+	gunzip := func(t *testing.T, body io.Reader) string {
+		t.Helper()
+		gz, err := gzip.NewReader(body)
+		require.NoError(t, err)
+		b, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	t.Run("compresses responses above the minimum size", func(t *testing.T) {
+		app := newApp(t, 100)
+		plain := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", nil)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", gzipHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), echo.HeaderAcceptEncoding)
+		assert.Less(t, rec.Body.Len(), plain.Body.Len())
+		assert.Equal(t, plain.Body.String(), gunzip(t, rec.Body))
+	})
+
+	t.Run("sends small responses as is", func(t *testing.T) {
+		app := newApp(t, 1<<20)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/", "", gzipHeader())
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Contains(t, rec.Body.String(), "available")
+	})
+
+	t.Run("compresses with brotli when preferred", func(t *testing.T) {
+		app := newApp(t, 100)
+		plain := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", nil)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", http.Header{echo.HeaderAcceptEncoding: {"gzip, br"}})
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "br", rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Less(t, rec.Body.Len(), plain.Body.Len())
+		b, err := io.ReadAll(brotli.NewReader(rec.Body))
+		require.NoError(t, err)
+		assert.Equal(t, plain.Body.String(), string(b))
+	})
+
+	t.Run("respects clients that accept neither", func(t *testing.T) {
+		app := newApp(t, 0)
+
+		for _, acceptEncoding := range []string{"", "deflate", "gzip;q=0", "identity", "*, br;q=0, gzip;q=0"} {
+			rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", http.Header{echo.HeaderAcceptEncoding: {acceptEncoding}})
+
+			assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding), acceptEncoding)
+			assert.Contains(t, rec.Header().Values(echo.HeaderVary), echo.HeaderAcceptEncoding)
+		}
+	})
+
+	t.Run("weakens the etag and still answers 304", func(t *testing.T) {
+		app := newApp(t, 0)
+		handler := app.routes()
+
+		rec := testRequest(t, handler, http.MethodGet, "/v1/testimonies", "", gzipHeader())
+		etag := rec.Header().Get(headerETag)
+		require.True(t, strings.HasPrefix(etag, `W/"`), etag)
+
+		header := gzipHeader()
+		header.Set(headerIfNoneMatch, etag)
+		rec = testRequest(t, handler, http.MethodGet, "/v1/testimonies", "", header)
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("compresses error responses", func(t *testing.T) {
+		app := newApp(t, 0)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/logs?limit=0", "", mergeHeaders(adminHeader(), gzipHeader()))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Contains(t, gunzip(t, rec.Body), "limit")
+	})
+
+	t.Run("skips already compressed content", func(t *testing.T) {
+		app := newApp(t, 0)
+		ec := echo.New()
+		ec.Use(app.withCompression())
+		ec.GET("/image", func(ctx echo.Context) error {
+			return ctx.Blob(http.StatusOK, "image/png", []byte(strings.Repeat("x", 4096)))
+		})
+
+		rec := testRequest(t, ec, http.MethodGet, "/image", "", gzipHeader())
+
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Equal(t, 4096, rec.Body.Len())
+	})
+
+	t.Run("does nothing when disabled", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", gzipHeader())
+
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+		assert.NotContains(t, rec.Header().Values(echo.HeaderVary), echo.HeaderAcceptEncoding)
+	})
+}
+
+func mergeHeaders(headers ...http.Header) http.Header {
+	merged := http.Header{}
+	for _, h := range headers {
+		for k, v := range h {
+			merged[k] = append(merged[k], v...)
+		}
+	}
+	return merged
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	for header, want := range map[string]string{
+		"gzip":                 "gzip",
+		"GZIP, deflate":        "gzip",
+		"deflate, gzip;q=.5":   "gzip",
+		"br":                   "br",
+		"gzip, br":             "br",
+		"br;q=0.5, gzip":       "gzip",
+		"br;q=0.9, gzip;q=0.8": "br",
+		"*":                    "br",
+		"*;q=0.5, gzip":        "gzip",
+		"*, br;q=0":            "gzip",
+		"br;q=bad, gzip;q=0.1": "gzip",
+		"gzip; q=0":            "",
+		"gzip;q=0.0, br;q=0":   "",
+		"deflate":              "",
+		"":                     "",
+	} {
+		assert.Equal(t, want, negotiateEncoding(header), header)
+	}
+}
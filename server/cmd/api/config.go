@@ -1,18 +1,26 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/ucok-man/mayobox-server/internal/validator"
+	"go.yaml.in/yaml/v3"
 )
 
+const envPrefix = "MAYOBOX"
+
 func init() {
-	env := os.Getenv("TCSA_ENV")
+	env := os.Getenv(envPrefix + "_ENV")
 	if env == "" {
 		env = "development"
 	}
@@ -21,36 +29,184 @@ func init() {
 	godotenv.Load()
 }
 
+// Config values are resolved with the following precedence (highest first):
+//
+//  1. command line flags
+//  2. MAYOBOX_* environment variables (including .env.<env> and .env files)
+//  3. the YAML/TOML config file given by --config or found in the search path
+//  4. flag defaults
+//
+// Fields tagged with secret:"true" are redacted by --print-config.
 type Config struct {
 	Port     uint   `mapstructure:"PORT" validate:"required,port"`
 	Env      string `mapstructure:"ENV" validate:"required,oneof=development staging production"`
 	Database struct {
-		Dsn         string        `mapstructure:"DB_DSN" validate:"required,url"`
+		Storage     string        `mapstructure:"STORAGE" validate:"required,oneof=postgres memory"`
+		Dsn         string        `mapstructure:"DB_DSN" validate:"required_if=Storage postgres,omitempty,url" secret:"true"`
 		MaxOpenConn int           `mapstructure:"DB_MAX_OPEN_CONN" validate:"required,min=1,max=100"`
 		MaxIdleConn int           `mapstructure:"DB_MAX_IDLE_CONN" validate:"required,min=1,max=100"`
 		MaxIdleTime time.Duration `mapstructure:"DB_MAX_IDLE_TIME" validate:"required,min=1s"`
 	} `mapstructure:",squash"`
 	Log struct {
-		Level string `mapstructure:"LOG_LEVEL" validate:"required,oneof=debug info warn error"`
+		Level            string        `mapstructure:"LOG_LEVEL" validate:"required,oneof=debug info warn error"`
+		Format           string        `mapstructure:"LOG_FORMAT" validate:"omitempty,oneof=pretty json"`
+		File             string        `mapstructure:"LOG_FILE"`
+		FileMaxSizeMB    int           `mapstructure:"LOG_FILE_MAX_SIZE_MB" validate:"min=1"`
+		FileMaxAge       time.Duration `mapstructure:"LOG_FILE_MAX_AGE" validate:"min=0"`
+		FileMaxBackups   int           `mapstructure:"LOG_FILE_MAX_BACKUPS" validate:"min=0"`
+		RingSize         int           `mapstructure:"LOG_RING_SIZE" validate:"min=0,max=100000"`
+		SampleInitial    int           `mapstructure:"LOG_SAMPLE_INITIAL" validate:"min=0"`
+		SampleThereafter int           `mapstructure:"LOG_SAMPLE_THEREAFTER" validate:"min=0"`
 	} `mapstructure:",squash"`
 	Cors struct {
-		TrustedOrigins []string `mapstructure:"CORS_TRUSTED_ORIGINS" validate:"omitempty,dive,url"`
+		TrustedOrigins []string `mapstructure:"CORS_TRUSTED_ORIGINS" validate:"omitempty,dive,url|eq=*"`
+	} `mapstructure:",squash"`
+	Limiter struct {
+		Enabled bool    `mapstructure:"LIMITER_ENABLED"`
+		Rps     float64 `mapstructure:"LIMITER_RPS" validate:"gt=0"`
+		Burst   int     `mapstructure:"LIMITER_BURST" validate:"min=1"`
+	} `mapstructure:",squash"`
+	Proxy struct {
+		TrustedCIDRs []string `mapstructure:"TRUSTED_PROXIES" validate:"omitempty,dive,cidr"`
+	} `mapstructure:",squash"`
+	Compression struct {
+		Enabled     bool `mapstructure:"COMPRESSION_ENABLED"`
+		MinSize     int  `mapstructure:"COMPRESSION_MIN_SIZE" validate:"min=0"`
+		Level       int  `mapstructure:"COMPRESSION_LEVEL" validate:"min=-1,max=9"`
+		BrotliLevel int  `mapstructure:"COMPRESSION_BROTLI_LEVEL" validate:"min=0,max=11"`
+	} `mapstructure:",squash"`
+	BodyLimit struct {
+		DefaultKB int `mapstructure:"BODY_LIMIT_KB" validate:"min=1"`
+		SmallKB   int `mapstructure:"BODY_LIMIT_SMALL_KB" validate:"min=1"`
+		LargeKB   int `mapstructure:"BODY_LIMIT_LARGE_KB" validate:"min=1"`
+	} `mapstructure:",squash"`
+	Upload struct {
+		ImageMaxSizeKB int `mapstructure:"UPLOAD_IMAGE_MAX_SIZE_KB" validate:"min=1"`
+	} `mapstructure:",squash"`
+	Media struct {
+		Storage     string `mapstructure:"MEDIA_STORAGE" validate:"required,oneof=local s3"`
+		Dir         string `mapstructure:"MEDIA_DIR" validate:"required_if=Storage local"`
+		BaseURL     string `mapstructure:"MEDIA_BASE_URL" validate:"omitempty,url"`
+		S3Endpoint  string `mapstructure:"MEDIA_S3_ENDPOINT" validate:"required_if=Storage s3,omitempty,url"`
+		S3Region    string `mapstructure:"MEDIA_S3_REGION" validate:"required_if=Storage s3"`
+		S3Bucket    string `mapstructure:"MEDIA_S3_BUCKET" validate:"required_if=Storage s3"`
+		S3AccessKey string `mapstructure:"MEDIA_S3_ACCESS_KEY" validate:"required_if=Storage s3" secret:"true"`
+		S3SecretKey string `mapstructure:"MEDIA_S3_SECRET_KEY" validate:"required_if=Storage s3" secret:"true"`
+		S3PathStyle bool   `mapstructure:"MEDIA_S3_PATH_STYLE"`
+	} `mapstructure:",squash"`
+	Cache struct {
+		Testimonies  string `mapstructure:"CACHE_CONTROL_TESTIMONIES"`
+		FAQs         string `mapstructure:"CACHE_CONTROL_FAQS"`
+		Featured     string `mapstructure:"CACHE_CONTROL_FEATURED"`
+		BestSellers  string `mapstructure:"CACHE_CONTROL_BEST_SELLERS"`
+		RecentOrders string `mapstructure:"CACHE_CONTROL_RECENT_ORDERS"`
+	} `mapstructure:",squash"`
+	QueryCache struct {
+		Enabled bool          `mapstructure:"QUERY_CACHE_ENABLED"`
+		Size    int           `mapstructure:"QUERY_CACHE_SIZE" validate:"min=1"`
+		TTL     time.Duration `mapstructure:"QUERY_CACHE_TTL" validate:"min=1s"`
+	} `mapstructure:",squash"`
+	Featured struct {
+		Count        int `mapstructure:"FEATURED_COUNT" validate:"min=1,max=50"`
+		CooldownDays int `mapstructure:"FEATURED_COOLDOWN_DAYS" validate:"min=0,max=365"`
+	} `mapstructure:",squash"`
+	OrdersStream struct {
+		MaxConnections int           `mapstructure:"ORDERS_STREAM_MAX_CONNECTIONS" validate:"min=1"`
+		Heartbeat      time.Duration `mapstructure:"ORDERS_STREAM_HEARTBEAT" validate:"min=1s,max=5m"`
+	} `mapstructure:",squash"`
+	Idempotency struct {
+		TTL time.Duration `mapstructure:"IDEMPOTENCY_TTL" validate:"min=1m"`
+	} `mapstructure:",squash"`
+	Payment struct {
+		Provider      string        `mapstructure:"PAYMENT_PROVIDER" validate:"required,oneof=fake gateway"`
+		GatewayURL    string        `mapstructure:"PAYMENT_GATEWAY_URL" validate:"required_if=Provider gateway,omitempty,url"`
+		ServerKey     string        `mapstructure:"PAYMENT_GATEWAY_SERVER_KEY" validate:"required_if=Provider gateway" secret:"true"`
+		WebhookSecret string        `mapstructure:"PAYMENT_WEBHOOK_SECRET" validate:"required_if=Provider gateway,omitempty,min=16" secret:"true"`
+		Expiry        time.Duration `mapstructure:"PAYMENT_EXPIRY" validate:"min=5m,max=48h"`
+	} `mapstructure:",squash"`
+	Fulfillment struct {
+		Deliverer      string        `mapstructure:"FULFILLMENT_DELIVERER" validate:"required,oneof=fake manual"`
+		Workers        int           `mapstructure:"FULFILLMENT_WORKERS" validate:"min=1,max=100"`
+		MaxAttempts    int           `mapstructure:"FULFILLMENT_MAX_ATTEMPTS" validate:"min=1,max=20"`
+		RetryBaseDelay time.Duration `mapstructure:"FULFILLMENT_RETRY_BASE_DELAY" validate:"min=1s"`
+		RetryMaxDelay  time.Duration `mapstructure:"FULFILLMENT_RETRY_MAX_DELAY" validate:"gtefield=RetryBaseDelay,max=24h"`
+	} `mapstructure:",squash"`
+	Admin struct {
+		Token string `mapstructure:"ADMIN_TOKEN" validate:"omitempty,min=16" secret:"true"`
 	} `mapstructure:",squash"`
 }
 
 func NewConfig() (Config, error) {
 	// Bind environment variables
-	viper.SetEnvPrefix("MAYOBOX")
+	viper.SetEnvPrefix(envPrefix)
 	viper.AutomaticEnv()
 
+	pflag.String("config", "", "Path to a YAML or TOML config file (default searches ./mayobox.{yaml,toml} and /etc/mayobox/)")
+	printConfig := pflag.Bool("print-config", false, "Print the effective configuration with secrets redacted and exit")
+	checkConfig := pflag.Bool("check-config", false, "Validate the effective configuration and exit")
+
 	pflag.Uint("port", 3000, "API server port")
 	pflag.String("env", "development", "Environment (development/staging/production)")
-	pflag.String("db-dsn", "", "Database connection string")
+	pflag.String("storage", "postgres", "Storage backend (postgres/memory, memory serves seeded demo data without a database)")
+	pflag.String("db-dsn", "", "Database connection string (required for postgres storage)")
 	pflag.Int("db-max-open-conn", 25, "Database max open connections")
 	pflag.Int("db-max-idle-conn", 25, "Database max idle connections")
 	pflag.Duration("db-max-idle-time", 15*time.Minute, "Database max idle time")
 	pflag.String("log-level", "debug", "Log level (debug/info/warn/error)")
-	pflag.StringSlice("cors-trusted-origins", []string{}, "Trusted CORS origins (comma separated)")
+	pflag.String("log-format", "", "Console log format (pretty/json, default pretty outside production)")
+	pflag.String("log-file", "", "Also write JSON logs to this file, rotated by size")
+	pflag.Int("log-file-max-size-mb", 100, "Log file size in megabytes before it is rotated")
+	pflag.Duration("log-file-max-age", 7*24*time.Hour, "Remove rotated log files older than this (0 keeps all)")
+	pflag.Int("log-file-max-backups", 5, "Number of rotated log files to keep (0 keeps all)")
+	pflag.Int("log-ring-size", 1000, "Number of recent log entries kept in memory for the admin API (0 disables)")
+	pflag.Int("log-sample-initial", 100, "Per second, log the first N identical request log entries (0 disables sampling)")
+	pflag.Int("log-sample-thereafter", 100, "After the initial entries, log every Nth identical request log entry")
+	pflag.StringSlice("cors-trusted-origins", []string{}, "Trusted CORS origins (comma separated, \"*\" allows any, empty allows none)")
+	pflag.Bool("limiter-enabled", false, "Enable per client rate limiter (payment webhooks are never limited)")
+	pflag.Float64("limiter-rps", 10, "Rate limiter maximum requests per second per client IP")
+	pflag.Int("limiter-burst", 40, "Rate limiter maximum burst per client IP")
+	pflag.StringSlice("trusted-proxies", []string{}, "CIDRs of reverse proxies whose X-Forwarded-For is trusted for the client IP (comma separated, empty uses the peer address)")
+	pflag.Bool("compression-enabled", true, "Compress responses with brotli or gzip for clients that accept it")
+	pflag.Int("compression-min-size", 1024, "Responses smaller than this many bytes are sent uncompressed")
+	pflag.Int("compression-level", -1, "Gzip level from 1 (fastest) to 9 (smallest), -1 uses the default")
+	pflag.Int("compression-brotli-level", 4, "Brotli quality from 0 (fastest) to 11 (smallest)")
+	pflag.Int("body-limit-kb", 1024, "Maximum request body size in kilobytes")
+	pflag.Int("body-limit-small-kb", 16, "Maximum request body size in kilobytes of routes taking small bodies")
+	pflag.Int("body-limit-large-kb", 32768, "Maximum request body size in kilobytes of upload and bulk import routes")
+	pflag.Int("upload-image-max-size-kb", 2048, "Maximum size in kilobytes of an uploaded avatar or testimony icon")
+	pflag.String("media-storage", "local", "Where uploaded images are stored (local/s3)")
+	pflag.String("media-dir", "./media", "Directory of local media storage, served at /media")
+	pflag.String("media-base-url", "", "Public URL of stored media (default http://localhost:<port>/media for local, the bucket URL for s3)")
+	pflag.String("media-s3-endpoint", "", "S3 compatible endpoint, e.g. https://s3.ap-southeast-1.amazonaws.com")
+	pflag.String("media-s3-region", "", "S3 region")
+	pflag.String("media-s3-bucket", "", "S3 bucket")
+	pflag.String("media-s3-access-key", "", "S3 access key ID")
+	pflag.String("media-s3-secret-key", "", "S3 secret access key")
+	pflag.Bool("media-s3-path-style", false, "Address the bucket as <endpoint>/<bucket>, needed by most self-hosted services")
+	pflag.String("cache-control-testimonies", "public, max-age=60", "Cache-Control header of GET /v1/testimonies (empty sends none)")
+	pflag.String("cache-control-faqs", "public, max-age=300", "Cache-Control header of GET /v1/faqs (empty sends none)")
+	pflag.String("cache-control-featured", "public, max-age=60", "Cache-Control header of GET /v1/products/featured (empty sends none)")
+	pflag.String("cache-control-best-sellers", "public, max-age=300", "Cache-Control header of GET /v1/products/best-sellers (empty sends none)")
+	pflag.String("cache-control-recent-orders", "public, max-age=10", "Cache-Control header of GET /v1/orders/recent (empty sends none)")
+	pflag.Bool("query-cache-enabled", true, "Cache testimony and FAQ reads in memory, invalidated by Postgres notifications")
+	pflag.Int("query-cache-size", 1000, "Maximum number of cached query results per model")
+	pflag.Duration("query-cache-ttl", 5*time.Minute, "Maximum age of a cached query result")
+	pflag.Int("featured-count", 10, "Number of Products of the Day picked per day")
+	pflag.Int("featured-cooldown-days", 7, "Days a randomly featured product sits out before it is preferred again")
+	pflag.Int("orders-stream-max-connections", 500, "Open /v1/orders/recent/stream connections per instance, more are refused with 503")
+	pflag.Duration("orders-stream-heartbeat", 15*time.Second, "Interval of keep-alive comments on /v1/orders/recent/stream")
+	pflag.Duration("idempotency-ttl", 24*time.Hour, "How long responses to requests with an Idempotency-Key are kept for replay")
+	pflag.String("payment-provider", "fake", "Payment provider (fake/gateway, fake settles charges through a local simulator)")
+	pflag.String("payment-gateway-url", "", "Base URL of the payment gateway API (required for the gateway provider)")
+	pflag.String("payment-gateway-server-key", "", "Server key of the payment gateway (required for the gateway provider)")
+	pflag.String("payment-webhook-secret", "", "Secret the provider signs webhooks with (min 16 chars, random for the fake provider when empty)")
+	pflag.Duration("payment-expiry", time.Hour, "How long a customer has to pay an order")
+	pflag.String("fulfillment-deliverer", "fake", "How paid orders are delivered (fake/manual, manual leaves them to admins)")
+	pflag.Int("fulfillment-workers", 10, "Deliveries running at once across every supplier account")
+	pflag.Int("fulfillment-max-attempts", 5, "Delivery attempts before an order fails and waits for an admin")
+	pflag.Duration("fulfillment-retry-base-delay", 30*time.Second, "Delay before the second delivery attempt, doubled for every attempt after")
+	pflag.Duration("fulfillment-retry-max-delay", 30*time.Minute, "Longest delay between delivery attempts")
+	pflag.String("admin-token", "", "Bearer token for the admin API (min 16 chars, empty disables it)")
 
 	pflag.Usage = func() {
 		w := pflag.CommandLine.Output()
@@ -63,37 +219,215 @@ func NewConfig() (Config, error) {
 
 		// Print any custom postamble
 		fmt.Fprintf(w, "\nEnvironment Variables:\n")
-		fmt.Fprintln(w, "      TCSA_PORT")
-		fmt.Fprintln(w, "      TCSA_ENV")
-		fmt.Fprintln(w, "      TCSA_DB_DSN")
-		fmt.Fprintln(w, "      TCSA_DB_MAX_OPEN_CONN")
-		fmt.Fprintln(w, "      TCSA_DB_MAX_IDLE_CONN")
-		fmt.Fprintln(w, "      TCSA_DB_MAX_IDLE_TIME")
-		fmt.Fprintln(w, "      TCSA_LOG_LEVEL")
-		fmt.Fprintln(w, "      TCSA_CORS_TRUSTED_ORIGINS")
+		fmt.Fprintf(w, "      %s_CONFIG\n", envPrefix)
+		for _, key := range configKeys() {
+			fmt.Fprintf(w, "      %s_%s\n", envPrefix, key)
+		}
+
+		fmt.Fprintf(w, "\nPrecedence: flags > environment (.env.<%s_ENV>, .env) > config file > defaults.\n", envPrefix)
+		fmt.Fprintln(w, "Config file keys are the environment names in lower case without the prefix, e.g. db_dsn.")
 	}
 
 	pflag.Parse()
 
 	// Bind flags to Viper keys, flags override environment
+	viper.BindPFlag("CONFIG", pflag.Lookup("config"))
 	viper.BindPFlag("PORT", pflag.Lookup("port"))
 	viper.BindPFlag("ENV", pflag.Lookup("env"))
+	viper.BindPFlag("STORAGE", pflag.Lookup("storage"))
 	viper.BindPFlag("DB_DSN", pflag.Lookup("db-dsn"))
 	viper.BindPFlag("DB_MAX_OPEN_CONN", pflag.Lookup("db-max-open-conn"))
 	viper.BindPFlag("DB_MAX_IDLE_CONN", pflag.Lookup("db-max-idle-conn"))
 	viper.BindPFlag("DB_MAX_IDLE_TIME", pflag.Lookup("db-max-idle-time"))
 	viper.BindPFlag("LOG_LEVEL", pflag.Lookup("log-level"))
+	viper.BindPFlag("LOG_FORMAT", pflag.Lookup("log-format"))
+	viper.BindPFlag("LOG_FILE", pflag.Lookup("log-file"))
+	viper.BindPFlag("LOG_FILE_MAX_SIZE_MB", pflag.Lookup("log-file-max-size-mb"))
+	viper.BindPFlag("LOG_FILE_MAX_AGE", pflag.Lookup("log-file-max-age"))
+	viper.BindPFlag("LOG_FILE_MAX_BACKUPS", pflag.Lookup("log-file-max-backups"))
+	viper.BindPFlag("LOG_RING_SIZE", pflag.Lookup("log-ring-size"))
+	viper.BindPFlag("LOG_SAMPLE_INITIAL", pflag.Lookup("log-sample-initial"))
+	viper.BindPFlag("LOG_SAMPLE_THEREAFTER", pflag.Lookup("log-sample-thereafter"))
 	viper.BindPFlag("CORS_TRUSTED_ORIGINS", pflag.Lookup("cors-trusted-origins"))
+	viper.BindPFlag("LIMITER_ENABLED", pflag.Lookup("limiter-enabled"))
+	viper.BindPFlag("LIMITER_RPS", pflag.Lookup("limiter-rps"))
+	viper.BindPFlag("LIMITER_BURST", pflag.Lookup("limiter-burst"))
+	viper.BindPFlag("TRUSTED_PROXIES", pflag.Lookup("trusted-proxies"))
+	viper.BindPFlag("COMPRESSION_ENABLED", pflag.Lookup("compression-enabled"))
+	viper.BindPFlag("COMPRESSION_MIN_SIZE", pflag.Lookup("compression-min-size"))
+	viper.BindPFlag("COMPRESSION_LEVEL", pflag.Lookup("compression-level"))
+	viper.BindPFlag("COMPRESSION_BROTLI_LEVEL", pflag.Lookup("compression-brotli-level"))
+	viper.BindPFlag("BODY_LIMIT_KB", pflag.Lookup("body-limit-kb"))
+	viper.BindPFlag("BODY_LIMIT_SMALL_KB", pflag.Lookup("body-limit-small-kb"))
+	viper.BindPFlag("BODY_LIMIT_LARGE_KB", pflag.Lookup("body-limit-large-kb"))
+	viper.BindPFlag("UPLOAD_IMAGE_MAX_SIZE_KB", pflag.Lookup("upload-image-max-size-kb"))
+	viper.BindPFlag("MEDIA_STORAGE", pflag.Lookup("media-storage"))
+	viper.BindPFlag("MEDIA_DIR", pflag.Lookup("media-dir"))
+	viper.BindPFlag("MEDIA_BASE_URL", pflag.Lookup("media-base-url"))
+	viper.BindPFlag("MEDIA_S3_ENDPOINT", pflag.Lookup("media-s3-endpoint"))
+	viper.BindPFlag("MEDIA_S3_REGION", pflag.Lookup("media-s3-region"))
+	viper.BindPFlag("MEDIA_S3_BUCKET", pflag.Lookup("media-s3-bucket"))
+	viper.BindPFlag("MEDIA_S3_ACCESS_KEY", pflag.Lookup("media-s3-access-key"))
+	viper.BindPFlag("MEDIA_S3_SECRET_KEY", pflag.Lookup("media-s3-secret-key"))
+	viper.BindPFlag("MEDIA_S3_PATH_STYLE", pflag.Lookup("media-s3-path-style"))
+	viper.BindPFlag("CACHE_CONTROL_TESTIMONIES", pflag.Lookup("cache-control-testimonies"))
+	viper.BindPFlag("CACHE_CONTROL_FAQS", pflag.Lookup("cache-control-faqs"))
+	viper.BindPFlag("CACHE_CONTROL_FEATURED", pflag.Lookup("cache-control-featured"))
+	viper.BindPFlag("CACHE_CONTROL_BEST_SELLERS", pflag.Lookup("cache-control-best-sellers"))
+	viper.BindPFlag("CACHE_CONTROL_RECENT_ORDERS", pflag.Lookup("cache-control-recent-orders"))
+	viper.BindPFlag("QUERY_CACHE_ENABLED", pflag.Lookup("query-cache-enabled"))
+	viper.BindPFlag("QUERY_CACHE_SIZE", pflag.Lookup("query-cache-size"))
+	viper.BindPFlag("QUERY_CACHE_TTL", pflag.Lookup("query-cache-ttl"))
+	viper.BindPFlag("FEATURED_COUNT", pflag.Lookup("featured-count"))
+	viper.BindPFlag("FEATURED_COOLDOWN_DAYS", pflag.Lookup("featured-cooldown-days"))
+	viper.BindPFlag("ORDERS_STREAM_MAX_CONNECTIONS", pflag.Lookup("orders-stream-max-connections"))
+	viper.BindPFlag("ORDERS_STREAM_HEARTBEAT", pflag.Lookup("orders-stream-heartbeat"))
+	viper.BindPFlag("IDEMPOTENCY_TTL", pflag.Lookup("idempotency-ttl"))
+	viper.BindPFlag("PAYMENT_PROVIDER", pflag.Lookup("payment-provider"))
+	viper.BindPFlag("PAYMENT_GATEWAY_URL", pflag.Lookup("payment-gateway-url"))
+	viper.BindPFlag("PAYMENT_GATEWAY_SERVER_KEY", pflag.Lookup("payment-gateway-server-key"))
+	viper.BindPFlag("PAYMENT_WEBHOOK_SECRET", pflag.Lookup("payment-webhook-secret"))
+	viper.BindPFlag("PAYMENT_EXPIRY", pflag.Lookup("payment-expiry"))
+	viper.BindPFlag("FULFILLMENT_DELIVERER", pflag.Lookup("fulfillment-deliverer"))
+	viper.BindPFlag("FULFILLMENT_WORKERS", pflag.Lookup("fulfillment-workers"))
+	viper.BindPFlag("FULFILLMENT_MAX_ATTEMPTS", pflag.Lookup("fulfillment-max-attempts"))
+	viper.BindPFlag("FULFILLMENT_RETRY_BASE_DELAY", pflag.Lookup("fulfillment-retry-base-delay"))
+	viper.BindPFlag("FULFILLMENT_RETRY_MAX_DELAY", pflag.Lookup("fulfillment-retry-max-delay"))
+	viper.BindPFlag("ADMIN_TOKEN", pflag.Lookup("admin-token"))
+
+	if err := readConfigFile(viper.GetString("CONFIG")); err != nil {
+		return Config{}, err
+	}
+
+	cfg, err := decodeConfig()
+	if err != nil {
+		return Config{}, err
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			return Config{}, fmt.Errorf("unable to print config: %w", err)
+		}
+		os.Exit(0)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	if *checkConfig {
+		fmt.Fprintln(os.Stdout, "configuration is valid")
+		os.Exit(0)
+	}
 
+	return cfg, nil
+}
+
+// decodeConfig builds a Config from the current viper state.
+func decodeConfig() (Config, error) {
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return Config{}, fmt.Errorf("unable to decode config: %w", err)
 	}
+	return cfg, nil
+}
 
+func (cfg Config) Validate() error {
 	validate := validator.New()
 	if err := validate.Struct(&cfg); err != nil {
-		return Config{}, fmt.Errorf("config validation failed: %w", err)
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+	// The fake provider marks orders paid on request, anyone could shop for free.
+	if cfg.Env == "production" && cfg.Payment.Provider == "fake" {
+		return errors.New("config validation failed: PAYMENT_PROVIDER fake is not allowed in production")
+	}
+	// The fake deliverer completes orders without sending any Robux.
+	if cfg.Env == "production" && cfg.Fulfillment.Deliverer == "fake" {
+		return errors.New("config validation failed: FULFILLMENT_DELIVERER fake is not allowed in production")
 	}
+	return nil
+}
 
-	return cfg, nil
+// readConfigFile loads the optional config file. An explicit path must
+// exist, while the default search path is allowed to be empty.
+func readConfigFile(path string) error {
+	if path != "" {
+		viper.SetConfigFile(path)
+	} else {
+		viper.SetConfigName("mayobox")
+		viper.AddConfigPath(".")
+		viper.AddConfigPath("/etc/mayobox/")
+	}
+
+	err := viper.ReadInConfig()
+	if err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if path == "" && errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("unable to read config file: %w", err)
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML using the config file key names,
+// so the output can be reused as a config file. Secrets are redacted.
+func (cfg Config) Print(w io.Writer) error {
+	settings := make(map[string]any)
+	walkConfig(reflect.ValueOf(cfg), func(key string, field reflect.StructField, value reflect.Value) {
+		v := value.Interface()
+		if d, ok := v.(time.Duration); ok {
+			v = d.String()
+		}
+		if field.Tag.Get("secret") == "true" {
+			v = redact(fmt.Sprintf("%v", v))
+		}
+		settings[strings.ToLower(key)] = v
+	})
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(settings)
+}
+
+// configKeys returns every config key in declaration order.
+func configKeys() []string {
+	var keys []string
+	walkConfig(reflect.ValueOf(Config{}), func(key string, _ reflect.StructField, _ reflect.Value) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// walkConfig calls fn for every leaf field of the (squashed) config struct.
+func walkConfig(v reflect.Value, fn func(key string, field reflect.StructField, value reflect.Value)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+
+		if strings.HasSuffix(tag, ",squash") && field.Type.Kind() == reflect.Struct {
+			walkConfig(v.Field(i), fn)
+			continue
+		}
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fn(tag, field, v.Field(i))
+	}
+}
+
+// redact hides a secret value. URLs keep everything but the password so
+// the host and database remain visible when debugging.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	u, err := url.Parse(value)
+	if err == nil && u.Scheme != "" && u.User != nil {
+		return u.Redacted()
+	}
+	return "xxxxx"
 }
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/openapi"
+)
+
+// undocumentedRoutes serve the documentation itself or uploaded files and
+// are not part of the API contract.
+var undocumentedRoutes = map[string]bool{
+	"GET /media/*":      true,
+	"GET /openapi.yaml": true,
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+	"GET /docs/assets*": true,
+	"GET /redoc":        true,
+}
+
+var (
+	avatarBody, avatarHeader = uploadRequest("avatar", "avatar.png", testPNG(300, 200))
+	pdfBody, pdfHeader       = uploadRequest("avatar", "avatar.png", []byte("%PDF-1.4\n%test"))
+	iconBody, iconHeader     = uploadRequest("icon", "icon.png", testPNG(32, 32))
+)
+
+type contractCase struct {
+	name   string
+	method string
+	// route is the path as registered in routes(), target the request URI.
+	route  string
+	target string
+	header http.Header
+	body   string
+	status int
+}
+
+// contractCases must cover every documented route at least once. Add a case
+// together with the route and its openAPIDocument() entry.
+var contractCases = []contractCase{
+	{name: "healthcheck", method: http.MethodGet, route: "/", target: "/", status: http.StatusOK},
+
+	{name: "list testimonies", method: http.MethodGet, route: "/v1/testimonies", target: "/v1/testimonies?page=1&page_size=5", status: http.StatusOK},
+	{name: "list testimonies invalid page", method: http.MethodGet, route: "/v1/testimonies", target: "/v1/testimonies?page=0", status: http.StatusUnprocessableEntity},
+	{name: "list testimonies malformed page", method: http.MethodGet, route: "/v1/testimonies", target: "/v1/testimonies?page=abc", status: http.StatusBadRequest},
+	{name: "list testimonies invalid page problem", method: http.MethodGet, route: "/v1/testimonies", target: "/v1/testimonies?page=0", header: problemHeader(), status: http.StatusUnprocessableEntity},
+	{name: "list testimonies malformed page problem", method: http.MethodGet, route: "/v1/testimonies", target: "/v1/testimonies?page=abc", header: problemHeader(), status: http.StatusBadRequest},
+
+	{name: "list testimonies not modified", method: http.MethodGet, route: "/v1/testimonies", target: "/v1/testimonies", header: http.Header{headerIfNoneMatch: {"*"}}, status: http.StatusNotModified},
+
+	{name: "list faqs", method: http.MethodGet, route: "/v1/faqs", target: "/v1/faqs", status: http.StatusOK},
+	{name: "list testimonies csv", method: http.MethodGet, route: "/v1/testimonies", target: "/v1/testimonies?columns=id,testimoni", header: http.Header{"Accept": {"text/csv"}}, status: http.StatusOK},
+	{name: "list testimonies unknown csv column", method: http.MethodGet, route: "/v1/testimonies", target: "/v1/testimonies?columns=nope", header: http.Header{"Accept": {"text/csv"}}, status: http.StatusBadRequest},
+	{name: "list faqs messagepack", method: http.MethodGet, route: "/v1/faqs", target: "/v1/faqs", header: http.Header{"Accept": {"application/msgpack"}}, status: http.StatusOK},
+	{name: "list faqs not modified", method: http.MethodGet, route: "/v1/faqs", target: "/v1/faqs", header: http.Header{headerIfNoneMatch: {"*"}}, status: http.StatusNotModified},
+
+	{name: "recent orders", method: http.MethodGet, route: "/v1/orders/recent", target: "/v1/orders/recent?limit=5", status: http.StatusOK},
+	{name: "recent orders invalid limit", method: http.MethodGet, route: "/v1/orders/recent", target: "/v1/orders/recent?limit=100", status: http.StatusUnprocessableEntity},
+	{name: "recent orders stream invalid last event id", method: http.MethodGet, route: "/v1/orders/recent/stream", target: "/v1/orders/recent/stream", header: http.Header{"Last-Event-ID": {"42"}}, status: http.StatusUnprocessableEntity},
+	{name: "create order", method: http.MethodPost, route: "/v1/orders", target: "/v1/orders", body: `{"productId":"990e8400-e29b-41d4-a716-446655440002","robloxUsername":"builderman","paymentMethod":"qris"}`, status: http.StatusCreated},
+	{name: "create order inactive product", method: http.MethodPost, route: "/v1/orders", target: "/v1/orders", body: `{"productId":"990e8400-e29b-41d4-a716-446655449999","robloxUsername":"builderman","paymentMethod":"qris"}`, status: http.StatusUnprocessableEntity},
+	{name: "create order unknown method", method: http.MethodPost, route: "/v1/orders", target: "/v1/orders", body: `{"productId":"990e8400-e29b-41d4-a716-446655440002","robloxUsername":"builderman","paymentMethod":"cash"}`, status: http.StatusUnprocessableEntity},
+	{name: "create order malformed", method: http.MethodPost, route: "/v1/orders", target: "/v1/orders", body: `{"productId":`, status: http.StatusBadRequest},
+	{name: "get order unknown", method: http.MethodGet, route: "/v1/orders/:id", target: "/v1/orders/aa0e8400-e29b-41d4-a716-446655449999", status: http.StatusNotFound},
+	{name: "get order invalid id", method: http.MethodGet, route: "/v1/orders/:id", target: "/v1/orders/42", status: http.StatusUnprocessableEntity},
+	{name: "payment webhook unsigned", method: http.MethodPost, route: "/v1/payments/webhooks/:provider", target: "/v1/payments/webhooks/fake", body: `{"id":"evt_1"}`, status: http.StatusBadRequest},
+	{name: "payment webhook other provider", method: http.MethodPost, route: "/v1/payments/webhooks/:provider", target: "/v1/payments/webhooks/gateway", body: `{"id":"evt_1"}`, status: http.StatusNotFound},
+	{name: "simulate payment unknown charge", method: http.MethodPost, route: "/v1/payments/simulator/:chargeId", target: "/v1/payments/simulator/fake_missing", body: `{"status":"paid"}`, status: http.StatusNotFound},
+	{name: "simulate payment invalid status", method: http.MethodPost, route: "/v1/payments/simulator/:chargeId", target: "/v1/payments/simulator/fake_missing", body: `{"status":"refunded"}`, status: http.StatusUnprocessableEntity},
+	{name: "create order with voucher", method: http.MethodPost, route: "/v1/orders", target: "/v1/orders", body: `{"productId":"990e8400-e29b-41d4-a716-446655440003","robloxUsername":"guest","paymentMethod":"qris","voucherCode":"mayo10"}`, status: http.StatusCreated},
+	{name: "create order unknown voucher", method: http.MethodPost, route: "/v1/orders", target: "/v1/orders", body: `{"productId":"990e8400-e29b-41d4-a716-446655440003","robloxUsername":"builderman","paymentMethod":"qris","voucherCode":"NOPE"}`, status: http.StatusUnprocessableEntity},
+	{name: "validate voucher", method: http.MethodPost, route: "/v1/vouchers/validate", target: "/v1/vouchers/validate", body: `{"code":"MAYO10","productId":"990e8400-e29b-41d4-a716-446655440003"}`, status: http.StatusOK},
+	{name: "validate voucher below min spend", method: http.MethodPost, route: "/v1/vouchers/validate", target: "/v1/vouchers/validate", body: `{"code":"MAYO10","productId":"990e8400-e29b-41d4-a716-446655440001"}`, status: http.StatusUnprocessableEntity},
+	{name: "validate voucher missing code", method: http.MethodPost, route: "/v1/vouchers/validate", target: "/v1/vouchers/validate", body: `{"productId":"990e8400-e29b-41d4-a716-446655440003"}`, status: http.StatusUnprocessableEntity},
+	{name: "validate voucher malformed", method: http.MethodPost, route: "/v1/vouchers/validate", target: "/v1/vouchers/validate", body: `{"code":`, status: http.StatusBadRequest},
+	{name: "active flash sales", method: http.MethodGet, route: "/v1/flash-sales/active", target: "/v1/flash-sales/active", status: http.StatusOK},
+	{name: "quote price", method: http.MethodGet, route: "/v1/pricing/quote", target: "/v1/pricing/quote?robux=1053", status: http.StatusOK},
+	{name: "quote price missing robux", method: http.MethodGet, route: "/v1/pricing/quote", target: "/v1/pricing/quote", status: http.StatusUnprocessableEntity},
+	{name: "quote price malformed robux", method: http.MethodGet, route: "/v1/pricing/quote", target: "/v1/pricing/quote?robux=many", status: http.StatusBadRequest},
+	{name: "featured products", method: http.MethodGet, route: "/v1/products/featured", target: "/v1/products/featured", status: http.StatusOK},
+	{name: "best sellers", method: http.MethodGet, route: "/v1/products/best-sellers", target: "/v1/products/best-sellers?window=7d&limit=5", status: http.StatusOK},
+	{name: "best sellers invalid window", method: http.MethodGet, route: "/v1/products/best-sellers", target: "/v1/products/best-sellers?window=1y", status: http.StatusUnprocessableEntity},
+	{name: "best sellers malformed limit", method: http.MethodGet, route: "/v1/products/best-sellers", target: "/v1/products/best-sellers?limit=many", status: http.StatusBadRequest},
+	{name: "featured products csv", method: http.MethodGet, route: "/v1/products/featured", target: "/v1/products/featured", header: http.Header{"Accept": {"text/csv"}}, status: http.StatusOK},
+
+	{name: "get log level", method: http.MethodGet, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: adminHeader(), status: http.StatusOK},
+	{name: "get log level without token", method: http.MethodGet, route: "/v1/admin/log-level", target: "/v1/admin/log-level", status: http.StatusUnauthorized},
+	{name: "get log level without token problem", method: http.MethodGet, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: problemHeader(), status: http.StatusUnauthorized},
+	{name: "update log level", method: http.MethodPut, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: adminHeader(), body: `{"level":"warn"}`, status: http.StatusOK},
+	{name: "update log level invalid", method: http.MethodPut, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: adminHeader(), body: `{"level":"loud"}`, status: http.StatusUnprocessableEntity},
+	{name: "update log level malformed", method: http.MethodPut, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: adminHeader(), body: `{"level":`, status: http.StatusBadRequest},
+	{name: "update log level too large", method: http.MethodPut, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: adminHeader(), body: `{"level":"` + strings.Repeat("d", 20<<10) + `"}`, status: http.StatusRequestEntityTooLarge},
+	{name: "update log level idempotent", method: http.MethodPut, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: idempotentAdminHeader("contract-1"), body: `{"level":"info"}`, status: http.StatusOK},
+	{name: "update log level idempotent replay", method: http.MethodPut, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: idempotentAdminHeader("contract-1"), body: `{"level":"info"}`, status: http.StatusOK},
+	{name: "update log level idempotency key reused", method: http.MethodPut, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: idempotentAdminHeader("contract-1"), body: `{"level":"debug"}`, status: http.StatusUnprocessableEntity},
+	{name: "update log level idempotency key too long", method: http.MethodPut, route: "/v1/admin/log-level", target: "/v1/admin/log-level", header: idempotentAdminHeader(strings.Repeat("k", 256)), body: `{"level":"info"}`, status: http.StatusBadRequest},
+	{name: "featured calendar", method: http.MethodGet, route: "/v1/admin/featured-calendar", target: "/v1/admin/featured-calendar?from=2026-10-01&to=2026-10-31", header: adminHeader(), status: http.StatusOK},
+	{name: "featured calendar invalid date", method: http.MethodGet, route: "/v1/admin/featured-calendar", target: "/v1/admin/featured-calendar?from=yesterday", header: adminHeader(), status: http.StatusUnprocessableEntity},
+	{name: "update featured calendar", method: http.MethodPut, route: "/v1/admin/featured-calendar/:date", target: "/v1/admin/featured-calendar/2026-12-25", header: adminHeader(), body: `{"productIds":["990e8400-e29b-41d4-a716-446655440006"]}`, status: http.StatusOK},
+	{name: "update featured calendar unknown product", method: http.MethodPut, route: "/v1/admin/featured-calendar/:date", target: "/v1/admin/featured-calendar/2026-12-25", header: adminHeader(), body: `{"productIds":["990e8400-e29b-41d4-a716-446655449999"]}`, status: http.StatusUnprocessableEntity},
+	{name: "update featured calendar invalid date", method: http.MethodPut, route: "/v1/admin/featured-calendar/:date", target: "/v1/admin/featured-calendar/25-12-2026", header: adminHeader(), body: `{"productIds":[]}`, status: http.StatusUnprocessableEntity},
+	{name: "update featured calendar malformed", method: http.MethodPut, route: "/v1/admin/featured-calendar/:date", target: "/v1/admin/featured-calendar/2026-12-25", header: adminHeader(), body: `{"productIds":`, status: http.StatusBadRequest},
+	{name: "admin orders", method: http.MethodGet, route: "/v1/admin/orders", target: "/v1/admin/orders?status=pending_payment&limit=5", header: adminHeader(), status: http.StatusOK},
+	{name: "admin orders invalid status", method: http.MethodGet, route: "/v1/admin/orders", target: "/v1/admin/orders?status=lost", header: adminHeader(), status: http.StatusUnprocessableEntity},
+	{name: "admin orders malformed limit", method: http.MethodGet, route: "/v1/admin/orders", target: "/v1/admin/orders?limit=many", header: adminHeader(), status: http.StatusBadRequest},
+	{name: "admin order unknown", method: http.MethodGet, route: "/v1/admin/orders/:id", target: "/v1/admin/orders/aa0e8400-e29b-41d4-a716-446655449999", header: adminHeader(), status: http.StatusNotFound},
+	{name: "admin order invalid id", method: http.MethodGet, route: "/v1/admin/orders/:id", target: "/v1/admin/orders/42", header: adminHeader(), status: http.StatusUnprocessableEntity},
+	{name: "retry order unknown", method: http.MethodPost, route: "/v1/admin/orders/:id/retry", target: "/v1/admin/orders/aa0e8400-e29b-41d4-a716-446655449999/retry", header: adminHeader(), body: `{}`, status: http.StatusNotFound},
+	{name: "retry order invalid id", method: http.MethodPost, route: "/v1/admin/orders/:id/retry", target: "/v1/admin/orders/42/retry", header: adminHeader(), status: http.StatusUnprocessableEntity},
+	{name: "resolve order unknown", method: http.MethodPost, route: "/v1/admin/orders/:id/resolve", target: "/v1/admin/orders/aa0e8400-e29b-41d4-a716-446655449999/resolve", header: adminHeader(), body: `{"status":"failed","reason":"customer cancelled"}`, status: http.StatusNotFound},
+	{name: "resolve order invalid status", method: http.MethodPost, route: "/v1/admin/orders/:id/resolve", target: "/v1/admin/orders/aa0e8400-e29b-41d4-a716-446655449999/resolve", header: adminHeader(), body: `{"status":"paid","reason":"customer cancelled"}`, status: http.StatusUnprocessableEntity},
+	{name: "resolve order malformed", method: http.MethodPost, route: "/v1/admin/orders/:id/resolve", target: "/v1/admin/orders/aa0e8400-e29b-41d4-a716-446655449999/resolve", header: adminHeader(), body: `{"status":`, status: http.StatusBadRequest},
+	{name: "dead letters", method: http.MethodGet, route: "/v1/admin/dead-letters", target: "/v1/admin/dead-letters?all=true&limit=5", header: adminHeader(), status: http.StatusOK},
+	{name: "dead letters invalid limit", method: http.MethodGet, route: "/v1/admin/dead-letters", target: "/v1/admin/dead-letters?limit=500", header: adminHeader(), status: http.StatusUnprocessableEntity},
+	{name: "dead letters malformed all", method: http.MethodGet, route: "/v1/admin/dead-letters", target: "/v1/admin/dead-letters?all=maybe", header: adminHeader(), status: http.StatusBadRequest},
+	{name: "supplier accounts", method: http.MethodGet, route: "/v1/admin/supplier-accounts", target: "/v1/admin/supplier-accounts", header: adminHeader(), status: http.StatusOK},
+	{name: "create supplier account", method: http.MethodPost, route: "/v1/admin/supplier-accounts", target: "/v1/admin/supplier-accounts", header: adminHeader(), body: `{"name":"Backup","maxConcurrency":2}`, status: http.StatusCreated},
+	{name: "create supplier account invalid concurrency", method: http.MethodPost, route: "/v1/admin/supplier-accounts", target: "/v1/admin/supplier-accounts", header: adminHeader(), body: `{"name":"Backup","maxConcurrency":100}`, status: http.StatusUnprocessableEntity},
+	{name: "create supplier account malformed", method: http.MethodPost, route: "/v1/admin/supplier-accounts", target: "/v1/admin/supplier-accounts", header: adminHeader(), body: `{"name":`, status: http.StatusBadRequest},
+	{name: "update supplier account unknown", method: http.MethodPut, route: "/v1/admin/supplier-accounts/:id", target: "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999", header: adminHeader(), body: `{"active":false}`, status: http.StatusNotFound},
+	{name: "update supplier account invalid id", method: http.MethodPut, route: "/v1/admin/supplier-accounts/:id", target: "/v1/admin/supplier-accounts/42", header: adminHeader(), body: `{"active":false}`, status: http.StatusUnprocessableEntity},
+	{name: "stock balances", method: http.MethodGet, route: "/v1/admin/stock", target: "/v1/admin/stock", header: adminHeader(), status: http.StatusOK},
+	{name: "stock ledger unknown account", method: http.MethodGet, route: "/v1/admin/supplier-accounts/:id/ledger", target: "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999/ledger", header: adminHeader(), status: http.StatusNotFound},
+	{name: "stock ledger invalid limit", method: http.MethodGet, route: "/v1/admin/supplier-accounts/:id/ledger", target: "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999/ledger?limit=0", header: adminHeader(), status: http.StatusUnprocessableEntity},
+	{name: "stock purchase unknown account", method: http.MethodPost, route: "/v1/admin/supplier-accounts/:id/purchases", target: "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999/purchases", header: adminHeader(), body: `{"robux":1000}`, status: http.StatusNotFound},
+	{name: "stock purchase invalid robux", method: http.MethodPost, route: "/v1/admin/supplier-accounts/:id/purchases", target: "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999/purchases", header: adminHeader(), body: `{"robux":0}`, status: http.StatusUnprocessableEntity},
+	{name: "stock purchase malformed", method: http.MethodPost, route: "/v1/admin/supplier-accounts/:id/purchases", target: "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999/purchases", header: adminHeader(), body: `{"robux":`, status: http.StatusBadRequest},
+	{name: "reconcile stock unknown account", method: http.MethodPost, route: "/v1/admin/supplier-accounts/:id/reconcile", target: "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999/reconcile", header: adminHeader(), body: `{"robux":0}`, status: http.StatusNotFound},
+	{name: "reconcile stock missing robux", method: http.MethodPost, route: "/v1/admin/supplier-accounts/:id/reconcile", target: "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999/reconcile", header: adminHeader(), body: `{}`, status: http.StatusUnprocessableEntity},
+	{name: "vouchers", method: http.MethodGet, route: "/v1/admin/vouchers", target: "/v1/admin/vouchers", header: adminHeader(), status: http.StatusOK},
+	{name: "create voucher", method: http.MethodPost, route: "/v1/admin/vouchers", target: "/v1/admin/vouchers", header: adminHeader(), body: `{"code":"GAMEPASS5K","amountOffIdr":5000,"category":"gamepass"}`, status: http.StatusCreated},
+	{name: "create voucher without discount", method: http.MethodPost, route: "/v1/admin/vouchers", target: "/v1/admin/vouchers", header: adminHeader(), body: `{"code":"FREE"}`, status: http.StatusUnprocessableEntity},
+	{name: "create voucher malformed", method: http.MethodPost, route: "/v1/admin/vouchers", target: "/v1/admin/vouchers", header: adminHeader(), body: `{"code":`, status: http.StatusBadRequest},
+	{name: "update voucher unknown", method: http.MethodPut, route: "/v1/admin/vouchers/:id", target: "/v1/admin/vouchers/aa0e8400-e29b-41d4-a716-446655449999", header: adminHeader(), body: `{"code":"GONE","percentOff":5}`, status: http.StatusNotFound},
+	{name: "flash sales", method: http.MethodGet, route: "/v1/admin/flash-sales", target: "/v1/admin/flash-sales", header: adminHeader(), status: http.StatusOK},
+	{name: "create flash sale", method: http.MethodPost, route: "/v1/admin/flash-sales", target: "/v1/admin/flash-sales", header: adminHeader(), body: `{"name":"Payday","startsAt":"2026-10-25T12:00","endsAt":"2026-10-25T14:00","items":[{"productId":"990e8400-e29b-41d4-a716-446655440007","priceIdr":50000,"quantity":20}]}`, status: http.StatusCreated},
+	{name: "create flash sale ending before start", method: http.MethodPost, route: "/v1/admin/flash-sales", target: "/v1/admin/flash-sales", header: adminHeader(), body: `{"name":"Payday","startsAt":"2026-10-25T12:00","endsAt":"2026-10-25T11:00","items":[{"productId":"990e8400-e29b-41d4-a716-446655440007","priceIdr":50000,"quantity":20}]}`, status: http.StatusUnprocessableEntity},
+	{name: "create flash sale malformed", method: http.MethodPost, route: "/v1/admin/flash-sales", target: "/v1/admin/flash-sales", header: adminHeader(), body: `{"name":`, status: http.StatusBadRequest},
+	{name: "update flash sale unknown", method: http.MethodPut, route: "/v1/admin/flash-sales/:id", target: "/v1/admin/flash-sales/aa0e8400-e29b-41d4-a716-446655449999", header: adminHeader(), body: `{"name":"Payday","startsAt":"2026-10-25T12:00","endsAt":"2026-10-25T14:00","items":[{"productId":"990e8400-e29b-41d4-a716-446655440007","priceIdr":50000,"quantity":20}]}`, status: http.StatusNotFound},
+	{name: "update flash sale without items", method: http.MethodPut, route: "/v1/admin/flash-sales/:id", target: "/v1/admin/flash-sales/aa0e8400-e29b-41d4-a716-446655449999", header: adminHeader(), body: `{"name":"Payday","startsAt":"2026-10-25T12:00","endsAt":"2026-10-25T14:00","items":[]}`, status: http.StatusUnprocessableEntity},
+	{name: "update voucher invalid percent", method: http.MethodPut, route: "/v1/admin/vouchers/:id", target: "/v1/admin/vouchers/aa0e8400-e29b-41d4-a716-446655449999", header: adminHeader(), body: `{"code":"GONE","percentOff":150}`, status: http.StatusUnprocessableEntity},
+	{name: "recent logs", method: http.MethodGet, route: "/v1/admin/logs", target: "/v1/admin/logs?limit=5", header: adminHeader(), status: http.StatusOK},
+	{name: "export testimonies json", method: http.MethodGet, route: "/v1/admin/exports/testimonies", target: "/v1/admin/exports/testimonies", header: adminHeader(), status: http.StatusOK},
+	{name: "export testimonies csv", method: http.MethodGet, route: "/v1/admin/exports/testimonies", target: "/v1/admin/exports/testimonies?format=csv", header: adminHeader(), status: http.StatusOK},
+	{name: "export testimonies invalid format", method: http.MethodGet, route: "/v1/admin/exports/testimonies", target: "/v1/admin/exports/testimonies?format=xml", header: adminHeader(), status: http.StatusUnprocessableEntity},
+	{name: "upload user avatar", method: http.MethodPost, route: "/v1/admin/users/:id/avatar", target: "/v1/admin/users/550e8400-e29b-41d4-a716-446655440001/avatar", header: avatarHeader, body: avatarBody, status: http.StatusOK},
+	{name: "upload user avatar unknown user", method: http.MethodPost, route: "/v1/admin/users/:id/avatar", target: "/v1/admin/users/550e8400-e29b-41d4-a716-446655449999/avatar", header: avatarHeader, body: avatarBody, status: http.StatusNotFound},
+	{name: "upload user avatar invalid id", method: http.MethodPost, route: "/v1/admin/users/:id/avatar", target: "/v1/admin/users/1/avatar", header: avatarHeader, body: avatarBody, status: http.StatusUnprocessableEntity},
+	{name: "upload user avatar not an image", method: http.MethodPost, route: "/v1/admin/users/:id/avatar", target: "/v1/admin/users/550e8400-e29b-41d4-a716-446655440001/avatar", header: pdfHeader, body: pdfBody, status: http.StatusUnprocessableEntity},
+	{name: "upload user avatar json", method: http.MethodPost, route: "/v1/admin/users/:id/avatar", target: "/v1/admin/users/550e8400-e29b-41d4-a716-446655440001/avatar", header: adminHeader(), body: `{}`, status: http.StatusUnsupportedMediaType},
+	{name: "upload testimoni icon", method: http.MethodPost, route: "/v1/admin/testimonies/:id/icon", target: "/v1/admin/testimonies/660e8400-e29b-41d4-a716-446655440001/icon", header: iconHeader, body: iconBody, status: http.StatusOK},
+	{name: "upload testimoni icon unknown testimoni", method: http.MethodPost, route: "/v1/admin/testimonies/:id/icon", target: "/v1/admin/testimonies/660e8400-e29b-41d4-a716-446655449999/icon", header: iconHeader, body: iconBody, status: http.StatusNotFound},
+	{name: "export testimonies without token", method: http.MethodGet, route: "/v1/admin/exports/testimonies", target: "/v1/admin/exports/testimonies", status: http.StatusUnauthorized},
+}
+
+func TestContract(t *testing.T) {
+	doc := openAPIDocument()
+	app := newTestApplication(t)
+	ec := app.routes().(*echo.Echo)
+
+	t.Run("every route is documented and covered", func(t *testing.T) {
+		covered := make(map[string]bool)
+		for _, tc := range contractCases {
+			covered[tc.method+" "+tc.route] = true
+		}
+
+		for _, route := range ec.Routes() {
+			key := route.Method + " " + route.Path
+			if route.Method == echo.RouteNotFound || undocumentedRoutes[key] {
+				continue
+			}
+			assert.NotNil(t, doc.Operation(route.Method, route.Path), "%s has no entry in openAPIDocument()", key)
+			assert.True(t, covered[key], "%s has no contract case", key)
+		}
+	})
+
+	t.Run("every documented operation has a route", func(t *testing.T) {
+		registered := make(map[string]bool)
+		for _, route := range ec.Routes() {
+			registered[route.Method+" "+route.Path] = true
+		}
+
+		for _, tc := range contractCases {
+			assert.True(t, registered[tc.method+" "+tc.route], "contract case %q targets unknown route %s %s", tc.name, tc.method, tc.route)
+		}
+		for path := range doc.Paths {
+			for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+				if doc.Operation(method, path) == nil {
+					continue
+				}
+				assert.True(t, isRouted(ec, method, path), "%s %s is documented but not routed", method, path)
+			}
+		}
+	})
+
+	for _, tc := range contractCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, ec, tc.method, tc.target, tc.body, tc.header)
+
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			assert.NoError(t, doc.ValidateResponse(tc.method, tc.route, rec.Code, rec.Header(), rec.Body.Bytes()))
+		})
+	}
+}
+
+func isRouted(ec *echo.Echo, method, path string) bool {
+	for _, route := range ec.Routes() {
+		if route.Method == method && openapi.PathFromEcho(route.Path) == path {
+			return true
+		}
+	}
+	return false
+}
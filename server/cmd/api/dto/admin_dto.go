@@ -0,0 +1,21 @@
+package dto
+
+type AdminLogLevelUpdateDTO struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn error off"`
+}
+
+type AdminLogListDTO struct {
+	Limit *int `query:"limit" validate:"omitempty,min=1,max=10000"`
+}
+
+type AdminExportDTO struct {
+	Format *string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+type AdminUserAvatarDTO struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+type AdminTestimoniIconDTO struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
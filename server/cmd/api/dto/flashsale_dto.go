@@ -0,0 +1,24 @@
+package dto
+
+type AdminFlashSaleItemDTO struct {
+	ProductID string `json:"productId" validate:"required,uuid"`
+	PriceIDR  int64  `json:"priceIdr" validate:"required,min=1,max=100000000" doc:"Whole rupiah per unit"`
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=100000" doc:"Units sold at the price"`
+}
+
+type AdminFlashSaleCreateDTO struct {
+	Name     string                  `json:"name" validate:"required,max=100"`
+	StartsAt string                  `json:"startsAt" validate:"required,datetime=2006-01-02T15:04" doc:"Asia/Jakarta time"`
+	EndsAt   string                  `json:"endsAt" validate:"required,datetime=2006-01-02T15:04" doc:"Asia/Jakarta time"`
+	Items    []AdminFlashSaleItemDTO `json:"items" validate:"required,min=1,max=50,unique=ProductID,dive"`
+	Active   *bool                   `json:"active" doc:"Defaults to true"`
+}
+
+type AdminFlashSaleUpdateDTO struct {
+	ID       string                  `param:"id" json:"-" validate:"required,uuid"`
+	Name     string                  `json:"name" validate:"required,max=100"`
+	StartsAt string                  `json:"startsAt" validate:"required,datetime=2006-01-02T15:04" doc:"Asia/Jakarta time"`
+	EndsAt   string                  `json:"endsAt" validate:"required,datetime=2006-01-02T15:04" doc:"Asia/Jakarta time"`
+	Items    []AdminFlashSaleItemDTO `json:"items" validate:"required,min=1,max=50,unique=ProductID,dive" doc:"Items are matched by product and keep the units they sold"`
+	Active   *bool                   `json:"active" doc:"Defaults to true"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+type OrderRecentDTO struct {
+	Limit *int `query:"limit" validate:"omitempty,min=1,max=50" doc:"Defaults to 10"`
+}
+
+type OrderRecentStreamDTO struct {
+	LastEventID *string `header:"Last-Event-ID" validate:"omitempty,uuid" doc:"ID of the last event received, sent by EventSource when it reconnects"`
+}
+
+type OrderCreateDTO struct {
+	ProductID      string  `json:"productId" validate:"required,uuid"`
+	RobloxUsername string  `json:"robloxUsername" validate:"required,min=3,max=20" doc:"Account the Robux are delivered to"`
+	Quantity       *int    `json:"quantity" validate:"omitempty,min=1,max=10" doc:"Defaults to 1"`
+	PaymentMethod  string  `json:"paymentMethod" validate:"required,oneof=qris va_bca va_bni va_bri va_mandiri ewallet_gopay ewallet_ovo ewallet_dana ewallet_shopeepay"`
+	VoucherCode    *string `json:"voucherCode" validate:"omitempty,max=32"`
+}
+
+type OrderGetDTO struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+type AdminOrderListDTO struct {
+	Status *string `query:"status" validate:"omitempty,oneof=pending_payment paid delivering delivered failed refunded" doc:"Defaults to every status"`
+	Limit  *int    `query:"limit" validate:"omitempty,min=1,max=100" doc:"Defaults to 50"`
+}
+
+type AdminOrderGetDTO struct {
+	ID string `param:"id" validate:"required,uuid"`
+}
+
+type AdminOrderRetryDTO struct {
+	ID     string  `param:"id" json:"-" validate:"required,uuid"`
+	Reason *string `json:"reason" validate:"omitempty,max=500"`
+}
+
+type AdminOrderResolveDTO struct {
+	ID        string  `param:"id" json:"-" validate:"required,uuid"`
+	Status    string  `json:"status" validate:"required,oneof=delivered failed refunded"`
+	Reason    string  `json:"reason" validate:"required,max=500"`
+	Reference *string `json:"reference" validate:"omitempty,max=200" doc:"Delivery at Roblox, for orders delivered by hand"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+type PaymentWebhookDTO struct {
+	Provider string `param:"provider" validate:"required"`
+}
+
+type PaymentSimulateDTO struct {
+	ChargeID string `param:"chargeId" json:"-" validate:"required"`
+	Status   string `json:"status" validate:"required,oneof=paid expired failed"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type PricingQuoteDTO struct {
+	Robux *int `query:"robux" validate:"required,min=1,max=1000000"`
+}
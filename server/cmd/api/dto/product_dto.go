@@ -0,0 +1,16 @@
+package dto
+
+type ProductBestSellersDTO struct {
+	Window *string `query:"window" validate:"omitempty,oneof=7d 30d all" doc:"Defaults to 30d"`
+	Limit  *int    `query:"limit" validate:"omitempty,min=1,max=50" doc:"Defaults to 10"`
+}
+
+type AdminFeaturedCalendarListDTO struct {
+	From *string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To   *string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type AdminFeaturedCalendarUpdateDTO struct {
+	Date       string   `param:"date" json:"-" validate:"required,datetime=2006-01-02"`
+	ProductIDs []string `json:"productIds" validate:"required,max=50,unique,dive,uuid"`
+}
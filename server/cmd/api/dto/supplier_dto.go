@@ -0,0 +1,36 @@
+package dto
+
+type AdminSupplierAccountCreateDTO struct {
+	Name           string `json:"name" validate:"required,max=100"`
+	MaxConcurrency *int   `json:"maxConcurrency" validate:"omitempty,min=1,max=50" doc:"Defaults to 1"`
+	Active         *bool  `json:"active" doc:"Defaults to true"`
+}
+
+type AdminSupplierAccountUpdateDTO struct {
+	ID             string  `param:"id" json:"-" validate:"required,uuid"`
+	Name           *string `json:"name" validate:"omitempty,min=1,max=100"`
+	MaxConcurrency *int    `json:"maxConcurrency" validate:"omitempty,min=1,max=50"`
+	Active         *bool   `json:"active"`
+}
+
+type AdminDeadLetterListDTO struct {
+	All   *bool `query:"all" doc:"Include resolved dead letters"`
+	Limit *int  `query:"limit" validate:"omitempty,min=1,max=100" doc:"Defaults to 50"`
+}
+
+type AdminStockLedgerDTO struct {
+	ID    string `param:"id" validate:"required,uuid"`
+	Limit *int   `query:"limit" validate:"omitempty,min=1,max=500" doc:"Defaults to 100"`
+}
+
+type AdminStockPurchaseDTO struct {
+	ID    string  `param:"id" json:"-" validate:"required,uuid"`
+	Robux int     `json:"robux" validate:"required,min=1,max=100000000"`
+	Note  *string `json:"note" validate:"omitempty,max=500"`
+}
+
+type AdminStockReconcileDTO struct {
+	ID    string  `param:"id" json:"-" validate:"required,uuid"`
+	Robux *int    `json:"robux" validate:"required,min=0,max=1000000000" doc:"Robux balance of the account at Roblox"`
+	Note  *string `json:"note" validate:"omitempty,max=500"`
+}
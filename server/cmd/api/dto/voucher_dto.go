@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+type VoucherValidateDTO struct {
+	Code           string  `json:"code" validate:"required,max=32"`
+	ProductID      string  `json:"productId" validate:"required,uuid"`
+	Quantity       *int    `json:"quantity" validate:"omitempty,min=1,max=10" doc:"Defaults to 1"`
+	RobloxUsername *string `json:"robloxUsername" validate:"omitempty,min=3,max=20" doc:"Buyer the per-user limit is checked for, skipped when empty"`
+}
+
+type AdminVoucherCreateDTO struct {
+	Code           string     `json:"code" validate:"required,min=3,max=32,alphanum" doc:"Stored upper-case"`
+	Description    *string    `json:"description" validate:"omitempty,max=200"`
+	PercentOff     *int       `json:"percentOff" validate:"omitempty,min=1,max=100" doc:"Set this or amountOffIdr"`
+	AmountOffIDR   *int64     `json:"amountOffIdr" validate:"omitempty,min=1,max=100000000" doc:"Whole rupiah"`
+	MaxDiscountIDR *int64     `json:"maxDiscountIdr" validate:"omitempty,min=1,max=100000000" doc:"Whole rupiah, caps percent vouchers"`
+	MinSpendIDR    *int64     `json:"minSpendIdr" validate:"omitempty,min=0,max=100000000" doc:"Whole rupiah, defaults to 0"`
+	Category       *string    `json:"category" validate:"omitempty,oneof=robux gamepass"`
+	ProductIDs     []string   `json:"productIds" validate:"omitempty,max=50,dive,uuid"`
+	UsageLimit     *int       `json:"usageLimit" validate:"omitempty,min=1,max=1000000"`
+	PerUserLimit   *int       `json:"perUserLimit" validate:"omitempty,min=1,max=1000"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	Active         *bool      `json:"active" doc:"Defaults to true"`
+}
+
+type AdminVoucherUpdateDTO struct {
+	ID             string     `param:"id" json:"-" validate:"required,uuid"`
+	Code           string     `json:"code" validate:"required,min=3,max=32,alphanum" doc:"Stored upper-case"`
+	Description    *string    `json:"description" validate:"omitempty,max=200"`
+	PercentOff     *int       `json:"percentOff" validate:"omitempty,min=1,max=100" doc:"Set this or amountOffIdr"`
+	AmountOffIDR   *int64     `json:"amountOffIdr" validate:"omitempty,min=1,max=100000000" doc:"Whole rupiah"`
+	MaxDiscountIDR *int64     `json:"maxDiscountIdr" validate:"omitempty,min=1,max=100000000" doc:"Whole rupiah, caps percent vouchers"`
+	MinSpendIDR    *int64     `json:"minSpendIdr" validate:"omitempty,min=0,max=100000000" doc:"Whole rupiah, defaults to 0"`
+	Category       *string    `json:"category" validate:"omitempty,oneof=robux gamepass"`
+	ProductIDs     []string   `json:"productIds" validate:"omitempty,max=50,dive,uuid"`
+	UsageLimit     *int       `json:"usageLimit" validate:"omitempty,min=1,max=1000000"`
+	PerUserLimit   *int       `json:"perUserLimit" validate:"omitempty,min=1,max=1000"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	Active         *bool      `json:"active" doc:"Defaults to true"`
+}
@@ -6,71 +6,48 @@ import (
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/data"
 	"github.com/ucok-man/mayobox-server/internal/tlog"
 )
 
+// HTTPErrorHandler writes every error as the default envelope
+//
+//	{"error": {"code": "Not Found", "message": "...", "details": ...}}
+//
+// or, when the Accept header asks for it, as an RFC 7807
+// application/problem+json body carrying the stable apperror code.
 func (app *application) HTTPErrorHandler(err error, ctx echo.Context) {
 	if ctx.Response().Committed {
 		return
 	}
 
-	var response struct {
-		Code    string `json:"code"`
-		Message string `json:"message"`
-		Details any    `json:"details,omitempty"`
-	}
-
-	if he, ok := err.(*echo.HTTPError); ok {
-		response.Code = http.StatusText(he.Code)
-		switch he.Code {
-		case http.StatusUnprocessableEntity:
-			response.Message = "unable to proccess request because some malformed input"
-			response.Details = he.Message
-
-		// Change default notfound and method not allowed.
-		case http.StatusNotFound:
-			response.Message = "the requested resource could not be found"
-		case http.StatusMethodNotAllowed:
-			response.Message = fmt.Sprintf("the %s method is not supported for this resource", ctx.Request().Method)
-		default:
-			msg, ok := he.Message.(string)
-			if !ok {
-				msg = fmt.Sprintf("%v", he.Message)
-			}
-
-			// Extract just the actual error message if it's in Echo's format
-			if idx := strings.Index(msg, "message="); idx != -1 {
-				msg = msg[idx+8:] // Skip "message="
-				// Remove internal error suffix if present
-				if commaIdx := strings.Index(msg, ", internal="); commaIdx != -1 {
-					msg = msg[:commaIdx]
-				}
-			}
-			response.Message = msg
-		}
+	appErr := toAppError(err)
 
-		err = ctx.JSON(he.Code, envelope{"error": response})
-		if err != nil {
-			app.logger.Errorj(tlog.JSON{
-				"message": "error sending json response",
-				"error":   err,
-			})
-			ctx.Response().WriteHeader(http.StatusInternalServerError)
-		}
-		return
+	// Handlers log through ErrInternalServer, anything else is unexpected.
+	if _, ok := err.(*echo.HTTPError); !ok && apperror.As(err) == nil {
+		app.logger.Errorj(tlog.JSON{
+			"message": "unhandled error occured",
+			"error":   err,
+		})
 	}
 
-	// Uncaught Error
-	app.logger.Errorj(tlog.JSON{
-		"message": "unhandled error occured",
-		"error":   err,
-	})
-	ctx.Response().Status = 500
-	ctx.Response().WriteHeader(500)
+	req := ctx.Request()
+	if apperror.WantsProblem(req.Header.Get(echo.HeaderAccept)) {
+		lang := apperror.NegotiateLanguage(req.Header.Get("Accept-Language"))
+		problem := appErr.Problem(lang, req.URL.Path)
+		if appErr.Code == apperror.CodeMethodNotAllowed {
+			problem.Detail = fmt.Sprintf("the %s method is not supported for this resource", req.Method)
+		}
+
+		ctx.Response().Header().Set("Content-Language", lang)
+		ctx.Response().Header().Add(echo.HeaderVary, "Accept, Accept-Language")
+		err = app.writeProblem(ctx, problem)
+	} else {
+		ctx.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+		err = ctx.JSON(appErr.Status(), envelope{"error": envelopeError(appErr, req.Method)})
+	}
 
-	response.Code = http.StatusText(http.StatusInternalServerError)
-	response.Message = "the server encountered a problem and could not process your request"
-	err = ctx.JSON(http.StatusInternalServerError, envelope{"error": response})
 	if err != nil {
 		app.logger.Errorj(tlog.JSON{
 			"message": "error sending json response",
@@ -78,7 +55,76 @@ func (app *application) HTTPErrorHandler(err error, ctx echo.Context) {
 		})
 		ctx.Response().WriteHeader(http.StatusInternalServerError)
 	}
+}
+
+func (app *application) writeProblem(ctx echo.Context, problem apperror.Problem) error {
+	ctx.Response().Header().Set(echo.HeaderContentType, apperror.MIMEProblemJSON)
+	ctx.Response().WriteHeader(problem.Status)
+	return ctx.Echo().JSONSerializer.Serialize(ctx, problem, "")
+}
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// envelopeError builds the default error body. Its messages predate the
+// error catalog and are kept as they were for existing clients.
+func envelopeError(appErr *apperror.Error, method string) errorResponse {
+	status := appErr.Status()
+	response := errorResponse{Code: http.StatusText(status)}
+
+	switch status {
+	case http.StatusUnprocessableEntity:
+		response.Message = "unable to proccess request because some malformed input"
+		response.Details = appErr.Details
+	case http.StatusNotFound:
+		response.Message = "the requested resource could not be found"
+	case http.StatusMethodNotAllowed:
+		response.Message = fmt.Sprintf("the %s method is not supported for this resource", method)
+	default:
+		response.Message = appErr.LocalizedMessage(apperror.DefaultLanguage)
+	}
+	return response
+}
+
+// toAppError converts errors raised outside the handlers, e.g. by echo's
+// router and middleware, into an *apperror.Error.
+func toAppError(err error) *apperror.Error {
+	if appErr := apperror.As(err); appErr != nil {
+		return appErr
+	}
+
+	he, ok := err.(*echo.HTTPError)
+	if !ok {
+		return apperror.New(apperror.CodeInternal).Wrap(err)
+	}
+
+	appErr := apperror.New(apperror.FromStatus(he.Code)).Wrap(he.Internal)
+	if appErr.Code == apperror.CodeValidationFailed {
+		return appErr.WithDetails(he.Message)
+	}
+	if appErr.Code == apperror.CodeInternal {
+		return appErr
+	}
+	if msg, ok := he.Message.(string); ok && msg != http.StatusText(he.Code) {
+		appErr.Message = echoMessage(msg)
+	}
+	return appErr
+}
 
+// echoMessage extracts just the message from the "code=400, message=...,
+// internal=..." string of a wrapped *echo.HTTPError.
+func echoMessage(msg string) string {
+	if idx := strings.Index(msg, "message="); idx != -1 {
+		msg = msg[idx+8:] // Skip "message="
+		// Remove internal error suffix if present
+		if commaIdx := strings.Index(msg, ", internal="); commaIdx != -1 {
+			msg = msg[:commaIdx]
+		}
+	}
+	return msg
 }
 
 func (app *application) ErrInternalServer(err error, message string, req *http.Request) error {
@@ -89,50 +135,102 @@ func (app *application) ErrInternalServer(err error, message string, req *http.R
 		"method":  req.Method,
 		"error":   err,
 	})
-	return echo.NewHTTPError(
-		http.StatusInternalServerError,
-		"the server encountered a problem and could not process your request",
-	)
+	return apperror.New(apperror.CodeInternal).Wrap(err)
 }
 
-func (app *application) ErrNotFound(customMsg ...string) error {
-	message := "the requested resource could not be found"
-	if len(customMsg) > 0 && customMsg[0] != "" {
-		message = customMsg[0]
+// ErrNotFound reports a missing resource. Pass a more specific code, e.g.
+// apperror.CodeLogBufferDisabled, when clients need to tell causes apart.
+func (app *application) ErrNotFound(code ...apperror.Code) error {
+	if len(code) > 0 {
+		return apperror.New(code[0])
 	}
-	return echo.NewHTTPError(http.StatusNotFound, message)
+	return apperror.New(apperror.CodeResourceNotFound)
 }
 
 func (app *application) ErrMethodNotAllowed(method string) error {
-	return echo.NewHTTPError(
-		http.StatusMethodNotAllowed,
-		fmt.Sprintf("the %s method is not supported for this resource", method),
-	)
+	return apperror.New(apperror.CodeMethodNotAllowed).
+		WithMessage("the %s method is not supported for this resource", method)
 }
 
 func (app *application) ErrBadRequest(message string) error {
-	return echo.NewHTTPError(http.StatusBadRequest, message)
+	return apperror.New(apperror.CodeBadRequest).WithMessage("%s", echoMessage(message))
 }
 
 func (app *application) ErrFailedValidation(errmap any) error {
-	return echo.NewHTTPError(http.StatusUnprocessableEntity, errmap)
+	return apperror.New(apperror.CodeValidationFailed).WithDetails(errmap)
 }
 
 func (app *application) ErrEditConflict() error {
-	return echo.NewHTTPError(
-		http.StatusConflict,
-		"unable to update the record due to an edit conflict, please try again",
-	)
+	return apperror.New(apperror.CodeEditConflict)
 }
 
 func (app *application) ErrRateLimitExceeded() error {
-	return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+	return apperror.New(apperror.CodeRateLimitExceeded)
+}
+
+func (app *application) ErrInvalidAuthenticationToken() error {
+	return apperror.New(apperror.CodeInvalidAuthToken)
+}
+
+// ErrForbidden denies the request. Pass a more specific code, e.g.
+// apperror.CodeAdminAPIDisabled, when clients need to tell causes apart.
+func (app *application) ErrForbidden(code ...apperror.Code) error {
+	if len(code) > 0 {
+		return apperror.New(code[0])
+	}
+	return apperror.New(apperror.CodeForbidden)
+}
+
+// ErrServiceUnavailable asks the client to come back later. Pass a more
+// specific code, e.g. apperror.CodeTooManyStreams, when clients need to
+// tell causes apart.
+func (app *application) ErrServiceUnavailable(code ...apperror.Code) error {
+	if len(code) > 0 {
+		return apperror.New(code[0])
+	}
+	return apperror.New(apperror.CodeServiceUnavailable)
+}
+
+// ErrInvalidOrderTransition rejects moving an order from status from to
+// status to. The details list the statuses it may move to instead.
+func (app *application) ErrInvalidOrderTransition(from, to string, allowed []string) error {
+	next := "none"
+	if len(allowed) > 0 {
+		next = strings.Join(allowed, ", ")
+	}
+	return apperror.New(apperror.CodeInvalidOrderTransition).
+		WithMessage("the order cannot move from %s to %s", from, to).
+		WithDetails(map[string]string{"status": from, "allowed": next})
+}
+
+func (app *application) ErrOutOfStock() error {
+	return apperror.New(apperror.CodeOutOfStock)
 }
 
-func (app *application) ErrForbidden(message ...string) error {
-	msg := "forbidden"
-	if len(message) > 0 && message[0] != "" {
-		msg = message[0]
+func (app *application) ErrFlashSaleSoldOut() error {
+	return apperror.New(apperror.CodeFlashSaleSoldOut)
+}
+
+// ErrInvalidVoucher rejects a voucher that does not apply to an order. The
+// details carry the reason, and the minimum spend when it is not met.
+func (app *application) ErrInvalidVoucher(err *data.VoucherError) error {
+	details := map[string]string{"reason": err.Reason}
+	if err.Reason == data.VoucherMinSpend {
+		details["minSpend"] = err.MinSpend.String()
 	}
-	return echo.NewHTTPError(http.StatusForbidden, msg)
+	return apperror.New(apperror.CodeInvalidVoucher).WithDetails(details)
+}
+
+func (app *application) ErrInvalidIdempotencyKey() error {
+	return apperror.New(apperror.CodeInvalidIdempotencyKey)
+}
+
+func (app *application) ErrIdempotencyKeyReused() error {
+	return apperror.New(apperror.CodeIdempotencyKeyReused).WithDetails(map[string]string{
+		"idempotency_key": "must not be reused with a different request",
+	})
+}
+
+func (app *application) ErrIdempotencyInProgress() error {
+	return apperror.New(apperror.CodeIdempotencyInProgress)
 }
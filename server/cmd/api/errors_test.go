@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	t.Run("keeps the default envelope", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/nope", "", nil)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+		assert.JSONEq(t, `{"error":{"code":"Not Found","message":"the requested resource could not be found"}}`, rec.Body.String())
+	})
+
+	t.Run("keeps envelope validation details", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/testimonies?page=0", "", nil)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Equal(t, "Unprocessable Entity", body.Error.Code)
+		assert.Equal(t, "unable to proccess request because some malformed input", body.Error.Message)
+		assert.Contains(t, body.Error.Details, "pagination.page")
+	})
+
+	t.Run("strips echo formatting from bind errors", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/testimonies?page=abc", "", nil)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.NotContains(t, body.Error.Message, "code=400")
+		assert.NotContains(t, body.Error.Message, "internal=")
+	})
+
+	t.Run("serves problem details when asked", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/nope", "", problemHeader())
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, apperror.MIMEProblemJSON, rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+		assert.JSONEq(t, `{
+			"type": "urn:mayobox:error:route_not_found",
+			"title": "Not found",
+			"status": 404,
+			"detail": "the requested resource could not be found",
+			"instance": "/v1/nope",
+			"code": "route_not_found"
+		}`, rec.Body.String())
+	})
+
+	t.Run("localizes problem details", func(t *testing.T) {
+		app := newTestApplication(t)
+		header := problemHeader()
+		header.Set("Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/testimonies?page=0", "", header)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "id", rec.Header().Get("Content-Language"))
+
+		var problem apperror.Problem
+		decodeBody(t, rec, &problem)
+		assert.Equal(t, apperror.CodeValidationFailed, problem.Code)
+		assert.Equal(t, "Validasi gagal", problem.Title)
+		assert.Contains(t, problem.Details, "pagination.page")
+	})
+
+	t.Run("uses specific codes", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.logRing = nil
+		header := adminHeader()
+		header.Set(echo.HeaderAccept, apperror.MIMEProblemJSON)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/logs", "", header)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		var problem apperror.Problem
+		decodeBody(t, rec, &problem)
+		assert.Equal(t, apperror.CodeLogBufferDisabled, problem.Code)
+		assert.Equal(t, "the in-memory log buffer is disabled", problem.Detail)
+	})
+
+	t.Run("reports the method for 405", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodDelete, "/v1/faqs", "", problemHeader())
+
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		var problem apperror.Problem
+		decodeBody(t, rec, &problem)
+		assert.Equal(t, apperror.CodeMethodNotAllowed, problem.Code)
+		assert.Equal(t, "the DELETE method is not supported for this resource", problem.Detail)
+	})
+
+	t.Run("hides unexpected errors", func(t *testing.T) {
+		app := newTestApplication(t)
+		ec := echo.New()
+		rec := testRequest(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			app.HTTPErrorHandler(errors.New("pq: connection refused"), ec.NewContext(r, w))
+		}), http.MethodGet, "/", "", nil)
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.JSONEq(t, `{"error":{"code":"Internal Server Error","message":"the server encountered a problem and could not process your request"}}`, rec.Body.String())
+	})
+}
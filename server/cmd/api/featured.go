@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/featured"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// PricedProduct is a product with its price in both currencies.
+type PricedProduct struct {
+	*data.Product
+	Price pricing.Price `json:"price"`
+	// InStock is false while no supplier account has the Robux of one
+	// unit available, reservations included.
+	InStock bool `json:"inStock"`
+	// FlashSale is set while the price is a flash sale price.
+	FlashSale *FlashSalePrice `json:"flashSale" doc:"null when the product sells at the rate table price"`
+}
+
+// FlashSalePrice tells where the price of a product on a flash sale comes
+// from.
+type FlashSalePrice struct {
+	FlashSaleID string        `json:"flashSaleId"`
+	ItemID      string        `json:"itemId"`
+	RegularIDR  pricing.Money `json:"regularIdr" doc:"Rate table price"`
+	Remaining   int           `json:"remaining" doc:"Units left at the flash sale price"`
+	EndsAt      time.Time     `json:"endsAt"`
+}
+
+// FeaturedProduct is a Product of the Day.
+type FeaturedProduct struct {
+	Date     string        `json:"date" doc:"Day in Asia/Jakarta"`
+	Position int           `json:"position"`
+	Source   string        `json:"source" doc:"calendar when an editor picked it, random otherwise"`
+	Product  PricedProduct `json:"product"`
+}
+
+// startFeaturedScheduler picks the Products of the Day until shutdown. It
+// checks every minute, so a new set is served shortly after midnight in
+// Asia/Jakarta. Each replica runs a scheduler; the first to save a day's
+// picks wins and the others serve them.
+func (app *application) startFeaturedScheduler() {
+	app.every("featured products", time.Minute, func(ctx context.Context) error {
+		_, err := app.scheduleFeatured(featured.Day(time.Now()))
+		return err
+	})
+}
+
+// scheduleFeatured returns the picks of day, choosing and saving them
+// first when the day has none yet.
+func (app *application) scheduleFeatured(day string) ([]*data.FeaturedPick, error) {
+	picks, err := app.models.Featured.GetPicks(day)
+	if err != nil || len(picks) > 0 {
+		return picks, err
+	}
+
+	cfg := app.currentConfig()
+	products, err := app.models.Product.GetAllActive()
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := app.models.Featured.GetCalendar(day, day)
+	if err != nil {
+		return nil, err
+	}
+	since, err := featured.AddDays(day, -cfg.Featured.CooldownDays)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := app.models.Featured.RecentProductIDs(since, day)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool, len(products))
+	candidates := make([]featured.Candidate, 0, len(products))
+	for _, product := range products {
+		active[product.ID] = true
+		candidates = append(candidates, featured.Candidate{ProductID: product.ID, Weight: product.FeaturedWeight})
+	}
+	// Products taken off sale after they were put on the calendar are
+	// skipped.
+	var calendarIDs []string
+	for _, entry := range calendar {
+		if active[entry.ProductID] {
+			calendarIDs = append(calendarIDs, entry.ProductID)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	chosen := featured.Choose(cfg.Featured.Count, calendarIDs, candidates, recent, rng)
+
+	rows := make([]*data.FeaturedPick, 0, len(chosen))
+	for i, pick := range chosen {
+		rows = append(rows, &data.FeaturedPick{Date: day, Position: i + 1, Source: pick.Source, ProductID: pick.ProductID})
+	}
+	saved, err := app.models.Featured.SavePicks(day, rows)
+	if err != nil {
+		return nil, err
+	}
+	if saved {
+		app.logger.Infoj(tlog.JSON{"message": "scheduled featured products", "date": day, "count": len(rows), "from_calendar": len(calendarIDs)})
+	}
+
+	return app.models.Featured.GetPicks(day)
+}
+
+// priceProducts prices products with the rate table in effect, or the
+// live flash sale with a unit left, and tells whether they are in stock.
+func (app *application) priceProducts(products ...*data.Product) ([]PricedProduct, error) {
+	now := time.Now()
+	table, err := app.models.RateTable.Current(now)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := app.models.FlashSale.Live(now)
+	if err != nil {
+		return nil, err
+	}
+	available, err := app.models.Stock.Available()
+	if err != nil {
+		return nil, err
+	}
+
+	priced := make([]PricedProduct, 0, len(products))
+	for _, product := range products {
+		price, err := table.Price(product.Robux)
+		if err != nil {
+			return nil, err
+		}
+		p := PricedProduct{Product: product, Price: price, InStock: product.Robux <= available}
+		if sale, item := data.FlashSaleOffer(sales, product.ID, 1); item != nil {
+			p.Price.IDR = item.PriceIDR
+			p.FlashSale = &FlashSalePrice{
+				FlashSaleID: sale.ID,
+				ItemID:      item.ID,
+				RegularIDR:  price.IDR,
+				Remaining:   item.Remaining(),
+				EndsAt:      sale.EndsAt.In(utility.Jakarta),
+			}
+		}
+		priced = append(priced, p)
+	}
+	return priced, nil
+}
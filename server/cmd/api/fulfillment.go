@@ -0,0 +1,213 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+const (
+	// fulfillmentInterval is how often paid orders are claimed for delivery
+	// when no delivery finishes in between.
+	fulfillmentInterval = 5 * time.Second
+	// deliveryTimeout bounds one delivery attempt.
+	deliveryTimeout = 2 * time.Minute
+)
+
+// deliveryPool counts the deliveries running, in total and per supplier
+// account, so no more orders are claimed than can start at once.
+type deliveryPool struct {
+	mu       sync.Mutex
+	running  int
+	inFlight map[string]int
+	// done wakes the dispatcher when a delivery finishes.
+	done chan struct{}
+}
+
+func newDeliveryPool() *deliveryPool {
+	return &deliveryPool{
+		inFlight: make(map[string]int),
+		done:     make(chan struct{}, 1),
+	}
+}
+
+// free returns how many more deliveries may start from account with at
+// most workers running in total.
+func (p *deliveryPool) free(account *data.SupplierAccount, workers int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return max(min(account.MaxConcurrency-p.inFlight[account.ID], workers-p.running), 0)
+}
+
+// busy returns the deliveries running from accountID.
+func (p *deliveryPool) busy(accountID string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inFlight[accountID]
+}
+
+func (p *deliveryPool) start(accountID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running++
+	p.inFlight[accountID]++
+}
+
+func (p *deliveryPool) finish(accountID string) {
+	p.mu.Lock()
+	p.running--
+	p.inFlight[accountID]--
+	if p.inFlight[accountID] == 0 {
+		delete(p.inFlight, accountID)
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.done <- struct{}{}:
+	default:
+	}
+}
+
+// newDeliverer returns the deliverer of paid orders, nil when admins
+// deliver them by hand.
+func newDeliverer(cfg Config) fulfillment.Deliverer {
+	if cfg.Fulfillment.Deliverer == "manual" {
+		return nil
+	}
+	return fulfillment.Fake{}
+}
+
+// retryPolicy returns the delivery retry policy in effect.
+func (app *application) retryPolicy() fulfillment.Policy {
+	cfg := app.currentConfig().Fulfillment
+	return fulfillment.Policy{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.RetryBaseDelay,
+		MaxDelay:    cfg.RetryMaxDelay,
+	}
+}
+
+// startFulfillment delivers paid orders as their attempts fall due, at
+// most FULFILLMENT_WORKERS at once and no more per supplier account than
+// its max concurrency. Orders are claimed again every fulfillmentInterval
+// and whenever a delivery finishes. With the manual deliverer paid orders
+// wait for an admin instead.
+func (app *application) startFulfillment() {
+	if app.deliverer == nil {
+		return
+	}
+	app.background("fulfillment", func(ctx context.Context) {
+		ticker := time.NewTicker(fulfillmentInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := app.dispatchDeliveries(ctx, time.Now()); err != nil {
+				app.logger.Errorj(tlog.JSON{"message": "background task failed", "task": "fulfillment", "error": err})
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-app.deliveries.done:
+			}
+		}
+	})
+}
+
+// dispatchDeliveries claims the orders due at now that the free workers of
+// every active supplier account can take, and starts delivering them as
+// background tasks, so shutdown waits for them. It returns the number of
+// deliveries started.
+func (app *application) dispatchDeliveries(ctx context.Context, now time.Time) (int, error) {
+	accounts, err := app.models.SupplierAccount.GetAll()
+	if err != nil {
+		return 0, err
+	}
+	accounts = slices.DeleteFunc(accounts, func(a *data.SupplierAccount) bool { return !a.Active })
+	// The least busy accounts claim first, spreading orders across them.
+	slices.SortStableFunc(accounts, func(a, b *data.SupplierAccount) int {
+		return cmp.Compare(app.deliveries.busy(a.ID), app.deliveries.busy(b.ID))
+	})
+
+	workers := app.currentConfig().Fulfillment.Workers
+	started := 0
+	for _, account := range accounts {
+		if ctx.Err() != nil {
+			break
+		}
+		free := app.deliveries.free(account, workers)
+		if free == 0 {
+			continue
+		}
+
+		orders, err := app.models.Order.ClaimForDelivery(account.ID, now, free)
+		if err != nil {
+			return started, err
+		}
+		for _, order := range orders {
+			app.deliveries.start(account.ID)
+			app.background("delivery", func(ctx context.Context) {
+				defer app.deliveries.finish(account.ID)
+				if err := app.deliver(ctx, order); err != nil {
+					app.logger.Errorj(tlog.JSON{"message": "background task failed", "task": "delivery", "orderId": order.ID, "error": err})
+				}
+			})
+		}
+		started += len(orders)
+	}
+	return started, nil
+}
+
+// deliver makes one delivery attempt of an order claimed for delivery,
+// then moves it to delivered, back to paid with the next attempt
+// scheduled, or to failed and the dead letters once the retry policy gives
+// up.
+func (app *application) deliver(ctx context.Context, order *data.Order) error {
+	// An attempt that started runs to the end, so shutting down does not
+	// leave the order delivering with Robux possibly sent.
+	deliverCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deliveryTimeout)
+	defer cancel()
+
+	delivery := fulfillment.Delivery{
+		OrderID:        order.ID,
+		RobloxUsername: order.RobloxUsername,
+		Robux:          order.Robux,
+		Attempt:        order.Delivery.Attempts,
+	}
+	if order.Delivery.SupplierAccountID != nil {
+		delivery.SupplierAccountID = *order.Delivery.SupplierAccountID
+	}
+	receipt, err := app.deliverer.Deliver(deliverCtx, delivery)
+	if err == nil {
+		_, err = app.models.Order.Transition(order.ID, data.OrderChange{
+			To:        data.OrderStatusDelivered,
+			Actor:     fulfillment.ActorSystem,
+			Reference: receipt.Reference,
+		})
+		if err != nil {
+			return err
+		}
+		app.logger.Infoj(tlog.JSON{"message": "order delivered", "orderId": order.ID, "robux": order.Robux, "attempt": order.Delivery.Attempts})
+		return nil
+	}
+
+	change := data.OrderChange{To: data.OrderStatusFailed, Actor: fulfillment.ActorSystem, Error: err.Error(), Reason: "delivery attempts exhausted", DeadLetter: true}
+	if fulfillment.IsPermanent(err) {
+		change.Reason = "delivery failed permanently"
+	}
+	if delay, ok := app.retryPolicy().Retry(order.Delivery.Attempts, err); ok {
+		retryAt := time.Now().Add(delay)
+		change = data.OrderChange{To: data.OrderStatusPaid, Actor: fulfillment.ActorSystem, Error: err.Error(), RetryAt: &retryAt, Reason: "delivery attempt failed"}
+	}
+
+	app.logger.Warnj(tlog.JSON{"message": "order delivery failed", "orderId": order.ID, "attempt": order.Delivery.Attempts, "status": change.To, "error": err})
+	_, err = app.models.Order.Transition(order.ID, change)
+	return err
+}
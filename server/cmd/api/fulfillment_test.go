@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+)
+
+// failingDeliverer fails every delivery with err.
+type failingDeliverer struct {
+	err      error
+	mu       sync.Mutex
+	attempts []int
+}
+
+func (d *failingDeliverer) Name() string {
+	return "failing"
+}
+
+func (d *failingDeliverer) Deliver(_ context.Context, delivery fulfillment.Delivery) (*fulfillment.Receipt, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.attempts = append(d.attempts, delivery.Attempt)
+	return nil, d.err
+}
+
+// blockingDeliverer holds every delivery until release is closed.
+type blockingDeliverer struct {
+	release chan struct{}
+	started chan string
+}
+
+func newBlockingDeliverer() *blockingDeliverer {
+	return &blockingDeliverer{release: make(chan struct{}), started: make(chan string, 100)}
+}
+
+func (d *blockingDeliverer) Name() string {
+	return "blocking"
+}
+
+func (d *blockingDeliverer) Deliver(_ context.Context, delivery fulfillment.Delivery) (*fulfillment.Receipt, error) {
+	d.started <- delivery.SupplierAccountID
+	<-d.release
+	return &fulfillment.Receipt{Reference: "blocked"}, nil
+}
+
+// deliverDue dispatches the orders due at now and waits for their
+// deliveries to finish.
+func deliverDue(t *testing.T, app *application, now time.Time) int {
+	t.Helper()
+
+	started, err := app.dispatchDeliveries(context.Background(), now)
+	require.NoError(t, err)
+	waitDeliveries(t, app)
+	return started
+}
+
+// waitDeliveries waits for the deliveries running to finish. The other
+// background tasks keep running until the test ends.
+func waitDeliveries(t *testing.T, app *application) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		app.deliveries.mu.Lock()
+		defer app.deliveries.mu.Unlock()
+		return app.deliveries.running == 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+// createPaidTestOrder places an order and pays it through the simulator.
+func createPaidTestOrder(t *testing.T, handler http.Handler) orderResponse {
+	t.Helper()
+
+	order := createTestOrder(t, handler, payment.MethodQRIS)
+	rec := testRequest(t, handler, http.MethodPost, "/v1/payments/simulator/"+order.Data.Payment.ChargeID, `{"status":"paid"}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return order
+}
+
+func TestDispatchDeliveries(t *testing.T) {
+	t.Run("delivers paid orders", func(t *testing.T) {
+		app := newTestApplication(t)
+		handler := app.routes()
+		paid := createPaidTestOrder(t, handler)
+		pending := createTestOrder(t, handler, payment.MethodQRIS)
+
+		assert.Equal(t, 1, deliverDue(t, app, time.Now()))
+
+		order, err := app.models.Order.Get(paid.Data.ID)
+		require.NoError(t, err)
+		assert.Equal(t, data.OrderStatusDelivered, order.Status)
+		assert.NotNil(t, order.DeliveredAt)
+		assert.Equal(t, 1, order.Delivery.Attempts)
+		require.NotNil(t, order.Delivery.Reference)
+		assert.Contains(t, *order.Delivery.Reference, "fake_")
+		assert.Equal(t, data.OrderStatusPendingPayment, getTestOrder(t, handler, pending.Data.ID).Data.Status)
+
+		sales, err := app.models.Order.RecentSales(10)
+		require.NoError(t, err)
+		assert.Len(t, sales, 1)
+	})
+
+	t.Run("retries with backoff then fails", func(t *testing.T) {
+		app := newTestApplication(t)
+		deliverer := &failingDeliverer{err: errors.New("roblox timed out")}
+		app.deliverer = deliverer
+		paid := createPaidTestOrder(t, app.routes())
+
+		now := time.Now()
+		assert.Equal(t, 1, deliverDue(t, app, now))
+
+		order, err := app.models.Order.Get(paid.Data.ID)
+		require.NoError(t, err)
+		assert.Equal(t, data.OrderStatusPaid, order.Status)
+		assert.Equal(t, "roblox timed out", *order.Delivery.LastError)
+		require.NotNil(t, order.Delivery.NextAttemptAt)
+		assert.WithinDuration(t, now.Add(time.Minute), *order.Delivery.NextAttemptAt, 5*time.Second)
+
+		// Not due until the backoff passes.
+		assert.Zero(t, deliverDue(t, app, now))
+		assert.Equal(t, []int{1}, deliverer.attempts)
+
+		// MaxAttempts is 3 in tests.
+		for range 2 {
+			deliverDue(t, app, now.Add(3*time.Hour))
+		}
+		assert.Equal(t, []int{1, 2, 3}, deliverer.attempts)
+
+		order, err = app.models.Order.Get(paid.Data.ID)
+		require.NoError(t, err)
+		assert.Equal(t, data.OrderStatusFailed, order.Status)
+		assert.Nil(t, order.Delivery.NextAttemptAt)
+
+		letters, err := app.models.Order.DeadLetters(true, 10)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, paid.Data.ID, letters[0].OrderID)
+		assert.Equal(t, 3, letters[0].Attempts)
+		assert.Equal(t, "roblox timed out", letters[0].Error)
+	})
+
+	t.Run("fails permanent errors at once", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.deliverer = &failingDeliverer{err: fulfillment.Permanent(errors.New("user not found"))}
+		paid := createPaidTestOrder(t, app.routes())
+
+		assert.Equal(t, 1, deliverDue(t, app, time.Now()))
+
+		history, err := app.models.Order.History(paid.Data.ID)
+		require.NoError(t, err)
+		last := history[len(history)-1]
+		assert.Equal(t, data.OrderStatusFailed, last.ToStatus)
+		assert.Equal(t, fulfillment.ActorSystem, last.Actor)
+		assert.Equal(t, "delivery failed permanently", *last.Reason)
+	})
+	t.Run("throttles per supplier account", func(t *testing.T) {
+		app := newTestApplication(t)
+		deliverer := newBlockingDeliverer()
+		app.deliverer = deliverer
+		bulk := data.SupplierAccount{Name: "Bulk", MaxConcurrency: 3, Active: true}
+		require.NoError(t, app.models.SupplierAccount.Insert(&bulk))
+		_, err := app.models.Stock.Purchase(bulk.ID, 100000, "")
+		require.NoError(t, err)
+		require.NoError(t, app.models.SupplierAccount.Insert(&data.SupplierAccount{Name: "Idle", MaxConcurrency: 5}))
+		handler := app.routes()
+		for range 6 {
+			createPaidTestOrder(t, handler)
+		}
+
+		// Checkouts reserve from Bulk and Main in turn. Bulk delivers 3 of
+		// its orders, Main (seeded) 1, then the 4 workers are busy.
+		started, err := app.dispatchDeliveries(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 4, started)
+		perAccount := map[string]int{}
+		for range started {
+			perAccount[<-deliverer.started]++
+		}
+		assert.Equal(t, 3, perAccount[bulk.ID])
+		assert.Len(t, perAccount, 2)
+
+		started, err = app.dispatchDeliveries(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, started)
+
+		close(deliverer.release)
+		waitDeliveries(t, app)
+		// Main delivers its other 2 orders one at a time.
+		assert.Equal(t, 1, deliverDue(t, app, time.Now()))
+		assert.Equal(t, 1, deliverDue(t, app, time.Now()))
+		assert.Zero(t, deliverDue(t, app, time.Now()))
+
+		delivered, err := app.models.Order.ListByStatus(data.OrderStatusDelivered, 10)
+		require.NoError(t, err)
+		assert.Len(t, delivered, 6)
+	})
+
+	t.Run("finishes deliveries in flight on shutdown", func(t *testing.T) {
+		app := newTestApplication(t)
+		deliverer := newBlockingDeliverer()
+		app.deliverer = deliverer
+		paid := createPaidTestOrder(t, app.routes())
+
+		app.startFulfillment()
+		<-deliverer.started
+		app.stopBackground()
+
+		waited := make(chan struct{})
+		go func() {
+			app.wg.Wait()
+			close(waited)
+		}()
+		select {
+		case <-waited:
+			t.Fatal("shutdown did not wait for the delivery")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(deliverer.release)
+		<-waited
+		order, err := app.models.Order.Get(paid.Data.ID)
+		require.NoError(t, err)
+		assert.Equal(t, data.OrderStatusDelivered, order.Status)
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+func (app *application) getLogLevelHandler(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": map[string]any{
+			"level": tlog.LevelString(app.logger.Level()),
+		},
+	})
+}
+
+// updateLogLevelHandler changes the level until the next restart or config
+// reload, whichever comes first.
+func (app *application) updateLogLevelHandler(ctx echo.Context) error {
+	var dto dto.AdminLogLevelUpdateDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	lvl, err := tlog.ParseLevel(dto.Level)
+	if err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	previous := app.logger.Level()
+	app.logger.SetLevel(lvl)
+	app.logger.Warnj(tlog.JSON{
+		"message": "log level changed",
+		"from":    tlog.LevelString(previous),
+		"to":      tlog.LevelString(lvl),
+		"ip_addr": ctx.RealIP(),
+		"trigger": "admin api",
+	})
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": map[string]any{
+			"level": tlog.LevelString(lvl),
+		},
+	})
+}
+
+func (app *application) getRecentLogsHandler(ctx echo.Context) error {
+	var dto dto.AdminLogListDTO
+
+	// Set Default Value
+	dto.Limit = utility.SetPtrValue(100)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	if app.logRing == nil {
+		return app.ErrNotFound(apperror.CodeLogBufferDisabled)
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": app.logRing.Entries(*dto.Limit),
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+func TestAdminAuth(t *testing.T) {
+	t.Run("rejects missing token", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/log-level", "", nil)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+	})
+
+	t.Run("rejects wrong token", func(t *testing.T) {
+		app := newTestApplication(t)
+		header := http.Header{echo.HeaderAuthorization: {"Bearer not-the-admin-token"}}
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/log-level", "", header)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("forbids everything when no token is configured", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.config.Admin.Token = ""
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/log-level", "", adminHeader())
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestLogLevelHandlers(t *testing.T) {
+	t.Run("reports the current level", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/log-level", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data":{"level":"error"}}`, rec.Body.String())
+	})
+
+	t.Run("changes the level", func(t *testing.T) {
+		app := newTestApplication(t)
+		handler := app.routes()
+
+		rec := testRequest(t, handler, http.MethodPut, "/v1/admin/log-level", `{"level":"debug"}`, adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data":{"level":"debug"}}`, rec.Body.String())
+		assert.Equal(t, "debug", tlog.LevelString(app.logger.Level()))
+
+		rec = testRequest(t, handler, http.MethodGet, "/v1/admin/log-level", "", adminHeader())
+		assert.JSONEq(t, `{"data":{"level":"debug"}}`, rec.Body.String())
+	})
+
+	t.Run("rejects unknown levels", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodPut, "/v1/admin/log-level", `{"level":"loud"}`, adminHeader())
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Contains(t, body.Error.Details, "level")
+		assert.Equal(t, "error", tlog.LevelString(app.logger.Level()))
+	})
+}
+
+func TestGetRecentLogsHandler(t *testing.T) {
+	t.Run("returns the newest entries", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.logger = tlog.New(tlog.JSONSink(app.logRing))
+		app.logger.SetLevel(log.INFO)
+		for _, msg := range []string{"first", "second", "third"} {
+			app.logger.Infoj(tlog.JSON{"message": msg})
+		}
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/logs?limit=2", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var body struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		decodeBody(t, rec, &body)
+		require.Len(t, body.Data, 2)
+		assert.Contains(t, string(body.Data[0]), "second")
+		assert.Contains(t, string(body.Data[1]), "third")
+	})
+
+	t.Run("rejects invalid limit", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/logs?limit=0", "", adminHeader())
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("is not found when the ring buffer is disabled", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.logRing = nil
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/logs", "", adminHeader())
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
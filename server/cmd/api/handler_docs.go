@@ -0,0 +1,107 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// docsFiles holds the generated OpenAPI document and the pinned Swagger UI
+// and Redoc bundles, so /docs and /redoc work without network access. The
+// bundles are committed under docs/assets, `make docs/assets` updates them.
+//
+//go:embed docs/openapi.yaml docs/openapi.json docs/assets
+var docsFiles embed.FS
+
+// docsAssetFiles are the bundles the documentation pages load.
+var docsAssetFiles = []string{
+	"swagger-ui/swagger-ui.css",
+	"swagger-ui/swagger-ui-bundle.js",
+	"swagger-ui/swagger-ui-standalone-preset.js",
+	"redoc/redoc.standalone.js",
+}
+
+const swaggerUIHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>API Documentation - Mayobox</title>
+    <link rel="stylesheet" href="/docs/assets/swagger-ui/swagger-ui.css">
+    <style>
+        body { margin: 0; padding: 0; }
+        .swagger-ui .topbar { display: none; }
+    </style>
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="/docs/assets/swagger-ui/swagger-ui-bundle.js"></script>
+    <script src="/docs/assets/swagger-ui/swagger-ui-standalone-preset.js"></script>
+    <script>
+        window.onload = function() {
+            SwaggerUIBundle({
+                url: '/openapi.yaml',
+                dom_id: '#swagger-ui',
+                deepLinking: true,
+                presets: [
+                    SwaggerUIBundle.presets.apis,
+                    SwaggerUIStandalonePreset
+                ],
+                plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+                layout: "StandaloneLayout",
+                defaultModelsExpandDepth: 1,
+                defaultModelExpandDepth: 1,
+                docExpansion: "list",
+                filter: true,
+                tryItOutEnabled: false
+            });
+        };
+    </script>
+</body>
+</html>`
+
+const redocHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>API Reference - Mayobox</title>
+    <style>
+        body { margin: 0; padding: 0; }
+    </style>
+</head>
+<body>
+    <redoc spec-url="/openapi.yaml"></redoc>
+    <script src="/docs/assets/redoc/redoc.standalone.js"></script>
+</body>
+</html>`
+
+func (app *application) serveSwaggerUI(c echo.Context) error {
+	return c.HTML(http.StatusOK, swaggerUIHTML)
+}
+
+func (app *application) serveRedoc(c echo.Context) error {
+	return c.HTML(http.StatusOK, redocHTML)
+}
+
+func docsAssets() fs.FS {
+	sub, err := fs.Sub(docsFiles, "docs/assets")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// checkDocsAssets fails when the binary was built without the bundles the
+// documentation pages load, so it does not serve broken pages.
+func checkDocsAssets() error {
+	for _, name := range docsAssetFiles {
+		if _, err := fs.Stat(docsAssets(), name); err != nil {
+			return fmt.Errorf("documentation asset %s is not embedded, run make docs/assets: %w", name, err)
+		}
+	}
+	return nil
+}
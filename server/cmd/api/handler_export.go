@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/serializer"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+const (
+	// exportFlushEvery rows the export is flushed to the client, which
+	// keeps memory flat however many rows there are.
+	exportFlushEvery = 500
+	// exportWriteTimeout replaces the server WriteTimeout, it is extended
+	// on every flush so long exports are not cut off.
+	exportWriteTimeout = 30 * time.Second
+)
+
+var testimoniCSVHeader = []string{
+	"id", "user_id", "username", "email", "testimoni", "icon_url", "created_at", "updated_at",
+}
+
+func (app *application) exportTestimoniesHandler(ctx echo.Context) error {
+	var dto dto.AdminExportDTO
+
+	// Set Default Value
+	dto.Format = utility.SetPtrValue("json")
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	res := ctx.Response()
+	var (
+		write  func(t *data.TestimoniWithUser) error
+		flush  func() error
+		finish func() error
+	)
+	switch *dto.Format {
+	case "csv":
+		w := csv.NewWriter(res)
+		w.Write(testimoniCSVHeader)
+		write = func(t *data.TestimoniWithUser) error {
+			return w.Write([]string{
+				t.ID, t.UserID, t.User.Username, t.User.Email, t.Testimoni.Testimoni, t.IconURL,
+				t.CreatedAt.Format(time.RFC3339), t.UpdatedAt.Format(time.RFC3339),
+			})
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+		finish = flush
+		res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	default:
+		stream := serializer.NewJSONArrayStream(res, "data")
+		write = func(t *data.TestimoniWithUser) error { return stream.Write(t) }
+		flush = func() error { return nil }
+		finish = stream.Close
+		res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+
+	filename := fmt.Sprintf("testimonies-%s.%s", time.Now().UTC().Format("20060102"), *dto.Format)
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	res.Header().Set(echo.HeaderCacheControl, "no-store")
+
+	rc := http.NewResponseController(res)
+	rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+
+	rows := 0
+	err := app.models.Testimoni.Stream(ctx.Request().Context(), func(t *data.TestimoniWithUser) error {
+		if err := write(t); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			rc.Flush()
+			rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+		}
+		return nil
+	})
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		if ctx.Request().Context().Err() != nil {
+			// The client went away, nobody is left to tell.
+			return nil
+		}
+		if !res.Committed {
+			res.Header().Del(echo.HeaderContentDisposition)
+			res.Header().Del(echo.HeaderCacheControl)
+			return app.ErrInternalServer(err, "failed exporting testimonies", ctx.Request())
+		}
+
+		// Part of the export was sent already. Abort the connection so the
+		// client sees a failed download instead of a truncated file.
+		app.logger.Errorj(tlog.JSON{"message": "testimonies export aborted", "rows": rows, "error": err})
+		panic(http.ErrAbortHandler)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+)
+
+type failingTestimoniModel struct {
+	data.TestimoniModeler
+}
+
+func (failingTestimoniModel) Stream(ctx context.Context, fn func(*data.TestimoniWithUser) error) error {
+	return errors.New("connection refused")
+}
+
+func TestExportTestimoniesHandler(t *testing.T) {
+	t.Run("streams json in list order", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/exports/testimonies", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), `attachment; filename="testimonies-`)
+		assert.Equal(t, "no-store", rec.Header().Get(echo.HeaderCacheControl))
+
+		var exported struct {
+			Data []data.TestimoniWithUser `json:"data"`
+		}
+		decodeBody(t, rec, &exported)
+		listed, _, err := app.models.Testimoni.GetAll(data.TestimoniGetAllParam{Page: 1, PageSize: 100})
+		require.NoError(t, err)
+		require.Len(t, exported.Data, len(listed))
+		for i := range listed {
+			assert.Equal(t, listed[i].ID, exported.Data[i].ID)
+		}
+	})
+
+	t.Run("streams csv with a header row", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/exports/testimonies?format=csv", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, testimoniCSVHeader, records[0])
+		assert.Greater(t, len(records), 1)
+	})
+
+	t.Run("flushes large exports compressed", func(t *testing.T) {
+		store := memstore.New()
+		store.AddUser(data.User{ID: "u-1", Username: "exporter"})
+		created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := range 2*exportFlushEvery + 1 {
+			store.AddTestimoni(data.Testimoni{ID: fmt.Sprintf("t-%04d", i), UserID: "u-1", Testimoni: "great", CreatedAt: created})
+		}
+		app := newTestApplicationWithStore(t, store)
+		app.config.Compression.Enabled = true
+		app.config.Compression.MinSize = 1 << 20
+		header := adminHeader()
+		header.Set(echo.HeaderAcceptEncoding, "gzip")
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/exports/testimonies?format=csv", "", header)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, rec.Flushed)
+		assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+		gz, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, 2*exportFlushEvery+2, strings.Count(string(body), "\n"))
+	})
+
+	t.Run("reports failures before anything was sent", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.models.Testimoni = failingTestimoniModel{}
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/exports/testimonies", "", adminHeader())
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentDisposition))
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/admin/exports/testimonies?format=xml", "", adminHeader())
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Contains(t, body.Error.Details, "format")
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+)
+
+func TestGetAllFAQHandler(t *testing.T) {
+	t.Run("lists faqs with answers in display order", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var body struct {
+			Data     []*data.FAQWithAnswers `json:"data"`
+			Metadata *data.Metadata         `json:"metadata"`
+		}
+		decodeBody(t, rec, &body)
+		require.Len(t, body.Data, 5)
+		assert.Nil(t, body.Metadata)
+		for i, faq := range body.Data {
+			assert.Equal(t, i+1, faq.DisplayOrder)
+			require.Len(t, faq.Answers, 2)
+			assert.Equal(t, 1, faq.Answers[0].DisplayOrder)
+			assert.Equal(t, faq.ID, faq.Answers[0].FAQID)
+		}
+	})
+
+	t.Run("returns empty list without faqs", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, memstore.New())
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data":[],"metadata":null}`, rec.Body.String())
+	})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// flashSaleTimeLayout is how admins write the start and end of a flash
+// sale, in Asia/Jakarta.
+const flashSaleTimeLayout = "2006-01-02T15:04"
+
+// ActiveFlashSales are the live flash sales. ServerTime lets clients count
+// down to their end with a clock that may be off.
+type ActiveFlashSales struct {
+	ServerTime time.Time         `json:"serverTime" doc:"In Asia/Jakarta"`
+	Sales      []ActiveFlashSale `json:"sales" doc:"The first to end first"`
+}
+
+// ActiveFlashSale is a live flash sale with its products.
+type ActiveFlashSale struct {
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	StartsAt time.Time        `json:"startsAt" doc:"In Asia/Jakarta"`
+	EndsAt   time.Time        `json:"endsAt" doc:"In Asia/Jakarta"`
+	Items    []FlashSaleOffer `json:"items" doc:"The cheapest first"`
+}
+
+// FlashSaleOffer is a product of a flash sale. It sells at PriceIDR while
+// units remain, at RegularIDR after.
+type FlashSaleOffer struct {
+	ItemID     string        `json:"itemId"`
+	Product    *data.Product `json:"product"`
+	PriceIDR   pricing.Money `json:"priceIdr" doc:"Flash sale price of one unit"`
+	RegularIDR pricing.Money `json:"regularIdr" doc:"Rate table price of one unit"`
+	Quantity   int           `json:"quantity"`
+	Remaining  int           `json:"remaining" doc:"0 once sold out"`
+	InStock    bool          `json:"inStock"`
+}
+
+// getActiveFlashSalesHandler lists the live flash sales with the server
+// time, so the storefront can count down to their end. Items of products
+// no longer on sale are left out.
+func (app *application) getActiveFlashSalesHandler(ctx echo.Context) error {
+	now := time.Now()
+	sales, err := app.models.FlashSale.Live(now)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get live flash sales", ctx.Request())
+	}
+	table, err := app.models.RateTable.Current(now)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get current rate table", ctx.Request())
+	}
+	available, err := app.models.Stock.Available()
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get available stock", ctx.Request())
+	}
+
+	active := make([]ActiveFlashSale, 0, len(sales))
+	for _, sale := range sales {
+		offers := make([]FlashSaleOffer, 0, len(sale.Items))
+		for _, item := range sale.Items {
+			product, err := app.models.Product.Get(item.ProductID)
+			if err != nil {
+				if errors.Is(err, data.ErrRecordNotFound) {
+					continue
+				}
+				return app.ErrInternalServer(err, "failed get product", ctx.Request())
+			}
+			if !product.Active {
+				continue
+			}
+			price, err := table.Price(product.Robux)
+			if err != nil {
+				return app.ErrInternalServer(err, "failed price product", ctx.Request())
+			}
+			offers = append(offers, FlashSaleOffer{
+				ItemID:     item.ID,
+				Product:    product,
+				PriceIDR:   item.PriceIDR,
+				RegularIDR: price.IDR,
+				Quantity:   item.Quantity,
+				Remaining:  item.Remaining(),
+				InStock:    product.Robux <= available,
+			})
+		}
+		active = append(active, ActiveFlashSale{
+			ID:       sale.ID,
+			Name:     sale.Name,
+			StartsAt: sale.StartsAt.In(utility.Jakarta),
+			EndsAt:   sale.EndsAt.In(utility.Jakarta),
+			Items:    offers,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": ActiveFlashSales{
+			ServerTime: now.In(utility.Jakarta),
+			Sales:      active,
+		},
+	})
+}
+
+func (app *application) listFlashSalesHandler(ctx echo.Context) error {
+	sales, err := app.models.FlashSale.GetAll()
+	if err != nil {
+		return app.ErrInternalServer(err, "failed list flash sales", ctx.Request())
+	}
+	for _, sale := range sales {
+		flashSaleInJakarta(sale)
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": sales,
+	})
+}
+
+func (app *application) createFlashSaleHandler(ctx echo.Context) error {
+	var dto dto.AdminFlashSaleCreateDTO
+
+	// Set Default Value
+	dto.Active = utility.SetPtrValue(true)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	sale := data.FlashSale{Name: dto.Name, Active: *dto.Active}
+	if err := app.setFlashSaleFields(&sale, dto.StartsAt, dto.EndsAt, dto.Items); err != nil {
+		return err
+	}
+
+	if err := app.models.FlashSale.Insert(&sale); err != nil {
+		return app.flashSaleWriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, envelope{
+		"data": flashSaleInJakarta(&sale),
+	})
+}
+
+// updateFlashSaleHandler replaces every field of a flash sale. Its items
+// keep the units they sold, so lowering a quantity below them sells the
+// item out.
+func (app *application) updateFlashSaleHandler(ctx echo.Context) error {
+	var dto dto.AdminFlashSaleUpdateDTO
+
+	// Set Default Value
+	dto.Active = utility.SetPtrValue(true)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	if _, err := app.models.FlashSale.Get(dto.ID); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed get flash sale", ctx.Request())
+	}
+
+	sale := data.FlashSale{ID: dto.ID, Name: dto.Name, Active: *dto.Active}
+	if err := app.setFlashSaleFields(&sale, dto.StartsAt, dto.EndsAt, dto.Items); err != nil {
+		return err
+	}
+
+	if err := app.models.FlashSale.Update(&sale); err != nil {
+		return app.flashSaleWriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": flashSaleInJakarta(&sale),
+	})
+}
+
+// setFlashSaleFields parses the Asia/Jakarta window and converts the whole
+// rupiah prices sent for a flash sale.
+func (app *application) setFlashSaleFields(sale *data.FlashSale, startsAt, endsAt string, items []dto.AdminFlashSaleItemDTO) error {
+	var err error
+	sale.StartsAt, err = time.ParseInLocation(flashSaleTimeLayout, startsAt, utility.Jakarta)
+	if err != nil {
+		return app.ErrFailedValidation(map[string]string{"startsat": "must be a time like " + flashSaleTimeLayout})
+	}
+	sale.EndsAt, err = time.ParseInLocation(flashSaleTimeLayout, endsAt, utility.Jakarta)
+	if err != nil {
+		return app.ErrFailedValidation(map[string]string{"endsat": "must be a time like " + flashSaleTimeLayout})
+	}
+	if !sale.EndsAt.After(sale.StartsAt) {
+		return app.ErrFailedValidation(map[string]string{"endsat": "must be after startsAt"})
+	}
+
+	sale.Items = make([]*data.FlashSaleItem, 0, len(items))
+	for _, item := range items {
+		sale.Items = append(sale.Items, &data.FlashSaleItem{
+			ProductID: item.ProductID,
+			PriceIDR:  pricing.Rupiah(item.PriceIDR),
+			Quantity:  item.Quantity,
+		})
+	}
+	return nil
+}
+
+// flashSaleWriteError maps the errors of storing a flash sale.
+func (app *application) flashSaleWriteError(ctx echo.Context, err error) error {
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return app.ErrFailedValidation(map[string]string{"items": "must be existing products"})
+	}
+	return app.ErrInternalServer(err, "failed save flash sale", ctx.Request())
+}
+
+// flashSaleInJakarta shows the window of sale in Asia/Jakarta, the time
+// zone it was set in.
+func flashSaleInJakarta(sale *data.FlashSale) *data.FlashSale {
+	sale.StartsAt = sale.StartsAt.In(utility.Jakarta)
+	sale.EndsAt = sale.EndsAt.In(utility.Jakarta)
+	return sale
+}
+
+// flashSaleItem returns the item of the live flash sales that sells
+// quantity units of the product with productID the cheapest, nil when no
+// sale has the units left.
+func (app *application) flashSaleItem(productID string, quantity int, at time.Time) (*data.FlashSaleItem, error) {
+	sales, err := app.models.FlashSale.Live(at)
+	if err != nil {
+		return nil, err
+	}
+	_, item := data.FlashSaleOffer(sales, productID, quantity)
+	return item, nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// vipGamepassID is the seeded 499 Robux VIP gamepass.
+const vipGamepassID = "990e8400-e29b-41d4-a716-446655440007"
+
+type flashSaleResponse struct {
+	Data struct {
+		ID       string    `json:"id"`
+		Name     string    `json:"name"`
+		StartsAt time.Time `json:"startsAt"`
+		EndsAt   time.Time `json:"endsAt"`
+		Active   bool      `json:"active"`
+		Items    []struct {
+			ID        string         `json:"id"`
+			ProductID string         `json:"productId"`
+			PriceIDR  map[string]any `json:"priceIdr"`
+			Quantity  int            `json:"quantity"`
+			Sold      int            `json:"sold"`
+		} `json:"items"`
+	} `json:"data"`
+}
+
+// flashSaleBody is a flash sale of the VIP gamepass at Rp50.000 running
+// from start to end after now, in Asia/Jakarta.
+func flashSaleBody(start, end time.Duration, quantity int) string {
+	now := time.Now().In(utility.Jakarta)
+	return fmt.Sprintf(`{"name":"Payday","startsAt":%q,"endsAt":%q,"items":[{"productId":%q,"priceIdr":50000,"quantity":%d}]}`,
+		now.Add(start).Format(flashSaleTimeLayout), now.Add(end).Format(flashSaleTimeLayout), vipGamepassID, quantity)
+}
+
+// createTestFlashSale stores a flash sale through the admin API.
+func createTestFlashSale(t *testing.T, handler http.Handler, body string) flashSaleResponse {
+	t.Helper()
+
+	rec := testRequest(t, handler, http.MethodPost, "/v1/admin/flash-sales", body, adminHeader())
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res flashSaleResponse
+	decodeBody(t, rec, &res)
+	return res
+}
+
+// vipOrderBody is an order of quantity VIP gamepasses.
+func vipOrderBody(username string, quantity int) string {
+	return fmt.Sprintf(`{"productId":%q,"robloxUsername":%q,"paymentMethod":"qris","quantity":%d}`, vipGamepassID, username, quantity)
+}
+
+func TestActiveFlashSalesHandler(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+
+	live := createTestFlashSale(t, handler, flashSaleBody(-time.Hour, time.Hour, 5))
+	createTestFlashSale(t, handler, flashSaleBody(time.Hour, 2*time.Hour, 5))
+	inactive := flashSaleBody(-time.Hour, time.Hour, 5)
+	createTestFlashSale(t, handler, inactive[:len(inactive)-1]+`,"active":false}`)
+
+	t.Run("lists the live sales with the server time", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/flash-sales/active", "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NoError(t, openAPIDocument().ValidateResponse(http.MethodGet, "/v1/flash-sales/active", rec.Code, rec.Header(), rec.Body.Bytes()))
+		var res struct {
+			Data struct {
+				ServerTime time.Time `json:"serverTime"`
+				Sales      []struct {
+					ID     string `json:"id"`
+					EndsAt string `json:"endsAt"`
+					Items  []struct {
+						Product struct {
+							ID string `json:"id"`
+						} `json:"product"`
+						PriceIDR   map[string]any `json:"priceIdr"`
+						RegularIDR map[string]any `json:"regularIdr"`
+						Quantity   int            `json:"quantity"`
+						Remaining  int            `json:"remaining"`
+					} `json:"items"`
+				} `json:"sales"`
+			} `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		assert.WithinDuration(t, time.Now(), res.Data.ServerTime, time.Minute)
+		require.Len(t, res.Data.Sales, 1)
+		sale := res.Data.Sales[0]
+		assert.Equal(t, live.Data.ID, sale.ID)
+		assert.Contains(t, sale.EndsAt, "+07:00")
+		require.Len(t, sale.Items, 1)
+		assert.Equal(t, vipGamepassID, sale.Items[0].Product.ID)
+		assert.Equal(t, "Rp50.000", sale.Items[0].PriceIDR["display"])
+		assert.NotEqual(t, sale.Items[0].PriceIDR, sale.Items[0].RegularIDR)
+		assert.Equal(t, 5, sale.Items[0].Quantity)
+		assert.Equal(t, 5, sale.Items[0].Remaining)
+	})
+}
+
+func TestCheckoutFlashSale(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+
+	sale := createTestFlashSale(t, handler, flashSaleBody(-time.Hour, time.Hour, 3))
+	itemID := sale.Data.Items[0].ID
+	product, err := app.models.Product.Get(vipGamepassID)
+	require.NoError(t, err)
+
+	t.Run("prices products at the sale price", func(t *testing.T) {
+		priced, err := app.priceProducts(product)
+
+		require.NoError(t, err)
+		assert.Equal(t, pricing.Rupiah(50000), priced[0].Price.IDR)
+		require.NotNil(t, priced[0].FlashSale)
+		assert.Equal(t, itemID, priced[0].FlashSale.ItemID)
+		assert.Equal(t, 3, priced[0].FlashSale.Remaining)
+		assert.Greater(t, priced[0].FlashSale.RegularIDR, priced[0].Price.IDR)
+	})
+
+	var saleOrders []string
+	t.Run("never sells more than the quantity", func(t *testing.T) {
+		var wg sync.WaitGroup
+		orders := make([]orderResponse, 8)
+		for i := range orders {
+			wg.Go(func() {
+				rec := testRequest(t, handler, http.MethodPost, "/v1/orders", vipOrderBody(fmt.Sprintf("buyer%02d", i), 1), problemHeader())
+				// Orders priced at the sale that lose the last units are
+				// refused rather than charged the regular price.
+				if rec.Code == http.StatusConflict {
+					var problem struct {
+						Code string `json:"code"`
+					}
+					decodeBody(t, rec, &problem)
+					assert.Equal(t, "flash_sale_sold_out", problem.Code)
+					return
+				}
+				if assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String()) {
+					decodeBody(t, rec, &orders[i])
+				}
+			})
+		}
+		wg.Wait()
+
+		for _, order := range orders {
+			switch {
+			case order.Data.ID == "":
+			case order.Data.FlashSaleItemID != nil:
+				assert.Equal(t, "Rp50.000", order.Data.TotalIDR["display"])
+				saleOrders = append(saleOrders, order.Data.ID)
+			default:
+				assert.NotEqual(t, "Rp50.000", order.Data.TotalIDR["display"])
+			}
+		}
+		assert.Len(t, saleOrders, 3)
+	})
+
+	t.Run("sells at the regular price once sold out", func(t *testing.T) {
+		priced, err := app.priceProducts(product)
+
+		require.NoError(t, err)
+		assert.Nil(t, priced[0].FlashSale)
+		assert.NotEqual(t, pricing.Rupiah(50000), priced[0].Price.IDR)
+	})
+
+	t.Run("gives the units of failed orders back", func(t *testing.T) {
+		require.NotEmpty(t, saleOrders)
+		_, err := app.models.Order.Transition(saleOrders[0], data.OrderChange{To: data.OrderStatusFailed, Actor: fulfillment.ActorPayment})
+		require.NoError(t, err)
+
+		sale, err := app.models.FlashSale.Get(sale.Data.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sale.Items[0].Remaining())
+	})
+
+	t.Run("orders more units than remain at the regular price", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/orders", vipOrderBody("builderman", 2), nil)
+
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var order orderResponse
+		decodeBody(t, rec, &order)
+		assert.Nil(t, order.Data.FlashSaleItemID)
+	})
+
+	t.Run("applies vouchers to the sale price", func(t *testing.T) {
+		createTestVoucher(t, handler, `{"code":"SALE5K","amountOffIdr":5000}`)
+
+		body := fmt.Sprintf(`{"code":"SALE5K","productId":%q}`, vipGamepassID)
+		rec := testRequest(t, handler, http.MethodPost, "/v1/vouchers/validate", body, nil)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), `"display":"Rp45.000"`)
+
+		rec = testRequest(t, handler, http.MethodPost, "/v1/orders", `{"productId":"`+vipGamepassID+`","robloxUsername":"guest","paymentMethod":"qris","voucherCode":"SALE5K"}`, nil)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var order orderResponse
+		decodeBody(t, rec, &order)
+		assert.Equal(t, "Rp50.000", order.Data.SubtotalIDR["display"])
+		assert.Equal(t, "Rp45.000", order.Data.TotalIDR["display"])
+		assert.Equal(t, itemID, *order.Data.FlashSaleItemID)
+	})
+}
+
+// staleFlashSales returns sales as they were live before, like a checkout
+// priced just before the last units sold.
+type staleFlashSales struct {
+	data.FlashSaleModeler
+	live []*data.FlashSale
+}
+
+func (m staleFlashSales) Live(time.Time) ([]*data.FlashSale, error) {
+	return m.live, nil
+}
+
+func TestCheckoutFlashSaleSoldOut(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+
+	createTestFlashSale(t, handler, flashSaleBody(-time.Hour, time.Hour, 1))
+	live, err := app.models.FlashSale.Live(time.Now())
+	require.NoError(t, err)
+
+	rec := testRequest(t, handler, http.MethodPost, "/v1/orders", vipOrderBody("buyer01", 1), nil)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	app.models.FlashSale = staleFlashSales{FlashSaleModeler: app.models.FlashSale, live: live}
+	rec = testRequest(t, handler, http.MethodPost, "/v1/orders", vipOrderBody("buyer02", 1), problemHeader())
+
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	var problem struct {
+		Code string `json:"code"`
+	}
+	decodeBody(t, rec, &problem)
+	assert.Equal(t, "flash_sale_sold_out", problem.Code)
+
+	orders, err := app.models.Order.ListByStatus(data.OrderStatusPendingPayment, 10)
+	require.NoError(t, err)
+	assert.Len(t, orders, 1)
+}
+
+func TestFlashSaleHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	var created flashSaleResponse
+
+	t.Run("creates in Asia/Jakarta", func(t *testing.T) {
+		body := fmt.Sprintf(`{"name":"Payday","startsAt":"2099-10-25T12:00","endsAt":"2099-10-25T14:00","items":[{"productId":%q,"priceIdr":50000,"quantity":20}]}`, vipGamepassID)
+		created = createTestFlashSale(t, handler, body)
+
+		assert.Equal(t, "Payday", created.Data.Name)
+		assert.True(t, created.Data.Active)
+		assert.True(t, created.Data.StartsAt.Equal(time.Date(2099, 10, 25, 5, 0, 0, 0, time.UTC)))
+		require.Len(t, created.Data.Items, 1)
+		assert.Equal(t, "Rp50.000", created.Data.Items[0].PriceIDR["display"])
+		assert.Equal(t, 0, created.Data.Items[0].Sold)
+	})
+
+	t.Run("rejects invalid sales", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"ending before start": `{"name":"Payday","startsAt":"2099-10-25T12:00","endsAt":"2099-10-25T12:00","items":[{"productId":"` + vipGamepassID + `","priceIdr":50000,"quantity":20}]}`,
+			"unknown products":    `{"name":"Payday","startsAt":"2099-10-25T12:00","endsAt":"2099-10-25T14:00","items":[{"productId":"aa0e8400-e29b-41d4-a716-446655449999","priceIdr":50000,"quantity":20}]}`,
+			"duplicate products":  `{"name":"Payday","startsAt":"2099-10-25T12:00","endsAt":"2099-10-25T14:00","items":[{"productId":"` + vipGamepassID + `","priceIdr":50000,"quantity":20},{"productId":"` + vipGamepassID + `","priceIdr":40000,"quantity":5}]}`,
+			"times with offsets":  `{"name":"Payday","startsAt":"2099-10-25T12:00:00+07:00","endsAt":"2099-10-25T14:00:00+07:00","items":[{"productId":"` + vipGamepassID + `","priceIdr":50000,"quantity":20}]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				rec := testRequest(t, handler, http.MethodPost, "/v1/admin/flash-sales", body, adminHeader())
+
+				assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+			})
+		}
+	})
+
+	t.Run("replaces keeping the units sold", func(t *testing.T) {
+		sale := createTestFlashSale(t, handler, flashSaleBody(-time.Hour, time.Hour, 5))
+		rec := testRequest(t, handler, http.MethodPost, "/v1/orders", vipOrderBody("builderman", 2), nil)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		body := flashSaleBody(-time.Hour, 2*time.Hour, 10)
+		rec = testRequest(t, handler, http.MethodPut, "/v1/admin/flash-sales/"+sale.Data.ID, body, adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res flashSaleResponse
+		decodeBody(t, rec, &res)
+		require.Len(t, res.Data.Items, 1)
+		assert.Equal(t, sale.Data.Items[0].ID, res.Data.Items[0].ID)
+		assert.Equal(t, 10, res.Data.Items[0].Quantity)
+		assert.Equal(t, 2, res.Data.Items[0].Sold)
+	})
+
+	t.Run("returns 404 for unknown sales", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPut, "/v1/admin/flash-sales/aa0e8400-e29b-41d4-a716-446655449999", flashSaleBody(0, time.Hour, 5), adminHeader())
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("lists the latest to start first", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/admin/flash-sales", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, openAPIDocument().ValidateResponse(http.MethodGet, "/v1/admin/flash-sales", rec.Code, rec.Header(), rec.Body.Bytes()))
+		var res struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		require.Len(t, res.Data, 2)
+		assert.Equal(t, created.Data.ID, res.Data[0].ID)
+	})
+}
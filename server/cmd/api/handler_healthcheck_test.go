@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthcheckHandler(t *testing.T) {
+	app := newTestApplication(t)
+
+	rec := testRequest(t, app.routes(), http.MethodGet, "/", "", nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Status     string            `json:"status"`
+		SystemInfo map[string]string `json:"system_info"`
+	}
+	decodeBody(t, rec, &body)
+	assert.Equal(t, "available", body.Status)
+	assert.Equal(t, "development", body.SystemInfo["environment"])
+	assert.Equal(t, VERSION, body.SystemInfo["version"])
+}
@@ -0,0 +1,580 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+const (
+	// streamWriteTimeout bounds every write to an event stream. It stands
+	// in for the server WriteTimeout, which would end streams after 10s.
+	streamWriteTimeout = 10 * time.Second
+	// streamRetry is the reconnection delay suggested to EventSource.
+	streamRetry = 5 * time.Second
+	// streamResumeLimit caps the missed sales sent on reconnection.
+	streamResumeLimit = 100
+)
+
+// OrderDetail is an order with its product and payment, as shown to the
+// buyer.
+type OrderDetail struct {
+	*data.Order
+	Product *data.Product `json:"product"`
+	Payment *data.Payment `json:"payment" doc:"null when no charge could be created"`
+}
+
+// createOrderHandler prices an order with the rate table in effect now,
+// or at a live flash sale with its units left, takes the discount of its
+// voucher off, reserves its Robux and creates the charge the buyer pays it
+// with. The flash sale units are claimed and the voucher redeemed in the
+// same transaction as the order is stored, so concurrent checkouts never
+// exceed their limits. The order ID is the charge reference, so the
+// provider never charges an order twice.
+func (app *application) createOrderHandler(ctx echo.Context) error {
+	var dto dto.OrderCreateDTO
+
+	// Set Default Value
+	dto.Quantity = utility.SetPtrValue(1)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	product, err := app.models.Product.Get(dto.ProductID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		return app.ErrInternalServer(err, "failed get product", ctx.Request())
+	}
+	if product == nil || !product.Active {
+		return app.ErrFailedValidation(map[string]string{"productid": "must be a product on sale"})
+	}
+
+	now := time.Now()
+	table, err := app.models.RateTable.Current(now)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get current rate table", ctx.Request())
+	}
+
+	robux := product.Robux * *dto.Quantity
+	quote, err := table.Quote(robux)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed quote robux price", ctx.Request())
+	}
+
+	order := &data.Order{
+		ProductID:      product.ID,
+		RobloxUsername: dto.RobloxUsername,
+		Quantity:       *dto.Quantity,
+		Robux:          robux,
+		SubtotalIDR:    quote.Total,
+	}
+	item, err := app.flashSaleItem(product.ID, order.Quantity, now)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get flash sales", ctx.Request())
+	}
+	if item != nil {
+		order.SubtotalIDR = item.PriceIDR * pricing.Money(order.Quantity)
+		order.FlashSaleItemID = &item.ID
+	}
+
+	var voucherErr *data.VoucherError
+	if code := utility.DerefOrDefault(dto.VoucherCode, ""); code != "" {
+		use := data.VoucherUse{Product: product, RobloxUsername: order.RobloxUsername, SubtotalIDR: order.SubtotalIDR, At: now}
+		voucher, _, err := app.models.Voucher.Apply(code, use)
+		if err != nil {
+			if errors.As(err, &voucherErr) {
+				return app.ErrInvalidVoucher(voucherErr)
+			}
+			return app.ErrInternalServer(err, "failed apply voucher", ctx.Request())
+		}
+		order.VoucherID = &voucher.ID
+	}
+
+	// The Robux stay reserved as long as the buyer has to pay.
+	expiresAt := now.Add(app.config.Payment.Expiry)
+	err = app.models.Order.Insert(order, expiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrOutOfStock):
+			return app.ErrOutOfStock()
+		case errors.Is(err, data.ErrFlashSaleSoldOut):
+			// The last units sold or the sale ended since the order was
+			// priced. The buyer decides whether to pay the regular price.
+			return app.ErrFlashSaleSoldOut()
+		case errors.As(err, &voucherErr):
+			// Another checkout used up the voucher since it was applied.
+			return app.ErrInvalidVoucher(voucherErr)
+		}
+		return app.ErrInternalServer(err, "failed create order", ctx.Request())
+	}
+
+	charge, err := app.payments.CreateCharge(ctx.Request().Context(), payment.ChargeRequest{
+		Reference:   order.ID,
+		Amount:      order.TotalIDR,
+		Method:      dto.PaymentMethod,
+		Description: fmt.Sprintf("%d Robux for %s", robux, order.RobloxUsername),
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		app.logger.Errorj(tlog.JSON{"message": "failed create payment charge", "orderId": order.ID, "provider": app.payments.Name(), "error": err})
+		app.failCheckout(order.ID, "no payment charge could be created")
+		ctx.Response().Header().Set(echo.HeaderRetryAfter, fmt.Sprint(int(paymentRetryAfter.Seconds())))
+		return app.ErrServiceUnavailable(apperror.CodePaymentUnavailable)
+	}
+
+	pay := &data.Payment{
+		OrderID:      order.ID,
+		Provider:     app.payments.Name(),
+		ChargeID:     charge.ID,
+		Method:       charge.Method,
+		AmountIDR:    charge.Amount,
+		Status:       charge.Status,
+		Instructions: charge.Instructions,
+		ExpiresAt:    charge.ExpiresAt,
+		PaidAt:       charge.PaidAt,
+	}
+	if err := app.models.Payment.Insert(pay); err != nil {
+		// Webhooks and the reconciler find a charge by its payment only,
+		// so one that was not stored is expired and the order fails.
+		expired, expireErr := app.payments.Expire(ctx.Request().Context(), charge.ID)
+		if expireErr != nil || expired.Status != payment.StatusExpired {
+			app.logger.Errorj(tlog.JSON{"message": "failed expire unstored payment charge", "orderId": order.ID, "provider": app.payments.Name(), "chargeId": charge.ID, "error": expireErr})
+		}
+		app.failCheckout(order.ID, "the payment charge could not be stored")
+		return app.ErrInternalServer(err, "failed create payment", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusCreated, envelope{
+		"data": OrderDetail{Order: order, Product: product, Payment: pay},
+	})
+}
+
+// failCheckout fails an order whose payment could not be set up, which
+// gives back its Robux, voucher and flash sale units.
+func (app *application) failCheckout(orderID, reason string) {
+	change := data.OrderChange{To: data.OrderStatusFailed, Actor: fulfillment.ActorPayment, Reason: reason}
+	if _, err := app.models.Order.Transition(orderID, change); err != nil {
+		app.logger.Errorj(tlog.JSON{"message": "failed mark order failed", "orderId": orderID, "error": err})
+	}
+}
+
+// getOrderHandler shows an order to its buyer, who polls it for the
+// payment status. Order IDs are random UUIDs only the buyer is given.
+func (app *application) getOrderHandler(ctx echo.Context) error {
+	var dto dto.OrderGetDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	order, err := app.models.Order.Get(dto.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed get order", ctx.Request())
+	}
+
+	product, err := app.models.Product.Get(order.ProductID)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get order product", ctx.Request())
+	}
+
+	pay, err := app.models.Payment.GetByOrderID(order.ID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		return app.ErrInternalServer(err, "failed get order payment", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": OrderDetail{Order: order, Product: product, Payment: pay},
+	})
+}
+
+// AdminOrder is an order with its delivery attempts, as shown to admins.
+type AdminOrder struct {
+	*data.Order
+	Delivery data.OrderDelivery `json:"delivery"`
+}
+
+// AdminOrderDetail is an order with everything admins need to settle it.
+type AdminOrderDetail struct {
+	AdminOrder
+	Product     *data.Product           `json:"product"`
+	Payment     *data.Payment           `json:"payment" doc:"null when no charge could be created"`
+	Transitions []*data.OrderTransition `json:"transitions" doc:"Changes of status, oldest first"`
+	Next        []string                `json:"next" doc:"Statuses an admin may move the order to"`
+}
+
+func newAdminOrder(order *data.Order) AdminOrder {
+	return AdminOrder{Order: order, Delivery: order.Delivery}
+}
+
+// listAdminOrdersHandler lists orders by status, those stuck the longest
+// first.
+func (app *application) listAdminOrdersHandler(ctx echo.Context) error {
+	var dto dto.AdminOrderListDTO
+
+	// Set Default Value
+	dto.Status = utility.SetPtrValue("")
+	dto.Limit = utility.SetPtrValue(50)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	orders, err := app.models.Order.ListByStatus(*dto.Status, *dto.Limit)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed list orders", ctx.Request())
+	}
+
+	result := make([]AdminOrder, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, newAdminOrder(order))
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": result,
+	})
+}
+
+func (app *application) getAdminOrderHandler(ctx echo.Context) error {
+	var dto dto.AdminOrderGetDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	return app.writeAdminOrder(ctx, dto.ID)
+}
+
+// retryAdminOrderHandler schedules another delivery attempt of a paid,
+// stuck or failed order at once, with a fresh set of attempts.
+func (app *application) retryAdminOrderHandler(ctx echo.Context) error {
+	var dto dto.AdminOrderRetryDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	reason := "retried by an admin"
+	if dto.Reason != nil && *dto.Reason != "" {
+		reason = *dto.Reason
+	}
+
+	_, err := app.models.Order.Transition(dto.ID, data.OrderChange{
+		To:            data.OrderStatusPaid,
+		Actor:         fulfillment.ActorAdmin,
+		Reason:        reason,
+		ResetAttempts: true,
+	})
+	if err != nil {
+		return app.orderTransitionError(ctx, dto.ID, data.OrderStatusPaid, err)
+	}
+
+	return app.writeAdminOrder(ctx, dto.ID)
+}
+
+// resolveAdminOrderHandler settles an order by hand: delivered when the
+// Robux were sent outside the API, failed, or refunded. Refunds return
+// the payment at the provider first.
+func (app *application) resolveAdminOrderHandler(ctx echo.Context) error {
+	var dto dto.AdminOrderResolveDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	order, pay, err := app.getOrderWithPayment(dto.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed get order", ctx.Request())
+	}
+
+	change := data.OrderChange{To: dto.Status, Actor: fulfillment.ActorAdmin, Reason: dto.Reason}
+	if dto.Reference != nil {
+		change.Reference = *dto.Reference
+	}
+
+	var refund *payment.Refund
+	if dto.Status == data.OrderStatusRefunded {
+		// Checked up front, the money cannot be taken back once refunded.
+		state := orderState(order, pay)
+		if err := fulfillment.Check(state, dto.Status, fulfillment.ActorAdmin); err != nil || pay == nil {
+			return app.ErrInvalidOrderTransition(order.Status, dto.Status, fulfillment.Next(state, fulfillment.ActorAdmin))
+		}
+		if pay.Status != payment.StatusRefunded {
+			if pay.Provider != app.payments.Name() {
+				app.logger.Errorj(tlog.JSON{"message": "cannot refund payment of another provider", "orderId": order.ID, "provider": pay.Provider})
+				return app.ErrServiceUnavailable(apperror.CodeRefundUnavailable)
+			}
+			refund, err = app.payments.Refund(ctx.Request().Context(), pay.ChargeID, dto.Reason)
+			if err != nil {
+				app.logger.Errorj(tlog.JSON{"message": "failed refund payment", "orderId": order.ID, "chargeId": pay.ChargeID, "error": err})
+				return app.ErrServiceUnavailable(apperror.CodeRefundUnavailable)
+			}
+		}
+	}
+
+	if _, err := app.models.Order.Transition(dto.ID, change); err != nil {
+		return app.orderTransitionError(ctx, dto.ID, dto.Status, err)
+	}
+
+	// A pending refund is applied when the provider reports it.
+	if refund != nil && refund.Status == payment.StatusRefunded {
+		_, _, err := app.models.Payment.ApplyEvent(&data.PaymentEvent{
+			Provider:   pay.Provider,
+			EventID:    "refund:" + refund.ID,
+			ChargeID:   pay.ChargeID,
+			Status:     payment.StatusRefunded,
+			OccurredAt: time.Now(),
+		})
+		if err != nil {
+			return app.ErrInternalServer(err, "failed record refund", ctx.Request())
+		}
+	}
+
+	return app.writeAdminOrder(ctx, dto.ID)
+}
+
+// writeAdminOrder responds with the detail of the order with id.
+func (app *application) writeAdminOrder(ctx echo.Context, id string) error {
+	order, pay, err := app.getOrderWithPayment(id)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed get order", ctx.Request())
+	}
+
+	product, err := app.models.Product.Get(order.ProductID)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get order product", ctx.Request())
+	}
+
+	transitions, err := app.models.Order.History(order.ID)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get order history", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": AdminOrderDetail{
+			AdminOrder:  newAdminOrder(order),
+			Product:     product,
+			Payment:     pay,
+			Transitions: transitions,
+			Next:        fulfillment.Next(orderState(order, pay), fulfillment.ActorAdmin),
+		},
+	})
+}
+
+// getOrderWithPayment returns an order and its payment, nil when no
+// charge could be created.
+func (app *application) getOrderWithPayment(id string) (*data.Order, *data.Payment, error) {
+	order, err := app.models.Order.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pay, err := app.models.Payment.GetByOrderID(order.ID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	return order, pay, nil
+}
+
+// orderTransitionError turns a failed admin transition of the order with
+// id to status to into a response.
+func (app *application) orderTransitionError(ctx echo.Context, id, to string, err error) error {
+	if errors.Is(err, data.ErrRecordNotFound) {
+		return app.ErrNotFound()
+	}
+	if !errors.Is(err, fulfillment.ErrInvalidTransition) && !errors.Is(err, fulfillment.ErrNotPaid) {
+		return app.ErrInternalServer(err, "failed change order status", ctx.Request())
+	}
+
+	order, pay, err := app.getOrderWithPayment(id)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get order", ctx.Request())
+	}
+	return app.ErrInvalidOrderTransition(order.Status, to, fulfillment.Next(orderState(order, pay), fulfillment.ActorAdmin))
+}
+
+// orderState returns what the fulfillment guards know of an order.
+func orderState(order *data.Order, pay *data.Payment) fulfillment.State {
+	return fulfillment.State{Status: order.Status, Paid: pay != nil && pay.PaidAt != nil}
+}
+
+func (app *application) getRecentOrdersHandler(ctx echo.Context) error {
+	var dto dto.OrderRecentDTO
+
+	// Set Default Value
+	dto.Limit = utility.SetPtrValue(10)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	sales, err := app.models.Order.RecentSales(*dto.Limit)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get recent orders", ctx.Request())
+	}
+
+	orders := make([]RecentOrder, 0, len(sales))
+	for _, sale := range sales {
+		orders = append(orders, newRecentOrder(sale))
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": orders,
+	})
+}
+
+// streamRecentOrdersHandler pushes orders as they complete, as Server-Sent
+// Events. Each event carries the order ID, so a client reconnecting with
+// Last-Event-ID first gets the orders it missed.
+func (app *application) streamRecentOrdersHandler(ctx echo.Context) error {
+	var dto dto.OrderRecentStreamDTO
+
+	if err := new(echo.DefaultBinder).BindHeaders(ctx, &dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	cfg := app.currentConfig()
+	sales, err := app.salesFeed.subscribe(cfg.OrdersStream.MaxConnections)
+	if err != nil {
+		ctx.Response().Header().Set(echo.HeaderRetryAfter, fmt.Sprint(int(streamRetry.Seconds())))
+		if errors.Is(err, errTooManyStreams) {
+			return app.ErrServiceUnavailable(apperror.CodeTooManyStreams)
+		}
+		return app.ErrServiceUnavailable()
+	}
+	defer app.salesFeed.unsubscribe(sales)
+
+	// Subscribed first, so no order falls between the missed ones and the
+	// live ones. sent skips those sent twice.
+	var missed []*data.Sale
+	if dto.LastEventID != nil {
+		missed, err = app.models.Order.SalesAfter(*dto.LastEventID, streamResumeLimit)
+		if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrInternalServer(err, "failed get missed orders", ctx.Request())
+		}
+	}
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	// Keeps nginx from buffering the stream.
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	stream := eventStream{rc: http.NewResponseController(res), w: res}
+	if err := stream.write(fmt.Sprintf("retry: %d\n\n", streamRetry.Milliseconds())); err != nil {
+		return nil
+	}
+
+	sent := make(map[string]bool, len(missed))
+	for _, sale := range missed {
+		sent[sale.OrderID] = true
+		if err := stream.event(newRecentOrder(sale)); err != nil {
+			return nil
+		}
+	}
+
+	heartbeat := time.NewTicker(cfg.OrdersStream.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if err := stream.write(": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case order, ok := <-sales:
+			if !ok {
+				// Fell behind or shutting down, the client reconnects.
+				return nil
+			}
+			if sent[order.ID] {
+				continue
+			}
+			if err := stream.event(order); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// eventStream writes Server-Sent Events. Every write gets its own deadline
+// in place of the server WriteTimeout, so a stream stays open as long as
+// the client keeps reading.
+type eventStream struct {
+	rc *http.ResponseController
+	w  http.ResponseWriter
+}
+
+func (s eventStream) event(order RecentOrder) error {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("id: %s\nevent: order\ndata: %s\n\n", order.ID, payload))
+}
+
+func (s eventStream) write(msg string) error {
+	err := s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+func deliveredOrder(n int, at time.Time) data.Order {
+	return data.Order{
+		ID:             fmt.Sprintf("aa0e8400-e29b-41d4-a716-4466554400%02d", n),
+		ProductID:      "990e8400-e29b-41d4-a716-446655440003",
+		RobloxUsername: fmt.Sprintf("Roblox_Fan%02d", n),
+		Quantity:       1,
+		Robux:          1053,
+		TotalIDR:       pricing.Rupiah(144261),
+		Status:         data.OrderStatusDelivered,
+		DeliveredAt:    &at,
+	}
+}
+
+func TestGetRecentOrdersHandler(t *testing.T) {
+	store := memstore.NewSeeded()
+	now := time.Now()
+	for n := range 3 {
+		store.AddOrder(deliveredOrder(n, now.Add(time.Duration(n)*time.Minute)))
+	}
+	app := newTestApplicationWithStore(t, store)
+
+	rec := testRequest(t, app.routes(), http.MethodGet, "/v1/orders/recent?limit=2", "", nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Data []map[string]any `json:"data"`
+	}
+	decodeBody(t, rec, &body)
+	require.Len(t, body.Data, 2)
+	assert.Equal(t, "aa0e8400-e29b-41d4-a716-446655440002", body.Data[0]["id"])
+	assert.Equal(t, "Rob***02", body.Data[0]["buyer"])
+	assert.Equal(t, map[string]any{"minor": float64(14426100), "display": "Rp144.261"}, body.Data[0]["total"])
+	assert.Equal(t, "1053 Robux", body.Data[0]["product"].(map[string]any)["name"])
+	assert.NotContains(t, rec.Body.String(), "Roblox_Fan")
+}
+
+type sseEvent struct {
+	id, event, data, comment string
+}
+
+// readEvent reads the next event or comment block of an event stream.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return ev
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			ev.id = value
+		case "event":
+			ev.event = value
+		case "data":
+			ev.data = value
+		case "":
+			ev.comment = value
+		}
+	}
+}
+
+func TestStreamRecentOrdersHandler(t *testing.T) {
+	// openStream connects to srv and reads past the retry preamble.
+	openStream := func(t *testing.T, srv *httptest.Server, header http.Header) (*http.Response, *bufio.Reader) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/orders/recent/stream", nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		res, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() })
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		r := bufio.NewReader(res.Body)
+		assert.Equal(t, "retry: 5000\n", func() string { line, _ := r.ReadString('\n'); return line }())
+		_, _ = r.ReadString('\n')
+		return res, r
+	}
+
+	t.Run("pushes orders as they complete", func(t *testing.T) {
+		store := memstore.NewSeeded()
+		now := time.Now()
+		store.AddOrder(deliveredOrder(1, now.Add(-time.Hour)))
+		app := newTestApplicationWithStore(t, store)
+		srv := httptest.NewServer(app.routes())
+		t.Cleanup(srv.Close)
+		require.NoError(t, app.salesFeed.poll(app.models.Order, now))
+
+		_, r := openStream(t, srv, nil)
+		store.AddOrder(deliveredOrder(2, now.Add(time.Second)))
+		require.NoError(t, app.salesFeed.poll(app.models.Order, now.Add(2*time.Second)))
+
+		ev := readEvent(t, r)
+		assert.Equal(t, "order", ev.event)
+		assert.Equal(t, "aa0e8400-e29b-41d4-a716-446655440002", ev.id)
+		var order map[string]any
+		require.NoError(t, json.Unmarshal([]byte(ev.data), &order))
+		assert.Equal(t, "Rob***02", order["buyer"])
+
+		// Seen sales are not pushed again.
+		store.AddOrder(deliveredOrder(3, now.Add(-30*time.Second)))
+		require.NoError(t, app.salesFeed.poll(app.models.Order, now.Add(4*time.Second)))
+		assert.Equal(t, "aa0e8400-e29b-41d4-a716-446655440003", readEvent(t, r).id)
+	})
+
+	t.Run("resumes after Last-Event-ID", func(t *testing.T) {
+		store := memstore.NewSeeded()
+		now := time.Now()
+		for n := range 3 {
+			store.AddOrder(deliveredOrder(n, now.Add(time.Duration(n-10)*time.Minute)))
+		}
+		app := newTestApplicationWithStore(t, store)
+		srv := httptest.NewServer(app.routes())
+		t.Cleanup(srv.Close)
+
+		_, r := openStream(t, srv, http.Header{"Last-Event-ID": {"aa0e8400-e29b-41d4-a716-446655440000"}})
+
+		assert.Equal(t, "aa0e8400-e29b-41d4-a716-446655440001", readEvent(t, r).id)
+		assert.Equal(t, "aa0e8400-e29b-41d4-a716-446655440002", readEvent(t, r).id)
+	})
+
+	t.Run("outlives the server write timeout with heartbeats", func(t *testing.T) {
+		app := newTestApplication(t)
+		cfg := app.config
+		cfg.OrdersStream.Heartbeat = 50 * time.Millisecond
+		app.applyConfig(cfg)
+		srv := httptest.NewUnstartedServer(app.routes())
+		srv.Config.WriteTimeout = 100 * time.Millisecond
+		srv.Start()
+		t.Cleanup(srv.Close)
+
+		_, r := openStream(t, srv, nil)
+		deadline := time.Now().Add(300 * time.Millisecond)
+		for time.Now().Before(deadline) {
+			assert.Equal(t, "heartbeat", readEvent(t, r).comment)
+		}
+	})
+
+	t.Run("caps the number of streams", func(t *testing.T) {
+		app := newTestApplication(t)
+		cfg := app.config
+		cfg.OrdersStream.MaxConnections = 1
+		app.applyConfig(cfg)
+		srv := httptest.NewServer(app.routes())
+		t.Cleanup(srv.Close)
+
+		openStream(t, srv, nil)
+		res, err := srv.Client().Get(srv.URL + "/v1/orders/recent/stream")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, "5", res.Header.Get("Retry-After"))
+		var body errorBody
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, http.StatusText(http.StatusServiceUnavailable), body.Error.Code)
+		assert.Equal(t, "too many open streams, please try again later", body.Error.Message)
+	})
+
+	t.Run("ends streams on shutdown", func(t *testing.T) {
+		app := newTestApplication(t)
+		srv := httptest.NewServer(app.routes())
+		t.Cleanup(srv.Close)
+
+		_, r := openStream(t, srv, nil)
+		app.salesFeed.close()
+
+		_, err := r.ReadString('\n')
+		assert.Error(t, err)
+
+		res, err := srv.Client().Get(srv.URL + "/v1/orders/recent/stream")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+}
+
+// orderResponse is the body of the order endpoints.
+type orderResponse struct {
+	Data struct {
+		ID              string         `json:"id"`
+		Status          string         `json:"status"`
+		Quantity        int            `json:"quantity"`
+		Robux           int            `json:"robux"`
+		SubtotalIDR     map[string]any `json:"subtotalIdr"`
+		DiscountIDR     map[string]any `json:"discountIdr"`
+		TotalIDR        map[string]any `json:"totalIdr"`
+		VoucherID       *string        `json:"voucherId"`
+		FlashSaleItemID *string        `json:"flashSaleItemId"`
+		Product         struct {
+			Name string `json:"name"`
+		} `json:"product"`
+		Payment *struct {
+			ChargeID     string            `json:"chargeId"`
+			Provider     string            `json:"provider"`
+			Method       string            `json:"method"`
+			AmountIDR    map[string]any    `json:"amountIdr"`
+			Status       string            `json:"status"`
+			Instructions map[string]string `json:"instructions"`
+		} `json:"payment"`
+	} `json:"data"`
+}
+
+// createTestOrder places an order of 400 Robux paid with method.
+func createTestOrder(t *testing.T, handler http.Handler, method string) orderResponse {
+	t.Helper()
+
+	body := `{"productId":"990e8400-e29b-41d4-a716-446655440002","robloxUsername":"builderman","quantity":2,"paymentMethod":"` + method + `"}`
+	rec := testRequest(t, handler, http.MethodPost, "/v1/orders", body, nil)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var order orderResponse
+	decodeBody(t, rec, &order)
+	return order
+}
+
+func TestCreateOrderHandler(t *testing.T) {
+	t.Run("prices the order and creates its charge", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		order := createTestOrder(t, app.routes(), payment.MethodVABCA)
+
+		assert.Equal(t, data.OrderStatusPendingPayment, order.Data.Status)
+		assert.Equal(t, 2, order.Data.Quantity)
+		assert.Equal(t, 800, order.Data.Robux)
+		assert.Equal(t, "400 Robux", order.Data.Product.Name)
+		require.NotNil(t, order.Data.Payment)
+		assert.Equal(t, "fake", order.Data.Payment.Provider)
+		assert.Equal(t, payment.StatusPending, order.Data.Payment.Status)
+		assert.Equal(t, "bca", order.Data.Payment.Instructions["bank"])
+		assert.NotEmpty(t, order.Data.Payment.Instructions["vaNumber"])
+
+		quote := testRequest(t, app.routes(), http.MethodGet, "/v1/pricing/quote?robux=800", "", nil)
+		var price struct {
+			Data struct {
+				Total map[string]any `json:"total"`
+			} `json:"data"`
+		}
+		decodeBody(t, quote, &price)
+		assert.Equal(t, price.Data.Total, order.Data.TotalIDR)
+	})
+
+	t.Run("rejects products not on sale", func(t *testing.T) {
+		store := memstore.NewSeeded()
+		product, err := store.Models().Product.Get("990e8400-e29b-41d4-a716-446655440002")
+		require.NoError(t, err)
+		product.ID = "990e8400-e29b-41d4-a716-446655440099"
+		product.Active = false
+		store.AddProduct(*product)
+		app := newTestApplicationWithStore(t, store)
+
+		body := `{"productId":"990e8400-e29b-41d4-a716-446655440099","robloxUsername":"builderman","paymentMethod":"qris"}`
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/orders", body, nil)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Equal(t, "must be a product on sale", res.Error.Details["productid"])
+	})
+
+	t.Run("fails the order when no charge can be created", func(t *testing.T) {
+		// The charge reference is the order ID.
+		var orderID string
+		gatewaySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			orderID = r.Header.Get("Idempotency-Key")
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		t.Cleanup(gatewaySrv.Close)
+
+		store := memstore.NewSeeded()
+		app := newTestApplicationWithStore(t, store)
+		gw, err := payment.NewGateway(payment.GatewayConfig{BaseURL: gatewaySrv.URL, ServerKey: "sk_test", WebhookSecret: testWebhookSecret}, gatewaySrv.Client())
+		require.NoError(t, err)
+		app.payments = gw
+
+		body := `{"productId":"990e8400-e29b-41d4-a716-446655440002","robloxUsername":"builderman","paymentMethod":"ewallet_ovo"}`
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/orders", body, nil)
+
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+		assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Equal(t, "the payment provider could not create the charge, please try again later", res.Error.Message)
+
+		order, err := store.Models().Order.Get(orderID)
+		require.NoError(t, err)
+		assert.Equal(t, data.OrderStatusFailed, order.Status)
+	})
+
+	t.Run("expires the charge and fails the order when its payment cannot be stored", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.models.Payment = failingPayments{PaymentModeler: app.models.Payment}
+
+		body := `{"productId":"990e8400-e29b-41d4-a716-446655440002","robloxUsername":"builderman","paymentMethod":"qris"}`
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/orders", body, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+		failed, err := app.models.Order.ListByStatus(data.OrderStatusFailed, 10)
+		require.NoError(t, err)
+		require.Len(t, failed, 1)
+
+		// The fake returns the existing charge of a reference.
+		charge, err := app.payments.CreateCharge(context.Background(), payment.ChargeRequest{Reference: failed[0].ID, Amount: failed[0].TotalIDR, Method: payment.MethodQRIS})
+		require.NoError(t, err)
+		assert.Equal(t, payment.StatusExpired, charge.Status)
+	})
+}
+
+// failingPayments fails to store every payment.
+type failingPayments struct {
+	data.PaymentModeler
+}
+
+func (failingPayments) Insert(*data.Payment) error {
+	return errors.New("connection reset")
+}
+
+func TestGetOrderHandler(t *testing.T) {
+	app := newTestApplication(t)
+	created := createTestOrder(t, app.routes(), payment.MethodQRIS)
+
+	rec := testRequest(t, app.routes(), http.MethodGet, "/v1/orders/"+created.Data.ID, "", nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, openAPIDocument().ValidateResponse(http.MethodGet, "/v1/orders/:id", rec.Code, rec.Header(), rec.Body.Bytes()))
+	var order orderResponse
+	decodeBody(t, rec, &order)
+	assert.Equal(t, created, order)
+	assert.NotEmpty(t, order.Data.Payment.Instructions["qrString"])
+}
+
+type adminOrderResponse struct {
+	Data struct {
+		ID       string `json:"id"`
+		Status   string `json:"status"`
+		Delivery struct {
+			Attempts  int     `json:"attempts"`
+			LastError *string `json:"lastError"`
+			Reference *string `json:"reference"`
+		} `json:"delivery"`
+		Payment *struct {
+			Status string `json:"status"`
+		} `json:"payment"`
+		Transitions []struct {
+			ToStatus string  `json:"toStatus"`
+			Actor    string  `json:"actor"`
+			Reason   *string `json:"reason"`
+		} `json:"transitions"`
+		Next []string `json:"next"`
+	} `json:"data"`
+}
+
+func TestAdminOrderHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	paid := createPaidTestOrder(t, handler)
+	pending := createTestOrder(t, handler, payment.MethodVABNI)
+
+	t.Run("lists orders by status", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/admin/orders?status=paid", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res struct {
+			Data []struct {
+				ID       string         `json:"id"`
+				Delivery map[string]any `json:"delivery"`
+			} `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		require.Len(t, res.Data, 1)
+		assert.Equal(t, paid.Data.ID, res.Data[0].ID)
+		assert.Contains(t, res.Data[0].Delivery, "attempts")
+	})
+
+	t.Run("shows the history", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/admin/orders/"+paid.Data.ID, "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NoError(t, openAPIDocument().ValidateResponse(http.MethodGet, "/v1/admin/orders/:id", rec.Code, rec.Header(), rec.Body.Bytes()))
+		var res adminOrderResponse
+		decodeBody(t, rec, &res)
+		require.Len(t, res.Data.Transitions, 2)
+		assert.Equal(t, "customer", res.Data.Transitions[0].Actor)
+		assert.Equal(t, "payment", res.Data.Transitions[1].Actor)
+		assert.Equal(t, []string{"paid", "delivered", "failed", "refunded"}, res.Data.Next)
+	})
+
+	t.Run("hides delivery from buyers", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/orders/"+paid.Data.ID, "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "delivery")
+	})
+
+	t.Run("retries failed orders with fresh attempts", func(t *testing.T) {
+		app.deliverer = &failingDeliverer{err: fulfillment.Permanent(errors.New("user not found"))}
+		deliverDue(t, app, time.Now())
+		app.deliverer = fulfillment.Fake{}
+
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/orders/"+paid.Data.ID+"/retry", `{"reason":"username fixed"}`, adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res adminOrderResponse
+		decodeBody(t, rec, &res)
+		assert.Equal(t, data.OrderStatusPaid, res.Data.Status)
+		assert.Zero(t, res.Data.Delivery.Attempts)
+		assert.Nil(t, res.Data.Delivery.LastError)
+		last := res.Data.Transitions[len(res.Data.Transitions)-1]
+		assert.Equal(t, "admin", last.Actor)
+		assert.Equal(t, "username fixed", *last.Reason)
+	})
+
+	t.Run("refuses to retry unpaid orders", func(t *testing.T) {
+		header := adminHeader()
+		header.Set(echo.HeaderAccept, apperror.MIMEProblemJSON)
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/orders/"+pending.Data.ID+"/retry", "", header)
+
+		require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+		var problem struct {
+			Code    string            `json:"code"`
+			Details map[string]string `json:"details"`
+		}
+		decodeBody(t, rec, &problem)
+		assert.Equal(t, "invalid_order_transition", problem.Code)
+		assert.Equal(t, map[string]string{"status": "pending_payment", "allowed": "none"}, problem.Details)
+	})
+
+	t.Run("refunds at the provider", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/orders/"+paid.Data.ID+"/resolve", `{"status":"refunded","reason":"out of stock"}`, adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res adminOrderResponse
+		decodeBody(t, rec, &res)
+		assert.Equal(t, data.OrderStatusRefunded, res.Data.Status)
+		assert.Equal(t, payment.StatusRefunded, res.Data.Payment.Status)
+		assert.Empty(t, res.Data.Next)
+
+		charge, err := app.payments.GetCharge(context.Background(), getTestOrder(t, handler, paid.Data.ID).Data.Payment.ChargeID)
+		require.NoError(t, err)
+		assert.Equal(t, payment.StatusRefunded, charge.Status)
+	})
+
+	t.Run("refuses settled orders", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/orders/"+paid.Data.ID+"/resolve", `{"status":"delivered","reason":"sent by hand"}`, adminHeader())
+
+		require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Equal(t, "the order cannot move from refunded to delivered", res.Error.Message)
+	})
+
+	t.Run("requires a reason", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/orders/"+pending.Data.ID+"/resolve", `{"status":"failed"}`, adminHeader())
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Contains(t, res.Error.Details, "reason")
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+	"github.com/ucok-man/mayobox-server/internal/serializer"
+)
+
+// PaymentWebhookResult acknowledges a webhook delivery.
+type PaymentWebhookResult struct {
+	EventID string `json:"eventId"`
+	Applied bool   `json:"applied" doc:"false when the event was delivered before or reports a status the payment cannot move to"`
+}
+
+// paymentWebhookHandler receives status changes from the payment provider.
+// The provider retries deliveries until it gets a 2xx, so every event is
+// applied once however often it arrives.
+func (app *application) paymentWebhookHandler(ctx echo.Context) error {
+	var dto dto.PaymentWebhookDTO
+
+	if err := new(echo.DefaultBinder).BindPathParams(ctx, &dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	if dto.Provider != app.payments.Name() {
+		return app.ErrNotFound()
+	}
+
+	// The signature covers the body as sent, so it is read raw.
+	limit := serializer.BodyLimit(ctx)
+	body, err := io.ReadAll(io.LimitReader(ctx.Request().Body, limit+1))
+	if err != nil {
+		return app.ErrBadRequest("unable to read request body")
+	}
+	if int64(len(body)) > limit {
+		return apperror.New(apperror.CodeRequestTooLarge)
+	}
+
+	event, applied, err := app.processPaymentWebhook(ctx.Request().Header, body)
+	if err != nil {
+		return app.paymentWebhookError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": PaymentWebhookResult{EventID: event.ID, Applied: applied},
+	})
+}
+
+// simulatePaymentHandler pays, expires or fails a charge of the fake
+// provider and delivers the webhook the gateway would have sent. It is
+// only routed when the fake provider is configured.
+func (app *application) simulatePaymentHandler(ctx echo.Context) error {
+	var dto dto.PaymentSimulateDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	fake, ok := app.payments.(*payment.Fake)
+	if !ok {
+		return app.ErrNotFound()
+	}
+
+	header, body, err := fake.Simulate(dto.ChargeID, dto.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrChargeNotFound):
+			return app.ErrNotFound()
+		case errors.Is(err, payment.ErrInvalidTransition):
+			return app.ErrFailedValidation(map[string]string{"status": "must be reachable from the current status of the charge"})
+		default:
+			return app.ErrInternalServer(err, "failed simulate payment", ctx.Request())
+		}
+	}
+
+	event, applied, err := app.processPaymentWebhook(header, body)
+	if err != nil {
+		return app.paymentWebhookError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": PaymentWebhookResult{EventID: event.ID, Applied: applied},
+	})
+}
+
+// paymentWebhookError maps an error of processPaymentWebhook to a response.
+func (app *application) paymentWebhookError(ctx echo.Context, err error) error {
+	switch {
+	case errors.Is(err, payment.ErrInvalidSignature):
+		return apperror.New(apperror.CodeInvalidWebhookSignature)
+	case errors.Is(err, payment.ErrMalformedWebhook):
+		return app.ErrBadRequest("body is not a valid webhook event")
+	case errors.Is(err, data.ErrRecordNotFound):
+		// Answered with an error, so the provider retries once the order
+		// that created the charge is committed.
+		return app.ErrNotFound()
+	default:
+		return app.ErrInternalServer(err, "failed process payment webhook", ctx.Request())
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+)
+
+type webhookResponse struct {
+	Data PaymentWebhookResult `json:"data"`
+}
+
+func getTestOrder(t *testing.T, handler http.Handler, id string) orderResponse {
+	t.Helper()
+
+	rec := testRequest(t, handler, http.MethodGet, "/v1/orders/"+id, "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var order orderResponse
+	decodeBody(t, rec, &order)
+	return order
+}
+
+func TestSimulatePaymentHandler(t *testing.T) {
+	t.Run("pays the order", func(t *testing.T) {
+		app := newTestApplication(t)
+		handler := app.routes()
+		order := createTestOrder(t, handler, payment.MethodQRIS)
+
+		rec := testRequest(t, handler, http.MethodPost, "/v1/payments/simulator/"+order.Data.Payment.ChargeID, `{"status":"paid"}`, nil)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res webhookResponse
+		decodeBody(t, rec, &res)
+		assert.True(t, res.Data.Applied)
+		assert.NotEmpty(t, res.Data.EventID)
+
+		paid := getTestOrder(t, handler, order.Data.ID)
+		assert.Equal(t, data.OrderStatusPaid, paid.Data.Status)
+		assert.Equal(t, payment.StatusPaid, paid.Data.Payment.Status)
+	})
+
+	t.Run("fails the order of an expired charge", func(t *testing.T) {
+		app := newTestApplication(t)
+		handler := app.routes()
+		order := createTestOrder(t, handler, payment.MethodEWalletDANA)
+
+		rec := testRequest(t, handler, http.MethodPost, "/v1/payments/simulator/"+order.Data.Payment.ChargeID, `{"status":"expired"}`, nil)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		expired := getTestOrder(t, handler, order.Data.ID)
+		assert.Equal(t, data.OrderStatusFailed, expired.Data.Status)
+		assert.Equal(t, payment.StatusExpired, expired.Data.Payment.Status)
+
+		rec = testRequest(t, handler, http.MethodPost, "/v1/payments/simulator/"+order.Data.Payment.ChargeID, `{"status":"paid"}`, nil)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Contains(t, res.Error.Details, "status")
+	})
+
+	t.Run("is not routed for the gateway", func(t *testing.T) {
+		app := newTestApplication(t)
+		gw, err := payment.NewGateway(payment.GatewayConfig{BaseURL: "https://api.example.co.id", ServerKey: "sk_test", WebhookSecret: testWebhookSecret}, nil)
+		require.NoError(t, err)
+		app.payments = gw
+
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/payments/simulator/fake_1", `{"status":"paid"}`, nil)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestPaymentWebhookHandler(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	fake := app.payments.(*payment.Fake)
+
+	order := createTestOrder(t, handler, payment.MethodVABRI)
+	header, body, err := fake.Simulate(order.Data.Payment.ChargeID, payment.StatusPaid)
+	require.NoError(t, err)
+
+	t.Run("rejects bad signatures", func(t *testing.T) {
+		forged := payment.Sign("not-the-secret", body, time.Now())
+
+		rec := testRequest(t, handler, http.MethodPost, "/v1/payments/webhooks/fake", string(body), forged)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Equal(t, "the webhook signature is missing, invalid or expired", res.Error.Message)
+		assert.Equal(t, data.OrderStatusPendingPayment, getTestOrder(t, handler, order.Data.ID).Data.Status)
+	})
+
+	t.Run("applies an event once", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/payments/webhooks/fake", string(body), header)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var first webhookResponse
+		decodeBody(t, rec, &first)
+		assert.True(t, first.Data.Applied)
+
+		rec = testRequest(t, handler, http.MethodPost, "/v1/payments/webhooks/fake", string(body), header)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var again webhookResponse
+		decodeBody(t, rec, &again)
+		assert.Equal(t, first.Data.EventID, again.Data.EventID)
+		assert.False(t, again.Data.Applied)
+
+		assert.Equal(t, data.OrderStatusPaid, getTestOrder(t, handler, order.Data.ID).Data.Status)
+	})
+
+	t.Run("refuses a paid event for another amount", func(t *testing.T) {
+		short := createTestOrder(t, handler, payment.MethodQRIS)
+		_, body, err := fake.Simulate(short.Data.Payment.ChargeID, payment.StatusPaid)
+		require.NoError(t, err)
+
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(body, &event))
+		event["data"].(map[string]any)["amount"] = 1000
+		body, err = json.Marshal(event)
+		require.NoError(t, err)
+
+		rec := testRequest(t, handler, http.MethodPost, "/v1/payments/webhooks/fake", string(body), payment.Sign(testWebhookSecret, body, time.Now()))
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res webhookResponse
+		decodeBody(t, rec, &res)
+		assert.False(t, res.Data.Applied)
+		assert.Equal(t, data.OrderStatusPendingPayment, getTestOrder(t, handler, short.Data.ID).Data.Status)
+	})
+
+	t.Run("asks for a retry of unknown charges", func(t *testing.T) {
+		charge, err := fake.CreateCharge(context.Background(), payment.ChargeRequest{Reference: "not-stored", Amount: 100, Method: payment.MethodQRIS})
+		require.NoError(t, err)
+		header, body, err := fake.Simulate(charge.ID, payment.StatusPaid)
+		require.NoError(t, err)
+
+		rec := testRequest(t, handler, http.MethodPost, "/v1/payments/webhooks/fake", string(body), header)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestReconcilePayments(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	fake := app.payments.(*payment.Fake)
+
+	paid := createTestOrder(t, handler, payment.MethodQRIS)
+	pending := createTestOrder(t, handler, payment.MethodVABNI)
+	// The webhook of this payment never arrives.
+	_, _, err := fake.Simulate(paid.Data.Payment.ChargeID, payment.StatusPaid)
+	require.NoError(t, err)
+
+	t.Run("leaves recent payments to their webhook", func(t *testing.T) {
+		require.NoError(t, app.reconcilePayments(context.Background(), time.Now()))
+
+		assert.Equal(t, data.OrderStatusPendingPayment, getTestOrder(t, handler, paid.Data.ID).Data.Status)
+	})
+
+	t.Run("applies the status the provider reports", func(t *testing.T) {
+		require.NoError(t, app.reconcilePayments(context.Background(), time.Now().Add(paymentReconcileAfter+time.Second)))
+
+		assert.Equal(t, data.OrderStatusPaid, getTestOrder(t, handler, paid.Data.ID).Data.Status)
+		assert.Equal(t, data.OrderStatusPendingPayment, getTestOrder(t, handler, pending.Data.ID).Data.Status)
+	})
+
+	t.Run("expires charges the provider forgot", func(t *testing.T) {
+		app.payments = payment.NewFake(testWebhookSecret)
+
+		require.NoError(t, app.reconcilePayments(context.Background(), time.Now().Add(2*time.Hour)))
+
+		forgotten := getTestOrder(t, handler, pending.Data.ID)
+		assert.Equal(t, data.OrderStatusFailed, forgotten.Data.Status)
+		assert.Equal(t, payment.StatusExpired, forgotten.Data.Payment.Status)
+	})
+}
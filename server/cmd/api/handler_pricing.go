@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+)
+
+// getPricingQuoteHandler prices Robux with the rate table in effect now.
+func (app *application) getPricingQuoteHandler(ctx echo.Context) error {
+	var dto dto.PricingQuoteDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	// A missing or broken rate table is a deployment error, not the
+	// caller's.
+	table, err := app.models.RateTable.Current(time.Now())
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get current rate table", ctx.Request())
+	}
+
+	quote, err := table.Quote(*dto.Robux)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed quote robux price", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": quote,
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+func TestGetPricingQuoteHandler(t *testing.T) {
+	t.Run("quotes with the table in effect", func(t *testing.T) {
+		store := memstore.New()
+		store.AddRateTable(pricing.Table{
+			ID:            "current",
+			EffectiveFrom: time.Now().Add(-time.Hour),
+			Tiers:         []pricing.Tier{{MinRobux: 1, IDRPerRobux: pricing.Rupiah(137)}},
+			RoundingStep:  pricing.Rupiah(1),
+			RoundingMode:  pricing.RoundNearest,
+		})
+		store.AddRateTable(pricing.Table{
+			ID:            "scheduled",
+			EffectiveFrom: time.Now().Add(time.Hour),
+			Tiers:         []pricing.Tier{{MinRobux: 1, IDRPerRobux: pricing.Rupiah(150)}},
+			RoundingStep:  pricing.Rupiah(1),
+			RoundingMode:  pricing.RoundNearest,
+		})
+		app := newTestApplicationWithStore(t, store)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/pricing/quote?robux=1053", "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			Data map[string]any `json:"data"`
+		}
+		decodeBody(t, rec, &body)
+		assert.Equal(t, "current", body.Data["rateTableId"])
+		assert.Equal(t, float64(1505), body.Data["gamepassPrice"])
+		assert.Equal(t, map[string]any{"minor": float64(14426100), "display": "Rp144.261"}, body.Data["total"])
+	})
+
+	t.Run("validates robux", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		for _, target := range []string{"/v1/pricing/quote", "/v1/pricing/quote?robux=0", "/v1/pricing/quote?robux=1000001"} {
+			rec := testRequest(t, app.routes(), http.MethodGet, target, "", nil)
+
+			require.Equal(t, http.StatusUnprocessableEntity, rec.Code, target)
+			var body errorBody
+			decodeBody(t, rec, &body)
+			assert.Contains(t, body.Error.Details, "robux", target)
+		}
+	})
+
+	t.Run("fails without a rate table", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, memstore.New())
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/pricing/quote?robux=10", "", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/featured"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// getFeaturedProductsHandler serves today's Products of the Day, scheduling
+// them first when the background scheduler has not yet.
+func (app *application) getFeaturedProductsHandler(ctx echo.Context) error {
+	picks, err := app.scheduleFeatured(featured.Day(time.Now()))
+	if err != nil {
+		return app.ErrInternalServer(err, "failed schedule featured products", ctx.Request())
+	}
+
+	products := make([]*data.Product, 0, len(picks))
+	for _, pick := range picks {
+		products = append(products, pick.Product)
+	}
+	priced, err := app.priceProducts(products...)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed price featured products", ctx.Request())
+	}
+
+	items := make([]FeaturedProduct, 0, len(picks))
+	for i, pick := range picks {
+		items = append(items, FeaturedProduct{
+			Date:     pick.Date,
+			Position: pick.Position,
+			Source:   pick.Source,
+			Product:  priced[i],
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": items,
+	})
+}
+
+// getBestSellersHandler ranks the products by units delivered in a window
+// of Jakarta days, today included. Sales show up once rolled up.
+func (app *application) getBestSellersHandler(ctx echo.Context) error {
+	var dto dto.ProductBestSellersDTO
+
+	// Set Default Value
+	dto.Window = utility.SetPtrValue("30d")
+	dto.Limit = utility.SetPtrValue(10)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	from, err := bestSellersFrom(*dto.Window, time.Now())
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get best sellers window", ctx.Request())
+	}
+
+	sellers, err := app.models.Sales.BestSellers(from, *dto.Limit)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get best sellers", ctx.Request())
+	}
+
+	products := make([]*data.Product, 0, len(sellers))
+	for _, seller := range sellers {
+		products = append(products, seller.Product)
+	}
+	priced, err := app.priceProducts(products...)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed price best sellers", ctx.Request())
+	}
+
+	items := make([]BestSellerProduct, 0, len(sellers))
+	for i, seller := range sellers {
+		items = append(items, BestSellerProduct{
+			Rank:    i + 1,
+			Sold:    seller.Sold,
+			Product: priced[i],
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": items,
+	})
+}
+
+func (app *application) getFeaturedCalendarHandler(ctx echo.Context) error {
+	var dto dto.AdminFeaturedCalendarListDTO
+
+	// Set Default Value
+	today := featured.Day(time.Now())
+	monthAhead, _ := featured.AddDays(today, 30)
+	dto.From = utility.SetPtrValue(today)
+	dto.To = utility.SetPtrValue(monthAhead)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	entries, err := app.models.Featured.GetCalendar(*dto.From, *dto.To)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get featured calendar", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": entries,
+	})
+}
+
+// updateFeaturedCalendarHandler replaces the editor picks of a day. A day
+// that is already scheduled is picked again on its next request.
+func (app *application) updateFeaturedCalendarHandler(ctx echo.Context) error {
+	var dto dto.AdminFeaturedCalendarUpdateDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	entries, err := app.models.Featured.SetCalendar(dto.Date, dto.ProductIDs)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrFailedValidation(map[string]string{"productids": "must only contain existing products"})
+		}
+		return app.ErrInternalServer(err, "failed update featured calendar", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": entries,
+	})
+}
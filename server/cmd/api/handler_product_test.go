@@ -0,0 +1,228 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+	"github.com/ucok-man/mayobox-server/internal/featured"
+)
+
+type featuredBody struct {
+	Data []struct {
+		Date     string `json:"date"`
+		Position int    `json:"position"`
+		Source   string `json:"source"`
+		Product  struct {
+			ID      string `json:"id"`
+			InStock bool   `json:"inStock"`
+			Price   struct {
+				Robux int `json:"robux"`
+				IDR   struct {
+					Minor int64 `json:"minor"`
+				} `json:"idr"`
+			} `json:"price"`
+		} `json:"product"`
+	} `json:"data"`
+}
+
+func (b featuredBody) ids() []string {
+	var ids []string
+	for _, item := range b.Data {
+		ids = append(ids, item.Product.ID)
+	}
+	return ids
+}
+
+func getFeatured(t *testing.T, app *application) featuredBody {
+	t.Helper()
+
+	rec := testRequest(t, app.routes(), http.MethodGet, "/v1/products/featured", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body featuredBody
+	decodeBody(t, rec, &body)
+	return body
+}
+
+func TestGetFeaturedProductsHandler(t *testing.T) {
+	t.Run("keeps the picks of the day", func(t *testing.T) {
+		app := newTestApplication(t)
+		cfg := app.config
+		cfg.Featured.Count = 4
+		app.applyConfig(cfg)
+
+		first := getFeatured(t, app)
+		second := getFeatured(t, app)
+
+		require.Len(t, first.Data, 4)
+		assert.Equal(t, first.ids(), second.ids())
+		assert.Len(t, slices.Compact(slices.Sorted(slices.Values(first.ids()))), 4)
+		for i, item := range first.Data {
+			assert.Equal(t, featured.Day(time.Now()), item.Date)
+			assert.Equal(t, i+1, item.Position)
+			assert.Equal(t, featured.SourceRandom, item.Source)
+			assert.Positive(t, item.Product.Price.Robux)
+			assert.Positive(t, item.Product.Price.IDR.Minor)
+		}
+	})
+
+	t.Run("features calendar picks first", func(t *testing.T) {
+		app := newTestApplication(t)
+		cfg := app.config
+		cfg.Featured.Count = 3
+		app.applyConfig(cfg)
+		today := featured.Day(time.Now())
+		ids := []string{"990e8400-e29b-41d4-a716-446655440012", "990e8400-e29b-41d4-a716-446655440006"}
+		_, err := app.models.Featured.SetCalendar(today, ids)
+		require.NoError(t, err)
+
+		body := getFeatured(t, app)
+
+		require.Len(t, body.Data, 3)
+		assert.Equal(t, ids, body.ids()[:2])
+		assert.Equal(t, featured.SourceCalendar, body.Data[0].Source)
+		assert.Equal(t, featured.SourceRandom, body.Data[2].Source)
+	})
+
+	t.Run("picks again after the calendar changes", func(t *testing.T) {
+		app := newTestApplication(t)
+		today := featured.Day(time.Now())
+		getFeatured(t, app)
+
+		rec := testRequest(t, app.routes(), http.MethodPut, "/v1/admin/featured-calendar/"+today, `{"productIds":["990e8400-e29b-41d4-a716-446655440008"]}`, adminHeader())
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		body := getFeatured(t, app)
+		require.NotEmpty(t, body.Data)
+		assert.Equal(t, "990e8400-e29b-41d4-a716-446655440008", body.Data[0].Product.ID)
+		assert.Equal(t, featured.SourceCalendar, body.Data[0].Source)
+	})
+}
+
+func TestFeaturedCalendarHandlers(t *testing.T) {
+	t.Run("lists the entries in range", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodPut, "/v1/admin/featured-calendar/2026-12-25", `{"productIds":["990e8400-e29b-41d4-a716-446655440006","990e8400-e29b-41d4-a716-446655440007"]}`, adminHeader())
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = testRequest(t, app.routes(), http.MethodGet, "/v1/admin/featured-calendar?from=2026-12-01&to=2026-12-31", "", adminHeader())
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			Data []map[string]any `json:"data"`
+		}
+		decodeBody(t, rec, &body)
+		require.Len(t, body.Data, 2)
+		assert.Equal(t, "2026-12-25", body.Data[0]["date"])
+		assert.Equal(t, float64(1), body.Data[0]["position"])
+		assert.Equal(t, "990e8400-e29b-41d4-a716-446655440007", body.Data[1]["productId"])
+
+		rec = testRequest(t, app.routes(), http.MethodGet, "/v1/admin/featured-calendar?from=2027-01-01&to=2027-01-31", "", adminHeader())
+		require.Equal(t, http.StatusOK, rec.Code)
+		decodeBody(t, rec, &body)
+		assert.Empty(t, body.Data)
+	})
+
+	t.Run("rejects unknown products", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodPut, "/v1/admin/featured-calendar/2026-12-25", `{"productIds":["990e8400-e29b-41d4-a716-446655449999"]}`, adminHeader())
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Contains(t, body.Error.Details, "productids")
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		app := newTestApplication(t)
+		tests := []struct {
+			target, body, field string
+		}{
+			{"/v1/admin/featured-calendar/2026-13-01", `{"productIds":[]}`, "date"},
+			{"/v1/admin/featured-calendar/2026-12-25", `{"productIds":["nope"]}`, "productids[0]"},
+			{"/v1/admin/featured-calendar/2026-12-25", `{"productIds":["990e8400-e29b-41d4-a716-446655440006","990e8400-e29b-41d4-a716-446655440006"]}`, "productids"},
+		}
+
+		for _, tt := range tests {
+			rec := testRequest(t, app.routes(), http.MethodPut, tt.target, tt.body, adminHeader())
+
+			require.Equal(t, http.StatusUnprocessableEntity, rec.Code, tt.target)
+			var body errorBody
+			decodeBody(t, rec, &body)
+			assert.Contains(t, body.Error.Details, tt.field, tt.body)
+		}
+	})
+}
+
+func TestGetBestSellersHandler(t *testing.T) {
+	store := memstore.NewSeeded()
+	delivered := func(productID string, quantity int, at time.Time) data.Order {
+		return data.Order{ProductID: productID, Quantity: quantity, Robux: 100, Status: data.OrderStatusDelivered, DeliveredAt: &at}
+	}
+	now := time.Now()
+	store.AddOrder(delivered("990e8400-e29b-41d4-a716-446655440006", 3, now.Add(-2*time.Hour)))
+	store.AddOrder(delivered("990e8400-e29b-41d4-a716-446655440007", 2, now.Add(-2*time.Hour)))
+	store.AddOrder(delivered("990e8400-e29b-41d4-a716-446655440007", 5, now.AddDate(0, 0, -20)))
+	store.AddOrder(delivered("990e8400-e29b-41d4-a716-446655440008", 9, now.AddDate(0, 0, -60)))
+	app := newTestApplicationWithStore(t, store)
+	_, err := app.models.Sales.Refresh(now)
+	require.NoError(t, err)
+
+	type bestSellers struct {
+		Data []struct {
+			Rank    int `json:"rank"`
+			Sold    int `json:"sold"`
+			Product struct {
+				ID    string `json:"id"`
+				Price struct {
+					Robux int `json:"robux"`
+					IDR   struct {
+						Display string `json:"display"`
+					} `json:"idr"`
+				} `json:"price"`
+			} `json:"product"`
+		} `json:"data"`
+	}
+
+	tests := []struct {
+		target string
+		ids    []string
+		sold   []int
+	}{
+		{"/v1/products/best-sellers?window=7d", []string{"990e8400-e29b-41d4-a716-446655440006", "990e8400-e29b-41d4-a716-446655440007"}, []int{3, 2}},
+		{"/v1/products/best-sellers", []string{"990e8400-e29b-41d4-a716-446655440007", "990e8400-e29b-41d4-a716-446655440006"}, []int{7, 3}},
+		{"/v1/products/best-sellers?window=all&limit=1", []string{"990e8400-e29b-41d4-a716-446655440008"}, []int{9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := testRequest(t, app.routes(), http.MethodGet, tt.target, "", nil)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			var body bestSellers
+			decodeBody(t, rec, &body)
+			require.Len(t, body.Data, len(tt.ids))
+			for i, item := range body.Data {
+				assert.Equal(t, i+1, item.Rank)
+				assert.Equal(t, tt.ids[i], item.Product.ID)
+				assert.Equal(t, tt.sold[i], item.Sold)
+				assert.Positive(t, item.Product.Price.Robux)
+				assert.NotEmpty(t, item.Product.Price.IDR.Display)
+			}
+		})
+	}
+
+	t.Run("validates the query", func(t *testing.T) {
+		for _, target := range []string{"/v1/products/best-sellers?window=1y", "/v1/products/best-sellers?limit=0"} {
+			rec := testRequest(t, app.routes(), http.MethodGet, target, "", nil)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, target)
+		}
+	})
+}
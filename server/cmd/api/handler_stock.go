@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// listStockBalancesHandler shows the Robux of every supplier account by
+// ledger bucket.
+func (app *application) listStockBalancesHandler(ctx echo.Context) error {
+	balances, err := app.models.Stock.Balances()
+	if err != nil {
+		return app.ErrInternalServer(err, "failed list stock balances", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": balances,
+	})
+}
+
+func (app *application) listStockLedgerHandler(ctx echo.Context) error {
+	var dto dto.AdminStockLedgerDTO
+
+	// Set Default Value
+	dto.Limit = utility.SetPtrValue(100)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	if _, err := app.models.SupplierAccount.Get(dto.ID); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed get supplier account", ctx.Request())
+	}
+
+	entries, err := app.models.Stock.Entries(dto.ID, *dto.Limit)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed list stock ledger", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": entries,
+	})
+}
+
+// createStockPurchaseHandler records Robux bought for a supplier account,
+// available to sell at once.
+func (app *application) createStockPurchaseHandler(ctx echo.Context) error {
+	var dto dto.AdminStockPurchaseDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	entry, err := app.models.Stock.Purchase(dto.ID, dto.Robux, utility.DerefOrDefault(dto.Note, ""))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed record stock purchase", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusCreated, envelope{
+		"data": entry,
+	})
+}
+
+// reconcileStockHandler compares the Robux a supplier account should hold,
+// available and reserved, with its balance at Roblox, and adjusts the
+// available Robux by the difference.
+func (app *application) reconcileStockHandler(ctx echo.Context) error {
+	var dto dto.AdminStockReconcileDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	rec, err := app.models.Stock.Reconcile(dto.ID, *dto.Robux, utility.DerefOrDefault(dto.Note, ""))
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed reconcile stock", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": rec,
+	})
+}
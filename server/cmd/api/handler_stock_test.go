@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+)
+
+// mainSupplierAccount returns the seeded supplier account.
+func mainSupplierAccount(t *testing.T, app *application) *data.SupplierAccount {
+	t.Helper()
+
+	accounts, err := app.models.SupplierAccount.GetAll()
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	return accounts[0]
+}
+
+func TestStockHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	account := mainSupplierAccount(t, app)
+	base := "/v1/admin/supplier-accounts/" + account.ID
+
+	t.Run("records purchases", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, base+"/purchases", `{"robux":500,"note":"invoice 17"}`, adminHeader())
+
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var res struct {
+			Data data.LedgerEntry `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		assert.Equal(t, data.LedgerPurchase, res.Data.Kind)
+		assert.Equal(t, 500, res.Data.Amount)
+		assert.Equal(t, "invoice 17", *res.Data.Note)
+	})
+
+	t.Run("lists balances", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/admin/stock", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, openAPIDocument().ValidateResponse(http.MethodGet, "/v1/admin/stock", rec.Code, rec.Header(), rec.Body.Bytes()))
+		var res struct {
+			Data []data.StockBalance `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		require.Len(t, res.Data, 1)
+		assert.Equal(t, 100500, res.Data[0].Purchased)
+		assert.Equal(t, 100500, res.Data[0].Available)
+		assert.Equal(t, 100500, res.Data[0].OnHand)
+	})
+
+	t.Run("reconciles with the roblox balance", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, base+"/reconcile", `{"robux":100000}`, adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res struct {
+			Data data.Reconciliation `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		assert.Equal(t, 100500, res.Data.Expected)
+		assert.Equal(t, -500, res.Data.Difference)
+		require.NotNil(t, res.Data.Entry)
+		assert.Equal(t, 100000, res.Data.Balance.Available)
+	})
+
+	t.Run("lists the ledger newest first", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, base+"/ledger?limit=2", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res struct {
+			Data []data.LedgerEntry `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		require.Len(t, res.Data, 2)
+		assert.Equal(t, data.LedgerAdjusted, res.Data[0].Kind)
+		assert.Equal(t, data.LedgerPurchase, res.Data[1].Kind)
+	})
+
+	t.Run("rejects a missing reconciled balance", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, base+"/reconcile", `{"note":"forgot"}`, adminHeader())
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/admin/supplier-accounts/aa0e8400-e29b-41d4-a716-446655449999/ledger", "", adminHeader())
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestCheckoutStock(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	account := mainSupplierAccount(t, app)
+	_, err := app.models.Stock.Reconcile(account.ID, 800, "")
+	require.NoError(t, err)
+
+	order := createTestOrder(t, handler, payment.MethodQRIS)
+
+	t.Run("reserves the robux of an order", func(t *testing.T) {
+		balance, err := app.models.Stock.Balance(account.ID)
+		require.NoError(t, err)
+		assert.Zero(t, balance.Available)
+		assert.Equal(t, order.Data.Robux, balance.Reserved)
+	})
+
+	t.Run("refuses orders out of stock", func(t *testing.T) {
+		body := `{"productId":"990e8400-e29b-41d4-a716-446655440002","robloxUsername":"builderman","quantity":1,"paymentMethod":"qris"}`
+		rec := testRequest(t, handler, http.MethodPost, "/v1/orders", body, problemHeader())
+
+		require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+		var problem struct {
+			Code string `json:"code"`
+		}
+		decodeBody(t, rec, &problem)
+		assert.Equal(t, "out_of_stock", problem.Code)
+
+		featured := getFeatured(t, app)
+		require.NotEmpty(t, featured.Data)
+		for _, item := range featured.Data {
+			assert.False(t, item.Product.InStock, item.Product.ID)
+		}
+	})
+
+	t.Run("releases unpaid reservations", func(t *testing.T) {
+		require.NoError(t, app.releaseExpiredReservations(time.Now().Add(app.config.Payment.Expiry+time.Minute)))
+
+		reservation, err := app.models.Stock.Reservation(order.Data.ID)
+		require.NoError(t, err)
+		assert.Equal(t, data.ReservationReleased, reservation.Status)
+
+		for _, item := range getFeatured(t, app).Data {
+			assert.Equal(t, item.Product.Price.Robux <= 800, item.Product.InStock, item.Product.ID)
+		}
+	})
+}
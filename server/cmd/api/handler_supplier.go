@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+func (app *application) listSupplierAccountsHandler(ctx echo.Context) error {
+	accounts, err := app.models.SupplierAccount.GetAll()
+	if err != nil {
+		return app.ErrInternalServer(err, "failed list supplier accounts", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": accounts,
+	})
+}
+
+func (app *application) createSupplierAccountHandler(ctx echo.Context) error {
+	var dto dto.AdminSupplierAccountCreateDTO
+
+	// Set Default Value
+	dto.MaxConcurrency = utility.SetPtrValue(1)
+	dto.Active = utility.SetPtrValue(true)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	account := data.SupplierAccount{
+		Name:           dto.Name,
+		MaxConcurrency: *dto.MaxConcurrency,
+		Active:         *dto.Active,
+	}
+	if err := app.models.SupplierAccount.Insert(&account); err != nil {
+		return app.ErrInternalServer(err, "failed create supplier account", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusCreated, envelope{
+		"data": account,
+	})
+}
+
+// updateSupplierAccountHandler changes the fields sent. Lowering the max
+// concurrency or deactivating an account lets its running deliveries
+// finish; it only stops new ones.
+func (app *application) updateSupplierAccountHandler(ctx echo.Context) error {
+	var dto dto.AdminSupplierAccountUpdateDTO
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	account, err := app.models.SupplierAccount.Get(dto.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed get supplier account", ctx.Request())
+	}
+
+	if dto.Name != nil {
+		account.Name = *dto.Name
+	}
+	if dto.MaxConcurrency != nil {
+		account.MaxConcurrency = *dto.MaxConcurrency
+	}
+	if dto.Active != nil {
+		account.Active = *dto.Active
+	}
+
+	if err := app.models.SupplierAccount.Update(account); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed update supplier account", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": account,
+	})
+}
+
+// listDeadLettersHandler lists the orders whose delivery attempts ran out,
+// newest first. A dead letter is resolved once its order leaves failed.
+func (app *application) listDeadLettersHandler(ctx echo.Context) error {
+	var dto dto.AdminDeadLetterListDTO
+
+	// Set Default Value
+	dto.All = utility.SetPtrValue(false)
+	dto.Limit = utility.SetPtrValue(50)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	letters, err := app.models.Order.DeadLetters(!*dto.All, *dto.Limit)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed list dead letters", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": letters,
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type supplierAccountResponse struct {
+	Data data.SupplierAccount `json:"data"`
+}
+
+func TestSupplierAccountHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	var created supplierAccountResponse
+
+	t.Run("creates with defaults", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/supplier-accounts", `{"name":"Backup"}`, adminHeader())
+
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		decodeBody(t, rec, &created)
+		assert.NotEmpty(t, created.Data.ID)
+		assert.Equal(t, 1, created.Data.MaxConcurrency)
+		assert.True(t, created.Data.Active)
+	})
+
+	t.Run("updates the fields sent", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPut, "/v1/admin/supplier-accounts/"+created.Data.ID, `{"maxConcurrency":4,"active":false}`, adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res supplierAccountResponse
+		decodeBody(t, rec, &res)
+		assert.Equal(t, "Backup", res.Data.Name)
+		assert.Equal(t, 4, res.Data.MaxConcurrency)
+		assert.False(t, res.Data.Active)
+	})
+
+	t.Run("lists by name", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/admin/supplier-accounts", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res struct {
+			Data []data.SupplierAccount `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		require.Len(t, res.Data, 2)
+		assert.Equal(t, "Backup", res.Data[0].Name)
+		assert.Equal(t, "Main", res.Data[1].Name)
+	})
+
+	t.Run("inactive accounts deliver nothing", func(t *testing.T) {
+		createPaidTestOrder(t, handler)
+		accounts, err := app.models.SupplierAccount.GetAll()
+		require.NoError(t, err)
+		for _, account := range accounts {
+			account.Active = false
+			require.NoError(t, app.models.SupplierAccount.Update(account))
+		}
+
+		assert.Zero(t, deliverDue(t, app, time.Now()))
+	})
+}
+
+func TestListDeadLettersHandler(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	app.deliverer = &failingDeliverer{err: errors.New("roblox timed out")}
+	paid := createPaidTestOrder(t, handler)
+	for i := range 3 {
+		deliverDue(t, app, time.Now().Add(time.Duration(i)*3*time.Hour))
+	}
+
+	rec := testRequest(t, handler, http.MethodGet, "/v1/admin/dead-letters", "", adminHeader())
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, openAPIDocument().ValidateResponse(http.MethodGet, "/v1/admin/dead-letters", rec.Code, rec.Header(), rec.Body.Bytes()))
+	var res struct {
+		Data []data.DeadLetter `json:"data"`
+	}
+	decodeBody(t, rec, &res)
+	require.Len(t, res.Data, 1)
+	assert.Equal(t, paid.Data.ID, res.Data[0].OrderID)
+	assert.Equal(t, "roblox timed out", res.Data[0].Error)
+
+	// Retrying the order resolves its dead letter.
+	rec = testRequest(t, handler, http.MethodPost, "/v1/admin/orders/"+paid.Data.ID+"/retry", "", adminHeader())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = testRequest(t, handler, http.MethodGet, "/v1/admin/dead-letters", "", adminHeader())
+	decodeBody(t, rec, &res)
+	assert.Empty(t, res.Data)
+
+	rec = testRequest(t, handler, http.MethodGet, "/v1/admin/dead-letters?all=true", "", adminHeader())
+	decodeBody(t, rec, &res)
+	require.Len(t, res.Data, 1)
+	assert.NotNil(t, res.Data[0].ResolvedAt)
+}
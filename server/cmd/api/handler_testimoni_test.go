@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+)
+
+type testimoniListBody struct {
+	Data     []*data.TestimoniWithUser `json:"data"`
+	Metadata *data.Metadata            `json:"metadata"`
+}
+
+func TestGetAllTestimoniHandler(t *testing.T) {
+	store := memstore.New()
+	store.AddUser(data.User{ID: "550e8400-e29b-41d4-a716-446655440001", Username: "RobloxMaster99"})
+	created := time.Date(2026, 1, 20, 4, 41, 27, 0, time.UTC)
+	for i := 1; i <= 15; i++ {
+		store.AddTestimoni(data.Testimoni{
+			ID:        fmt.Sprintf("660e8400-e29b-41d4-a716-4466554400%02d", i),
+			UserID:    "550e8400-e29b-41d4-a716-446655440001",
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	handler := newTestApplicationWithStore(t, store).routes()
+
+	t.Run("uses default pagination", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/testimonies", "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var body testimoniListBody
+		decodeBody(t, rec, &body)
+		assert.Len(t, body.Data, 10)
+		assert.Equal(t, "660e8400-e29b-41d4-a716-446655440015", body.Data[0].ID)
+		assert.Equal(t, "RobloxMaster99", body.Data[0].User.Username)
+		assert.Equal(t, &data.Metadata{CurrentPage: 1, PageSize: 10, FirstPage: 1, LastPage: 2, TotalRecords: 15}, body.Metadata)
+	})
+
+	t.Run("returns the requested page", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/testimonies?page=2&page_size=10", "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var body testimoniListBody
+		decodeBody(t, rec, &body)
+		assert.Len(t, body.Data, 5)
+		assert.Equal(t, "660e8400-e29b-41d4-a716-446655440005", body.Data[0].ID)
+		assert.Equal(t, 2, body.Metadata.CurrentPage)
+	})
+
+	t.Run("returns null data past the last page", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/testimonies?page=3&page_size=10", "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data":null,"metadata":{}}`, rec.Body.String())
+	})
+
+	t.Run("rejects out of range pagination", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/testimonies?page=0&page_size=101", "", nil)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Contains(t, body.Error.Details, "pagination.page")
+		assert.Contains(t, body.Error.Details, "pagination.pagesize")
+	})
+
+	t.Run("rejects malformed pagination", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/testimonies?page_size=ten", "", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/imaging"
+	"github.com/ucok-man/mayobox-server/internal/upload"
+)
+
+// uploadUserAvatarHandler stores the image in the avatar form field and
+// points the user's image_url at it.
+func (app *application) uploadUserAvatarHandler(ctx echo.Context) error {
+	var dto dto.AdminUserAvatarDTO
+
+	// ctx.Bind would read the multipart body, only bind the path.
+	if err := new(echo.DefaultBinder).BindPathParams(ctx, &dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	image, err := app.uploadImage(ctx, "avatars", avatarRule(app.currentConfig()))
+	if err != nil {
+		return err
+	}
+
+	_, err = app.models.User.UpdateImageURL(dto.ID, image.URL)
+	if err != nil {
+		// The stored files are named by content and may be shared with
+		// another record, so they are left in place.
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed updating user image url", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": image,
+	})
+}
+
+// uploadTestimoniIconHandler stores the image in the icon form field and
+// points the testimoni's icon_url at it.
+func (app *application) uploadTestimoniIconHandler(ctx echo.Context) error {
+	var dto dto.AdminTestimoniIconDTO
+
+	// ctx.Bind would read the multipart body, only bind the path.
+	if err := new(echo.DefaultBinder).BindPathParams(ctx, &dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	image, err := app.uploadImage(ctx, "icons", testimoniIconRule(app.currentConfig()))
+	if err != nil {
+		return err
+	}
+
+	_, err = app.models.Testimoni.UpdateIconURL(dto.ID, image.URL)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed updating testimoni icon url", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": image,
+	})
+}
+
+// uploadImage parses the single image file of rule and stores it below
+// prefix.
+func (app *application) uploadImage(ctx echo.Context, prefix string, rule upload.Rule) (*UploadedImage, error) {
+	form, err := app.parseUpload(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := app.storeImage(ctx.Request().Context(), prefix, form.Files[rule.Field].Data)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedFormat), errors.Is(err, imaging.ErrCorrupt):
+		return nil, app.ErrFailedValidation(map[string]string{rule.Field: "must be a valid image"})
+	case errors.Is(err, imaging.ErrTooManyPixels):
+		return nil, app.ErrFailedValidation(map[string]string{rule.Field: "must not have more than 40 megapixels"})
+	case err != nil:
+		return nil, app.ErrInternalServer(err, "failed storing image", ctx.Request())
+	}
+	return image, nil
+}
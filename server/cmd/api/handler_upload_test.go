@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+)
+
+const (
+	uploadUserID      = "6f1c2a6e-6d4b-4c8e-9a61-1d2b3c4d5e6f"
+	uploadTestimoniID = "0b7a4f3e-2c1d-4e5f-8a9b-7c6d5e4f3a2b"
+)
+
+func newUploadStore() *memstore.Store {
+	store := memstore.New()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.AddUser(data.User{ID: uploadUserID, Username: "budi", Email: "budi@example.com", CreatedAt: now, UpdatedAt: now})
+	store.AddTestimoni(data.Testimoni{ID: uploadTestimoniID, UserID: uploadUserID, Testimoni: "Fast top up", CreatedAt: now, UpdatedAt: now})
+	return store
+}
+
+// testNoisyPNG returns a w x h PNG of random pixels, which barely
+// compresses.
+func testNoisyPNG(w, h int) []byte {
+	rng := rand.New(rand.NewPCG(1, 2))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = byte(rng.Uint32())
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// listedTestimoni returns the only testimoni of the public listing.
+func listedTestimoni(t *testing.T, handler http.Handler) map[string]any {
+	t.Helper()
+
+	rec := testRequest(t, handler, http.MethodGet, "/v1/testimonies", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Data []map[string]any `json:"data"`
+	}
+	decodeBody(t, rec, &body)
+	require.Len(t, body.Data, 1)
+	return body.Data[0]
+}
+
+func TestUploadUserAvatarHandler(t *testing.T) {
+	t.Run("stores the image and its thumbnails", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, newUploadStore())
+		handler := app.routes()
+		body, header := uploadRequest("avatar", "me.png", testPNG(2048, 1024))
+
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/users/"+uploadUserID+"/avatar", body, header)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res struct {
+			Data UploadedImage `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		assert.Equal(t, [2]int{maxImageSide, maxImageSide / 2}, [2]int{res.Data.Width, res.Data.Height})
+		assert.True(t, strings.HasPrefix(res.Data.URL, "http://localhost:4000/media/avatars/"), res.Data.URL)
+		require.Len(t, res.Data.Thumbnails, len(thumbnailSizes))
+
+		user := listedTestimoni(t, handler)["user"].(map[string]any)
+		assert.Equal(t, res.Data.URL, user["imageUrl"])
+
+		// The stored files are served from /media.
+		for _, thumb := range res.Data.Thumbnails {
+			path := strings.TrimPrefix(thumb.URL, "http://localhost:4000")
+			rec := testRequest(t, handler, http.MethodGet, path, "", nil)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "public, max-age=31536000, immutable", rec.Header().Get(echo.HeaderCacheControl))
+			img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, thumb.Size, img.Bounds().Dx())
+		}
+	})
+
+	t.Run("accepts images above the default body limit", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, newUploadStore())
+		image := testNoisyPNG(600, 600)
+		require.Greater(t, len(image), app.config.BodyLimit.DefaultKB<<10)
+		require.Less(t, len(image), app.config.Upload.ImageMaxSizeKB<<10)
+		body, header := uploadRequest("avatar", "big.png", image)
+
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/admin/users/"+uploadUserID+"/avatar", body, header)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+
+	t.Run("names files by content", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, newUploadStore())
+		handler := app.routes()
+		body, header := uploadRequest("avatar", "me.png", testPNG(8, 8))
+
+		first := testRequest(t, handler, http.MethodPost, "/v1/admin/users/"+uploadUserID+"/avatar", body, header)
+		second := testRequest(t, handler, http.MethodPost, "/v1/admin/users/"+uploadUserID+"/avatar", body, header)
+
+		require.Equal(t, http.StatusOK, first.Code)
+		assert.JSONEq(t, first.Body.String(), second.Body.String())
+	})
+
+	t.Run("returns not found for an unknown user", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, newUploadStore())
+		body, header := uploadRequest("avatar", "me.png", testPNG(8, 8))
+
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/admin/users/"+uploadTestimoniID+"/avatar", body, header)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("rejects an invalid id", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, newUploadStore())
+		body, header := uploadRequest("avatar", "me.png", testPNG(8, 8))
+
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/admin/users/not-a-uuid/avatar", body, header)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Contains(t, res.Error.Details, "id")
+	})
+
+	t.Run("rejects a corrupt image", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, newUploadStore())
+		// The PNG signature passes the type check, the rest does not decode.
+		body, header := uploadRequest("avatar", "me.png", testPNG(8, 8)[:40])
+
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/admin/users/"+uploadUserID+"/avatar", body, header)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Equal(t, "must be a valid image", res.Error.Details["avatar"])
+	})
+}
+
+func TestUploadTestimoniIconHandler(t *testing.T) {
+	t.Run("stores the icon", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, newUploadStore())
+		handler := app.routes()
+		body, header := uploadRequest("icon", "icon.png", testPNG(32, 32))
+
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/testimonies/"+uploadTestimoniID+"/icon", body, header)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res struct {
+			Data UploadedImage `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		assert.True(t, strings.HasPrefix(res.Data.URL, "http://localhost:4000/media/icons/"), res.Data.URL)
+		assert.Equal(t, res.Data.URL, listedTestimoni(t, handler)["iconUrl"])
+	})
+
+	t.Run("requires the icon field", func(t *testing.T) {
+		app := newTestApplicationWithStore(t, newUploadStore())
+		body, header := uploadRequest("avatar", "icon.png", testPNG(8, 8))
+
+		rec := testRequest(t, app.routes(), http.MethodPost, "/v1/admin/testimonies/"+uploadTestimoniID+"/icon", body, header)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var res errorBody
+		decodeBody(t, rec, &res)
+		assert.Contains(t, res.Error.Details, "icon")
+	})
+}
+
+func TestServeMedia(t *testing.T) {
+	t.Run("returns not found for missing files and directories", func(t *testing.T) {
+		app := newTestApplication(t)
+		handler := app.routes()
+
+		for _, path := range []string{"/media/avatars/missing.png", "/media/", "/media/../go.mod"} {
+			rec := testRequest(t, handler, http.MethodGet, path, "", nil)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code, path)
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// VoucherQuote is the price of an order with a voucher applied.
+type VoucherQuote struct {
+	Code        string        `json:"code"`
+	Description string        `json:"description"`
+	SubtotalIDR pricing.Money `json:"subtotalIdr"`
+	DiscountIDR pricing.Money `json:"discountIdr"`
+	TotalIDR    pricing.Money `json:"totalIdr"`
+}
+
+// validateVoucherHandler prices an order with a voucher for the checkout
+// page. Nothing is redeemed; the voucher is checked again when the order
+// is placed.
+func (app *application) validateVoucherHandler(ctx echo.Context) error {
+	var dto dto.VoucherValidateDTO
+
+	// Set Default Value
+	dto.Quantity = utility.SetPtrValue(1)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	product, err := app.models.Product.Get(dto.ProductID)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		return app.ErrInternalServer(err, "failed get product", ctx.Request())
+	}
+	if product == nil || !product.Active {
+		return app.ErrFailedValidation(map[string]string{"productid": "must be a product on sale"})
+	}
+
+	now := time.Now()
+	table, err := app.models.RateTable.Current(now)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get current rate table", ctx.Request())
+	}
+	quote, err := table.Quote(product.Robux * *dto.Quantity)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed quote robux price", ctx.Request())
+	}
+	subtotal := quote.Total
+	item, err := app.flashSaleItem(product.ID, *dto.Quantity, now)
+	if err != nil {
+		return app.ErrInternalServer(err, "failed get flash sales", ctx.Request())
+	}
+	if item != nil {
+		subtotal = item.PriceIDR * pricing.Money(*dto.Quantity)
+	}
+
+	use := data.VoucherUse{Product: product, RobloxUsername: utility.DerefOrDefault(dto.RobloxUsername, ""), SubtotalIDR: subtotal, At: now}
+	voucher, discount, err := app.models.Voucher.Apply(dto.Code, use)
+	if err != nil {
+		var voucherErr *data.VoucherError
+		if errors.As(err, &voucherErr) {
+			return app.ErrInvalidVoucher(voucherErr)
+		}
+		return app.ErrInternalServer(err, "failed apply voucher", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": VoucherQuote{
+			Code:        voucher.Code,
+			Description: voucher.Description,
+			SubtotalIDR: subtotal,
+			DiscountIDR: discount,
+			TotalIDR:    subtotal - discount,
+		},
+	})
+}
+
+func (app *application) listVouchersHandler(ctx echo.Context) error {
+	vouchers, err := app.models.Voucher.GetAll()
+	if err != nil {
+		return app.ErrInternalServer(err, "failed list vouchers", ctx.Request())
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": vouchers,
+	})
+}
+
+func (app *application) createVoucherHandler(ctx echo.Context) error {
+	var dto dto.AdminVoucherCreateDTO
+
+	// Set Default Value
+	dto.Active = utility.SetPtrValue(true)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	voucher := data.Voucher{
+		Code:         dto.Code,
+		Description:  utility.DerefOrDefault(dto.Description, ""),
+		PercentOff:   dto.PercentOff,
+		Category:     dto.Category,
+		ProductIDs:   dto.ProductIDs,
+		UsageLimit:   dto.UsageLimit,
+		PerUserLimit: dto.PerUserLimit,
+		StartsAt:     dto.StartsAt,
+		EndsAt:       dto.EndsAt,
+		Active:       *dto.Active,
+	}
+	if err := app.setVoucherAmounts(&voucher, dto.AmountOffIDR, dto.MaxDiscountIDR, dto.MinSpendIDR); err != nil {
+		return err
+	}
+
+	if err := app.models.Voucher.Insert(&voucher); err != nil {
+		return app.voucherWriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, envelope{
+		"data": voucher,
+	})
+}
+
+// updateVoucherHandler replaces every field of a voucher. Orders already
+// using it keep their discount and still count toward its limits.
+func (app *application) updateVoucherHandler(ctx echo.Context) error {
+	var dto dto.AdminVoucherUpdateDTO
+
+	// Set Default Value
+	dto.Active = utility.SetPtrValue(true)
+
+	if err := ctx.Bind(&dto); err != nil {
+		return app.ErrBadRequest(err.Error())
+	}
+
+	if err := ctx.Validate(&dto); err != nil {
+		return app.ErrFailedValidation(err)
+	}
+
+	if _, err := app.models.Voucher.Get(dto.ID); err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return app.ErrNotFound()
+		}
+		return app.ErrInternalServer(err, "failed get voucher", ctx.Request())
+	}
+
+	voucher := data.Voucher{
+		ID:           dto.ID,
+		Code:         dto.Code,
+		Description:  utility.DerefOrDefault(dto.Description, ""),
+		PercentOff:   dto.PercentOff,
+		Category:     dto.Category,
+		ProductIDs:   dto.ProductIDs,
+		UsageLimit:   dto.UsageLimit,
+		PerUserLimit: dto.PerUserLimit,
+		StartsAt:     dto.StartsAt,
+		EndsAt:       dto.EndsAt,
+		Active:       *dto.Active,
+	}
+	if err := app.setVoucherAmounts(&voucher, dto.AmountOffIDR, dto.MaxDiscountIDR, dto.MinSpendIDR); err != nil {
+		return err
+	}
+
+	if err := app.models.Voucher.Update(&voucher); err != nil {
+		return app.voucherWriteError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, envelope{
+		"data": voucher,
+	})
+}
+
+// setVoucherAmounts converts the whole rupiah amounts sent for a voucher
+// and checks the rules the validator cannot express.
+func (app *application) setVoucherAmounts(voucher *data.Voucher, amountOff, maxDiscount, minSpend *int64) error {
+	switch {
+	case (voucher.PercentOff == nil) == (amountOff == nil):
+		return app.ErrFailedValidation(map[string]string{"percentoff": "exactly one of percentOff and amountOffIdr must be set"})
+	case maxDiscount != nil && voucher.PercentOff == nil:
+		return app.ErrFailedValidation(map[string]string{"maxdiscountidr": "only applies to percent vouchers"})
+	case voucher.StartsAt != nil && voucher.EndsAt != nil && !voucher.EndsAt.After(*voucher.StartsAt):
+		return app.ErrFailedValidation(map[string]string{"endsat": "must be after startsAt"})
+	}
+
+	if amountOff != nil {
+		voucher.AmountOffIDR = utility.SetPtrValue(pricing.Rupiah(*amountOff))
+	}
+	if maxDiscount != nil {
+		voucher.MaxDiscountIDR = utility.SetPtrValue(pricing.Rupiah(*maxDiscount))
+	}
+	voucher.MinSpendIDR = pricing.Rupiah(utility.DerefOrDefault(minSpend, 0))
+	return nil
+}
+
+// voucherWriteError maps the errors of storing a voucher.
+func (app *application) voucherWriteError(ctx echo.Context, err error) error {
+	switch {
+	case errors.Is(err, data.ErrDuplicateVoucherCode):
+		return app.ErrFailedValidation(map[string]string{"code": "is already used by another voucher"})
+	case errors.Is(err, data.ErrRecordNotFound):
+		return app.ErrFailedValidation(map[string]string{"productids": "must be existing products"})
+	}
+	return app.ErrInternalServer(err, "failed save voucher", ctx.Request())
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type voucherResponse struct {
+	Data struct {
+		ID           string         `json:"id"`
+		Code         string         `json:"code"`
+		PercentOff   *int           `json:"percentOff"`
+		AmountOffIDR map[string]any `json:"amountOffIdr"`
+		ProductIDs   []string       `json:"productIds"`
+		Redemptions  int            `json:"redemptions"`
+		Active       bool           `json:"active"`
+	} `json:"data"`
+}
+
+// createTestVoucher stores a voucher through the admin API.
+func createTestVoucher(t *testing.T, handler http.Handler, body string) voucherResponse {
+	t.Helper()
+
+	rec := testRequest(t, handler, http.MethodPost, "/v1/admin/vouchers", body, adminHeader())
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var res voucherResponse
+	decodeBody(t, rec, &res)
+	return res
+}
+
+// voucherOrderBody is an order of one 1053 Robux package.
+func voucherOrderBody(username, code string) string {
+	return `{"productId":"990e8400-e29b-41d4-a716-446655440003","robloxUsername":"` + username + `","paymentMethod":"qris","voucherCode":"` + code + `"}`
+}
+
+func TestValidateVoucherHandler(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+
+	t.Run("prices the order with the discount", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/vouchers/validate", `{"code":"mayo10","productId":"990e8400-e29b-41d4-a716-446655440003"}`, nil)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NoError(t, openAPIDocument().ValidateResponse(http.MethodPost, "/v1/vouchers/validate", rec.Code, rec.Header(), rec.Body.Bytes()))
+		var res struct {
+			Data struct {
+				Code        string         `json:"code"`
+				SubtotalIDR map[string]any `json:"subtotalIdr"`
+				DiscountIDR map[string]any `json:"discountIdr"`
+				TotalIDR    map[string]any `json:"totalIdr"`
+			} `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		assert.Equal(t, "MAYO10", res.Data.Code)
+		assert.Equal(t, "Rp144.261", res.Data.SubtotalIDR["display"])
+		assert.Equal(t, "Rp14.426", res.Data.DiscountIDR["display"])
+		assert.Equal(t, "Rp129.835", res.Data.TotalIDR["display"])
+	})
+
+	t.Run("explains why a voucher does not apply", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/vouchers/validate", `{"code":"MAYO10","productId":"990e8400-e29b-41d4-a716-446655440001"}`, problemHeader())
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var problem struct {
+			Code    string            `json:"code"`
+			Details map[string]string `json:"details"`
+		}
+		decodeBody(t, rec, &problem)
+		assert.Equal(t, "invalid_voucher", problem.Code)
+		assert.Equal(t, map[string]string{"reason": data.VoucherMinSpend, "minSpend": "Rp50.000"}, problem.Details)
+	})
+
+	t.Run("checks the per-user limit for the buyer", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/orders", voucherOrderBody("builderman", "MAYO10"), nil)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		body := `{"code":"MAYO10","productId":"990e8400-e29b-41d4-a716-446655440003","robloxUsername":"BuilderMan"}`
+		rec = testRequest(t, handler, http.MethodPost, "/v1/vouchers/validate", body, problemHeader())
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), data.VoucherUserLimit)
+
+		body = `{"code":"MAYO10","productId":"990e8400-e29b-41d4-a716-446655440003","robloxUsername":"guest"}`
+		rec = testRequest(t, handler, http.MethodPost, "/v1/vouchers/validate", body, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestCreateOrderWithVoucher(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+
+	t.Run("charges the discounted total", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/orders", voucherOrderBody("builderman", "MAYO10"), nil)
+
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var order orderResponse
+		decodeBody(t, rec, &order)
+		assert.Equal(t, "Rp144.261", order.Data.SubtotalIDR["display"])
+		assert.Equal(t, "Rp14.426", order.Data.DiscountIDR["display"])
+		assert.Equal(t, "Rp129.835", order.Data.TotalIDR["display"])
+		assert.NotNil(t, order.Data.VoucherID)
+		assert.Equal(t, order.Data.TotalIDR, order.Data.Payment.AmountIDR)
+	})
+
+	t.Run("rejects vouchers that do not apply", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/orders", voucherOrderBody("builderman", "MAYO10"), problemHeader())
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), data.VoucherUserLimit)
+	})
+
+	t.Run("never exceeds the usage limit", func(t *testing.T) {
+		createTestVoucher(t, handler, `{"code":"FIRST3","amountOffIdr":5000,"usageLimit":3}`)
+
+		var wg sync.WaitGroup
+		codes := make([]int, 10)
+		for i := range codes {
+			wg.Go(func() {
+				rec := testRequest(t, handler, http.MethodPost, "/v1/orders", voucherOrderBody(fmt.Sprintf("buyer%02d", i), "FIRST3"), nil)
+				codes[i] = rec.Code
+			})
+		}
+		wg.Wait()
+
+		created := 0
+		for _, code := range codes {
+			if code == http.StatusCreated {
+				created++
+			} else {
+				assert.Equal(t, http.StatusUnprocessableEntity, code)
+			}
+		}
+		assert.Equal(t, 3, created)
+	})
+}
+
+func TestVoucherHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	handler := app.routes()
+	var created voucherResponse
+
+	t.Run("creates", func(t *testing.T) {
+		created = createTestVoucher(t, handler, `{"code":"pass5k","amountOffIdr":5000,"productIds":["990e8400-e29b-41d4-a716-446655440006"]}`)
+
+		assert.Equal(t, "PASS5K", created.Data.Code)
+		assert.Equal(t, "Rp5.000", created.Data.AmountOffIDR["display"])
+		assert.Equal(t, []string{"990e8400-e29b-41d4-a716-446655440006"}, created.Data.ProductIDs)
+		assert.True(t, created.Data.Active)
+	})
+
+	t.Run("rejects duplicate codes", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/vouchers", `{"code":"Pass5K","percentOff":5}`, adminHeader())
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "already used")
+	})
+
+	t.Run("rejects unknown products", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/vouchers", `{"code":"GHOST","percentOff":5,"productIds":["aa0e8400-e29b-41d4-a716-446655449999"]}`, adminHeader())
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("rejects both kinds of discount", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPost, "/v1/admin/vouchers", `{"code":"BOTH","percentOff":5,"amountOffIdr":5000}`, adminHeader())
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("replaces every field", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodPut, "/v1/admin/vouchers/"+created.Data.ID, `{"code":"PASS10","percentOff":10,"active":false}`, adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var res voucherResponse
+		decodeBody(t, rec, &res)
+		assert.Equal(t, "PASS10", res.Data.Code)
+		assert.Equal(t, 10, *res.Data.PercentOff)
+		assert.Nil(t, res.Data.AmountOffIDR)
+		assert.Empty(t, res.Data.ProductIDs)
+		assert.False(t, res.Data.Active)
+	})
+
+	t.Run("lists newest first", func(t *testing.T) {
+		rec := testRequest(t, handler, http.MethodGet, "/v1/admin/vouchers", "", adminHeader())
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, openAPIDocument().ValidateResponse(http.MethodGet, "/v1/admin/vouchers", rec.Code, rec.Header(), rec.Body.Bytes()))
+		var res struct {
+			Data []struct {
+				Code string `json:"code"`
+			} `json:"data"`
+		}
+		decodeBody(t, rec, &res)
+		require.Len(t, res.Data, 2)
+		assert.Equal(t, "PASS10", res.Data[0].Code)
+		assert.Equal(t, "MAYO10", res.Data[1].Code)
+	})
+}
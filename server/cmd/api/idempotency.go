@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+
+	// maxIdempotentBodyBytes bounds the stored response. Larger responses
+	// are not stored, so retries run again.
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// withIdempotency makes POST, PUT, PATCH and DELETE requests that carry an
+// Idempotency-Key header safe to retry. The first request is processed and
+// its response stored for the configured TTL; duplicates get the stored
+// response with Idempotent-Replayed: true. Reusing a key for a different
+// request is rejected with 422, and a duplicate that arrives while the first
+// request is still running gets 409.
+//
+// Keys are scoped by path and caller, so two clients cannot collide. Server
+// errors and responses that did not execute the request (401, 403, 409, 429)
+// are not stored.
+func (app *application) withIdempotency() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			key := req.Header.Get(headerIdempotencyKey)
+			if key == "" || !isMutating(req.Method) {
+				return next(ctx)
+			}
+			if len(key) > 255 {
+				return app.ErrInvalidIdempotencyKey()
+			}
+
+			// The route's own body limit applies later, when the buffered
+			// body is decoded, so buffer up to the largest one.
+			limits := app.currentConfig().BodyLimit
+			maxBody := int64(max(limits.DefaultKB, limits.LargeKB)) << 10
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxBody+1))
+			if err != nil {
+				return app.ErrBadRequest("unable to read request body")
+			}
+			if int64(len(body)) > maxBody {
+				return apperror.New(apperror.CodeRequestTooLarge)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			rec := &data.IdempotencyKey{
+				Scope:       idempotencyScope(req),
+				Key:         key,
+				Fingerprint: requestFingerprint(req, body),
+				ExpiresAt:   time.Now().Add(app.currentConfig().Idempotency.TTL),
+			}
+
+			found, claimed, err := app.models.IdempotencyKey.Claim(rec)
+			if err != nil {
+				return app.ErrInternalServer(err, "failed claiming idempotency key", req)
+			}
+			if !claimed {
+				return app.replayIdempotent(ctx, rec, found)
+			}
+
+			completed := false
+			defer func() {
+				// Also runs when the handler panics, so the key is not
+				// stuck in progress until it expires.
+				if !completed {
+					if err := app.models.IdempotencyKey.Release(rec.Scope, rec.Key); err != nil {
+						app.logger.Errorj(tlog.JSON{"message": "failed releasing idempotency key", "error": err})
+					}
+				}
+			}()
+
+			res := ctx.Response()
+			capture := &captureWriter{ResponseWriter: res.Writer}
+			res.Writer = capture
+			if err := next(ctx); err != nil {
+				// Render the error now so the response can be stored.
+				ctx.Error(err)
+			}
+			res.Writer = capture.ResponseWriter
+
+			if !storableStatus(res.Status) || capture.overflow {
+				return nil
+			}
+
+			rec.StatusCode = res.Status
+			rec.ContentType = res.Header().Get(echo.HeaderContentType)
+			rec.Body = capture.buf.Bytes()
+			if err := app.models.IdempotencyKey.Complete(rec); err != nil {
+				app.logger.Errorj(tlog.JSON{"message": "failed storing idempotent response", "error": err})
+				return nil
+			}
+			completed = true
+			return nil
+		}
+	}
+}
+
+func (app *application) replayIdempotent(ctx echo.Context, rec, found *data.IdempotencyKey) error {
+	switch {
+	case found.Fingerprint != rec.Fingerprint:
+		return app.ErrIdempotencyKeyReused()
+	case found.StatusCode == 0:
+		ctx.Response().Header().Set(echo.HeaderRetryAfter, "1")
+		return app.ErrIdempotencyInProgress()
+	}
+
+	ctx.Response().Header().Set(headerIdempotentReplayed, "true")
+	if len(found.Body) == 0 {
+		return ctx.NoContent(found.StatusCode)
+	}
+	return ctx.Blob(found.StatusCode, found.ContentType, found.Body)
+}
+
+// startIdempotencyCleanup deletes expired keys every hour until shutdown.
+func (app *application) startIdempotencyCleanup() {
+	app.every("idempotency key cleanup", time.Hour, func(ctx context.Context) error {
+		deleted, err := app.models.IdempotencyKey.DeleteExpired()
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			app.logger.Debugj(tlog.JSON{"message": "deleted expired idempotency keys", "count": deleted})
+		}
+		return nil
+	})
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func storableStatus(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+// idempotencyScope namespaces keys by method, path and a hash of the
+// credentials, so the same key from two callers never matches.
+func idempotencyScope(req *http.Request) string {
+	scope := req.Method + " " + req.URL.Path
+	if auth := req.Header.Get(echo.HeaderAuthorization); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+		scope += " " + hex.EncodeToString(sum[:8])
+	}
+	return scope
+}
+
+// requestFingerprint identifies the payload a key was first used with.
+func requestFingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, req.Method+"\n"+req.URL.RequestURI()+"\n"+req.Header.Get(echo.HeaderContentType)+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// captureWriter copies up to maxIdempotentBodyBytes of the response body.
+type captureWriter struct {
+	http.ResponseWriter
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.buf.Len()+len(b) > maxIdempotentBodyBytes {
+			w.overflow = true
+			w.buf.Reset()
+		} else {
+			w.buf.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
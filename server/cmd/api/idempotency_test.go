@@ -0,0 +1,227 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+)
+
+// newIdempotencyEcho routes POST /orders through withIdempotency, counting
+// how often the handler runs.
+func newIdempotencyEcho(t *testing.T, handler echo.HandlerFunc) (*echo.Echo, *application) {
+	t.Helper()
+
+	app := newTestApplicationWithStore(t, memstore.New())
+	ec := echo.New()
+	ec.HTTPErrorHandler = app.HTTPErrorHandler
+	ec.Use(app.withIdempotency())
+	ec.POST("/orders", handler)
+	ec.GET("/orders", handler)
+	return ec, app
+}
+
+func idempotencyHeader(key string) http.Header {
+	return http.Header{headerIdempotencyKey: {key}}
+}
+
+func TestWithIdempotency(t *testing.T) {
+	t.Run("replays the stored response", func(t *testing.T) {
+		calls := 0
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			calls++
+			return ctx.JSON(http.StatusCreated, envelope{"data": map[string]int{"order": calls}})
+		})
+
+		first := testRequest(t, ec, http.MethodPost, "/orders", `{"robux":100}`, idempotencyHeader("k-1"))
+		second := testRequest(t, ec, http.MethodPost, "/orders", `{"robux":100}`, idempotencyHeader("k-1"))
+
+		require.Equal(t, http.StatusCreated, first.Code)
+		assert.Empty(t, first.Header().Get(headerIdempotentReplayed))
+		require.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, "true", second.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, echo.MIMEApplicationJSON, second.Header().Get(echo.HeaderContentType))
+		assert.JSONEq(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("rejects a reused key with a different payload", func(t *testing.T) {
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			return ctx.NoContent(http.StatusCreated)
+		})
+
+		testRequest(t, ec, http.MethodPost, "/orders", `{"robux":100}`, idempotencyHeader("k-1"))
+		header := idempotencyHeader("k-1")
+		header.Set(echo.HeaderAccept, apperror.MIMEProblemJSON)
+		rec := testRequest(t, ec, http.MethodPost, "/orders", `{"robux":200}`, header)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var problem apperror.Problem
+		decodeBody(t, rec, &problem)
+		assert.Equal(t, apperror.CodeIdempotencyKeyReused, problem.Code)
+	})
+
+	t.Run("replays empty responses", func(t *testing.T) {
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			return ctx.NoContent(http.StatusNoContent)
+		})
+
+		testRequest(t, ec, http.MethodPost, "/orders", "", idempotencyHeader("k-1"))
+		rec := testRequest(t, ec, http.MethodPost, "/orders", "", idempotencyHeader("k-1"))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get(headerIdempotentReplayed))
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("stores client errors", func(t *testing.T) {
+		calls := 0
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			calls++
+			return apperror.New(apperror.CodeValidationFailed).WithDetails(map[string]string{"robux": "too small"})
+		})
+
+		testRequest(t, ec, http.MethodPost, "/orders", `{"robux":1}`, idempotencyHeader("k-1"))
+		rec := testRequest(t, ec, http.MethodPost, "/orders", `{"robux":1}`, idempotencyHeader("k-1"))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get(headerIdempotentReplayed))
+		assert.Contains(t, rec.Body.String(), "too small")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not store server errors", func(t *testing.T) {
+		calls := 0
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			calls++
+			if calls == 1 {
+				return apperror.New(apperror.CodeInternal)
+			}
+			return ctx.NoContent(http.StatusCreated)
+		})
+
+		first := testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader("k-1"))
+		second := testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader("k-1"))
+
+		assert.Equal(t, http.StatusInternalServerError, first.Code)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Empty(t, second.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("releases the key when the handler panics", func(t *testing.T) {
+		calls := 0
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			return ctx.NoContent(http.StatusCreated)
+		})
+
+		assert.Panics(t, func() {
+			testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader("k-1"))
+		})
+		rec := testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader("k-1"))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("rejects duplicates while the first request runs", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			close(started)
+			<-release
+			return ctx.NoContent(http.StatusCreated)
+		})
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader("k-1"))
+		}()
+		<-started
+
+		rec := testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader("k-1"))
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get(echo.HeaderRetryAfter))
+	})
+
+	t.Run("scopes keys by caller and path", func(t *testing.T) {
+		calls := 0
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			calls++
+			return ctx.NoContent(http.StatusCreated)
+		})
+
+		alice := idempotencyHeader("k-1")
+		alice.Set(echo.HeaderAuthorization, "Bearer alice")
+		bob := idempotencyHeader("k-1")
+		bob.Set(echo.HeaderAuthorization, "Bearer bob")
+
+		testRequest(t, ec, http.MethodPost, "/orders", `{}`, alice)
+		rec := testRequest(t, ec, http.MethodPost, "/orders", `{}`, bob)
+
+		assert.Empty(t, rec.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("ignores safe methods and requests without a key", func(t *testing.T) {
+		calls := 0
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			calls++
+			return ctx.NoContent(http.StatusOK)
+		})
+
+		testRequest(t, ec, http.MethodGet, "/orders", "", idempotencyHeader("k-1"))
+		testRequest(t, ec, http.MethodGet, "/orders", "", idempotencyHeader("k-1"))
+		testRequest(t, ec, http.MethodPost, "/orders", `{}`, nil)
+		testRequest(t, ec, http.MethodPost, "/orders", `{}`, nil)
+
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("rejects keys longer than 255 characters", func(t *testing.T) {
+		ec, _ := newIdempotencyEcho(t, func(ctx echo.Context) error {
+			return ctx.NoContent(http.StatusCreated)
+		})
+
+		rec := testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader(strings.Repeat("k", 256)))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("uses the live ttl", func(t *testing.T) {
+		store := memstore.New()
+		app := newTestApplicationWithStore(t, store)
+		cfg := app.config
+		cfg.Idempotency.TTL = 0
+		app.applyConfig(cfg)
+		ec := echo.New()
+		ec.Use(app.withIdempotency())
+		calls := 0
+		ec.POST("/orders", func(ctx echo.Context) error {
+			calls++
+			return ctx.NoContent(http.StatusCreated)
+		})
+
+		testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader("k-1"))
+		testRequest(t, ec, http.MethodPost, "/orders", `{}`, idempotencyHeader("k-1"))
+
+		assert.Equal(t, 2, calls, "expired keys are claimed again")
+
+		deleted, err := store.Models().IdempotencyKey.DeleteExpired()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
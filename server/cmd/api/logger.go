@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+// newLogger builds the application logger from the log config: a console
+// sink on stdout, plus an optional rotating JSON file and in-memory ring
+// buffer. The ring buffer is nil when disabled.
+func newLogger(cfg Config) (*tlog.Logger, *tlog.RingBuffer, error) {
+	format := cfg.Log.Format
+	if format == "" {
+		format = "json"
+		if cfg.Env != "production" {
+			format = "pretty"
+		}
+	}
+
+	var sinks []tlog.Sink
+	if format == "pretty" {
+		sinks = append(sinks, tlog.ConsoleSink(os.Stdout))
+	} else {
+		sinks = append(sinks, tlog.JSONSink(os.Stdout))
+	}
+
+	if cfg.Log.File != "" {
+		file, err := tlog.OpenRotatingFile(tlog.RotateConfig{
+			Filename:   cfg.Log.File,
+			MaxSize:    int64(cfg.Log.FileMaxSizeMB) * 1024 * 1024,
+			MaxAge:     cfg.Log.FileMaxAge,
+			MaxBackups: cfg.Log.FileMaxBackups,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		sinks = append(sinks, tlog.JSONSink(file))
+	}
+
+	var ring *tlog.RingBuffer
+	if cfg.Log.RingSize > 0 {
+		ring = tlog.NewRingBuffer(cfg.Log.RingSize)
+		sinks = append(sinks, tlog.JSONSink(ring))
+	}
+
+	logger := tlog.New(sinks...)
+
+	lvl, err := tlog.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.SetLevel(lvl)
+
+	return logger, ring, nil
+}
+
+// requestLogger samples the per-request access log so that traffic spikes
+// do not flood the sinks.
+func (app *application) requestLogger() *tlog.Logger {
+	return app.logger.Sampled(time.Second, app.config.Log.SampleInitial, app.config.Log.SampleThereafter)
+}
@@ -5,20 +5,39 @@ import (
 	"database/sql"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/jackc/pgx/stdlib"
 	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+	"github.com/ucok-man/mayobox-server/internal/storage"
 	"github.com/ucok-man/mayobox-server/internal/tlog"
 )
 
 const VERSION = "1.0.0"
 
 type application struct {
-	config Config
-	logger *tlog.Logger
-	models data.Models
-	wg     sync.WaitGroup
+	config   Config
+	live     atomic.Pointer[Config]
+	reloadMu sync.Mutex
+	logger   *tlog.Logger
+	logRing  *tlog.RingBuffer
+	models   data.Models
+	storage  storage.Storage
+	payments payment.Provider
+	// deliverer is nil when paid orders are delivered by hand.
+	deliverer fulfillment.Deliverer
+	// queryCache is nil unless the Postgres reads are cached.
+	queryCache *data.QueryCache
+	salesFeed  *salesFeed
+	deliveries *deliveryPool
+	wg         sync.WaitGroup
+	bgOnce     sync.Once
+	bgCtx      context.Context
+	bgCancel   context.CancelFunc
 }
 
 func main() {
@@ -27,23 +46,73 @@ func main() {
 		log.Fatal(err)
 	}
 
-	logger := tlog.Must(tlog.NewProduction())
-	if cfg.Env != "production" {
-		logger = tlog.Must(tlog.NewDevelopment())
+	logger, logRing, err := newLogger(cfg)
+	if err != nil {
+		log.Fatal(err)
 	}
 	defer logger.Sync()
 
-	db, err := openDB(cfg)
+	if err := checkDocsAssets(); err != nil {
+		logger.Fatalj(tlog.JSON{"message": "failed loading documentation", "err": err})
+	}
+
+	var (
+		models     data.Models
+		queryCache *data.QueryCache
+	)
+	switch cfg.Database.Storage {
+	case "memory":
+		logger.Warnj(tlog.JSON{"message": "using in-memory storage, data is seeded and not persisted"})
+		models = memstore.NewSeeded().Models()
+	default:
+		db, err := openDB(cfg)
+		if err != nil {
+			logger.Fatalj(tlog.JSON{"message": "failed connecting to database", "err": err})
+		}
+		defer db.Close()
+		models = data.NewModels(db)
+
+		if cfg.QueryCache.Enabled {
+			queryCache = data.NewQueryCache(cfg.QueryCache.Size, cfg.QueryCache.TTL)
+			models = queryCache.Wrap(models)
+		}
+	}
+
+	media, err := newStorage(cfg)
 	if err != nil {
-		logger.Fatalj(tlog.JSON{"message": "failed connecting to database", "err": err})
+		logger.Fatalj(tlog.JSON{"message": "failed setting up media storage", "err": err})
+	}
+
+	payments, err := newPaymentProvider(cfg)
+	if err != nil {
+		logger.Fatalj(tlog.JSON{"message": "failed setting up payment provider", "err": err})
+	}
+	if cfg.Payment.Provider == "fake" {
+		logger.Warnj(tlog.JSON{"message": "using the fake payment provider, orders are paid through the simulator"})
+	}
+
+	deliverer := newDeliverer(cfg)
+	switch cfg.Fulfillment.Deliverer {
+	case "fake":
+		logger.Warnj(tlog.JSON{"message": "using the fake deliverer, paid orders are completed without sending Robux"})
+	case "manual":
+		logger.Infoj(tlog.JSON{"message": "paid orders are delivered by hand through the admin API"})
 	}
-	defer db.Close()
 
 	app := &application{
-		config: cfg,
-		logger: logger,
-		models: data.NewModels(db),
+		config:    cfg,
+		logger:    logger,
+		logRing:   logRing,
+		models:    models,
+		storage:   media,
+		payments:  payments,
+		deliverer: deliverer,
+
+		queryCache: queryCache,
+		salesFeed:  newSalesFeed(),
+		deliveries: newDeliveryPool(),
 	}
+	app.applyConfig(cfg)
 
 	err = app.serve()
 	if err != nil {
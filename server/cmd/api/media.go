@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/internal/imaging"
+	"github.com/ucok-man/mayobox-server/internal/storage"
+)
+
+// Uploaded images are scaled down to fit maxImageSide, and get a square
+// thumbnail per thumbnailSizes entry.
+const maxImageSide = 1024
+
+var thumbnailSizes = []int{64, 128, 256}
+
+// UploadedImage describes a stored image and its thumbnails.
+type UploadedImage struct {
+	URL        string              `json:"url" doc:"Stored in the record, never changes for the same upload"`
+	Width      int                 `json:"width"`
+	Height     int                 `json:"height"`
+	Thumbnails []UploadedThumbnail `json:"thumbnails"`
+}
+
+type UploadedThumbnail struct {
+	Size int    `json:"size" doc:"Width and height in pixels"`
+	URL  string `json:"url"`
+}
+
+func newStorage(cfg Config) (storage.Storage, error) {
+	media := cfg.Media
+	if media.Storage == "s3" {
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  media.S3Endpoint,
+			Region:    media.S3Region,
+			Bucket:    media.S3Bucket,
+			AccessKey: media.S3AccessKey,
+			SecretKey: media.S3SecretKey,
+			PathStyle: media.S3PathStyle,
+			PublicURL: media.BaseURL,
+		}, nil)
+	}
+
+	baseURL := media.BaseURL
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("http://localhost:%d/media", cfg.Port)
+	}
+	return storage.NewLocal(media.Dir, baseURL)
+}
+
+// storeImage re-encodes an uploaded image and stores it with its thumbnails
+// below prefix. Keys are derived from the upload's content, so the URLs are
+// stable and can be cached forever.
+func (app *application) storeImage(ctx context.Context, prefix string, data []byte) (*UploadedImage, error) {
+	result, err := imaging.Process(data, maxImageSide, thumbnailSizes...)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	name := prefix + "/" + hex.EncodeToString(sum[:16])
+
+	key := name + "." + result.Original.Ext
+	if err := app.storage.Put(ctx, key, result.Original.Data, result.Original.ContentType); err != nil {
+		return nil, err
+	}
+	image := &UploadedImage{
+		URL:    app.storage.URL(key),
+		Width:  result.Original.Width,
+		Height: result.Original.Height,
+	}
+
+	for _, size := range thumbnailSizes {
+		thumb := result.Thumbnails[size]
+		key := name + "_" + strconv.Itoa(size) + "." + thumb.Ext
+		if err := app.storage.Put(ctx, key, thumb.Data, thumb.ContentType); err != nil {
+			return nil, err
+		}
+		image.Thumbnails = append(image.Thumbnails, UploadedThumbnail{Size: size, URL: app.storage.URL(key)})
+	}
+	return image, nil
+}
+
+// serveMedia serves the files of local media storage. Their names change
+// with their content, so they are cached for a year.
+func (app *application) serveMedia(fsys fs.FS) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		name := ctx.Param("*")
+		info, err := fs.Stat(fsys, name)
+		if err != nil || !info.Mode().IsRegular() {
+			return app.ErrNotFound()
+		}
+
+		ctx.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=31536000, immutable")
+		http.ServeFileFS(ctx.Response(), ctx.Request(), fsys, name)
+		return nil
+	}
+}
@@ -1,10 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/serializer"
 	"github.com/ucok-man/mayobox-server/internal/tlog"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 func (app *application) withRecover() echo.MiddlewareFunc {
@@ -21,6 +36,8 @@ func (app *application) withRecover() echo.MiddlewareFunc {
 }
 
 func (app *application) withRequestLogger() echo.MiddlewareFunc {
+	logger := app.requestLogger()
+
 	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogRemoteIP:     true,
 		LogStatus:       true,
@@ -30,6 +47,11 @@ func (app *application) withRequestLogger() echo.MiddlewareFunc {
 		LogResponseSize: true,
 		LogError:        true,
 		LogValuesFunc: func(ctx echo.Context, v middleware.RequestLoggerValues) error {
+			// echo only knows the status of its own HTTPError
+			if appErr := apperror.As(v.Error); appErr != nil {
+				v.Status = appErr.Status()
+			}
+
 			var message string
 			switch {
 			case v.Status >= 300 && v.Status <= 399:
@@ -53,16 +75,249 @@ func (app *application) withRequestLogger() echo.MiddlewareFunc {
 				"response_size": v.ResponseSize,
 			}
 
-			ctx.Logger().Infoj(data)
+			logger.Infoj(data)
 
 			return nil
 		},
 	})
 }
 
+// withCORS checks origins against the live config so that trusted origins
+// can be changed by a config reload.
 func (app *application) withCORS() echo.MiddlewareFunc {
 	return middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     app.config.Cors.TrustedOrigins,
+		AllowOriginFunc: func(origin string) (bool, error) {
+			origins := app.currentConfig().Cors.TrustedOrigins
+			return slices.Contains(origins, "*") || slices.Contains(origins, origin), nil
+		},
 		AllowCredentials: true,
 	})
 }
+
+// withRateLimit applies a token bucket per client IP, as resolved by
+// clientIPExtractor. Limits are read from the live config on every
+// request, so existing buckets pick up reloads. Payment webhooks are never
+// limited, the provider retries them and a lost one leaves an order
+// unpaid.
+func (app *application) withRateLimit() echo.MiddlewareFunc {
+	type client struct {
+		limiter  *rate.Limiter
+		lastSeen time.Time
+	}
+
+	var (
+		mu      sync.Mutex
+		clients = make(map[string]*client)
+	)
+
+	// Remove clients that have not been seen recently.
+	app.every("rate limiter cleanup", time.Minute, func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for ip, client := range clients {
+			if time.Since(client.lastSeen) > 3*time.Minute {
+				delete(clients, ip)
+			}
+		}
+		return nil
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			cfg := app.currentConfig().Limiter
+			if !cfg.Enabled || strings.HasPrefix(ctx.Request().URL.Path, "/v1/payments/webhooks/") {
+				return next(ctx)
+			}
+
+			ip := ctx.RealIP()
+			limit := rate.Limit(cfg.Rps)
+
+			mu.Lock()
+			c, found := clients[ip]
+			if !found {
+				c = &client{limiter: rate.NewLimiter(limit, cfg.Burst)}
+				clients[ip] = c
+			}
+			if c.limiter.Limit() != limit {
+				c.limiter.SetLimit(limit)
+			}
+			if c.limiter.Burst() != cfg.Burst {
+				c.limiter.SetBurst(cfg.Burst)
+			}
+			c.lastSeen = time.Now()
+
+			if !c.limiter.Allow() {
+				mu.Unlock()
+				return app.ErrRateLimitExceeded()
+			}
+			mu.Unlock()
+
+			return next(ctx)
+		}
+	}
+}
+
+// clientIPExtractor resolves the client IP of ctx.RealIP. Without trusted
+// proxies it is the peer address, so a client cannot pick its own IP with
+// X-Forwarded-For. Otherwise the header is walked back from the right,
+// skipping only the trusted proxies, already checked by Config.Validate.
+func (app *application) clientIPExtractor() echo.IPExtractor {
+	if len(app.config.Proxy.TrustedCIDRs) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range app.config.Proxy.TrustedCIDRs {
+		if _, ipRange, err := net.ParseCIDR(cidr); err == nil {
+			options = append(options, echo.TrustIPRange(ipRange))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// withAdminAuth guards the admin API with a static bearer token. The admin
+// API is disabled entirely when no token is configured.
+func (app *application) withAdminAuth() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			ctx.Response().Header().Add(echo.HeaderVary, echo.HeaderAuthorization)
+
+			token := app.config.Admin.Token
+			if token == "" {
+				return app.ErrForbidden(apperror.CodeAdminAPIDisabled)
+			}
+
+			scheme, value, ok := strings.Cut(ctx.Request().Header.Get(echo.HeaderAuthorization), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") ||
+				subtle.ConstantTimeCompare([]byte(value), []byte(token)) != 1 {
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return app.ErrInvalidAuthenticationToken()
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// bodyLimits holds the body limit tier of the routes that do not take the
+// default one, by method and path.
+type bodyLimits map[string]func(cfg *Config) int
+
+// set gives route the body limit tier limitKB.
+func (l bodyLimits) set(route *echo.Route, limitKB func(cfg *Config) int) {
+	l[route.Method+" "+route.Path] = limitKB
+}
+
+// withBodyLimit sets the maximum request body size, in kilobytes, picked
+// from the live config: the tier of the matched route in limits, or the
+// default one. Requests announcing a larger Content-Length are rejected
+// before the body is read; the rest fail while the body is decoded.
+func (app *application) withBodyLimit(limits bodyLimits) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			limitKB, ok := limits[ctx.Request().Method+" "+ctx.Path()]
+			if !ok {
+				limitKB = defaultBody
+			}
+
+			limit := int64(limitKB(app.currentConfig())) << 10
+			if ctx.Request().ContentLength > limit {
+				return apperror.New(apperror.CodeRequestTooLarge).
+					WithMessage("body must not be larger than %d bytes", limit)
+			}
+			serializer.SetBodyLimit(ctx, limit)
+			return next(ctx)
+		}
+	}
+}
+
+// Body limit tiers for withBodyLimit.
+func defaultBody(cfg *Config) int { return cfg.BodyLimit.DefaultKB }
+func smallBody(cfg *Config) int   { return cfg.BodyLimit.SmallKB }
+func largeBody(cfg *Config) int   { return cfg.BodyLimit.LargeKB }
+
+// withConditionalGET adds a strong ETag, computed from the body, to 200
+// responses of GET requests and answers a matching If-None-Match with 304
+// Not Modified. cacheControl picks the Cache-Control header from the live
+// config, an empty value sends none.
+func (app *application) withConditionalGET(cacheControl func(cfg *Config) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if ctx.Request().Method != http.MethodGet {
+				return next(ctx)
+			}
+
+			res := ctx.Response()
+			buffer := &bufferWriter{ResponseWriter: res.Writer, status: http.StatusOK}
+			res.Writer = buffer
+			err := next(ctx)
+			if err != nil {
+				ctx.Error(err)
+			}
+			res.Writer = buffer.ResponseWriter
+
+			if buffer.status != http.StatusOK {
+				return buffer.flush()
+			}
+
+			sum := sha256.Sum256(buffer.buf.Bytes())
+			etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+			res.Header().Set(headerETag, etag)
+			if value := cacheControl(app.currentConfig()); value != "" {
+				res.Header().Set(echo.HeaderCacheControl, value)
+			}
+
+			if etagMatches(ctx.Request().Header.Get(headerIfNoneMatch), etag) {
+				res.Header().Del(echo.HeaderContentType)
+				res.Header().Del(echo.HeaderContentLength)
+				buffer.status = http.StatusNotModified
+				buffer.buf.Reset()
+				res.Status = http.StatusNotModified
+			}
+			return buffer.flush()
+		}
+	}
+}
+
+// etagMatches implements the weak comparison If-None-Match requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferWriter holds back the status and body until flush, so headers can
+// still be changed after the handler ran.
+type bufferWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *bufferWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *bufferWriter) flush() error {
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	return err
+}
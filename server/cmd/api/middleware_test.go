@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+)
+
+func TestWithConditionalGET(t *testing.T) {
+	t.Run("sets etag and cache control", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Regexp(t, `^"[A-Za-z0-9_-]{22}"$`, rec.Header().Get(headerETag))
+		assert.Equal(t, "public, max-age=300", rec.Header().Get(echo.HeaderCacheControl))
+		assert.NotEmpty(t, rec.Body.String())
+	})
+
+	t.Run("answers a matching If-None-Match with 304", func(t *testing.T) {
+		app := newTestApplication(t)
+		handler := app.routes()
+		first := testRequest(t, handler, http.MethodGet, "/v1/testimonies?page_size=2", "", nil)
+		etag := first.Header().Get(headerETag)
+
+		for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+			rec := testRequest(t, handler, http.MethodGet, "/v1/testimonies?page_size=2", "", http.Header{headerIfNoneMatch: {ifNoneMatch}})
+
+			assert.Equal(t, http.StatusNotModified, rec.Code, ifNoneMatch)
+			assert.Empty(t, rec.Body.String())
+			assert.Equal(t, etag, rec.Header().Get(headerETag))
+			assert.Equal(t, "public, max-age=60", rec.Header().Get(echo.HeaderCacheControl))
+		}
+	})
+
+	t.Run("changes the etag with the content", func(t *testing.T) {
+		store := memstore.New()
+		app := newTestApplicationWithStore(t, store)
+		handler := app.routes()
+		before := testRequest(t, handler, http.MethodGet, "/v1/faqs", "", nil)
+
+		store.AddFAQ(data.FAQ{ID: "770e8400-e29b-41d4-a716-446655440009", Question: "New?", DisplayOrder: 9})
+		rec := testRequest(t, handler, http.MethodGet, "/v1/faqs", "", http.Header{headerIfNoneMatch: {before.Header().Get(headerETag)}})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEqual(t, before.Header().Get(headerETag), rec.Header().Get(headerETag))
+	})
+
+	t.Run("passes errors through without caching headers", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/testimonies?page=0", "", http.Header{headerIfNoneMatch: {"*"}})
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Empty(t, rec.Header().Get(headerETag))
+		assert.Empty(t, rec.Header().Get(echo.HeaderCacheControl))
+		assert.Contains(t, rec.Body.String(), "pagination.page")
+	})
+
+	t.Run("uses the live cache control", func(t *testing.T) {
+		app := newTestApplication(t)
+		cfg := app.config
+		cfg.Cache.FAQs = ""
+		app.applyConfig(cfg)
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs", "", nil)
+
+		assert.Empty(t, rec.Header().Get(echo.HeaderCacheControl))
+		assert.NotEmpty(t, rec.Header().Get(headerETag))
+	})
+}
+
+func TestWithBodyLimit(t *testing.T) {
+	largeLevel := `{"level":"` + strings.Repeat("d", 20<<10) + `"}`
+
+	t.Run("rejects a large Content-Length up front", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rec := testRequest(t, app.routes(), http.MethodPut, "/v1/admin/log-level", largeLevel, adminHeader())
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Equal(t, "Request Entity Too Large", body.Error.Code)
+		assert.Equal(t, "body must not be larger than 16384 bytes", body.Error.Message)
+	})
+
+	t.Run("limits bodies of unknown length while decoding", func(t *testing.T) {
+		app := newTestApplication(t)
+		req := httptest.NewRequest(http.MethodPut, "/v1/admin/log-level", io.MultiReader(strings.NewReader(largeLevel)))
+		req.Header = adminHeader()
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.ContentLength = -1
+		rec := httptest.NewRecorder()
+
+		app.routes().ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Equal(t, "body must not be larger than 16384 bytes", body.Error.Message)
+	})
+
+	t.Run("reads limits from the live config", func(t *testing.T) {
+		app := newTestApplication(t)
+		cfg := app.config
+		cfg.BodyLimit.SmallKB = 64
+		app.applyConfig(cfg)
+
+		rec := testRequest(t, app.routes(), http.MethodPut, "/v1/admin/log-level", largeLevel, adminHeader())
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+}
+
+func TestWithRateLimit(t *testing.T) {
+	newLimitedApp := func(t *testing.T, trustedProxies ...string) *application {
+		app := newTestApplication(t)
+		app.config.Proxy.TrustedCIDRs = trustedProxies
+		app.config.Limiter.Enabled = true
+		app.config.Limiter.Burst = 2
+		app.config.Limiter.Rps = 0.001
+		app.applyConfig(app.config)
+		return app
+	}
+	fromClient := func(handler http.Handler, target, xff string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = "203.0.113.7:4321"
+		if xff != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, xff)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("limits a client past its burst", func(t *testing.T) {
+		handler := newLimitedApp(t).routes()
+
+		assert.Equal(t, http.StatusOK, fromClient(handler, "/v1/faqs", ""))
+		assert.Equal(t, http.StatusOK, fromClient(handler, "/v1/faqs", ""))
+		assert.Equal(t, http.StatusTooManyRequests, fromClient(handler, "/v1/faqs", ""))
+	})
+
+	t.Run("ignores X-Forwarded-For without trusted proxies", func(t *testing.T) {
+		handler := newLimitedApp(t).routes()
+
+		for i := range 2 {
+			assert.Equal(t, http.StatusOK, fromClient(handler, "/v1/faqs", fmt.Sprintf("198.51.100.%d", i)))
+		}
+		assert.Equal(t, http.StatusTooManyRequests, fromClient(handler, "/v1/faqs", "198.51.100.9"))
+	})
+
+	t.Run("limits forwarded clients behind trusted proxies", func(t *testing.T) {
+		handler := newLimitedApp(t, "203.0.113.0/24").routes()
+
+		for i := range 3 {
+			assert.Equal(t, http.StatusOK, fromClient(handler, "/v1/faqs", fmt.Sprintf("198.51.100.%d", i)))
+		}
+		assert.Equal(t, http.StatusOK, fromClient(handler, "/v1/faqs", "198.51.100.1"))
+		assert.Equal(t, http.StatusTooManyRequests, fromClient(handler, "/v1/faqs", "198.51.100.1"))
+	})
+
+	t.Run("never limits payment webhooks", func(t *testing.T) {
+		handler := newLimitedApp(t).routes()
+
+		for range 4 {
+			req := httptest.NewRequest(http.MethodPost, "/v1/payments/webhooks/fake", strings.NewReader(`{}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.NotEqual(t, http.StatusTooManyRequests, rec.Code)
+		}
+	})
+}
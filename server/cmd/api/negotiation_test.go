@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/serializer"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	t.Run("lists testimonies as csv", func(t *testing.T) {
+		app := newTestApplication(t)
+		header := http.Header{echo.HeaderAccept: {serializer.MIMETextCSV}}
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/testimonies?page_size=3&columns=id,user.username", "", header)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+		assert.Equal(t, []string{"id", "user.username"}, records[0])
+		assert.NotEmpty(t, records[1][1])
+	})
+
+	t.Run("rejects unknown csv columns", func(t *testing.T) {
+		app := newTestApplication(t)
+		header := http.Header{echo.HeaderAccept: {serializer.MIMETextCSV}}
+
+		rec := testRequest(t, app.routes(), http.MethodGet, "/v1/faqs?columns=nope", "", header)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Equal(t, "unknown column nope", body.Error.Message)
+	})
+
+	t.Run("accepts and returns messagepack", func(t *testing.T) {
+		app := newTestApplication(t)
+		body, err := serializer.MarshalMessagePack(map[string]string{"level": "warn"})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/admin/log-level", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, serializer.MIMEMessagePack)
+		req.Header.Set(echo.HeaderAccept, serializer.MIMEMessagePack)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+testAdminToken)
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, serializer.MIMEMessagePack, rec.Header().Get(echo.HeaderContentType))
+		want, err := serializer.MarshalMessagePack(envelope{"data": map[string]any{"level": "warn"}})
+		require.NoError(t, err)
+		assert.Equal(t, want, rec.Body.Bytes())
+	})
+
+	t.Run("reports malformed messagepack like json", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/admin/log-level", bytes.NewReader([]byte{0x81, 0xa5}))
+		req.Header.Set(echo.HeaderContentType, serializer.MIMEMessagePack)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+testAdminToken)
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		var body errorBody
+		decodeBody(t, rec, &body)
+		assert.Equal(t, "body contains badly-formed MessagePack (at byte 1)", body.Error.Message)
+	})
+}
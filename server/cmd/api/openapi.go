@@ -0,0 +1,940 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/cmd/api/dto"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/openapi"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/serializer"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+	"github.com/ucok-man/mayobox-server/internal/validator"
+)
+
+//go:generate go test -run TestOpenAPIDocument -update .
+
+// openAPIDocument describes every API route. docs/openapi.yaml is generated
+// from it, run `make docs` after changing a route, DTO or response type.
+func openAPIDocument() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       "Mayobox API",
+		Description: "Public and admin API of the Mayobox Robux store.",
+		Version:     VERSION,
+	})
+	doc.Servers = []openapi.Server{{URL: "http://localhost:4000", Description: "local development"}}
+	doc.Tags = []openapi.Tag{
+		{Name: "system", Description: "Health and service information"},
+		{Name: "testimonies", Description: "Customer testimonies shown on the landing page"},
+		{Name: "faqs", Description: "Frequently asked questions"},
+		{Name: "products", Description: "Robux packages and gamepasses on sale"},
+		{Name: "orders", Description: "Checkout and completed orders"},
+		{Name: "pricing", Description: "Robux prices in rupiah"},
+		{Name: "payments", Description: "Payment provider webhooks"},
+		{Name: "admin", Description: "Operational endpoints, require the admin token"},
+	}
+	doc.Components.SecuritySchemes["adminToken"] = &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "The MAYOBOX_ADMIN_TOKEN value",
+	}
+
+	defineErrorResponses(doc)
+	doc.Define(validator.ValidationErrorMap{}, &openapi.Schema{
+		Type:                 "object",
+		Description:          "Validation message per field",
+		AdditionalProperties: &openapi.Schema{Type: "string"},
+	})
+	doc.Define(pricing.Money(0), openapi.Object(map[string]*openapi.Schema{
+		"minor":   {Type: "integer", Format: "int64", Description: "Amount in sen, 100 to the rupiah"},
+		"display": {Type: "string", Description: "Formatted amount, e.g. Rp144.261"},
+	}))
+
+	doc.Add(http.MethodGet, "/", &openapi.Operation{
+		OperationID: "healthcheck",
+		Summary:     "Service health and version",
+		Tags:        []string{"system"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Service is available", Content: openapi.JSON(openapi.Object(map[string]*openapi.Schema{
+				"status": {Type: "string", Enum: []any{"available"}},
+				"system_info": openapi.Object(map[string]*openapi.Schema{
+					"environment": {Type: "string", Enum: []any{"development", "staging", "production"}},
+					"version":     {Type: "string"},
+				}),
+			}))},
+			"429": openapi.ResponseRef("TooManyRequests"),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/v1/testimonies", csvList(conditionalGET(&openapi.Operation{
+		OperationID: "listTestimonies",
+		Summary:     "List testimonies, newest first",
+		Tags:        []string{"testimonies"},
+		Parameters:  doc.QueryParameters(dto.TestimoniGetAllDTO{}),
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "A page of testimonies", Content: openapi.JSON(
+				listEnvelope(doc, []*data.TestimoniWithUser{}),
+			)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})))
+
+	doc.Add(http.MethodGet, "/v1/faqs", csvList(conditionalGET(&openapi.Operation{
+		OperationID: "listFAQs",
+		Summary:     "List FAQs with their answers",
+		Tags:        []string{"faqs"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "All FAQs", Content: openapi.JSON(
+				listEnvelope(doc, []*data.FAQWithAnswers{}),
+			)},
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})))
+
+	doc.Add(http.MethodGet, "/v1/products/featured", csvList(conditionalGET(&openapi.Operation{
+		OperationID: "listFeaturedProducts",
+		Summary:     "Today's Products of the Day in Asia/Jakarta, by position",
+		Tags:        []string{"products"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The picks of today", Content: openapi.JSON(
+				dataEnvelope(doc.Schema([]FeaturedProduct{})),
+			)},
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})))
+
+	doc.Add(http.MethodGet, "/v1/products/best-sellers", csvList(conditionalGET(&openapi.Operation{
+		OperationID: "listBestSellers",
+		Summary:     "Products ranked by units delivered",
+		Description: "Windows count Asia/Jakarta days, today included. Delivered orders are counted within a few minutes.",
+		Tags:        []string{"products"},
+		Parameters:  doc.QueryParameters(dto.ProductBestSellersDTO{}),
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The best sellers, best first", Content: openapi.JSON(
+				dataEnvelope(doc.Schema([]BestSellerProduct{})),
+			)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})))
+
+	doc.Add(http.MethodGet, "/v1/flash-sales/active", &openapi.Operation{
+		OperationID: "listActiveFlashSales",
+		Summary:     "The live flash sales with the server time",
+		Description: "Count down to endsAt from serverTime rather than the client's clock. Items sell at priceIdr while units remain and at regularIdr after; " +
+			"product prices elsewhere and checkout follow the same rule. Times are in Asia/Jakarta.",
+		Tags: []string{"products"},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The live flash sales", Content: openapi.JSON(dataEnvelope(doc.Schema(ActiveFlashSales{})))},
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/v1/orders/recent", csvList(conditionalGET(&openapi.Operation{
+		OperationID: "listRecentOrders",
+		Summary:     "Latest completed orders, newest first",
+		Description: "Buyer names are masked.",
+		Tags:        []string{"orders"},
+		Parameters:  doc.QueryParameters(dto.OrderRecentDTO{}),
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The latest completed orders", Content: openapi.JSON(
+				dataEnvelope(doc.Schema([]RecentOrder{})),
+			)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})))
+
+	doc.Add(http.MethodGet, "/v1/orders/recent/stream", &openapi.Operation{
+		OperationID: "streamRecentOrders",
+		Summary:     "Server-Sent Events of orders as they complete",
+		Description: "Each `order` event has the order ID as its `id` and a RecentOrder as JSON `data`. " +
+			"Comment lines are sent as heartbeats. Reconnecting with `Last-Event-ID` first sends up to 100 orders completed since that one. " +
+			"Streams may be closed at any time, e.g. on shutdown or when the client falls behind, and should be reopened after the `retry` delay.",
+		Tags:       []string{"orders"},
+		Parameters: doc.HeaderParameters(dto.OrderRecentStreamDTO{}),
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The event stream", Content: map[string]openapi.MediaType{
+				"text/event-stream": {Schema: &openapi.Schema{Type: "string"}},
+			}},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+			"503": openapi.ResponseRef("ServiceUnavailable"),
+		},
+	})
+
+	order := dataEnvelope(doc.Schema(OrderDetail{}))
+
+	doc.Add(http.MethodPost, "/v1/orders", &openapi.Operation{
+		OperationID: "createOrder",
+		Summary:     "Place an order and create the charge that pays it",
+		Description: "The order is priced with the rate table in effect, or at a live flash sale while it has the units left, and its Robux are reserved until the payment expires. " +
+			"When the flash sale sells out or ends before the order is stored, 409 `flash_sale_sold_out` is returned and no order is placed. payment.instructions holds the QR string, virtual account or checkout URL of the method; " +
+			"poll the order until its status leaves pending_payment. voucherCode takes the voucher's discount off the total, and 422 `invalid_voucher` is returned when it does not apply, including when concurrent orders used it up. " +
+			"When not enough Robux are in stock 409 `out_of_stock` is returned. " +
+			"When the provider cannot create the charge the order fails and 503 `payment_unavailable` is returned.",
+		Tags:        []string{"orders"},
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.OrderCreateDTO{}))},
+		Responses: map[string]*openapi.Response{
+			"201": {Description: "The order, pending payment", Content: openapi.JSON(order)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"409": openapi.ResponseRef("Conflict"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+			"503": openapi.ResponseRef("ServiceUnavailable"),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/v1/orders/{id}", &openapi.Operation{
+		OperationID: "getOrder",
+		Summary:     "An order with its product and payment",
+		Tags:        []string{"orders"},
+		Parameters:  doc.PathParameters(dto.OrderGetDTO{}),
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The order", Content: openapi.JSON(order)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})
+
+	webhookResult := dataEnvelope(doc.Schema(PaymentWebhookResult{}))
+
+	doc.Add(http.MethodPost, "/v1/payments/webhooks/{provider}", &openapi.Operation{
+		OperationID: "receivePaymentWebhook",
+		Summary:     "Receive a charge status change from the payment provider",
+		Description: "Called by the configured provider only, other providers get 404. The body must be signed: " +
+			"`X-Callback-Signature` is the hex HMAC-SHA256 of `<X-Callback-Timestamp>.<body>` with the webhook secret, and the Unix timestamp must be within 5 minutes. " +
+			"Events are applied once by ID, redeliveries return 200 with applied false. A charge without an order returns 404 so the provider retries.",
+		Tags:        []string{"payments"},
+		Parameters:  doc.PathParameters(dto.PaymentWebhookDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(&openapi.Schema{Type: "object", Description: "Charge event in the provider's format"})},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The event was received", Content: openapi.JSON(webhookResult)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/v1/payments/simulator/{chargeId}", &openapi.Operation{
+		OperationID: "simulatePayment",
+		Summary:     "Pay, expire or fail a charge of the fake provider",
+		Description: "Only routed with `PAYMENT_PROVIDER=fake`. Delivers the signed webhook the gateway would send, through the same processing as the webhook endpoint.",
+		Tags:        []string{"payments"},
+		Parameters:  doc.PathParameters(dto.PaymentSimulateDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.PaymentSimulateDTO{}))},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The webhook was delivered", Content: openapi.JSON(webhookResult)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/v1/vouchers/validate", &openapi.Operation{
+		OperationID: "validateVoucher",
+		Summary:     "Price an order with a voucher",
+		Description: "For the checkout page; nothing is redeemed. The voucher is checked again when the order is placed. " +
+			"A voucher that does not apply returns 422 `invalid_voucher` with details.reason one of not_found, inactive, not_started, expired, min_spend, product_excluded, usage_limit or user_limit, and details.minSpend for min_spend.",
+		Tags:        []string{"orders"},
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.VoucherValidateDTO{}))},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The discounted price", Content: openapi.JSON(dataEnvelope(doc.Schema(VoucherQuote{})))},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/v1/pricing/quote", &openapi.Operation{
+		OperationID: "quotePrice",
+		Summary:     "Price Robux with the rate table in effect",
+		Description: "gamepassPrice is what the customer's gamepass must cost to pay out robux after Roblox's 30% cut.",
+		Tags:        []string{"pricing"},
+		Parameters:  doc.QueryParameters(dto.PricingQuoteDTO{}),
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The quote", Content: openapi.JSON(dataEnvelope(doc.Schema(pricing.Quote{})))},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"429": openapi.ResponseRef("TooManyRequests"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		},
+	})
+
+	addAdminOperations(doc)
+	addIdempotency(doc)
+	addBodyLimit(doc)
+	addMessagePack(doc)
+
+	return doc
+}
+
+func addAdminOperations(doc *openapi.Document) {
+	security := []map[string][]string{{"adminToken": {}}}
+	adminErrors := map[string]*openapi.Response{
+		"401": openapi.ResponseRef("Unauthorized"),
+		"403": openapi.ResponseRef("Forbidden"),
+		"429": openapi.ResponseRef("TooManyRequests"),
+	}
+	withErrors := func(responses map[string]*openapi.Response) map[string]*openapi.Response {
+		for code, r := range adminErrors {
+			responses[code] = r
+		}
+		return responses
+	}
+
+	logLevel := dataEnvelope(openapi.Object(map[string]*openapi.Schema{
+		"level": {Type: "string", Enum: []any{"debug", "info", "warn", "error", "off"}},
+	}))
+
+	doc.Add(http.MethodGet, "/v1/admin/log-level", &openapi.Operation{
+		OperationID: "getLogLevel",
+		Summary:     "Current log level",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The active log level", Content: openapi.JSON(logLevel)},
+		}),
+	})
+
+	doc.Add(http.MethodPut, "/v1/admin/log-level", &openapi.Operation{
+		OperationID: "updateLogLevel",
+		Summary:     "Change the log level until the next restart or config reload",
+		Tags:        []string{"admin"},
+		Security:    security,
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminLogLevelUpdateDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The new log level", Content: openapi.JSON(logLevel)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+		}),
+	})
+
+	doc.Add(http.MethodGet, "/v1/admin/logs", &openapi.Operation{
+		OperationID: "listRecentLogs",
+		Summary:     "Recent log entries from the in-memory buffer, oldest first",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.QueryParameters(dto.AdminLogListDTO{}),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "Raw JSON log entries", Content: openapi.JSON(dataEnvelope(
+				openapi.ArrayOf(&openapi.Schema{Type: "object", Description: "zap JSON log entry"}),
+			))},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+		}),
+	})
+
+	doc.Add(http.MethodGet, "/v1/admin/exports/testimonies", &openapi.Operation{
+		OperationID: "exportTestimonies",
+		Summary:     "Download every testimony as JSON or CSV, streamed newest first",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.QueryParameters(dto.AdminExportDTO{}),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {
+				Description: "All testimonies as an attachment",
+				Headers: map[string]*openapi.Header{
+					echo.HeaderContentDisposition: {Description: "attachment; filename=testimonies-<date>.<format>", Required: true, Schema: &openapi.Schema{Type: "string"}},
+				},
+				Content: map[string]openapi.MediaType{
+					echo.MIMEApplicationJSON: {Schema: dataEnvelope(openapi.ArrayOf(doc.Schema(data.TestimoniWithUser{})))},
+					"text/csv": {Schema: &openapi.Schema{
+						Type:        "string",
+						Description: "Header row: " + strings.Join(testimoniCSVHeader, ","),
+					}},
+				},
+			},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	imageUpload := func(field, description string) *openapi.RequestBody {
+		return &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+			echo.MIMEMultipartForm: {Schema: &openapi.Schema{
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{field: {Type: "string", Format: "binary", Description: description}},
+				Required:   []string{field},
+			}},
+		}}
+	}
+	uploaded := dataEnvelope(doc.Schema(UploadedImage{}))
+
+	doc.Add(http.MethodPost, "/v1/admin/users/:id/avatar", &openapi.Operation{
+		OperationID: "uploadUserAvatar",
+		Summary:     "Upload a user's avatar and point its image URL at it",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminUserAvatarDTO{}),
+		RequestBody: imageUpload("avatar", "PNG, JPEG or GIF image, re-encoded without metadata"),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The stored avatar and its thumbnails", Content: openapi.JSON(uploaded)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"415": openapi.ResponseRef("UnsupportedMediaType"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPost, "/v1/admin/testimonies/:id/icon", &openapi.Operation{
+		OperationID: "uploadTestimoniIcon",
+		Summary:     "Upload a testimony's icon and point its icon URL at it",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminTestimoniIconDTO{}),
+		RequestBody: imageUpload("icon", "PNG, JPEG or GIF image, re-encoded without metadata"),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The stored icon and its thumbnails", Content: openapi.JSON(uploaded)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"415": openapi.ResponseRef("UnsupportedMediaType"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	calendar := dataEnvelope(doc.Schema([]*data.FeaturedCalendarEntry{}))
+
+	doc.Add(http.MethodGet, "/v1/admin/featured-calendar", &openapi.Operation{
+		OperationID: "listFeaturedCalendar",
+		Summary:     "Editor picked Products of the Day, by date and position",
+		Description: "Defaults to today up to 30 days ahead, dates in Asia/Jakarta.",
+		Tags:        []string{"admin", "products"},
+		Security:    security,
+		Parameters:  doc.QueryParameters(dto.AdminFeaturedCalendarListDTO{}),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The calendar entries", Content: openapi.JSON(calendar)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPut, "/v1/admin/featured-calendar/{date}", &openapi.Operation{
+		OperationID: "updateFeaturedCalendar",
+		Summary:     "Replace the editor picks of a day",
+		Description: "Random picks fill the remaining slots. An already scheduled day is picked again on its next request.",
+		Tags:        []string{"admin", "products"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminFeaturedCalendarUpdateDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminFeaturedCalendarUpdateDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The calendar entries of the day", Content: openapi.JSON(calendar)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	adminOrder := dataEnvelope(doc.Schema(AdminOrderDetail{}))
+
+	doc.Add(http.MethodGet, "/v1/admin/orders", &openapi.Operation{
+		OperationID: "listAdminOrders",
+		Summary:     "Orders with their delivery attempts",
+		Description: "Least recently updated first, so the orders stuck the longest lead the list.",
+		Tags:        []string{"admin", "orders"},
+		Security:    security,
+		Parameters:  doc.QueryParameters(dto.AdminOrderListDTO{}),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The orders", Content: openapi.JSON(dataEnvelope(doc.Schema([]AdminOrder{})))},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodGet, "/v1/admin/orders/{id}", &openapi.Operation{
+		OperationID: "getAdminOrder",
+		Summary:     "An order with its payment, status history and the statuses it may move to",
+		Tags:        []string{"admin", "orders"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminOrderGetDTO{}),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The order", Content: openapi.JSON(adminOrder)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPost, "/v1/admin/orders/{id}/retry", &openapi.Operation{
+		OperationID: "retryAdminOrder",
+		Summary:     "Deliver a paid, stuck or failed order again",
+		Description: "Moves the order to paid with a fresh set of delivery attempts, the first one at once. " +
+			"Orders whose payment never settled cannot be retried (409 `invalid_order_transition`).",
+		Tags:        []string{"admin", "orders"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminOrderRetryDTO{}),
+		RequestBody: &openapi.RequestBody{Content: openapi.JSON(doc.Schema(dto.AdminOrderRetryDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The order", Content: openapi.JSON(adminOrder)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"409": openapi.ResponseRef("Conflict"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPost, "/v1/admin/orders/{id}/resolve", &openapi.Operation{
+		OperationID: "resolveAdminOrder",
+		Summary:     "Settle an order by hand",
+		Description: "delivered records Robux sent outside the API, failed gives up on the order, refunded returns the payment at the provider first. " +
+			"A status the order cannot move to gets 409 `invalid_order_transition`; a refund the provider refuses gets 503 `refund_unavailable`.",
+		Tags:        []string{"admin", "orders"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminOrderResolveDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminOrderResolveDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The order", Content: openapi.JSON(adminOrder)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"409": openapi.ResponseRef("Conflict"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+			"503": openapi.ResponseRef("ServiceUnavailable"),
+		}),
+	})
+
+	doc.Add(http.MethodGet, "/v1/admin/dead-letters", &openapi.Operation{
+		OperationID: "listDeadLetters",
+		Summary:     "Orders whose delivery attempts ran out",
+		Description: "Newest first. A dead letter is resolved once its order leaves failed, e.g. when it is retried or refunded; resolved ones are listed with `all=true`.",
+		Tags:        []string{"admin", "orders"},
+		Security:    security,
+		Parameters:  doc.QueryParameters(dto.AdminDeadLetterListDTO{}),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The dead letters", Content: openapi.JSON(dataEnvelope(doc.Schema([]data.DeadLetter{})))},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	supplierAccount := dataEnvelope(doc.Schema(data.SupplierAccount{}))
+
+	doc.Add(http.MethodGet, "/v1/admin/supplier-accounts", &openapi.Operation{
+		OperationID: "listSupplierAccounts",
+		Summary:     "Roblox accounts Robux are delivered from",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The supplier accounts", Content: openapi.JSON(dataEnvelope(doc.Schema([]data.SupplierAccount{})))},
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPost, "/v1/admin/supplier-accounts", &openapi.Operation{
+		OperationID: "createSupplierAccount",
+		Summary:     "Add a supplier account",
+		Tags:        []string{"admin"},
+		Security:    security,
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminSupplierAccountCreateDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": {Description: "The supplier account", Content: openapi.JSON(supplierAccount)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPut, "/v1/admin/supplier-accounts/{id}", &openapi.Operation{
+		OperationID: "updateSupplierAccount",
+		Summary:     "Rename, throttle or deactivate a supplier account",
+		Description: "Only the fields sent change. Deliveries already running from the account finish; the new limits apply to the next ones.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminSupplierAccountUpdateDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminSupplierAccountUpdateDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The supplier account", Content: openapi.JSON(supplierAccount)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodGet, "/v1/admin/stock", &openapi.Operation{
+		OperationID: "listStockBalances",
+		Summary:     "Robux of every supplier account by ledger bucket",
+		Description: "purchased plus adjusted always equals available, reserved and delivered together. onHand, available plus reserved, is what the Roblox account should hold.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The balances", Content: openapi.JSON(dataEnvelope(doc.Schema([]data.StockBalance{})))},
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodGet, "/v1/admin/supplier-accounts/{id}/ledger", &openapi.Operation{
+		OperationID: "listStockLedger",
+		Summary:     "Robux movements of a supplier account",
+		Description: "Newest first. Every entry moves amount from its credit bucket to its debit bucket.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  append(doc.PathParameters(dto.AdminStockLedgerDTO{}), doc.QueryParameters(dto.AdminStockLedgerDTO{})...),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The ledger entries", Content: openapi.JSON(dataEnvelope(doc.Schema([]data.LedgerEntry{})))},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPost, "/v1/admin/supplier-accounts/{id}/purchases", &openapi.Operation{
+		OperationID: "createStockPurchase",
+		Summary:     "Record Robux bought for a supplier account",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminStockPurchaseDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminStockPurchaseDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": {Description: "The ledger entry", Content: openapi.JSON(dataEnvelope(doc.Schema(data.LedgerEntry{})))},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPost, "/v1/admin/supplier-accounts/{id}/reconcile", &openapi.Operation{
+		OperationID: "reconcileStock",
+		Summary:     "Reconcile a supplier account with its Roblox balance",
+		Description: "Compares onHand with robux, the balance at Roblox, and records the difference as an adjustment of the available Robux.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminStockReconcileDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminStockReconcileDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The reconciliation", Content: openapi.JSON(dataEnvelope(doc.Schema(data.Reconciliation{})))},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	voucher := dataEnvelope(doc.Schema(data.Voucher{}))
+
+	doc.Add(http.MethodGet, "/v1/admin/vouchers", &openapi.Operation{
+		OperationID: "listVouchers",
+		Summary:     "Every voucher with its redemptions",
+		Description: "Newest first. redemptions counts the orders using the voucher, failed and refunded ones excluded.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The vouchers", Content: openapi.JSON(dataEnvelope(doc.Schema([]data.Voucher{})))},
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPost, "/v1/admin/vouchers", &openapi.Operation{
+		OperationID: "createVoucher",
+		Summary:     "Add a voucher",
+		Description: "Set exactly one of percentOff and amountOffIdr. Amounts are whole rupiah. A discount never brings an order below Rp1.000.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminVoucherCreateDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": {Description: "The voucher", Content: openapi.JSON(voucher)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPut, "/v1/admin/vouchers/{id}", &openapi.Operation{
+		OperationID: "updateVoucher",
+		Summary:     "Replace a voucher",
+		Description: "Every field is replaced, omitted ones are cleared. Orders already using the voucher keep their discount and still count toward its limits.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminVoucherUpdateDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminVoucherUpdateDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The voucher", Content: openapi.JSON(voucher)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	flashSale := dataEnvelope(doc.Schema(data.FlashSale{}))
+
+	doc.Add(http.MethodGet, "/v1/admin/flash-sales", &openapi.Operation{
+		OperationID: "listFlashSales",
+		Summary:     "Every flash sale with its items",
+		Description: "The latest to start first. sold counts the units of orders at the flash sale price, failed and refunded ones excluded.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The flash sales", Content: openapi.JSON(dataEnvelope(doc.Schema([]data.FlashSale{})))},
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPost, "/v1/admin/flash-sales", &openapi.Operation{
+		OperationID: "createFlashSale",
+		Summary:     "Add a flash sale",
+		Description: "startsAt and endsAt are Asia/Jakarta times like 2026-10-20T12:00. Prices are whole rupiah per unit.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminFlashSaleCreateDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": {Description: "The flash sale", Content: openapi.JSON(flashSale)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+
+	doc.Add(http.MethodPut, "/v1/admin/flash-sales/{id}", &openapi.Operation{
+		OperationID: "updateFlashSale",
+		Summary:     "Replace a flash sale",
+		Description: "Every field is replaced. Items are matched by product and keep the units they sold; a quantity below them sells the item out. " +
+			"Orders of removed items keep their price.",
+		Tags:        []string{"admin"},
+		Security:    security,
+		Parameters:  doc.PathParameters(dto.AdminFlashSaleUpdateDTO{}),
+		RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(dto.AdminFlashSaleUpdateDTO{}))},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": {Description: "The flash sale", Content: openapi.JSON(flashSale)},
+			"400": openapi.ResponseRef("BadRequest"),
+			"404": openapi.ResponseRef("NotFound"),
+			"422": openapi.ResponseRef("UnprocessableEntity"),
+			"500": openapi.ResponseRef("InternalServerError"),
+		}),
+	})
+}
+
+// conditionalGET documents withConditionalGET on op.
+func conditionalGET(op *openapi.Operation) *openapi.Operation {
+	op.Parameters = append(op.Parameters, &openapi.Parameter{
+		Name:        headerIfNoneMatch,
+		In:          "header",
+		Description: "ETag of a cached response, answered with 304 when it is still current",
+		Schema:      &openapi.Schema{Type: "string"},
+	})
+
+	cacheHeaders := func() map[string]*openapi.Header {
+		return map[string]*openapi.Header{
+			headerETag:              {Description: "Strong validator of the response body", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			echo.HeaderCacheControl: {Description: "Configured per route, omitted when empty", Schema: &openapi.Schema{Type: "string"}},
+		}
+	}
+	op.Responses["200"].Headers = cacheHeaders()
+	op.Responses["304"] = &openapi.Response{Description: "The cached response is still current", Headers: cacheHeaders()}
+	return op
+}
+
+// csvList documents the CSV representation of a list operation, which
+// serializer.JSONSerializer writes when Accept prefers text/csv.
+func csvList(op *openapi.Operation) *openapi.Operation {
+	op.Parameters = append(op.Parameters, &openapi.Parameter{
+		Name:        "columns",
+		In:          "query",
+		Description: "Comma separated CSV columns, nested fields by path (e.g. user.username). Defaults to every field.",
+		Schema:      &openapi.Schema{Type: "string"},
+	})
+	op.Responses["200"].Content[serializer.MIMETextCSV] = openapi.MediaType{Schema: &openapi.Schema{
+		Type:        "string",
+		Description: "One row per item with a header row, pagination metadata is omitted",
+	}}
+	return op
+}
+
+// addMessagePack documents that every JSON request and response body is
+// also available as MessagePack.
+func addMessagePack(doc *openapi.Document) {
+	withMessagePack := func(content map[string]openapi.MediaType) {
+		if mediaType, ok := content[echo.MIMEApplicationJSON]; ok {
+			content[serializer.MIMEMessagePack] = mediaType
+		}
+	}
+
+	for _, res := range doc.Components.Responses {
+		withMessagePack(res.Content)
+	}
+	for _, item := range doc.Paths {
+		for _, op := range []*openapi.Operation{item.Get, item.Post, item.Put, item.Patch, item.Delete} {
+			if op == nil {
+				continue
+			}
+			if op.RequestBody != nil {
+				withMessagePack(op.RequestBody.Content)
+			}
+			for _, res := range op.Responses {
+				withMessagePack(res.Content)
+			}
+		}
+	}
+}
+
+// addBodyLimit documents withBodyLimit on every operation taking a body.
+func addBodyLimit(doc *openapi.Document) {
+	for _, item := range doc.Paths {
+		for _, op := range []*openapi.Operation{item.Post, item.Put, item.Patch, item.Delete} {
+			if op != nil && op.RequestBody != nil {
+				op.Responses["413"] = openapi.ResponseRef("PayloadTooLarge")
+			}
+		}
+	}
+}
+
+// addIdempotency documents withIdempotency on every mutating operation.
+func addIdempotency(doc *openapi.Document) {
+	for _, item := range doc.Paths {
+		for _, op := range []*openapi.Operation{item.Post, item.Put, item.Patch, item.Delete} {
+			if op == nil {
+				continue
+			}
+
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:        headerIdempotencyKey,
+				In:          "header",
+				Description: "Makes the request safe to retry. The response is stored and replayed for duplicates with the same key and payload.",
+				Schema:      &openapi.Schema{Type: "string", MinLength: utility.SetPtrValue(1), MaxLength: utility.SetPtrValue(255)},
+			})
+			op.Responses["409"] = openapi.ResponseRef("Conflict")
+			if op.Responses["422"] == nil {
+				op.Responses["422"] = openapi.ResponseRef("UnprocessableEntity")
+			}
+
+			for status, res := range op.Responses {
+				if !strings.HasPrefix(status, "2") || res.Ref != "" {
+					continue
+				}
+				if res.Headers == nil {
+					res.Headers = map[string]*openapi.Header{}
+				}
+				res.Headers[headerIdempotentReplayed] = &openapi.Header{
+					Description: "true when the response is a replay of an earlier request with the same Idempotency-Key",
+					Schema:      &openapi.Schema{Type: "string", Enum: []any{"true"}},
+				}
+			}
+		}
+	}
+}
+
+// defineErrorResponses registers the body written by HTTPErrorHandler and
+// a reusable response per status code.
+func defineErrorResponses(doc *openapi.Document) {
+	doc.Components.Schemas["Error"] = &openapi.Schema{
+		Type:     "object",
+		Required: []string{"code", "message"},
+		Properties: map[string]*openapi.Schema{
+			"code":    {Type: "string", Description: "HTTP status text", Example: "Not Found"},
+			"message": {Type: "string", Description: "Human readable message"},
+			"details": {Description: "Extra information, e.g. validation messages per field"},
+		},
+	}
+	doc.Components.Schemas["ErrorResponse"] = openapi.Object(map[string]*openapi.Schema{
+		"error": openapi.Ref("Error"),
+	})
+
+	codes := make([]any, 0)
+	for _, code := range apperror.Codes() {
+		codes = append(codes, string(code))
+	}
+	doc.Components.Schemas["Problem"] = &openapi.Schema{
+		Type:        "object",
+		Description: "RFC 7807 problem details, sent instead of ErrorResponse when Accept prefers application/problem+json. Title and detail follow Accept-Language (en, id).",
+		Required:    []string{"type", "title", "status", "detail", "code"},
+		Properties: map[string]*openapi.Schema{
+			"type":     {Type: "string", Description: "urn:mayobox:error:<code>", Example: apperror.TypePrefix + string(apperror.CodeRouteNotFound)},
+			"title":    {Type: "string", Description: "Short localized summary of the code"},
+			"status":   {Type: "integer", Description: "HTTP status code"},
+			"detail":   {Type: "string", Description: "Localized human readable message"},
+			"instance": {Type: "string", Description: "Request path"},
+			"code":     {Type: "string", Description: "Stable machine readable error code", Enum: codes},
+			"details":  {Description: "Extra information, e.g. validation messages per field"},
+		},
+	}
+
+	for name, res := range map[string]struct {
+		status      int
+		description string
+	}{
+		"BadRequest":           {http.StatusBadRequest, "The request could not be parsed"},
+		"Unauthorized":         {http.StatusUnauthorized, "Missing or invalid authentication token"},
+		"Forbidden":            {http.StatusForbidden, "The request is not allowed"},
+		"Conflict":             {http.StatusConflict, "The request conflicts with the current state, e.g. a request with the same Idempotency-Key is still in progress, the order cannot move to the requested status or not enough Robux are in stock"},
+		"NotFound":             {http.StatusNotFound, "The requested resource could not be found"},
+		"UnprocessableEntity":  {http.StatusUnprocessableEntity, "The input failed validation, details holds a message per field. A voucher that does not apply returns `invalid_voucher` with the reason in details"},
+		"PayloadTooLarge":      {http.StatusRequestEntityTooLarge, "The request body is larger than the route allows"},
+		"UnsupportedMediaType": {http.StatusUnsupportedMediaType, "The request body has an unsupported Content-Type"},
+		"TooManyRequests":      {http.StatusTooManyRequests, "Rate limit exceeded"},
+		"InternalServerError":  {http.StatusInternalServerError, "The server encountered a problem"},
+		"ServiceUnavailable":   {http.StatusServiceUnavailable, "The server cannot take the request right now, retry after Retry-After seconds"},
+	} {
+		content := openapi.JSON(openapi.Ref("ErrorResponse"))
+		content[apperror.MIMEProblemJSON] = openapi.MediaType{Schema: openapi.Ref("Problem")}
+
+		var statusCodes []string
+		for _, code := range apperror.CodesForStatus(res.status) {
+			statusCodes = append(statusCodes, "`"+string(code)+"`")
+		}
+
+		doc.Components.Responses[name] = &openapi.Response{
+			Description: res.description + ". Problem codes: " + strings.Join(statusCodes, ", ") + ".",
+			Content:     content,
+		}
+	}
+
+	doc.Components.Responses["Unauthorized"].Headers = map[string]*openapi.Header{
+		"WWW-Authenticate": {Description: "Always Bearer", Required: true, Schema: &openapi.Schema{Type: "string"}},
+	}
+}
+
+// dataEnvelope describes envelope{"data": ...}.
+func dataEnvelope(data *openapi.Schema) *openapi.Schema {
+	return openapi.Object(map[string]*openapi.Schema{"data": data})
+}
+
+// listEnvelope describes envelope{"data": [...], "metadata": ...}. Models
+// return a nil slice when nothing matches and FAQ listings have no metadata,
+// so both may be null.
+func listEnvelope(doc *openapi.Document, items any) *openapi.Schema {
+	list := *doc.Schema(items)
+	list.Nullable = true
+
+	return &openapi.Schema{
+		Type:     "object",
+		Required: []string{"data", "metadata"},
+		Properties: map[string]*openapi.Schema{
+			"data":     &list,
+			"metadata": doc.Schema(&data.Metadata{}),
+		},
+	}
+}
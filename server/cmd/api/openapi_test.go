@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite docs/openapi.{yaml,json} from openapiDocument()")
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc := openAPIDocument()
+
+	yamlDoc, err := doc.MarshalYAML()
+	require.NoError(t, err)
+	jsonDoc, err := doc.MarshalJSONIndent()
+	require.NoError(t, err)
+
+	if *update {
+		require.NoError(t, os.WriteFile("docs/openapi.yaml", yamlDoc, 0o644))
+		require.NoError(t, os.WriteFile("docs/openapi.json", append(jsonDoc, '\n'), 0o644))
+	}
+
+	t.Run("docs/openapi.yaml is up to date", func(t *testing.T) {
+		current, err := os.ReadFile("docs/openapi.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, string(yamlDoc), string(current), "run `make docs` to regenerate")
+	})
+
+	t.Run("docs/openapi.json is up to date", func(t *testing.T) {
+		current, err := os.ReadFile("docs/openapi.json")
+		require.NoError(t, err)
+		assert.Equal(t, string(jsonDoc)+"\n", string(current), "run `make docs` to regenerate")
+	})
+
+	t.Run("every schema reference resolves", func(t *testing.T) {
+		for _, ref := range collectRefs(jsonDoc) {
+			_, err := resolvePointer(jsonDoc, ref)
+			assert.NoError(t, err, ref)
+		}
+	})
+}
+
+func TestDocsPages(t *testing.T) {
+	handler := newTestApplication(t).routes()
+
+	for path, asset := range map[string]string{
+		"/docs":  "/docs/assets/swagger-ui/swagger-ui-bundle.js",
+		"/redoc": "/docs/assets/redoc/redoc.standalone.js",
+	} {
+		t.Run(path+" loads the embedded bundle", func(t *testing.T) {
+			rec := testRequest(t, handler, http.MethodGet, path, "", nil)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), asset)
+			assert.Contains(t, docsAssetFiles, strings.TrimPrefix(asset, "/docs/assets/"))
+
+			// Fails until make docs/assets has downloaded the bundles.
+			rec = testRequest(t, handler, http.MethodGet, asset, "", nil)
+			assert.Equal(t, http.StatusOK, rec.Code, "run make docs/assets")
+		})
+	}
+}
+
+// collectRefs returns every $ref value in the JSON document.
+func collectRefs(doc []byte) []string {
+	var root any
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil
+	}
+
+	var refs []string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if ref, ok := child.(string); ok && k == "$ref" {
+					refs = append(refs, ref)
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+	return refs
+}
+
+// resolvePointer follows a local JSON pointer such as
+// #/components/schemas/Error.
+func resolvePointer(doc []byte, ref string) (any, error) {
+	var node any
+	if err := json.Unmarshal(doc, &node); err != nil {
+		return nil, err
+	}
+
+	for part := range strings.SplitSeq(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %q is not an object", ref, part)
+		}
+		if node, ok = m[part]; !ok {
+			return nil, fmt.Errorf("%s: %q not found", ref, part)
+		}
+	}
+	return node, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+const (
+	// paymentReconcileInterval is how often pending payments are checked
+	// with the provider, in case their webhook never arrives.
+	paymentReconcileInterval = time.Minute
+	// paymentReconcileAfter leaves the webhook time to arrive first.
+	paymentReconcileAfter = 5 * time.Minute
+	// paymentReconcileBatch caps the payments checked per run.
+	paymentReconcileBatch = 100
+	// paymentRetryAfter is suggested to buyers when no charge could be
+	// created.
+	paymentRetryAfter = 30 * time.Second
+)
+
+func newPaymentProvider(cfg Config) (payment.Provider, error) {
+	p := cfg.Payment
+	if p.Provider == "gateway" {
+		return payment.NewGateway(payment.GatewayConfig{
+			BaseURL:       p.GatewayURL,
+			ServerKey:     p.ServerKey,
+			WebhookSecret: p.WebhookSecret,
+		}, &http.Client{Timeout: 10 * time.Second})
+	}
+
+	// The simulator signs and verifies its own webhooks, any secret works.
+	secret := p.WebhookSecret
+	if secret == "" {
+		secret = rand.Text()
+	}
+	return payment.NewFake(secret), nil
+}
+
+// processPaymentWebhook verifies a webhook of the payment provider and
+// applies the status it reports. It returns the event and whether it
+// changed the payment; deliveries of an event already seen change nothing.
+func (app *application) processPaymentWebhook(header http.Header, body []byte) (*payment.Event, bool, error) {
+	event, err := app.payments.ParseWebhook(header, body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p, applied, err := app.models.Payment.ApplyEvent(&data.PaymentEvent{
+		Provider:   app.payments.Name(),
+		EventID:    event.ID,
+		ChargeID:   event.ChargeID,
+		Status:     event.Status,
+		Amount:     event.Amount,
+		OccurredAt: event.OccurredAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrPaymentAmountMismatch):
+			// Acknowledged, so the provider does not retry it; the order
+			// stays unpaid until an admin looks into it.
+			app.logAmountMismatch(p, event.ID, event.Amount)
+			return event, false, nil
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.logger.Warnj(tlog.JSON{"message": "webhook for unknown payment charge", "provider": app.payments.Name(), "chargeId": event.ChargeID})
+		}
+		return nil, false, err
+	}
+	return event, applied, nil
+}
+
+// logAmountMismatch reports a paid event for another amount than payment p
+// was created with.
+func (app *application) logAmountMismatch(p *data.Payment, eventID string, amount pricing.Money) {
+	app.logger.Errorj(tlog.JSON{
+		"message":   "refused payment event for another amount",
+		"orderId":   p.OrderID,
+		"chargeId":  p.ChargeID,
+		"eventId":   eventID,
+		"amountIdr": p.AmountIDR,
+		"paidIdr":   amount,
+	})
+}
+
+// startPaymentReconciler polls the provider for payments still pending
+// after paymentReconcileAfter, so a lost webhook does not leave an order
+// unpaid forever.
+func (app *application) startPaymentReconciler() {
+	app.every("payment reconciler", paymentReconcileInterval, func(ctx context.Context) error {
+		return app.reconcilePayments(ctx, time.Now())
+	})
+}
+
+func (app *application) reconcilePayments(ctx context.Context, now time.Time) error {
+	pending, err := app.models.Payment.Pending(now.Add(-paymentReconcileAfter), paymentReconcileBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		// Charges of a provider no longer configured cannot be looked up.
+		if p.Provider != app.payments.Name() {
+			continue
+		}
+
+		status, amount, occurredAt := payment.StatusPending, pricing.Money(0), now
+		charge, err := app.payments.GetCharge(ctx, p.ChargeID)
+		switch {
+		case errors.Is(err, payment.ErrChargeNotFound) && now.After(p.ExpiresAt):
+			// The provider forgot the charge, e.g. the fake after a
+			// restart. Nobody can pay it anymore.
+			status = payment.StatusExpired
+		case err != nil:
+			app.logger.Warnj(tlog.JSON{"message": "failed get payment charge", "chargeId": p.ChargeID, "error": err})
+			continue
+		default:
+			status, amount = charge.Status, charge.Amount
+			if charge.PaidAt != nil {
+				occurredAt = *charge.PaidAt
+			}
+		}
+		if status == p.Status {
+			continue
+		}
+
+		// The ID is derived from the status, so a webhook and a poll that
+		// report the same change are both recorded but applied once.
+		eventID := "poll:" + p.ChargeID + ":" + status
+		_, applied, err := app.models.Payment.ApplyEvent(&data.PaymentEvent{
+			Provider:   p.Provider,
+			EventID:    eventID,
+			ChargeID:   p.ChargeID,
+			Status:     status,
+			Amount:     amount,
+			OccurredAt: occurredAt,
+		})
+		if errors.Is(err, data.ErrPaymentAmountMismatch) {
+			app.logAmountMismatch(p, eventID, amount)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if applied {
+			app.logger.Infoj(tlog.JSON{"message": "payment reconciled", "orderId": p.OrderID, "chargeId": p.ChargeID, "status": status})
+		}
+	}
+	return nil
+}
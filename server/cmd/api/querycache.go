@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+// startQueryCacheInvalidation listens for table change notifications and
+// evicts the affected cached results until shutdown. Every replica runs its
+// own listener. A lost connection is retried with backoff; the cache is
+// purged on reconnect because notifications sent meanwhile are lost.
+func (app *application) startQueryCacheInvalidation() {
+	if app.queryCache == nil {
+		return
+	}
+
+	app.background("query cache invalidation", func(ctx context.Context) {
+		backoff := time.Second
+		for {
+			err := data.ListenForChanges(ctx, app.config.Database.Dsn,
+				func() {
+					app.queryCache.Purge()
+					backoff = time.Second
+					app.logger.Debugj(tlog.JSON{"message": "listening for table changes", "channel": data.ChangesChannel})
+				},
+				func(table string) {
+					app.queryCache.Invalidate(table)
+					app.logger.Debugj(tlog.JSON{"message": "invalidated query cache", "table": table})
+				},
+			)
+			if ctx.Err() != nil {
+				return
+			}
+
+			// Without a listener the cache could serve stale results until
+			// they expire, so drop them now.
+			app.queryCache.Purge()
+			app.logger.Errorj(tlog.JSON{"message": "table change listener failed", "error": err, "retry_in": backoff.String()})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, time.Minute)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+// currentConfig returns the configuration including the settings that were
+// hot reloaded since startup. Use it instead of app.config for the fields
+// listed in applyConfig.
+func (app *application) currentConfig() *Config {
+	return app.live.Load()
+}
+
+// applyConfig swaps in the settings that are safe to change while serving:
+// log level, CORS origins, rate limits, body and upload size limits,
+// Cache-Control headers, the Product of the Day count and cooldown, the
+// recent orders stream cap and heartbeat, the idempotency key TTL, and the
+// delivery workers and retry policy. Everything else (port, database, log
+// sinks, trusted proxies, payment provider, deliverer, admin token) keeps
+// its startup value until the process is restarted.
+func (app *application) applyConfig(cfg Config) {
+	lvl, err := tlog.ParseLevel(cfg.Log.Level)
+	if err == nil {
+		app.logger.SetLevel(lvl)
+	}
+
+	live := app.config
+	if current := app.live.Load(); current != nil {
+		live = *current
+	}
+	live.Log.Level = cfg.Log.Level
+	live.Cors = cfg.Cors
+	live.Limiter = cfg.Limiter
+	live.BodyLimit = cfg.BodyLimit
+	live.Upload = cfg.Upload
+	live.Cache = cfg.Cache
+	live.Featured = cfg.Featured
+	live.OrdersStream = cfg.OrdersStream
+	live.Idempotency = cfg.Idempotency
+	live.Fulfillment.Workers = cfg.Fulfillment.Workers
+	live.Fulfillment.MaxAttempts = cfg.Fulfillment.MaxAttempts
+	live.Fulfillment.RetryBaseDelay = cfg.Fulfillment.RetryBaseDelay
+	live.Fulfillment.RetryMaxDelay = cfg.Fulfillment.RetryMaxDelay
+	app.live.Store(&live)
+}
+
+// reloadConfig re-reads the config file and environment, then applies the
+// safe settings. Invalid configuration is logged and ignored.
+func (app *application) reloadConfig(reason string, readFile bool) {
+	app.reloadMu.Lock()
+	defer app.reloadMu.Unlock()
+
+	if readFile && viper.ConfigFileUsed() != "" {
+		if err := viper.ReadInConfig(); err != nil {
+			app.logger.Errorj(tlog.JSON{"message": "failed reloading config file", "reason": reason, "error": err})
+			return
+		}
+	}
+
+	cfg, err := decodeConfig()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		app.logger.Errorj(tlog.JSON{"message": "rejected reloaded config", "reason": reason, "error": err})
+		return
+	}
+
+	app.applyConfig(cfg)
+	app.logger.Infoj(tlog.JSON{
+		"message":      "config reloaded",
+		"reason":       reason,
+		"log_level":    cfg.Log.Level,
+		"cors_origins": cfg.Cors.TrustedOrigins,
+		"limiter_rps":  cfg.Limiter.Rps,
+	})
+}
+
+// watchConfig reloads the config on SIGHUP and, when a config file is in
+// use, whenever the file changes on disk.
+func (app *application) watchConfig() {
+	if file := viper.ConfigFileUsed(); file != "" {
+		viper.OnConfigChange(func(e fsnotify.Event) {
+			// viper has already re-read the file at this point.
+			app.reloadConfig("file "+e.Op.String(), false)
+		})
+		viper.WatchConfig()
+		app.logger.Infoj(tlog.JSON{"message": "watching config file", "file": file})
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			app.reloadConfig("SIGHUP", true)
+		}
+	}()
+}
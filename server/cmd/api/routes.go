@@ -4,24 +4,38 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/internal/payment"
 	"github.com/ucok-man/mayobox-server/internal/serializer"
+	"github.com/ucok-man/mayobox-server/internal/storage"
 	"github.com/ucok-man/mayobox-server/internal/validator"
 )
 
 func (app *application) routes() http.Handler {
 	ec := echo.New()
 	ec.JSONSerializer = serializer.New()
+	ec.Binder = serializer.NewBinder()
 	ec.Validator = validator.New()
 	ec.Logger = app.logger
 	ec.HTTPErrorHandler = app.HTTPErrorHandler
+	ec.IPExtractor = app.clientIPExtractor()
 
 	ec.Use(app.withRecover())
 	ec.Use(app.withCORS())
 	ec.Use(app.withRequestLogger())
+	ec.Use(app.withCompression())
+	ec.Use(app.withRateLimit())
+	// Routes taking more or less than the default body limit are added to
+	// limits as they are registered below.
+	limits := bodyLimits{}
+	ec.Use(app.withBodyLimit(limits))
+	ec.Use(app.withIdempotency())
 
 	// Documentation routes
-	ec.FileFS("/swagger.yaml", "docs/swagger.yaml", swaggerFile)
+	ec.FileFS("/openapi.yaml", "docs/openapi.yaml", docsFiles)
+	ec.FileFS("/openapi.json", "docs/openapi.json", docsFiles)
+	ec.StaticFS("/docs/assets", docsAssets())
 	ec.GET("/docs", app.serveSwaggerUI)
+	ec.GET("/redoc", app.serveRedoc)
 
 	// Health check
 	ec.GET("/", app.healthcheckHandler)
@@ -31,11 +45,79 @@ func (app *application) routes() http.Handler {
 
 	testimonies := v1.Group("/testimonies")
 	{
-		testimonies.GET("", app.getAllTestimoniHandler)
+		testimonies.GET("", app.getAllTestimoniHandler, app.withConditionalGET(func(cfg *Config) string { return cfg.Cache.Testimonies }))
 	}
 	faqs := v1.Group("/faqs")
 	{
-		faqs.GET("", app.getAllFAQHandler)
+		faqs.GET("", app.getAllFAQHandler, app.withConditionalGET(func(cfg *Config) string { return cfg.Cache.FAQs }))
+	}
+	products := v1.Group("/products")
+	{
+		products.GET("/featured", app.getFeaturedProductsHandler, app.withConditionalGET(func(cfg *Config) string { return cfg.Cache.Featured }))
+		products.GET("/best-sellers", app.getBestSellersHandler, app.withConditionalGET(func(cfg *Config) string { return cfg.Cache.BestSellers }))
+	}
+	orders := v1.Group("/orders")
+	{
+		orders.GET("/recent", app.getRecentOrdersHandler, app.withConditionalGET(func(cfg *Config) string { return cfg.Cache.RecentOrders }))
+		orders.GET("/recent/stream", app.streamRecentOrdersHandler)
+		limits.set(orders.POST("", app.createOrderHandler), smallBody)
+		orders.GET("/:id", app.getOrderHandler)
+	}
+	payments := v1.Group("/payments")
+	{
+		limits.set(payments.POST("/webhooks/:provider", app.paymentWebhookHandler), smallBody)
+
+		// Settles charges of the fake provider, so orders can be paid offline
+		if _, ok := app.payments.(*payment.Fake); ok {
+			limits.set(payments.POST("/simulator/:chargeId", app.simulatePaymentHandler), smallBody)
+		}
+	}
+	vouchers := v1.Group("/vouchers")
+	{
+		limits.set(vouchers.POST("/validate", app.validateVoucherHandler), smallBody)
+	}
+	flashSales := v1.Group("/flash-sales")
+	{
+		flashSales.GET("/active", app.getActiveFlashSalesHandler)
+	}
+	pricing := v1.Group("/pricing")
+	{
+		pricing.GET("/quote", app.getPricingQuoteHandler)
+	}
+
+	admin := v1.Group("/admin", app.withAdminAuth())
+	{
+		admin.GET("/log-level", app.getLogLevelHandler)
+		limits.set(admin.PUT("/log-level", app.updateLogLevelHandler), smallBody)
+		admin.GET("/logs", app.getRecentLogsHandler)
+		admin.GET("/exports/testimonies", app.exportTestimoniesHandler)
+		limits.set(admin.POST("/users/:id/avatar", app.uploadUserAvatarHandler), largeBody)
+		limits.set(admin.POST("/testimonies/:id/icon", app.uploadTestimoniIconHandler), largeBody)
+		admin.GET("/featured-calendar", app.getFeaturedCalendarHandler)
+		limits.set(admin.PUT("/featured-calendar/:date", app.updateFeaturedCalendarHandler), smallBody)
+		admin.GET("/orders", app.listAdminOrdersHandler)
+		admin.GET("/orders/:id", app.getAdminOrderHandler)
+		limits.set(admin.POST("/orders/:id/retry", app.retryAdminOrderHandler), smallBody)
+		limits.set(admin.POST("/orders/:id/resolve", app.resolveAdminOrderHandler), smallBody)
+		admin.GET("/dead-letters", app.listDeadLettersHandler)
+		admin.GET("/supplier-accounts", app.listSupplierAccountsHandler)
+		limits.set(admin.POST("/supplier-accounts", app.createSupplierAccountHandler), smallBody)
+		limits.set(admin.PUT("/supplier-accounts/:id", app.updateSupplierAccountHandler), smallBody)
+		admin.GET("/stock", app.listStockBalancesHandler)
+		admin.GET("/supplier-accounts/:id/ledger", app.listStockLedgerHandler)
+		limits.set(admin.POST("/supplier-accounts/:id/purchases", app.createStockPurchaseHandler), smallBody)
+		limits.set(admin.POST("/supplier-accounts/:id/reconcile", app.reconcileStockHandler), smallBody)
+		admin.GET("/vouchers", app.listVouchersHandler)
+		limits.set(admin.POST("/vouchers", app.createVoucherHandler), smallBody)
+		limits.set(admin.PUT("/vouchers/:id", app.updateVoucherHandler), smallBody)
+		admin.GET("/flash-sales", app.listFlashSalesHandler)
+		limits.set(admin.POST("/flash-sales", app.createFlashSaleHandler), smallBody)
+		limits.set(admin.PUT("/flash-sales/:id", app.updateFlashSaleHandler), smallBody)
+	}
+
+	// Uploaded media, when stored locally
+	if local, ok := app.storage.(*storage.Local); ok {
+		ec.GET("/media/*", app.serveMedia(local.FS()))
 	}
 
 	return ec
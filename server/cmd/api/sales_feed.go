@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+const (
+	// salesFeedInterval is how often new sales are looked up.
+	salesFeedInterval = 2 * time.Second
+	// salesFeedLookback re-reads sales delivered shortly before the last
+	// one seen, so orders committed late are not skipped.
+	salesFeedLookback = time.Minute
+	// salesFeedBatch caps the sales read per lookup.
+	salesFeedBatch = 1000
+	// salesFeedBuffer is how many sales a stream may fall behind before it
+	// is dropped. The client reconnects and resumes from its last event.
+	salesFeedBuffer = 64
+)
+
+var (
+	errTooManyStreams  = errors.New("sales feed: too many streams")
+	errSalesFeedClosed = errors.New("sales feed: closed")
+)
+
+// RecentOrder is a completed order as shown to the public.
+type RecentOrder struct {
+	ID          string        `json:"id"`
+	Buyer       string        `json:"buyer" doc:"Roblox username of the buyer, masked"`
+	Quantity    int           `json:"quantity"`
+	Robux       int           `json:"robux"`
+	Total       pricing.Money `json:"total"`
+	DeliveredAt time.Time     `json:"deliveredAt"`
+	Product     *data.Product `json:"product"`
+}
+
+func newRecentOrder(sale *data.Sale) RecentOrder {
+	return RecentOrder{
+		ID:          sale.OrderID,
+		Buyer:       utility.MaskName(sale.RobloxUsername),
+		Quantity:    sale.Quantity,
+		Robux:       sale.Robux,
+		Total:       sale.TotalIDR,
+		DeliveredAt: sale.DeliveredAt,
+		Product:     sale.Product,
+	}
+}
+
+// salesFeed fans newly completed orders out to the open streams. One
+// poller per instance looks them up, however many streams are open.
+type salesFeed struct {
+	mu     sync.Mutex
+	subs   map[chan RecentOrder]struct{}
+	closed bool
+
+	// primed is set once the sales before startup have been marked seen.
+	primed bool
+	// cursor is the delivery time of the latest sale seen, or the first
+	// poll if later. seen holds the sales delivered within
+	// salesFeedLookback of it.
+	cursor time.Time
+	seen   map[string]time.Time
+}
+
+func newSalesFeed() *salesFeed {
+	return &salesFeed{
+		subs: make(map[chan RecentOrder]struct{}),
+		seen: make(map[string]time.Time),
+	}
+}
+
+// subscribe opens a stream unless limit streams are open already.
+func (f *salesFeed) subscribe(limit int) (chan RecentOrder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, errSalesFeedClosed
+	}
+	if len(f.subs) >= limit {
+		return nil, errTooManyStreams
+	}
+	ch := make(chan RecentOrder, salesFeedBuffer)
+	f.subs[ch] = struct{}{}
+	return ch, nil
+}
+
+func (f *salesFeed) unsubscribe(ch chan RecentOrder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subs[ch]; ok {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
+
+// publish sends order to every stream. Streams that fell behind are closed
+// rather than waited for.
+func (f *salesFeed) publish(order RecentOrder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subs {
+		select {
+		case ch <- order:
+		default:
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// close ends every stream and refuses new ones. It is called when the
+// server starts shutting down, since open streams would otherwise hold
+// Shutdown until its timeout.
+func (f *salesFeed) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for ch := range f.subs {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
+
+// poll publishes the sales delivered since the last poll. The first poll
+// only marks the sales it finds as seen.
+func (f *salesFeed) poll(orders data.OrderModeler, now time.Time) error {
+	f.mu.Lock()
+	if !f.primed {
+		f.cursor = now
+	}
+	since := f.cursor.Add(-salesFeedLookback)
+	f.mu.Unlock()
+
+	sales, err := orders.SalesSince(since, salesFeedBatch)
+	if err != nil {
+		return err
+	}
+
+	var fresh []RecentOrder
+	f.mu.Lock()
+	for _, sale := range sales {
+		if _, ok := f.seen[sale.OrderID]; ok {
+			continue
+		}
+		f.seen[sale.OrderID] = sale.DeliveredAt
+		if sale.DeliveredAt.After(f.cursor) {
+			f.cursor = sale.DeliveredAt
+		}
+		if f.primed {
+			fresh = append(fresh, newRecentOrder(sale))
+		}
+	}
+	f.primed = true
+	for id, at := range f.seen {
+		if at.Before(f.cursor.Add(-salesFeedLookback)) {
+			delete(f.seen, id)
+		}
+	}
+	f.mu.Unlock()
+
+	for _, order := range fresh {
+		f.publish(order)
+	}
+	return nil
+}
+
+// startSalesFeed looks up newly completed orders for the open streams
+// until shutdown.
+func (app *application) startSalesFeed() {
+	app.every("sales feed", salesFeedInterval, func(ctx context.Context) error {
+		return app.salesFeed.poll(app.models.Order, time.Now())
+	})
+}
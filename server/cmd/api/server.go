@@ -15,6 +15,14 @@ import (
 )
 
 func (app *application) serve() error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	return app.serveUntil(quit)
+}
+
+// serveUntil serves until a signal arrives on quit, then returns once the
+// requests and background tasks in flight have finished.
+func (app *application) serveUntil(quit <-chan os.Signal) error {
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", app.config.Port),
 		Handler:      app.routes(),
@@ -24,12 +32,12 @@ func (app *application) serve() error {
 		ErrorLog:     stdlog.New(app.logger, "", 0),
 	}
 
+	// Streams never go idle on their own, end them as shutdown begins.
+	srv.RegisterOnShutdown(app.salesFeed.close)
+
 	shutdownError := make(chan error)
 
 	go func() {
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
 		// Blocking until receive quit signal
 		s := <-quit
 
@@ -38,13 +46,25 @@ func (app *application) serve() error {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		shutdownError <- srv.Shutdown(ctx)
+		err := srv.Shutdown(ctx)
 		app.logger.Infoj(tlog.JSON{"message": "completing background tasks", "addr": srv.Addr})
 
+		// Deliveries in flight finish even when the requests did not.
+		app.stopBackground()
 		app.wg.Wait()
-		shutdownError <- nil
+		shutdownError <- err
 	}()
 
+	app.watchConfig()
+	app.startIdempotencyCleanup()
+	app.startQueryCacheInvalidation()
+	app.startFeaturedScheduler()
+	app.startSalesRollup()
+	app.startSalesFeed()
+	app.startPaymentReconciler()
+	app.startFulfillment()
+	app.startReservationExpiry()
+
 	app.logger.Infoj(tlog.JSON{"message": "starting server", "addr": srv.Addr, "env": app.config.Env})
 
 	err := srv.ListenAndServe()
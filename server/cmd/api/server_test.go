@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+func TestServeUntil(t *testing.T) {
+	t.Run("returns after deliveries in flight finish", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.config.Port = 0
+		deliverer := newBlockingDeliverer()
+		app.deliverer = deliverer
+		paid := createPaidTestOrder(t, app.routes())
+
+		quit := make(chan os.Signal, 1)
+		served := make(chan error, 1)
+		go func() {
+			served <- app.serveUntil(quit)
+		}()
+
+		<-deliverer.started
+		quit <- syscall.SIGTERM
+		select {
+		case <-served:
+			close(deliverer.release)
+			t.Fatal("serve returned before the delivery finished")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(deliverer.release)
+		require.NoError(t, <-served)
+		order, err := app.models.Order.Get(paid.Data.ID)
+		require.NoError(t, err)
+		assert.Equal(t, data.OrderStatusDelivered, order.Status)
+	})
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+// reservationExpiryInterval is how often the Robux reserved for orders
+// left unpaid are released.
+const reservationExpiryInterval = time.Minute
+
+// startReservationExpiry puts the Robux reserved at checkout back in
+// stock once the buyer's time to pay runs out, without waiting for the
+// payment to expire at the provider.
+func (app *application) startReservationExpiry() {
+	app.every("stock reservation expiry", reservationExpiryInterval, func(ctx context.Context) error {
+		return app.releaseExpiredReservations(time.Now())
+	})
+}
+
+func (app *application) releaseExpiredReservations(now time.Time) error {
+	released, err := app.models.Stock.ReleaseExpired(now)
+	if err != nil {
+		return err
+	}
+	if released > 0 {
+		app.logger.Infoj(tlog.JSON{"message": "released expired stock reservations", "count": released})
+	}
+	return nil
+}
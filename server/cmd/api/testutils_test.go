@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/data/memstore"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+	"github.com/ucok-man/mayobox-server/internal/storage"
+	"github.com/ucok-man/mayobox-server/internal/tlog"
+)
+
+const (
+	testAdminToken    = "test-admin-token-0123456789"
+	testWebhookSecret = "test-webhook-secret-0123456789"
+)
+
+// newTestApplication returns an application backed by the seeded in-memory
+// store, see newTestApplicationWithStore.
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+	return newTestApplicationWithStore(t, memstore.NewSeeded())
+}
+
+func newTestApplicationWithStore(t *testing.T, store *memstore.Store) *application {
+	t.Helper()
+
+	var cfg Config
+	cfg.Port = 4000
+	cfg.Env = "development"
+	cfg.Log.Level = "error"
+	cfg.Cors.TrustedOrigins = []string{"http://localhost:3000"}
+	cfg.Limiter.Enabled = false
+	cfg.Limiter.Rps = 2
+	cfg.Limiter.Burst = 4
+	cfg.Admin.Token = testAdminToken
+	cfg.BodyLimit.DefaultKB = 1024
+	cfg.BodyLimit.SmallKB = 16
+	cfg.BodyLimit.LargeKB = 32768
+	cfg.Upload.ImageMaxSizeKB = 2048
+	cfg.Cache.Testimonies = "public, max-age=60"
+	cfg.Cache.FAQs = "public, max-age=300"
+	cfg.Cache.Featured = "public, max-age=60"
+	cfg.Cache.BestSellers = "public, max-age=300"
+	cfg.Cache.RecentOrders = "public, max-age=10"
+	cfg.Featured.Count = 10
+	cfg.Featured.CooldownDays = 7
+	cfg.OrdersStream.MaxConnections = 10
+	cfg.OrdersStream.Heartbeat = 15 * time.Second
+	cfg.Idempotency.TTL = time.Hour
+	cfg.Payment.Provider = "fake"
+	cfg.Payment.WebhookSecret = testWebhookSecret
+	cfg.Payment.Expiry = time.Hour
+	cfg.Fulfillment.Deliverer = "fake"
+	cfg.Fulfillment.Workers = 4
+	cfg.Fulfillment.MaxAttempts = 3
+	cfg.Fulfillment.RetryBaseDelay = time.Minute
+	cfg.Fulfillment.RetryMaxDelay = time.Hour
+
+	media, err := storage.NewLocal(t.TempDir(), "http://localhost:4000/media")
+	require.NoError(t, err)
+
+	app := &application{
+		config:    cfg,
+		logger:    tlog.New(tlog.JSONSink(io.Discard)),
+		logRing:   tlog.NewRingBuffer(10),
+		models:    store.Models(),
+		storage:   media,
+		payments:  payment.NewFake(testWebhookSecret),
+		deliverer: fulfillment.Fake{},
+
+		salesFeed:  newSalesFeed(),
+		deliveries: newDeliveryPool(),
+	}
+	app.applyConfig(cfg)
+	t.Cleanup(func() {
+		app.stopBackground()
+		app.wg.Wait()
+	})
+	return app
+}
+
+// testRequest sends a request through the full echo router.
+func testRequest(t *testing.T, handler http.Handler, method, target string, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func adminHeader() http.Header {
+	return http.Header{echo.HeaderAuthorization: {"Bearer " + testAdminToken}}
+}
+
+func idempotentAdminHeader(key string) http.Header {
+	header := adminHeader()
+	header.Set(headerIdempotencyKey, key)
+	return header
+}
+
+func problemHeader() http.Header {
+	return http.Header{echo.HeaderAccept: {apperror.MIMEProblemJSON}}
+}
+
+// decodeBody unmarshals the response body into dst.
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, dst any) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), dst), rec.Body.String())
+}
+
+// errorBody is the envelope written by HTTPErrorHandler.
+type errorBody struct {
+	Error struct {
+		Code    string            `json:"code"`
+		Message string            `json:"message"`
+		Details map[string]string `json:"details"`
+	} `json:"error"`
+}
+
+// testPNG returns a w x h opaque PNG.
+func testPNG(w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 200, G: 40, B: 40, A: 255}), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// uploadRequest returns a multipart/form-data body with one file and the
+// admin headers to send it with.
+func uploadRequest(field, filename string, data []byte) (string, http.Header) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	w, _ := mw.CreateFormFile(field, filename)
+	w.Write(data)
+	mw.Close()
+
+	header := adminHeader()
+	header.Set(echo.HeaderContentType, mw.FormDataContentType())
+	return body.String(), header
+}
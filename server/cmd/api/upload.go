@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/serializer"
+	"github.com/ucok-man/mayobox-server/internal/upload"
+)
+
+// imageTypes are the image formats the standard library can decode.
+var imageTypes = []string{"image/png", "image/jpeg", "image/gif"}
+
+func avatarRule(cfg *Config) upload.Rule {
+	return upload.Rule{Field: "avatar", MaxSize: int64(cfg.Upload.ImageMaxSizeKB) << 10, Types: imageTypes, Required: true}
+}
+
+func testimoniIconRule(cfg *Config) upload.Rule {
+	return upload.Rule{Field: "icon", MaxSize: int64(cfg.Upload.ImageMaxSizeKB) << 10, Types: imageTypes, Required: true}
+}
+
+// parseUpload reads a multipart/form-data body within the route's body
+// limit. Rejected files are reported as validation errors per field.
+func (app *application) parseUpload(ctx echo.Context, rules ...upload.Rule) (*upload.Form, error) {
+	form, err := upload.Parse(ctx.Response(), ctx.Request(), serializer.BodyLimit(ctx), rules...)
+	if err != nil {
+		var fieldErrs upload.FieldErrors
+		switch {
+		case errors.As(err, &fieldErrs):
+			return nil, app.ErrFailedValidation(map[string]string(fieldErrs))
+		case errors.Is(err, upload.ErrNotMultipart):
+			return nil, apperror.New(apperror.CodeUnsupportedMediaType).WithMessage("%s", err.Error())
+		default:
+			return nil, app.ErrBadRequest(err.Error())
+		}
+	}
+	return form, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/apperror"
+	"github.com/ucok-man/mayobox-server/internal/serializer"
+)
+
+func TestParseUpload(t *testing.T) {
+	newContext := func(t *testing.T, field, filename string, data []byte) echo.Context {
+		t.Helper()
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		w, err := mw.CreateFormFile(field, filename)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/", &body)
+		req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+		return echo.New().NewContext(req, httptest.NewRecorder())
+	}
+
+	t.Run("accepts an image", func(t *testing.T) {
+		app := newTestApplication(t)
+		ctx := newContext(t, "avatar", "avatar", testPNG(8, 8))
+
+		form, err := app.parseUpload(ctx, avatarRule(app.currentConfig()))
+
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", form.Files["avatar"].ContentType)
+	})
+
+	t.Run("reports rejected files as validation errors", func(t *testing.T) {
+		app := newTestApplication(t)
+		ctx := newContext(t, "avatar", "avatar.png", []byte("%PDF-1.4\n%test"))
+
+		_, err := app.parseUpload(ctx, avatarRule(app.currentConfig()))
+
+		appErr := apperror.As(err)
+		require.NotNil(t, appErr)
+		assert.Equal(t, apperror.CodeValidationFailed, appErr.Code)
+		assert.Equal(t, map[string]string{"avatar": "must be one of image/png, image/jpeg, image/gif"}, appErr.Details)
+	})
+
+	t.Run("uses the route body limit", func(t *testing.T) {
+		app := newTestApplication(t)
+		ctx := newContext(t, "icon", "icon.png", make([]byte, 4096))
+		serializer.SetBodyLimit(ctx, 1024)
+
+		_, err := app.parseUpload(ctx, testimoniIconRule(app.currentConfig()))
+
+		appErr := apperror.As(err)
+		require.NotNil(t, appErr)
+		assert.Equal(t, apperror.CodeBadRequest, appErr.Code)
+		assert.Equal(t, "body must not be larger than 1024 bytes", appErr.Message)
+	})
+
+	t.Run("rejects bodies that are not multipart", func(t *testing.T) {
+		app := newTestApplication(t)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		ctx := echo.New().NewContext(req, httptest.NewRecorder())
+
+		_, err := app.parseUpload(ctx, avatarRule(app.currentConfig()))
+
+		appErr := apperror.As(err)
+		require.NotNil(t, appErr)
+		assert.Equal(t, http.StatusUnsupportedMediaType, appErr.Status())
+	})
+}
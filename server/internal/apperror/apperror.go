@@ -0,0 +1,141 @@
+// Package apperror defines the application error type returned by handlers
+// and the catalog of stable, machine readable error codes. Codes are part of
+// the API contract: add new ones, never rename or reuse them.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type Code string
+
+const (
+	CodeBadRequest              Code = "bad_request"
+	CodeValidationFailed        Code = "validation_failed"
+	CodeInvalidAuthToken        Code = "invalid_authentication_token"
+	CodeForbidden               Code = "forbidden"
+	CodeAdminAPIDisabled        Code = "admin_api_disabled"
+	CodeRouteNotFound           Code = "route_not_found"
+	CodeResourceNotFound        Code = "resource_not_found"
+	CodeLogBufferDisabled       Code = "log_buffer_disabled"
+	CodeMethodNotAllowed        Code = "method_not_allowed"
+	CodeEditConflict            Code = "edit_conflict"
+	CodeRequestTooLarge         Code = "request_too_large"
+	CodeUnsupportedMediaType    Code = "unsupported_media_type"
+	CodeRateLimitExceeded       Code = "rate_limit_exceeded"
+	CodeInvalidIdempotencyKey   Code = "invalid_idempotency_key"
+	CodeIdempotencyKeyReused    Code = "idempotency_key_reused"
+	CodeIdempotencyInProgress   Code = "idempotency_request_in_progress"
+	CodeInternal                Code = "internal_error"
+	CodeServiceUnavailable      Code = "service_unavailable"
+	CodeTooManyStreams          Code = "too_many_streams"
+	CodeInvalidWebhookSignature Code = "invalid_webhook_signature"
+	CodePaymentUnavailable      Code = "payment_unavailable"
+	CodeRefundUnavailable       Code = "refund_unavailable"
+	CodeInvalidOrderTransition  Code = "invalid_order_transition"
+	CodeOutOfStock              Code = "out_of_stock"
+	CodeFlashSaleSoldOut        Code = "flash_sale_sold_out"
+	CodeInvalidVoucher          Code = "invalid_voucher"
+)
+
+// Error is an error with a stable code. Message, when set, replaces the
+// catalog message and is not localized; use it for specifics such as which
+// field of the body is malformed.
+type Error struct {
+	Code    Code
+	Message string
+	Details any
+	// Err is the underlying cause. It is logged, never sent to clients.
+	Err error
+}
+
+func New(code Code) *Error {
+	return &Error{Code: code}
+}
+
+func (e *Error) WithMessage(format string, args ...any) *Error {
+	e.Message = fmt.Sprintf(format, args...)
+	return e
+}
+
+func (e *Error) WithDetails(details any) *Error {
+	e.Details = details
+	return e
+}
+
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func (e *Error) Error() string {
+	msg := string(e.Code) + ": " + e.LocalizedMessage(DefaultLanguage)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status registered for the code.
+func (e *Error) Status() int {
+	return lookup(e.Code).status
+}
+
+func (e *Error) Title(lang string) string {
+	return lookup(e.Code).title.in(lang)
+}
+
+// LocalizedMessage returns Message if set, otherwise the catalog message in
+// lang.
+func (e *Error) LocalizedMessage(lang string) string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return lookup(e.Code).message.in(lang)
+}
+
+// As returns err as *Error, or nil if it is not one.
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return nil
+}
+
+// FromStatus returns the generic code for an HTTP status, for errors raised
+// outside the handlers such as echo's router and binder.
+func FromStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeInvalidAuthToken
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeRouteNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeEditConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeRequestTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusTooManyRequests:
+		return CodeRateLimitExceeded
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	default:
+		return CodeInternal
+	}
+}
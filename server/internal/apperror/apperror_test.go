@@ -0,0 +1,116 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	for _, code := range Codes() {
+		t.Run(string(code), func(t *testing.T) {
+			e := catalog[code]
+			assert.NotZero(t, e.status)
+			for _, lang := range Languages {
+				assert.NotEmpty(t, e.title[lang], "missing %s title", lang)
+				assert.NotEmpty(t, e.message[lang], "missing %s message", lang)
+			}
+		})
+	}
+
+	t.Run("every fallback code is in the catalog", func(t *testing.T) {
+		for _, status := range []int{400, 401, 403, 404, 405, 409, 413, 415, 422, 429, 500, 503, 418} {
+			_, ok := catalog[FromStatus(status)]
+			assert.True(t, ok, "status %d", status)
+		}
+	})
+}
+
+func TestError(t *testing.T) {
+	t.Run("uses catalog status and messages", func(t *testing.T) {
+		e := New(CodeRateLimitExceeded)
+
+		assert.Equal(t, http.StatusTooManyRequests, e.Status())
+		assert.Equal(t, "rate limit exceeded", e.LocalizedMessage("en"))
+		assert.Equal(t, "batas permintaan terlampaui", e.LocalizedMessage("id"))
+		assert.Equal(t, "rate limit exceeded", e.LocalizedMessage("fr"))
+	})
+
+	t.Run("custom message is not localized", func(t *testing.T) {
+		e := New(CodeBadRequest).WithMessage("body contains unknown key %q", "foo")
+
+		assert.Equal(t, `body contains unknown key "foo"`, e.LocalizedMessage("id"))
+		assert.Equal(t, "Permintaan tidak valid", e.Title("id"))
+	})
+
+	t.Run("unknown code is an internal error", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, New("nope").Status())
+	})
+
+	t.Run("wraps the cause", func(t *testing.T) {
+		cause := errors.New("connection refused")
+		err := fmt.Errorf("list: %w", New(CodeInternal).Wrap(cause))
+
+		require.NotNil(t, As(err))
+		assert.Equal(t, CodeInternal, As(err).Code)
+		assert.ErrorIs(t, err, cause)
+		assert.Nil(t, As(cause))
+	})
+}
+
+func TestProblem(t *testing.T) {
+	details := map[string]string{"level": "level must be one of [debug info warn error off]"}
+	p := New(CodeValidationFailed).WithDetails(details).Problem("id", "/v1/admin/log-level")
+
+	assert.Equal(t, Problem{
+		Type:     "urn:mayobox:error:validation_failed",
+		Title:    "Validasi gagal",
+		Status:   http.StatusUnprocessableEntity,
+		Detail:   "permintaan tidak dapat diproses karena ada input yang tidak valid",
+		Instance: "/v1/admin/log-level",
+		Code:     CodeValidationFailed,
+		Details:  details,
+	}, p)
+}
+
+func TestWantsProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/problem+json, application/json", true},
+		{"application/json, application/problem+json;q=0.5", false},
+		{"application/json;q=0.5, application/problem+json", true},
+		{"application/problem+json;q=0", false},
+		{"Application/Problem+JSON", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, WantsProblem(tt.accept), "Accept: %q", tt.accept)
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"id", "id"},
+		{"id-ID,id;q=0.9,en;q=0.8", "id"},
+		{"en-US,en;q=0.9,id;q=0.8", "en"},
+		{"fr, id;q=0.5", "id"},
+		{"fr", "en"},
+		{"en;q=0.2, id;q=0.7", "id"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NegotiateLanguage(tt.header), "Accept-Language: %q", tt.header)
+	}
+}
@@ -0,0 +1,177 @@
+package apperror
+
+import (
+	"net/http"
+	"slices"
+)
+
+type entry struct {
+	status  int
+	title   localized
+	message localized
+}
+
+// catalog holds every code. Messages are lower case without a trailing
+// period, like the rest of the API.
+var catalog = map[Code]entry{
+	CodeBadRequest: {
+		status:  http.StatusBadRequest,
+		title:   localized{"en": "Bad request", "id": "Permintaan tidak valid"},
+		message: localized{"en": "the request could not be parsed", "id": "permintaan tidak dapat diproses"},
+	},
+	CodeValidationFailed: {
+		status:  http.StatusUnprocessableEntity,
+		title:   localized{"en": "Validation failed", "id": "Validasi gagal"},
+		message: localized{"en": "unable to proccess request because some malformed input", "id": "permintaan tidak dapat diproses karena ada input yang tidak valid"},
+	},
+	CodeInvalidAuthToken: {
+		status:  http.StatusUnauthorized,
+		title:   localized{"en": "Unauthorized", "id": "Tidak terautentikasi"},
+		message: localized{"en": "invalid or missing authentication token", "id": "token autentikasi tidak valid atau tidak ada"},
+	},
+	CodeForbidden: {
+		status:  http.StatusForbidden,
+		title:   localized{"en": "Forbidden", "id": "Akses ditolak"},
+		message: localized{"en": "forbidden", "id": "akses ditolak"},
+	},
+	CodeAdminAPIDisabled: {
+		status:  http.StatusForbidden,
+		title:   localized{"en": "Admin API disabled", "id": "API admin dinonaktifkan"},
+		message: localized{"en": "the admin api is disabled", "id": "api admin dinonaktifkan"},
+	},
+	CodeRouteNotFound: {
+		status:  http.StatusNotFound,
+		title:   localized{"en": "Not found", "id": "Tidak ditemukan"},
+		message: localized{"en": "the requested resource could not be found", "id": "sumber daya yang diminta tidak ditemukan"},
+	},
+	CodeResourceNotFound: {
+		status:  http.StatusNotFound,
+		title:   localized{"en": "Resource not found", "id": "Data tidak ditemukan"},
+		message: localized{"en": "the requested resource could not be found", "id": "data yang diminta tidak ditemukan"},
+	},
+	CodeLogBufferDisabled: {
+		status:  http.StatusNotFound,
+		title:   localized{"en": "Log buffer disabled", "id": "Buffer log dinonaktifkan"},
+		message: localized{"en": "the in-memory log buffer is disabled", "id": "buffer log di memori dinonaktifkan"},
+	},
+	CodeMethodNotAllowed: {
+		status:  http.StatusMethodNotAllowed,
+		title:   localized{"en": "Method not allowed", "id": "Metode tidak diizinkan"},
+		message: localized{"en": "the method is not supported for this resource", "id": "metode tidak didukung untuk sumber daya ini"},
+	},
+	CodeEditConflict: {
+		status:  http.StatusConflict,
+		title:   localized{"en": "Edit conflict", "id": "Konflik perubahan"},
+		message: localized{"en": "unable to update the record due to an edit conflict, please try again", "id": "data tidak dapat diperbarui karena konflik perubahan, silakan coba lagi"},
+	},
+	CodeRequestTooLarge: {
+		status:  http.StatusRequestEntityTooLarge,
+		title:   localized{"en": "Request too large", "id": "Permintaan terlalu besar"},
+		message: localized{"en": "the request body is too large", "id": "isi permintaan terlalu besar"},
+	},
+	CodeUnsupportedMediaType: {
+		status:  http.StatusUnsupportedMediaType,
+		title:   localized{"en": "Unsupported media type", "id": "Jenis media tidak didukung"},
+		message: localized{"en": "the request content type is not supported", "id": "jenis konten permintaan tidak didukung"},
+	},
+	CodeRateLimitExceeded: {
+		status:  http.StatusTooManyRequests,
+		title:   localized{"en": "Too many requests", "id": "Terlalu banyak permintaan"},
+		message: localized{"en": "rate limit exceeded", "id": "batas permintaan terlampaui"},
+	},
+	CodeInvalidIdempotencyKey: {
+		status:  http.StatusBadRequest,
+		title:   localized{"en": "Invalid idempotency key", "id": "Idempotency key tidak valid"},
+		message: localized{"en": "the Idempotency-Key header must be 1 to 255 characters", "id": "header Idempotency-Key harus terdiri dari 1 sampai 255 karakter"},
+	},
+	CodeIdempotencyKeyReused: {
+		status:  http.StatusUnprocessableEntity,
+		title:   localized{"en": "Idempotency key reused", "id": "Idempotency key sudah dipakai"},
+		message: localized{"en": "the idempotency key was already used for a different request", "id": "idempotency key sudah dipakai untuk permintaan yang berbeda"},
+	},
+	CodeIdempotencyInProgress: {
+		status:  http.StatusConflict,
+		title:   localized{"en": "Request in progress", "id": "Permintaan sedang diproses"},
+		message: localized{"en": "a request with this idempotency key is still being processed, please retry later", "id": "permintaan dengan idempotency key ini masih diproses, silakan coba lagi nanti"},
+	},
+	CodeInternal: {
+		status:  http.StatusInternalServerError,
+		title:   localized{"en": "Internal server error", "id": "Kesalahan server"},
+		message: localized{"en": "the server encountered a problem and could not process your request", "id": "server mengalami masalah dan tidak dapat memproses permintaan anda"},
+	},
+	CodeServiceUnavailable: {
+		status:  http.StatusServiceUnavailable,
+		title:   localized{"en": "Service unavailable", "id": "Layanan tidak tersedia"},
+		message: localized{"en": "the service is temporarily unavailable, please try again later", "id": "layanan sedang tidak tersedia, silakan coba lagi nanti"},
+	},
+	CodeTooManyStreams: {
+		status:  http.StatusServiceUnavailable,
+		title:   localized{"en": "Too many streams", "id": "Terlalu banyak stream"},
+		message: localized{"en": "too many open streams, please try again later", "id": "terlalu banyak stream yang terbuka, silakan coba lagi nanti"},
+	},
+	CodeInvalidWebhookSignature: {
+		status:  http.StatusBadRequest,
+		title:   localized{"en": "Invalid webhook signature", "id": "Tanda tangan webhook tidak valid"},
+		message: localized{"en": "the webhook signature is missing, invalid or expired", "id": "tanda tangan webhook tidak ada, tidak valid atau kedaluwarsa"},
+	},
+	CodePaymentUnavailable: {
+		status:  http.StatusServiceUnavailable,
+		title:   localized{"en": "Payment unavailable", "id": "Pembayaran tidak tersedia"},
+		message: localized{"en": "the payment provider could not create the charge, please try again later", "id": "penyedia pembayaran tidak dapat membuat tagihan, silakan coba lagi nanti"},
+	},
+	CodeRefundUnavailable: {
+		status:  http.StatusServiceUnavailable,
+		title:   localized{"en": "Refund unavailable", "id": "Pengembalian dana tidak tersedia"},
+		message: localized{"en": "the payment provider could not refund the payment, please try again later", "id": "penyedia pembayaran tidak dapat mengembalikan dana, silakan coba lagi nanti"},
+	},
+	CodeInvalidOrderTransition: {
+		status:  http.StatusConflict,
+		title:   localized{"en": "Invalid order transition", "id": "Perubahan status pesanan tidak valid"},
+		message: localized{"en": "the order cannot move to the requested status", "id": "pesanan tidak dapat berpindah ke status yang diminta"},
+	},
+	CodeOutOfStock: {
+		status:  http.StatusConflict,
+		title:   localized{"en": "Out of stock", "id": "Stok habis"},
+		message: localized{"en": "not enough Robux are in stock for this order, please try a smaller amount or come back later", "id": "stok Robux tidak cukup untuk pesanan ini, silakan coba jumlah yang lebih kecil atau kembali nanti"},
+	},
+	CodeFlashSaleSoldOut: {
+		status:  http.StatusConflict,
+		title:   localized{"en": "Flash sale sold out", "id": "Flash sale habis"},
+		message: localized{"en": "the flash sale sold out or ended before the order was placed, please order again at the regular price", "id": "flash sale habis atau berakhir sebelum pesanan dibuat, silakan pesan lagi dengan harga reguler"},
+	},
+	CodeInvalidVoucher: {
+		status:  http.StatusUnprocessableEntity,
+		title:   localized{"en": "Invalid voucher", "id": "Voucher tidak valid"},
+		message: localized{"en": "this voucher cannot be used for this order", "id": "voucher ini tidak dapat digunakan untuk pesanan ini"},
+	},
+}
+
+// lookup falls back to CodeInternal so an unknown code never escapes as a
+// 200 or an empty message.
+func lookup(code Code) entry {
+	if e, ok := catalog[code]; ok {
+		return e
+	}
+	return catalog[CodeInternal]
+}
+
+// Codes returns every code in the catalog, sorted.
+func Codes() []Code {
+	codes := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// CodesForStatus returns the sorted codes that are sent with status.
+func CodesForStatus(status int) []Code {
+	var codes []Code
+	for _, code := range Codes() {
+		if catalog[code].status == status {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,54 @@
+package apperror
+
+import (
+	"strconv"
+	"strings"
+)
+
+const DefaultLanguage = "en"
+
+// Languages lists the supported message languages.
+var Languages = []string{"en", "id"}
+
+type localized map[string]string
+
+func (l localized) in(lang string) string {
+	if msg, ok := l[lang]; ok {
+		return msg
+	}
+	return l[DefaultLanguage]
+}
+
+// NegotiateLanguage picks the supported language with the highest quality
+// from an Accept-Language header, matching on the primary subtag so id-ID
+// selects id. It returns DefaultLanguage when nothing matches.
+func NegotiateLanguage(acceptLanguage string) string {
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, q := parseQuality(part)
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+
+		for _, lang := range Languages {
+			if primary == lang && q > bestQ {
+				best, bestQ = lang, q
+			}
+		}
+	}
+	return best
+}
+
+// parseQuality splits "value;q=0.5" into the value and its quality, which
+// defaults to 1.
+func parseQuality(part string) (string, float64) {
+	value, params, _ := strings.Cut(part, ";")
+	q := 1.0
+	for _, param := range strings.Split(params, ";") {
+		name, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.EqualFold(name, "q") {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+	return strings.TrimSpace(value), q
+}
@@ -0,0 +1,51 @@
+package apperror
+
+import "strings"
+
+const (
+	MIMEProblemJSON = "application/problem+json"
+	// TypePrefix prefixes the code in the problem type URI.
+	TypePrefix = "urn:mayobox:error:"
+)
+
+// Problem is an RFC 7807 problem details body. Code and Details are
+// extension members carrying the same values as the default envelope.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance,omitempty"`
+	Code     Code   `json:"code"`
+	Details  any    `json:"details,omitempty"`
+}
+
+// Problem renders e for the client language. instance is the request path.
+func (e *Error) Problem(lang, instance string) Problem {
+	return Problem{
+		Type:     TypePrefix + string(e.Code),
+		Title:    e.Title(lang),
+		Status:   e.Status(),
+		Detail:   e.LocalizedMessage(lang),
+		Instance: instance,
+		Code:     e.Code,
+		Details:  e.Details,
+	}
+}
+
+// WantsProblem reports whether an Accept header asks for problem details:
+// application/problem+json must be listed with a quality at least as high
+// as application/json. Wildcards alone keep the default envelope.
+func WantsProblem(accept string) bool {
+	var problemQ, jsonQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, q := parseQuality(part)
+		switch strings.ToLower(mediaType) {
+		case MIMEProblemJSON:
+			problemQ = max(problemQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return problemQ > 0 && problemQ >= jsonQ
+}
@@ -0,0 +1,178 @@
+// Package cache provides a bounded in-memory LRU cache with per entry TTL.
+// Concurrent misses for the same key share a single load.
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLoadPanicked is returned to callers that waited on a load that panicked.
+var ErrLoadPanicked = errors.New("cache: load panicked")
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// call is a load in flight. Callers that miss while it runs wait on done
+// and share its result.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	size     int
+	ttl      time.Duration
+	items    map[K]*list.Element
+	order    *list.List // front is the most recently used entry
+	inflight map[K]*call[V]
+	// generation is bumped by every invalidation, so a load that started
+	// before it does not store a stale value.
+	generation uint64
+	now        func() time.Time
+}
+
+// New returns a cache holding at most size entries, each for at most ttl.
+func New[K comparable, V any](size int, ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		size:     max(size, 1),
+		ttl:      ttl,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+		inflight: make(map[K]*call[V]),
+		now:      time.Now,
+	}
+}
+
+// Get returns the cached value for key if it has not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(key)
+}
+
+// Set stores value under key, evicting the least recently used entry when
+// the cache is full.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value)
+}
+
+// GetOrLoad returns the cached value for key, or calls load to fill it.
+// Concurrent callers missing the same key wait for one load instead of
+// each calling load. Errors are returned to every waiter and not cached.
+func (c *Cache[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+	if inflight, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		<-inflight.done
+		return inflight.value, inflight.err
+	}
+
+	current := &call[V]{done: make(chan struct{})}
+	c.inflight[key] = current
+	generation := c.generation
+	c.mu.Unlock()
+
+	finished := false
+	defer func() {
+		if !finished {
+			// load panicked, waiters must not mistake the zero value for a result
+			current.err = ErrLoadPanicked
+		}
+
+		c.mu.Lock()
+		delete(c.inflight, key)
+		if current.err == nil && generation == c.generation {
+			c.set(key, current.value)
+		}
+		c.mu.Unlock()
+		close(current.done)
+	}()
+
+	current.value, current.err = load()
+	finished = true
+	return current.value, current.err
+}
+
+// Delete removes key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Purge removes every entry from the cache.
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the number of entries, including expired ones that have not
+// been evicted yet.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	item := elem.Value.(*entry[K, V])
+	if !c.now().Before(item.expiresAt) {
+		c.remove(elem)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	return item.value, true
+}
+
+func (c *Cache[K, V]) set(key K, value V) {
+	expiresAt := c.now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*entry[K, V])
+		item.value, item.expiresAt = value, expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache[K, V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("returns stored values", func(t *testing.T) {
+		c := New[string, int](2, time.Minute)
+		c.Set("a", 1)
+
+		value, ok := c.Get("a")
+
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+	})
+
+	t.Run("evicts the least recently used entry", func(t *testing.T) {
+		c := New[string, int](2, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3)
+
+		_, okA := c.Get("a")
+		_, okB := c.Get("b")
+		_, okC := c.Get("c")
+
+		assert.True(t, okA)
+		assert.False(t, okB)
+		assert.True(t, okC)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("expires entries after the ttl", func(t *testing.T) {
+		now := time.Now()
+		c := New[string, int](2, time.Minute)
+		c.now = func() time.Time { return now }
+		c.Set("a", 1)
+
+		now = now.Add(time.Minute)
+		_, ok := c.Get("a")
+
+		assert.False(t, ok)
+		assert.Zero(t, c.Len())
+	})
+
+	t.Run("deletes and purges entries", func(t *testing.T) {
+		c := New[string, int](3, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		c.Delete("a")
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+
+		c.Purge()
+		assert.Zero(t, c.Len())
+	})
+
+	t.Run("loads a missing key once", func(t *testing.T) {
+		c := New[string, int](2, time.Minute)
+		var calls atomic.Int32
+		release := make(chan struct{})
+
+		var wg sync.WaitGroup
+		results := make([]int, 10)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := c.GetOrLoad("a", func() (int, error) {
+					calls.Add(1)
+					<-release
+					return 42, nil
+				})
+				assert.NoError(t, err)
+				results[i] = value
+			}()
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+		for _, value := range results {
+			assert.Equal(t, 42, value)
+		}
+
+		value, err := c.GetOrLoad("a", func() (int, error) { return 0, errors.New("not called") })
+		require.NoError(t, err)
+		assert.Equal(t, 42, value)
+	})
+
+	t.Run("does not cache load errors", func(t *testing.T) {
+		c := New[string, int](2, time.Minute)
+
+		_, err := c.GetOrLoad("a", func() (int, error) { return 0, errors.New("boom") })
+		require.Error(t, err)
+
+		value, err := c.GetOrLoad("a", func() (int, error) { return 7, nil })
+		require.NoError(t, err)
+		assert.Equal(t, 7, value)
+	})
+
+	t.Run("does not store a load invalidated while running", func(t *testing.T) {
+		c := New[string, int](2, time.Minute)
+
+		value, err := c.GetOrLoad("a", func() (int, error) {
+			c.Purge()
+			return 1, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+		assert.Zero(t, c.Len())
+	})
+
+	t.Run("reports a panicking load to waiters", func(t *testing.T) {
+		c := New[string, int](2, time.Minute)
+		started := make(chan struct{})
+		waiterErr := make(chan error)
+
+		go func() {
+			<-started
+			_, err := c.GetOrLoad("a", func() (int, error) { return 1, nil })
+			waiterErr <- err
+		}()
+
+		assert.Panics(t, func() {
+			c.GetOrLoad("a", func() (int, error) {
+				close(started)
+				time.Sleep(20 * time.Millisecond)
+				panic("boom")
+			})
+		})
+		assert.ErrorIs(t, <-waiterErr, ErrLoadPanicked)
+		assert.Zero(t, c.Len())
+	})
+}
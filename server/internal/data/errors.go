@@ -0,0 +1,21 @@
+package data
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx"
+)
+
+// isForeignKeyViolation reports whether err is a Postgres
+// foreign_key_violation, e.g. an insert referencing a missing row.
+func isForeignKeyViolation(err error) bool {
+	var pgErr pgx.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// isUniqueViolation reports whether err is a Postgres unique_violation,
+// e.g. an insert duplicating a unique column.
+func isUniqueViolation(err error) bool {
+	var pgErr pgx.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -67,7 +67,9 @@ func (m FAQModel) GetAll() ([]*FAQWithAnswers, *Metadata, error) {
 	}
 	defer rows.Close()
 
+	// faqMap groups answers, order keeps the ORDER BY of the query
 	faqMap := make(map[string]*FAQWithAnswers)
+	var order []string
 	var totalRecords int
 
 	for rows.Next() {
@@ -105,6 +107,7 @@ func (m FAQModel) GetAll() ([]*FAQWithAnswers, *Metadata, error) {
 				FAQ:     faq,
 				Answers: []*FAQAnswer{},
 			}
+			order = append(order, faq.ID)
 		}
 
 		// append answer if exists
@@ -123,8 +126,8 @@ func (m FAQModel) GetAll() ([]*FAQWithAnswers, *Metadata, error) {
 
 	// convert map → slice (ordered)
 	faqs := make([]*FAQWithAnswers, 0, len(faqMap))
-	for _, faq := range faqMap {
-		faqs = append(faqs, faq)
+	for _, id := range order {
+		faqs = append(faqs, faqMap[id])
 	}
 
 	return faqs, nil, nil
@@ -0,0 +1,244 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// FeaturedPick is one Product of the Day. Date is in Asia/Jakarta,
+// formatted as 2006-01-02.
+type FeaturedPick struct {
+	Date      string   `json:"date"`
+	Position  int      `json:"position"`
+	Source    string   `json:"source"`
+	ProductID string   `json:"productId"`
+	Product   *Product `json:"product,omitempty"`
+}
+
+// FeaturedCalendarEntry fixes a product as a pick of a date.
+type FeaturedCalendarEntry struct {
+	Date      string    `json:"date"`
+	Position  int       `json:"position"`
+	ProductID string    `json:"productId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type FeaturedModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// GetPicks returns the picks of day with their products, by position. It
+// returns an empty slice before the day is scheduled.
+func (m FeaturedModel) GetPicks(day string) ([]*FeaturedPick, error) {
+	query := `
+	SELECT
+		fp.position,
+		fp.source,
+		p.id,
+		p.name,
+		p.category,
+		p.robux,
+		p.icon_url,
+		p.active,
+		p.featured_weight,
+		p.created_at,
+		p.updated_at
+	FROM featured_picks fp
+	JOIN products p
+		ON p.id = fp.product_id
+	WHERE fp.date = $1::date
+	ORDER BY fp.position ASC;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	picks := []*FeaturedPick{}
+	for rows.Next() {
+		pick := FeaturedPick{Date: day, Product: &Product{}}
+		err := rows.Scan(
+			&pick.Position,
+			&pick.Source,
+			&pick.Product.ID,
+			&pick.Product.Name,
+			&pick.Product.Category,
+			&pick.Product.Robux,
+			&pick.Product.IconURL,
+			&pick.Product.Active,
+			&pick.Product.FeaturedWeight,
+			&pick.Product.CreatedAt,
+			&pick.Product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		pick.ProductID = pick.Product.ID
+		picks = append(picks, &pick)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return picks, nil
+}
+
+// SavePicks stores picks as the picks of day unless the day is already
+// scheduled, and reports whether they were stored. Replicas scheduling the
+// same day concurrently are serialized, so exactly one set is kept.
+func (m FeaturedModel) SavePicks(day string, picks []*FeaturedPick) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Released on commit or rollback.
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('featured_picks'), hashtext($1));`, day)
+	if err != nil {
+		return false, err
+	}
+
+	var scheduled bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM featured_picks WHERE date = $1::date);`, day).Scan(&scheduled)
+	if err != nil {
+		return false, err
+	}
+	if scheduled {
+		return false, nil
+	}
+
+	for _, pick := range picks {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO featured_picks (date, position, source, product_id)
+		VALUES ($1::date, $2, $3, $4);`, day, pick.Position, pick.Source, pick.ProductID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RecentProductIDs returns the products picked on from up to, not
+// including, to.
+func (m FeaturedModel) RecentProductIDs(from, to string) ([]string, error) {
+	query := `
+	SELECT DISTINCT product_id
+	FROM featured_picks
+	WHERE date >= $1::date AND date < $2::date;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetCalendar returns the calendar entries from from to to, both included,
+// by date and position.
+func (m FeaturedModel) GetCalendar(from, to string) ([]*FeaturedCalendarEntry, error) {
+	query := `
+	SELECT to_char(date, 'YYYY-MM-DD'), position, product_id, created_at
+	FROM featured_calendar
+	WHERE date >= $1::date AND date <= $2::date
+	ORDER BY date ASC, position ASC;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*FeaturedCalendarEntry{}
+	for rows.Next() {
+		var entry FeaturedCalendarEntry
+		if err := rows.Scan(&entry.Date, &entry.Position, &entry.ProductID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SetCalendar replaces the calendar entries of day with productIDs, in
+// order. The picks of day are dropped so the next schedule follows the new
+// calendar.
+func (m FeaturedModel) SetCalendar(day string, productIDs []string) ([]*FeaturedCalendarEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Taken before the picks are dropped, so a replica scheduling the day
+	// concurrently sees either the old picks or the new calendar.
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('featured_picks'), hashtext($1));`, day)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM featured_calendar WHERE date = $1::date;`, day); err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM featured_picks WHERE date = $1::date;`, day); err != nil {
+		return nil, err
+	}
+
+	entries := []*FeaturedCalendarEntry{}
+	for i, id := range productIDs {
+		entry := FeaturedCalendarEntry{Date: day, Position: i + 1, ProductID: id}
+		err = tx.QueryRowContext(ctx, `
+		INSERT INTO featured_calendar (date, position, product_id)
+		VALUES ($1::date, $2, $3)
+		RETURNING created_at;`, day, entry.Position, id).Scan(&entry.CreatedAt)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return nil, ErrRecordNotFound
+			}
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
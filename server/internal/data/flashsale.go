@@ -0,0 +1,374 @@
+package data
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+// ErrFlashSaleSoldOut is returned when a flash sale item has ended or has
+// fewer units left than an order takes.
+var ErrFlashSaleSoldOut = errors.New("data: flash sale sold out")
+
+// FlashSale is a time-boxed campaign selling its items below their rate
+// table price. It is live from StartsAt until EndsAt while it is active.
+type FlashSale struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	Active    bool             `json:"active"`
+	Items     []*FlashSaleItem `json:"items"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// FlashSaleItem is a product of a flash sale, sold at PriceIDR a unit
+// until Quantity units are sold. Failed and refunded orders give their
+// units back.
+type FlashSaleItem struct {
+	ID          string        `json:"id"`
+	FlashSaleID string        `json:"flashSaleId"`
+	ProductID   string        `json:"productId"`
+	PriceIDR    pricing.Money `json:"priceIdr"`
+	Quantity    int           `json:"quantity"`
+	Sold        int           `json:"sold"`
+}
+
+// Live reports whether s sells its items at at.
+func (s *FlashSale) Live(at time.Time) bool {
+	return s.Active && !at.Before(s.StartsAt) && at.Before(s.EndsAt)
+}
+
+// Remaining returns the units of i left to sell.
+func (i *FlashSaleItem) Remaining() int {
+	return max(i.Quantity-i.Sold, 0)
+}
+
+// FlashSaleOffer returns the cheapest item of the live sales that has
+// quantity units of the product with productID left, and its sale. It
+// returns nil when there is none.
+func FlashSaleOffer(sales []*FlashSale, productID string, quantity int) (*FlashSale, *FlashSaleItem) {
+	var offerSale *FlashSale
+	var offer *FlashSaleItem
+	for _, sale := range sales {
+		for _, item := range sale.Items {
+			if item.ProductID != productID || item.Remaining() < quantity {
+				continue
+			}
+			if offer == nil || item.PriceIDR < offer.PriceIDR {
+				offerSale, offer = sale, item
+			}
+		}
+	}
+	return offerSale, offer
+}
+
+type FlashSaleModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// Every row is a flash sale with one of its items, or with NULLs for a
+// sale without items.
+const flashSaleColumns = `
+		s.id,
+		s.name,
+		s.starts_at,
+		s.ends_at,
+		s.active,
+		s.created_at,
+		s.updated_at,
+		i.id,
+		i.product_id,
+		i.price_idr,
+		i.quantity,
+		i.sold`
+
+// GetAll returns every flash sale, the latest to start first.
+func (m FlashSaleModel) GetAll() ([]*FlashSale, error) {
+	query := `
+	SELECT` + flashSaleColumns + `
+	FROM flash_sales s
+	LEFT JOIN flash_sale_items i
+		ON i.flash_sale_id = s.id
+	ORDER BY s.starts_at DESC, s.id DESC, i.price_idr ASC, i.id ASC;`
+
+	return m.queryFlashSales(query)
+}
+
+// Get returns the flash sale with id.
+func (m FlashSaleModel) Get(id string) (*FlashSale, error) {
+	query := `
+	SELECT` + flashSaleColumns + `
+	FROM flash_sales s
+	LEFT JOIN flash_sale_items i
+		ON i.flash_sale_id = s.id
+	WHERE s.id = $1
+	ORDER BY i.price_idr ASC, i.id ASC;`
+
+	sales, err := m.queryFlashSales(query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sales) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return sales[0], nil
+}
+
+// Live returns the flash sales live at at, the first to end first.
+func (m FlashSaleModel) Live(at time.Time) ([]*FlashSale, error) {
+	query := `
+	SELECT` + flashSaleColumns + `
+	FROM flash_sales s
+	LEFT JOIN flash_sale_items i
+		ON i.flash_sale_id = s.id
+	WHERE s.active AND s.starts_at <= $1 AND $1 < s.ends_at
+	ORDER BY s.ends_at ASC, s.id ASC, i.price_idr ASC, i.id ASC;`
+
+	return m.queryFlashSales(query, at)
+}
+
+// Insert stores a new flash sale with its items and sets their IDs and
+// timestamps. It returns ErrRecordNotFound when a product does not exist.
+func (m FlashSaleModel) Insert(sale *FlashSale) error {
+	query := `
+	INSERT INTO flash_sales (name, starts_at, ends_at, active)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at, updated_at;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, sale.Name, sale.StartsAt, sale.EndsAt, sale.Active).Scan(&sale.ID, &sale.CreatedAt, &sale.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if err := setFlashSaleItems(ctx, tx, sale); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update saves the fields and items of a flash sale. Items are matched by
+// product and keep the units they sold; items of products no longer
+// listed are removed. It returns ErrRecordNotFound when the flash sale or
+// a product does not exist.
+func (m FlashSaleModel) Update(sale *FlashSale) error {
+	query := `
+	UPDATE flash_sales SET
+		name = $2,
+		starts_at = $3,
+		ends_at = $4,
+		active = $5,
+		updated_at = NOW()
+	WHERE id = $1
+	RETURNING created_at, updated_at;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, sale.ID, sale.Name, sale.StartsAt, sale.EndsAt, sale.Active).Scan(&sale.CreatedAt, &sale.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRecordNotFound
+		}
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, product_id FROM flash_sale_items WHERE flash_sale_id = $1;`, sale.ID)
+	if err != nil {
+		return err
+	}
+	stale := []string{}
+	for rows.Next() {
+		var id, productID string
+		if err := rows.Scan(&id, &productID); err != nil {
+			rows.Close()
+			return err
+		}
+		if !slices.ContainsFunc(sale.Items, func(item *FlashSaleItem) bool { return item.ProductID == productID }) {
+			stale = append(stale, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range stale {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM flash_sale_items WHERE id = $1;`, id); err != nil {
+			return err
+		}
+	}
+
+	if err := setFlashSaleItems(ctx, tx, sale); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setFlashSaleItems stores the items of sale, keeping the units sold of
+// the products it already has, and sorts them the way they are read back.
+func setFlashSaleItems(ctx context.Context, tx *sql.Tx, sale *FlashSale) error {
+	query := `
+	INSERT INTO flash_sale_items (flash_sale_id, product_id, price_idr, quantity)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (flash_sale_id, product_id) DO UPDATE SET
+		price_idr = EXCLUDED.price_idr,
+		quantity = EXCLUDED.quantity
+	RETURNING id, sold;`
+
+	for _, item := range sale.Items {
+		item.FlashSaleID = sale.ID
+		err := tx.QueryRowContext(ctx, query, sale.ID, item.ProductID, item.PriceIDR, item.Quantity).Scan(&item.ID, &item.Sold)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
+	}
+	if sale.Items == nil {
+		sale.Items = []*FlashSaleItem{}
+	}
+	// ORDER BY i.price_idr ASC, i.id ASC
+	slices.SortFunc(sale.Items, func(a, b *FlashSaleItem) int {
+		return cmp.Or(cmp.Compare(a.PriceIDR, b.PriceIDR), cmp.Compare(a.ID, b.ID))
+	})
+	return nil
+}
+
+// claimFlashSale adds the units of order to its flash sale item in tx and
+// prices the order at the item's price. The row lock taken by the update
+// makes concurrent checkouts claim the units in turn, so the item never
+// sells more than its quantity. It returns ErrFlashSaleSoldOut when the
+// sale is no longer live or too few units are left.
+func claimFlashSale(ctx context.Context, tx *sql.Tx, order *Order) error {
+	if order.FlashSaleItemID == nil {
+		return nil
+	}
+
+	var price pricing.Money
+	err := tx.QueryRowContext(ctx, `
+	UPDATE flash_sale_items i SET sold = i.sold + $3
+	FROM flash_sales s
+	WHERE i.id = $1
+		AND i.product_id = $2
+		AND i.sold + $3 <= i.quantity
+		AND s.id = i.flash_sale_id
+		AND s.active AND s.starts_at <= NOW() AND NOW() < s.ends_at
+	RETURNING i.price_idr;`, *order.FlashSaleItemID, order.ProductID, order.Quantity).Scan(&price)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrFlashSaleSoldOut
+		}
+		return err
+	}
+	order.SubtotalIDR = price * pricing.Money(order.Quantity)
+	return nil
+}
+
+// syncFlashSale gives the units of order back to its flash sale item when
+// it moves from status from to failed or refunded, and takes them again
+// when it leaves failed. Units taken again may exceed the quantity, the
+// buyer has paid for them.
+func syncFlashSale(ctx context.Context, tx *sql.Tx, order *Order, from string) error {
+	if order.FlashSaleItemID == nil {
+		return nil
+	}
+
+	var units int
+	switch {
+	case releasesFlashSale(order.Status) && !releasesFlashSale(from):
+		units = -order.Quantity
+	case !releasesFlashSale(order.Status) && releasesFlashSale(from):
+		units = order.Quantity
+	default:
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE flash_sale_items SET sold = GREATEST(sold + $2, 0) WHERE id = $1;`, *order.FlashSaleItemID, units)
+	return err
+}
+
+// releasesFlashSale reports whether orders in status give their flash
+// sale units back.
+func releasesFlashSale(status string) bool {
+	return status == OrderStatusFailed || status == OrderStatusRefunded
+}
+
+func (m FlashSaleModel) queryFlashSales(query string, args ...any) ([]*FlashSale, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := []*FlashSale{}
+	for rows.Next() {
+		var sale FlashSale
+		var itemID, productID *string
+		var price *pricing.Money
+		var quantity, sold *int
+		err := rows.Scan(
+			&sale.ID,
+			&sale.Name,
+			&sale.StartsAt,
+			&sale.EndsAt,
+			&sale.Active,
+			&sale.CreatedAt,
+			&sale.UpdatedAt,
+			&itemID,
+			&productID,
+			&price,
+			&quantity,
+			&sold,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Rows of a sale are adjacent, the ORDER BY starts with the sale.
+		if n := len(sales); n == 0 || sales[n-1].ID != sale.ID {
+			sale.Items = []*FlashSaleItem{}
+			sales = append(sales, &sale)
+		}
+		if itemID != nil {
+			current := sales[len(sales)-1]
+			current.Items = append(current.Items, &FlashSaleItem{
+				ID:          *itemID,
+				FlashSaleID: current.ID,
+				ProductID:   *productID,
+				PriceIDR:    *price,
+				Quantity:    *quantity,
+				Sold:        *sold,
+			})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
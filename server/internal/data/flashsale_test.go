@@ -0,0 +1,59 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+func TestFlashSaleLive(t *testing.T) {
+	start := time.Date(2026, 10, 19, 5, 0, 0, 0, time.UTC)
+	sale := FlashSale{StartsAt: start, EndsAt: start.Add(2 * time.Hour), Active: true}
+
+	assert.False(t, sale.Live(start.Add(-time.Second)))
+	assert.True(t, sale.Live(start))
+	assert.True(t, sale.Live(start.Add(time.Hour)))
+	assert.False(t, sale.Live(start.Add(2*time.Hour)))
+
+	sale.Active = false
+	assert.False(t, sale.Live(start.Add(time.Hour)))
+}
+
+func TestFlashSaleOffer(t *testing.T) {
+	cheap := &FlashSaleItem{ID: "i-1", ProductID: "p-1", PriceIDR: pricing.Rupiah(40000), Quantity: 5, Sold: 4}
+	dear := &FlashSaleItem{ID: "i-2", ProductID: "p-1", PriceIDR: pricing.Rupiah(45000), Quantity: 10}
+	other := &FlashSaleItem{ID: "i-3", ProductID: "p-2", PriceIDR: pricing.Rupiah(1000), Quantity: 10}
+	sales := []*FlashSale{
+		{ID: "s-1", Items: []*FlashSaleItem{dear, other}},
+		{ID: "s-2", Items: []*FlashSaleItem{cheap}},
+	}
+
+	t.Run("picks the cheapest item with the units left", func(t *testing.T) {
+		sale, item := FlashSaleOffer(sales, "p-1", 1)
+
+		assert.Equal(t, "s-2", sale.ID)
+		assert.Equal(t, cheap, item)
+	})
+
+	t.Run("skips items with too few units left", func(t *testing.T) {
+		sale, item := FlashSaleOffer(sales, "p-1", 2)
+
+		assert.Equal(t, "s-1", sale.ID)
+		assert.Equal(t, dear, item)
+	})
+
+	t.Run("returns nil without an offer", func(t *testing.T) {
+		sale, item := FlashSaleOffer(sales, "p-3", 1)
+
+		assert.Nil(t, sale)
+		assert.Nil(t, item)
+	})
+
+	t.Run("never counts oversold items as remaining", func(t *testing.T) {
+		item := FlashSaleItem{Quantity: 2, Sold: 3}
+
+		assert.Equal(t, 0, item.Remaining())
+	})
+}
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// IdempotencyKey is the stored outcome of a request sent with an
+// Idempotency-Key header. Scope namespaces the client supplied key, e.g. by
+// route and caller.
+type IdempotencyKey struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	// StatusCode is zero while the first request is in progress.
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type IdempotencyKeyModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// Claim inserts rec as in progress. When an unexpired record already holds
+// the key, nothing is written and that record is returned with claimed false.
+// Expired records are taken over.
+func (m IdempotencyKeyModel) Claim(rec *IdempotencyKey) (*IdempotencyKey, bool, error) {
+	claim := `
+	INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (scope, key) DO UPDATE SET
+		fingerprint = EXCLUDED.fingerprint,
+		status_code = NULL,
+		content_type = '',
+		body = NULL,
+		created_at = NOW(),
+		expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= NOW()
+	RETURNING created_at;`
+
+	existing := `
+	SELECT fingerprint, status_code, content_type, body, created_at, expires_at
+	FROM idempotency_keys
+	WHERE scope = $1 AND key = $2 AND expires_at > NOW();`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// The existing record can expire or be released between both queries,
+	// so try to claim it once more in that case.
+	for range 2 {
+		err := m.db.QueryRowContext(ctx, claim, rec.Scope, rec.Key, rec.Fingerprint, rec.ExpiresAt).Scan(&rec.CreatedAt)
+		if err == nil {
+			return rec, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, err
+		}
+
+		found := IdempotencyKey{Scope: rec.Scope, Key: rec.Key}
+		var status sql.NullInt64
+		err = m.db.QueryRowContext(ctx, existing, rec.Scope, rec.Key).Scan(
+			&found.Fingerprint,
+			&status,
+			&found.ContentType,
+			&found.Body,
+			&found.CreatedAt,
+			&found.ExpiresAt,
+		)
+		if err == nil {
+			found.StatusCode = int(status.Int64)
+			return &found, false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, err
+		}
+	}
+
+	return nil, false, ErrEditConflict
+}
+
+// Complete stores the response of a claimed key.
+func (m IdempotencyKeyModel) Complete(rec *IdempotencyKey) error {
+	query := `
+	UPDATE idempotency_keys
+	SET status_code = $3, content_type = $4, body = $5
+	WHERE scope = $1 AND key = $2 AND status_code IS NULL;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.db.ExecContext(ctx, query, rec.Scope, rec.Key, rec.StatusCode, rec.ContentType, rec.Body)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Release deletes an in-progress key so the request can be retried.
+func (m IdempotencyKeyModel) Release(scope, key string) error {
+	query := `
+	DELETE FROM idempotency_keys
+	WHERE scope = $1 AND key = $2 AND status_code IS NULL;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.db.ExecContext(ctx, query, scope, key)
+	return err
+}
+
+func (m IdempotencyKeyModel) DeleteExpired() (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= NOW();`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,40 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type FAQModel struct {
+	store *Store
+}
+
+func (m FAQModel) GetAll() ([]*data.FAQWithAnswers, *data.Metadata, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	faqs := make([]*data.FAQWithAnswers, 0, len(m.store.faqs))
+	for _, faq := range m.store.faqs {
+		item := &data.FAQWithAnswers{FAQ: faq, Answers: []*data.FAQAnswer{}}
+		for _, ans := range m.store.faqAnswers {
+			if ans.FAQID == faq.ID {
+				item.Answers = append(item.Answers, &ans)
+			}
+		}
+
+		// ORDER BY fa.display_order ASC
+		slices.SortStableFunc(item.Answers, func(a, b *data.FAQAnswer) int {
+			return cmp.Compare(a.DisplayOrder, b.DisplayOrder)
+		})
+		faqs = append(faqs, item)
+	}
+
+	// ORDER BY f.display_order ASC
+	slices.SortStableFunc(faqs, func(a, b *data.FAQWithAnswers) int {
+		return cmp.Compare(a.DisplayOrder, b.DisplayOrder)
+	})
+
+	return faqs, nil, nil
+}
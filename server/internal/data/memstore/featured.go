@@ -0,0 +1,114 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type FeaturedModel struct {
+	store *Store
+}
+
+func (m FeaturedModel) GetPicks(day string) ([]*data.FeaturedPick, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	picks := []*data.FeaturedPick{}
+	for _, pick := range m.store.featuredPicks[day] {
+		// JOIN products
+		product, ok := m.store.products[pick.ProductID]
+		if !ok {
+			continue
+		}
+		pick.Product = &product
+		picks = append(picks, &pick)
+	}
+	return picks, nil
+}
+
+func (m FeaturedModel) SavePicks(day string, picks []*data.FeaturedPick) (bool, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if len(m.store.featuredPicks[day]) > 0 {
+		return false, nil
+	}
+
+	saved := make([]data.FeaturedPick, 0, len(picks))
+	for _, pick := range picks {
+		saved = append(saved, data.FeaturedPick{Date: day, Position: pick.Position, Source: pick.Source, ProductID: pick.ProductID})
+	}
+	// ORDER BY position ASC
+	slices.SortFunc(saved, func(a, b data.FeaturedPick) int {
+		return cmp.Compare(a.Position, b.Position)
+	})
+	m.store.featuredPicks[day] = saved
+	return true, nil
+}
+
+func (m FeaturedModel) RecentProductIDs(from, to string) ([]string, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var ids []string
+	for day, picks := range m.store.featuredPicks {
+		// Dates formatted as 2006-01-02 sort as strings.
+		if day < from || day >= to {
+			continue
+		}
+		for _, pick := range picks {
+			if !slices.Contains(ids, pick.ProductID) {
+				ids = append(ids, pick.ProductID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (m FeaturedModel) GetCalendar(from, to string) ([]*data.FeaturedCalendarEntry, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	entries := []*data.FeaturedCalendarEntry{}
+	for day, dayEntries := range m.store.featuredCalendar {
+		if day < from || day > to {
+			continue
+		}
+		for _, entry := range dayEntries {
+			entries = append(entries, &entry)
+		}
+	}
+
+	// ORDER BY date ASC, position ASC
+	slices.SortFunc(entries, func(a, b *data.FeaturedCalendarEntry) int {
+		return cmp.Or(cmp.Compare(a.Date, b.Date), cmp.Compare(a.Position, b.Position))
+	})
+	return entries, nil
+}
+
+func (m FeaturedModel) SetCalendar(day string, productIDs []string) ([]*data.FeaturedCalendarEntry, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	// REFERENCES products(id)
+	for _, id := range productIDs {
+		if _, ok := m.store.products[id]; !ok {
+			return nil, data.ErrRecordNotFound
+		}
+	}
+
+	now := m.store.now()
+	saved := make([]data.FeaturedCalendarEntry, 0, len(productIDs))
+	entries := []*data.FeaturedCalendarEntry{}
+	for i, id := range productIDs {
+		entry := data.FeaturedCalendarEntry{Date: day, Position: i + 1, ProductID: id, CreatedAt: now}
+		saved = append(saved, entry)
+		entries = append(entries, &entry)
+	}
+
+	m.store.featuredCalendar[day] = saved
+	delete(m.store.featuredPicks, day)
+	return entries, nil
+}
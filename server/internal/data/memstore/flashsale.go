@@ -0,0 +1,218 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+type FlashSaleModel struct {
+	store *Store
+}
+
+func (m FlashSaleModel) GetAll() ([]*data.FlashSale, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	sales := m.store.filterFlashSales(func(data.FlashSale) bool { return true })
+
+	// ORDER BY s.starts_at DESC, s.id DESC
+	slices.SortFunc(sales, func(a, b *data.FlashSale) int {
+		return cmp.Or(b.StartsAt.Compare(a.StartsAt), cmp.Compare(b.ID, a.ID))
+	})
+	return sales, nil
+}
+
+func (m FlashSaleModel) Get(id string) (*data.FlashSale, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	if _, ok := m.store.flashSales[id]; !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return m.store.flashSale(id), nil
+}
+
+func (m FlashSaleModel) Live(at time.Time) ([]*data.FlashSale, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	sales := m.store.filterFlashSales(func(s data.FlashSale) bool { return s.Live(at) })
+
+	// ORDER BY s.ends_at ASC, s.id ASC
+	slices.SortFunc(sales, func(a, b *data.FlashSale) int {
+		return cmp.Or(a.EndsAt.Compare(b.EndsAt), cmp.Compare(a.ID, b.ID))
+	})
+	return sales, nil
+}
+
+func (m FlashSaleModel) Insert(sale *data.FlashSale) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if err := m.store.checkFlashSaleWrite(sale); err != nil {
+		return err
+	}
+
+	now := m.store.now()
+	sale.ID = newID()
+	sale.CreatedAt = now
+	sale.UpdatedAt = now
+	m.store.setFlashSale(sale)
+	return nil
+}
+
+func (m FlashSaleModel) Update(sale *data.FlashSale) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	stored, ok := m.store.flashSales[sale.ID]
+	if !ok {
+		return data.ErrRecordNotFound
+	}
+	if err := m.store.checkFlashSaleWrite(sale); err != nil {
+		return err
+	}
+
+	sale.CreatedAt = stored.CreatedAt
+	sale.UpdatedAt = m.store.now()
+	m.store.setFlashSale(sale)
+	return nil
+}
+
+// checkFlashSaleWrite stands in for the product foreign keys of a flash
+// sale about to be stored. The caller must hold the lock.
+func (s *Store) checkFlashSaleWrite(sale *data.FlashSale) error {
+	// REFERENCES products(id)
+	for _, item := range sale.Items {
+		if _, ok := s.products[item.ProductID]; !ok {
+			return data.ErrRecordNotFound
+		}
+	}
+	return nil
+}
+
+// setFlashSale stores sale and its items, keeping the IDs and units sold
+// of the products it already has, and removing the others. The caller
+// must hold the lock.
+func (s *Store) setFlashSale(sale *data.FlashSale) {
+	existing := map[string]data.FlashSaleItem{}
+	for id, item := range s.flashSaleItems {
+		if item.FlashSaleID == sale.ID {
+			existing[item.ProductID] = item
+			delete(s.flashSaleItems, id)
+		}
+	}
+
+	for _, item := range sale.Items {
+		item.ID = newID()
+		item.FlashSaleID = sale.ID
+		item.Sold = 0
+		// ON CONFLICT (flash_sale_id, product_id) DO UPDATE
+		if old, ok := existing[item.ProductID]; ok {
+			item.ID = old.ID
+			item.Sold = old.Sold
+		}
+		delete(existing, item.ProductID)
+		s.flashSaleItems[item.ID] = *item
+	}
+	// ON DELETE SET NULL
+	for _, removed := range existing {
+		for i := range s.orders {
+			if id := s.orders[i].FlashSaleItemID; id != nil && *id == removed.ID {
+				s.orders[i].FlashSaleItemID = nil
+			}
+		}
+	}
+
+	stored := *sale
+	stored.Items = nil
+	s.flashSales[sale.ID] = stored
+	*sale = *s.flashSale(sale.ID)
+}
+
+// flashSale returns a copy of the flash sale with id and its items. The
+// caller must hold the lock.
+func (s *Store) flashSale(id string) *data.FlashSale {
+	sale := s.flashSales[id]
+	sale.Items = []*data.FlashSaleItem{}
+	for _, item := range s.flashSaleItems {
+		if item.FlashSaleID == id {
+			sale.Items = append(sale.Items, &item)
+		}
+	}
+	// ORDER BY i.price_idr ASC, i.id ASC
+	slices.SortFunc(sale.Items, func(a, b *data.FlashSaleItem) int {
+		return cmp.Or(cmp.Compare(a.PriceIDR, b.PriceIDR), cmp.Compare(a.ID, b.ID))
+	})
+	return &sale
+}
+
+// filterFlashSales returns copies of the flash sales keep accepts. The
+// caller must hold the lock.
+func (s *Store) filterFlashSales(keep func(data.FlashSale) bool) []*data.FlashSale {
+	sales := []*data.FlashSale{}
+	for id, sale := range s.flashSales {
+		if keep(sale) {
+			sales = append(sales, s.flashSale(id))
+		}
+	}
+	return sales
+}
+
+// claimFlashSale checks that the flash sale item of order has its units
+// left and prices the order at the item's price. The units are taken by
+// takeFlashSale once nothing else can fail the insert, standing in for
+// the rollback. The caller must hold the lock.
+func (s *Store) claimFlashSale(order *data.Order) error {
+	if order.FlashSaleItemID == nil {
+		return nil
+	}
+
+	item, ok := s.flashSaleItems[*order.FlashSaleItemID]
+	if !ok || item.ProductID != order.ProductID || item.Sold+order.Quantity > item.Quantity {
+		return data.ErrFlashSaleSoldOut
+	}
+	if sale := s.flashSales[item.FlashSaleID]; !sale.Live(s.now()) {
+		return data.ErrFlashSaleSoldOut
+	}
+	order.SubtotalIDR = item.PriceIDR * pricing.Money(order.Quantity)
+	return nil
+}
+
+// takeFlashSale adds the units of order to its flash sale item. The
+// caller must hold the lock.
+func (s *Store) takeFlashSale(order *data.Order) {
+	if order.FlashSaleItemID == nil {
+		return
+	}
+	item := s.flashSaleItems[*order.FlashSaleItemID]
+	item.Sold += order.Quantity
+	s.flashSaleItems[item.ID] = item
+}
+
+// syncFlashSale gives the units of order back to its flash sale item when
+// it moves from status from to failed or refunded, and takes them again
+// when it leaves failed. The caller must hold the lock.
+func (s *Store) syncFlashSale(order *data.Order, from string) {
+	if order.FlashSaleItemID == nil {
+		return
+	}
+	item := s.flashSaleItems[*order.FlashSaleItemID]
+
+	released := func(status string) bool {
+		return status == data.OrderStatusFailed || status == data.OrderStatusRefunded
+	}
+	switch {
+	case released(order.Status) && !released(from):
+		item.Sold = max(item.Sold-order.Quantity, 0)
+	case !released(order.Status) && released(from):
+		item.Sold += order.Quantity
+	default:
+		return
+	}
+	s.flashSaleItems[item.ID] = item
+}
@@ -0,0 +1,71 @@
+package memstore
+
+import (
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type IdempotencyKeyModel struct {
+	store *Store
+}
+
+func (m IdempotencyKeyModel) Claim(rec *data.IdempotencyKey) (*data.IdempotencyKey, bool, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	id := idempotencyID{rec.Scope, rec.Key}
+	now := m.store.now()
+	if found, ok := m.store.idempotencyKeys[id]; ok && found.ExpiresAt.After(now) {
+		copied := found
+		return &copied, false, nil
+	}
+
+	rec.StatusCode = 0
+	rec.ContentType = ""
+	rec.Body = nil
+	rec.CreatedAt = now
+	m.store.idempotencyKeys[id] = *rec
+	return rec, true, nil
+}
+
+func (m IdempotencyKeyModel) Complete(rec *data.IdempotencyKey) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	id := idempotencyID{rec.Scope, rec.Key}
+	found, ok := m.store.idempotencyKeys[id]
+	if !ok || found.StatusCode != 0 {
+		return data.ErrRecordNotFound
+	}
+
+	found.StatusCode = rec.StatusCode
+	found.ContentType = rec.ContentType
+	found.Body = append([]byte(nil), rec.Body...)
+	m.store.idempotencyKeys[id] = found
+	return nil
+}
+
+func (m IdempotencyKeyModel) Release(scope, key string) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	id := idempotencyID{scope, key}
+	if found, ok := m.store.idempotencyKeys[id]; ok && found.StatusCode == 0 {
+		delete(m.store.idempotencyKeys, id)
+	}
+	return nil
+}
+
+func (m IdempotencyKeyModel) DeleteExpired() (int64, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	var deleted int64
+	now := m.store.now()
+	for id, rec := range m.store.idempotencyKeys {
+		if !rec.ExpiresAt.After(now) {
+			delete(m.store.idempotencyKeys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
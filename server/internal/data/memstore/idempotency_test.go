@@ -0,0 +1,93 @@
+package memstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+func TestIdempotencyKeyModel(t *testing.T) {
+	newRecord := func(now time.Time) *data.IdempotencyKey {
+		return &data.IdempotencyKey{Scope: "PUT /v1/admin/log-level", Key: "k-1", Fingerprint: "f-1", ExpiresAt: now.Add(time.Hour)}
+	}
+
+	t.Run("claims a new key", func(t *testing.T) {
+		s := New()
+
+		rec, claimed, err := s.Models().IdempotencyKey.Claim(newRecord(time.Now()))
+
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		assert.False(t, rec.CreatedAt.IsZero())
+	})
+
+	t.Run("returns the in-progress and completed record", func(t *testing.T) {
+		model := New().Models().IdempotencyKey
+		_, _, err := model.Claim(newRecord(time.Now()))
+		require.NoError(t, err)
+
+		found, claimed, err := model.Claim(newRecord(time.Now()))
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.Zero(t, found.StatusCode)
+
+		done := newRecord(time.Now())
+		done.StatusCode, done.ContentType, done.Body = 200, "application/json", []byte(`{"data":{}}`)
+		require.NoError(t, model.Complete(done))
+
+		found, claimed, err = model.Claim(newRecord(time.Now()))
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.Equal(t, 200, found.StatusCode)
+		assert.Equal(t, "f-1", found.Fingerprint)
+		assert.Equal(t, `{"data":{}}`, string(found.Body))
+	})
+
+	t.Run("completes only once", func(t *testing.T) {
+		model := New().Models().IdempotencyKey
+		rec, _, _ := model.Claim(newRecord(time.Now()))
+		rec.StatusCode = 200
+
+		require.NoError(t, model.Complete(rec))
+		assert.ErrorIs(t, model.Complete(rec), data.ErrRecordNotFound)
+	})
+
+	t.Run("release frees in-progress keys only", func(t *testing.T) {
+		model := New().Models().IdempotencyKey
+		rec, _, _ := model.Claim(newRecord(time.Now()))
+
+		require.NoError(t, model.Release(rec.Scope, rec.Key))
+		_, claimed, _ := model.Claim(newRecord(time.Now()))
+		assert.True(t, claimed)
+
+		rec.StatusCode = 201
+		require.NoError(t, model.Complete(rec))
+		require.NoError(t, model.Release(rec.Scope, rec.Key))
+		_, claimed, _ = model.Claim(newRecord(time.Now()))
+		assert.False(t, claimed)
+	})
+
+	t.Run("expired keys are taken over and deleted", func(t *testing.T) {
+		s := New()
+		now := time.Now()
+		s.now = func() time.Time { return now }
+		model := s.Models().IdempotencyKey
+
+		rec, _, _ := model.Claim(newRecord(now))
+		rec.StatusCode = 200
+		require.NoError(t, model.Complete(rec))
+
+		now = now.Add(2 * time.Hour)
+		_, claimed, err := model.Claim(newRecord(now))
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		now = now.Add(2 * time.Hour)
+		deleted, err := model.DeleteExpired()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
@@ -0,0 +1,189 @@
+// Package memstore implements the data Modeler interfaces in memory. It is
+// used by handler tests and by the API's --storage=memory demo mode, and
+// mirrors the ordering, pagination and metadata of the Postgres models.
+package memstore
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+// Store holds every table. Models created from the same Store share data.
+type Store struct {
+	mu          sync.RWMutex
+	users       map[string]data.User
+	testimonies []data.Testimoni
+	faqs        []data.FAQ
+	faqAnswers  []data.FAQAnswer
+	rateTables  []pricing.Table
+	products    map[string]data.Product
+	// featuredPicks and featuredCalendar are keyed by date, by position.
+	featuredPicks    map[string][]data.FeaturedPick
+	featuredCalendar map[string][]data.FeaturedCalendarEntry
+	orders           []data.Order
+	orderTransitions []data.OrderTransition
+	deadLetters      []data.DeadLetter
+	supplierAccounts map[string]data.SupplierAccount
+	// ledger is in created_at order. balances, robux_balances, sums it by
+	// account and bucket. reservations is keyed by order.
+	ledger       []data.LedgerEntry
+	balances     map[string]map[string]int
+	reservations map[string]data.Reservation
+	// vouchers is keyed by ID. Their Redemptions are counted from orders.
+	vouchers map[string]data.Voucher
+	// flashSales and flashSaleItems are keyed by ID, the items of a sale
+	// are found by their FlashSaleID.
+	flashSales     map[string]data.FlashSale
+	flashSaleItems map[string]data.FlashSaleItem
+	payments       []data.Payment
+	// paymentEvents holds the IDs of the events applied to payments.
+	paymentEvents map[paymentEventID]struct{}
+	// productSales is keyed by product and date, rolled up from orders
+	// delivered up to salesThrough.
+	productSales map[salesKey]int
+	salesThrough time.Time
+
+	idempotencyKeys map[idempotencyID]data.IdempotencyKey
+
+	// now stands in for the database clock.
+	now func() time.Time
+}
+
+type salesKey struct {
+	productID, date string
+}
+
+type paymentEventID struct {
+	provider, eventID string
+}
+
+type idempotencyID struct {
+	scope, key string
+}
+
+// Stand-ins for the constraint violations Postgres reports.
+var (
+	errForeignKey      = errors.New("memstore: foreign key violation")
+	errUniqueViolation = errors.New("memstore: unique violation")
+)
+
+// newID returns a random version 4 UUID, like uuid_generate_v4().
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func New() *Store {
+	return &Store{
+		users:            make(map[string]data.User),
+		products:         make(map[string]data.Product),
+		featuredPicks:    make(map[string][]data.FeaturedPick),
+		featuredCalendar: make(map[string][]data.FeaturedCalendarEntry),
+		productSales:     make(map[salesKey]int),
+		supplierAccounts: make(map[string]data.SupplierAccount),
+		balances:         make(map[string]map[string]int),
+		reservations:     make(map[string]data.Reservation),
+		vouchers:         make(map[string]data.Voucher),
+		flashSales:       make(map[string]data.FlashSale),
+		flashSaleItems:   make(map[string]data.FlashSaleItem),
+		paymentEvents:    make(map[paymentEventID]struct{}),
+		idempotencyKeys:  make(map[idempotencyID]data.IdempotencyKey),
+		now:              time.Now,
+	}
+}
+
+func (s *Store) Models() data.Models {
+	return data.Models{
+		User:            UserModel{store: s},
+		Testimoni:       TestimoniModel{store: s},
+		FAQ:             FAQModel{store: s},
+		Product:         ProductModel{store: s},
+		Featured:        FeaturedModel{store: s},
+		Order:           OrderModel{store: s},
+		SupplierAccount: SupplierAccountModel{store: s},
+		Stock:           StockModel{store: s},
+		Voucher:         VoucherModel{store: s},
+		FlashSale:       FlashSaleModel{store: s},
+		Payment:         PaymentModel{store: s},
+		Sales:           SalesModel{store: s},
+		RateTable:       RateTableModel{store: s},
+		IdempotencyKey:  IdempotencyKeyModel{store: s},
+	}
+}
+
+func (s *Store) AddUser(user data.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.ID] = user
+}
+
+// AddTestimoni stores a testimoni. Like the testimonies table, its user
+// must exist for it to be listed.
+func (s *Store) AddTestimoni(testimoni data.Testimoni) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.testimonies = append(s.testimonies, testimoni)
+}
+
+func (s *Store) AddFAQ(faq data.FAQ, answers ...data.FAQAnswer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faqs = append(s.faqs, faq)
+	for _, ans := range answers {
+		ans.FAQID = faq.ID
+		s.faqAnswers = append(s.faqAnswers, ans)
+	}
+}
+
+func (s *Store) AddProduct(product data.Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.products[product.ID] = product
+}
+
+// AddOrder stores an order. Like the orders table, its product must exist
+// for it to count as a sale.
+func (s *Store) AddOrder(order data.Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders = append(s.orders, order)
+}
+
+// AddSupplierAccount stores a supplier account with robux purchased for
+// it.
+func (s *Store) AddSupplierAccount(account data.SupplierAccount, robux int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.supplierAccounts[account.ID] = account
+	if robux > 0 {
+		s.post(account.ID, data.LedgerPurchase, data.LedgerAvailable, data.LedgerSupplier, robux, nil, "")
+	}
+}
+
+// AddVoucher stores a voucher. Its code is stored upper-case and its
+// product IDs are copied.
+func (s *Store) AddVoucher(voucher data.Voucher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	voucher.Code = data.NormalizeVoucherCode(voucher.Code)
+	voucher.ProductIDs = slices.Clone(voucher.ProductIDs)
+	s.vouchers[voucher.ID] = voucher
+}
+
+// AddRateTable stores a rate table. Its tiers are copied.
+func (s *Store) AddRateTable(table pricing.Table) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	table.Tiers = slices.Clone(table.Tiers)
+	s.rateTables = append(s.rateTables, table)
+}
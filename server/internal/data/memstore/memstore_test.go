@@ -0,0 +1,938 @@
+package memstore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+var baseTime = time.Date(2026, 1, 20, 4, 41, 27, 0, time.UTC)
+
+// newTestimoniStore returns a store with n testimonies, one second apart,
+// so the newest is "t-<n>".
+func newTestimoniStore(n int) *Store {
+	s := New()
+	s.AddUser(data.User{ID: "u-1", Username: "RobloxMaster99"})
+	for i := 1; i <= n; i++ {
+		s.AddTestimoni(data.Testimoni{
+			ID:        fmt.Sprintf("t-%d", i),
+			UserID:    "u-1",
+			CreatedAt: baseTime.Add(time.Duration(i) * time.Second),
+		})
+	}
+	return s
+}
+
+func ids(rows []*data.TestimoniWithUser) []string {
+	var out []string
+	for _, row := range rows {
+		out = append(out, row.ID)
+	}
+	return out
+}
+
+func TestTestimoniModelGetAll(t *testing.T) {
+	t.Run("orders by created_at desc and paginates", func(t *testing.T) {
+		model := newTestimoniStore(5).Models().Testimoni
+
+		rows, metadata, err := model.GetAll(data.TestimoniGetAllParam{Page: 2, PageSize: 2})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t-3", "t-2"}, ids(rows))
+		assert.Equal(t, data.Metadata{CurrentPage: 2, PageSize: 2, FirstPage: 1, LastPage: 3, TotalRecords: 5}, *metadata)
+	})
+
+	t.Run("returns partial last page", func(t *testing.T) {
+		model := newTestimoniStore(5).Models().Testimoni
+
+		rows, metadata, err := model.GetAll(data.TestimoniGetAllParam{Page: 3, PageSize: 2})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t-1"}, ids(rows))
+		assert.Equal(t, 3, metadata.LastPage)
+	})
+
+	t.Run("breaks created_at ties by id", func(t *testing.T) {
+		s := New()
+		s.AddUser(data.User{ID: "u-1"})
+		s.AddTestimoni(data.Testimoni{ID: "b", UserID: "u-1", CreatedAt: baseTime})
+		s.AddTestimoni(data.Testimoni{ID: "a", UserID: "u-1", CreatedAt: baseTime})
+
+		rows, _, err := s.Models().Testimoni.GetAll(data.TestimoniGetAllParam{Page: 1, PageSize: 10})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, ids(rows))
+	})
+
+	t.Run("joins the user", func(t *testing.T) {
+		model := newTestimoniStore(1).Models().Testimoni
+
+		rows, _, err := model.GetAll(data.TestimoniGetAllParam{Page: 1, PageSize: 10})
+
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, "RobloxMaster99", rows[0].User.Username)
+	})
+
+	t.Run("skips testimonies without a user", func(t *testing.T) {
+		s := newTestimoniStore(1)
+		s.AddTestimoni(data.Testimoni{ID: "orphan", UserID: "missing"})
+
+		rows, metadata, err := s.Models().Testimoni.GetAll(data.TestimoniGetAllParam{Page: 1, PageSize: 10})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"t-1"}, ids(rows))
+		assert.Equal(t, 1, metadata.TotalRecords)
+	})
+
+	t.Run("returns nil rows and empty metadata past the last page", func(t *testing.T) {
+		model := newTestimoniStore(5).Models().Testimoni
+
+		rows, metadata, err := model.GetAll(data.TestimoniGetAllParam{Page: 4, PageSize: 2})
+
+		require.NoError(t, err)
+		assert.Nil(t, rows)
+		assert.Equal(t, data.Metadata{}, *metadata)
+	})
+
+	t.Run("returns nil rows and empty metadata when empty", func(t *testing.T) {
+		rows, metadata, err := New().Models().Testimoni.GetAll(data.TestimoniGetAllParam{Page: 1, PageSize: 10})
+
+		require.NoError(t, err)
+		assert.Nil(t, rows)
+		assert.Equal(t, data.Metadata{}, *metadata)
+	})
+}
+
+func TestTestimoniModelUpdateIconURL(t *testing.T) {
+	s := newTestimoniStore(2)
+	s.now = func() time.Time { return baseTime.Add(time.Hour) }
+
+	testimoni, err := s.Models().Testimoni.UpdateIconURL("t-1", "http://localhost/icon.png")
+
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/icon.png", testimoni.IconURL)
+	assert.Equal(t, baseTime.Add(time.Hour), testimoni.UpdatedAt)
+	rows, _, _ := s.Models().Testimoni.GetAll(data.TestimoniGetAllParam{Page: 1, PageSize: 10})
+	assert.Equal(t, "http://localhost/icon.png", rows[1].IconURL)
+
+	_, err = s.Models().Testimoni.UpdateIconURL("t-9", "x")
+	assert.ErrorIs(t, err, data.ErrRecordNotFound)
+}
+
+func TestUserModelUpdateImageURL(t *testing.T) {
+	s := newTestimoniStore(1)
+
+	user, err := s.Models().User.UpdateImageURL("u-1", "http://localhost/avatar.jpg")
+
+	require.NoError(t, err)
+	assert.Equal(t, "RobloxMaster99", user.Username)
+	assert.Equal(t, "http://localhost/avatar.jpg", user.ImageUrl)
+	rows, _, _ := s.Models().Testimoni.GetAll(data.TestimoniGetAllParam{Page: 1, PageSize: 10})
+	assert.Equal(t, "http://localhost/avatar.jpg", rows[0].User.ImageUrl)
+
+	_, err = s.Models().User.UpdateImageURL("u-9", "x")
+	assert.ErrorIs(t, err, data.ErrRecordNotFound)
+}
+
+func TestFAQModelGetAll(t *testing.T) {
+	t.Run("orders faqs and answers by display order", func(t *testing.T) {
+		s := New()
+		s.AddFAQ(data.FAQ{ID: "f-2", DisplayOrder: 2},
+			data.FAQAnswer{ID: "a-2", DisplayOrder: 2},
+			data.FAQAnswer{ID: "a-1", DisplayOrder: 1},
+		)
+		s.AddFAQ(data.FAQ{ID: "f-1", DisplayOrder: 1})
+
+		faqs, metadata, err := s.Models().FAQ.GetAll()
+
+		require.NoError(t, err)
+		assert.Nil(t, metadata)
+		require.Len(t, faqs, 2)
+		assert.Equal(t, "f-1", faqs[0].ID)
+		assert.Empty(t, faqs[0].Answers)
+		assert.NotNil(t, faqs[0].Answers)
+		assert.Equal(t, "f-2", faqs[1].ID)
+		require.Len(t, faqs[1].Answers, 2)
+		assert.Equal(t, "a-1", faqs[1].Answers[0].ID)
+		assert.Equal(t, "f-2", faqs[1].Answers[0].FAQID)
+		assert.Equal(t, "a-2", faqs[1].Answers[1].ID)
+	})
+
+	t.Run("returns empty slice when empty", func(t *testing.T) {
+		faqs, _, err := New().Models().FAQ.GetAll()
+
+		require.NoError(t, err)
+		assert.NotNil(t, faqs)
+		assert.Empty(t, faqs)
+	})
+}
+
+func TestRateTableModelCurrent(t *testing.T) {
+	s := New()
+	for i, day := range []int{1, 15, 10} {
+		s.AddRateTable(pricing.Table{
+			ID:            fmt.Sprintf("r-%d", i+1),
+			EffectiveFrom: time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC),
+			Tiers:         []pricing.Tier{{MinRobux: 1, IDRPerRobux: 13700}},
+		})
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{name: "latest effective table", at: time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC), want: "r-3"},
+		{name: "takes effect at its start", at: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), want: "r-2"},
+		{name: "first table", at: time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC), want: "r-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := s.Models().RateTable.Current(tt.at)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, table.ID)
+		})
+	}
+
+	t.Run("returns not found before the first table", func(t *testing.T) {
+		_, err := s.Models().RateTable.Current(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC))
+
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("returns a copy", func(t *testing.T) {
+		table, err := s.Models().RateTable.Current(baseTime)
+		require.NoError(t, err)
+		table.Tiers[0].IDRPerRobux = 1
+
+		again, err := s.Models().RateTable.Current(baseTime)
+		require.NoError(t, err)
+		assert.Equal(t, pricing.Money(13700), again.Tiers[0].IDRPerRobux)
+	})
+}
+
+func TestNewSeeded(t *testing.T) {
+	models := NewSeeded().Models()
+
+	testimonies, metadata, err := models.Testimoni.GetAll(data.TestimoniGetAllParam{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Len(t, testimonies, 5)
+	assert.Equal(t, 5, metadata.TotalRecords)
+	assert.Equal(t, "660e8400-e29b-41d4-a716-446655440001", testimonies[0].ID)
+
+	faqs, _, err := models.FAQ.GetAll()
+	require.NoError(t, err)
+	require.Len(t, faqs, 5)
+	for i, faq := range faqs {
+		assert.Equal(t, i+1, faq.DisplayOrder)
+		assert.Len(t, faq.Answers, 2)
+	}
+
+	// The landing page's 1053 R$ card.
+	table, err := models.RateTable.Current(time.Now())
+	require.NoError(t, err)
+	quote, err := table.Quote(1053)
+	require.NoError(t, err)
+	assert.Equal(t, "Rp144.261", quote.Total.String())
+}
+
+func TestProductModelGetAllActive(t *testing.T) {
+	s := New()
+	s.AddProduct(data.Product{ID: "p-2", Name: "Radio Pass", Active: true})
+	s.AddProduct(data.Product{ID: "p-1", Name: "80 Robux", Active: true})
+	s.AddProduct(data.Product{ID: "p-3", Name: "Retired", Active: false})
+
+	products, err := s.Models().Product.GetAllActive()
+
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+	assert.Equal(t, "p-1", products[0].ID)
+	assert.Equal(t, "p-2", products[1].ID)
+}
+
+func TestFeaturedModel(t *testing.T) {
+	newStore := func() *Store {
+		s := New()
+		s.AddProduct(data.Product{ID: "p-1", Name: "80 Robux", Active: true})
+		s.AddProduct(data.Product{ID: "p-2", Name: "Radio Pass", Active: true})
+		return s
+	}
+
+	t.Run("saves the picks of a day once", func(t *testing.T) {
+		featured := newStore().Models().Featured
+
+		saved, err := featured.SavePicks("2026-10-19", []*data.FeaturedPick{
+			{Position: 2, Source: "random", ProductID: "p-1"},
+			{Position: 1, Source: "calendar", ProductID: "p-2"},
+		})
+		require.NoError(t, err)
+		assert.True(t, saved)
+
+		saved, err = featured.SavePicks("2026-10-19", []*data.FeaturedPick{{Position: 1, Source: "random", ProductID: "p-1"}})
+		require.NoError(t, err)
+		assert.False(t, saved)
+
+		picks, err := featured.GetPicks("2026-10-19")
+		require.NoError(t, err)
+		require.Len(t, picks, 2)
+		assert.Equal(t, "p-2", picks[0].Product.ID)
+		assert.Equal(t, "calendar", picks[0].Source)
+		assert.Equal(t, "2026-10-19", picks[0].Date)
+		assert.Equal(t, "p-1", picks[1].ProductID)
+	})
+
+	t.Run("returns empty picks before scheduling", func(t *testing.T) {
+		picks, err := newStore().Models().Featured.GetPicks("2026-10-19")
+
+		require.NoError(t, err)
+		assert.NotNil(t, picks)
+		assert.Empty(t, picks)
+	})
+
+	t.Run("lists recently featured products", func(t *testing.T) {
+		featured := newStore().Models().Featured
+		featured.SavePicks("2026-10-10", []*data.FeaturedPick{{Position: 1, Source: "random", ProductID: "p-1"}})
+		featured.SavePicks("2026-10-18", []*data.FeaturedPick{{Position: 1, Source: "random", ProductID: "p-2"}})
+		featured.SavePicks("2026-10-19", []*data.FeaturedPick{{Position: 1, Source: "random", ProductID: "p-1"}})
+
+		ids, err := featured.RecentProductIDs("2026-10-12", "2026-10-19")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p-2"}, ids)
+	})
+
+	t.Run("replaces the calendar and drops the picks of the day", func(t *testing.T) {
+		featured := newStore().Models().Featured
+		featured.SavePicks("2026-10-19", []*data.FeaturedPick{{Position: 1, Source: "random", ProductID: "p-1"}})
+		featured.SetCalendar("2026-10-19", []string{"p-1"})
+
+		entries, err := featured.SetCalendar("2026-10-19", []string{"p-2", "p-1"})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, 1, entries[0].Position)
+		assert.Equal(t, "p-2", entries[0].ProductID)
+
+		picks, err := featured.GetPicks("2026-10-19")
+		require.NoError(t, err)
+		assert.Empty(t, picks)
+
+		featured.SetCalendar("2026-10-21", []string{"p-1"})
+		calendar, err := featured.GetCalendar("2026-10-19", "2026-10-20")
+		require.NoError(t, err)
+		assert.Len(t, calendar, 2)
+	})
+
+	t.Run("rejects unknown products", func(t *testing.T) {
+		_, err := newStore().Models().Featured.SetCalendar("2026-10-19", []string{"p-9"})
+
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
+
+func TestSalesModel(t *testing.T) {
+	s := New()
+	s.AddProduct(data.Product{ID: "p-1", Name: "Radio Pass", Active: true})
+	s.AddProduct(data.Product{ID: "p-2", Name: "80 Robux", Active: true})
+	s.AddProduct(data.Product{ID: "p-3", Name: "Retired", Active: false})
+	delivered := func(productID string, quantity int, at time.Time) data.Order {
+		return data.Order{ProductID: productID, Quantity: quantity, Status: data.OrderStatusDelivered, DeliveredAt: &at}
+	}
+	// 2026-10-19 18:00 UTC is 2026-10-20 in Jakarta.
+	day := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+	s.AddOrder(delivered("p-1", 1, day.AddDate(0, 0, -10)))
+	s.AddOrder(delivered("p-2", 2, day))
+	s.AddOrder(delivered("p-3", 5, day))
+	s.AddOrder(data.Order{ProductID: "p-1", Quantity: 9, Status: data.OrderStatusPaid})
+	m := s.Models().Sales
+
+	added, err := m.Refresh(day)
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	t.Run("adds each order once", func(t *testing.T) {
+		s.AddOrder(delivered("p-1", 2, day.Add(time.Minute)))
+		s.AddOrder(delivered("p-1", 4, day.Add(time.Hour)))
+
+		added, err := m.Refresh(day.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, added)
+
+		added, err = m.Refresh(day.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, added)
+	})
+
+	t.Run("ranks active products by units sold", func(t *testing.T) {
+		sellers, err := m.BestSellers("", 10)
+		require.NoError(t, err)
+		require.Len(t, sellers, 2)
+		assert.Equal(t, "p-1", sellers[0].Product.ID)
+		assert.Equal(t, 3, sellers[0].Sold)
+		assert.Equal(t, "p-2", sellers[1].Product.ID)
+		assert.Equal(t, 2, sellers[1].Sold)
+
+		sellers, err = m.BestSellers("", 1)
+		require.NoError(t, err)
+		assert.Len(t, sellers, 1)
+	})
+
+	t.Run("counts sales from a Jakarta date", func(t *testing.T) {
+		sellers, err := m.BestSellers("2026-10-20", 10)
+		require.NoError(t, err)
+		require.Len(t, sellers, 2)
+		assert.Equal(t, "p-1", sellers[1].Product.ID)
+		assert.Equal(t, 2, sellers[1].Sold)
+
+		sellers, err = m.BestSellers("2026-10-21", 10)
+		require.NoError(t, err)
+		assert.Empty(t, sellers)
+	})
+}
+
+func TestOrderModelSales(t *testing.T) {
+	s := New()
+	s.AddProduct(data.Product{ID: "p-1", Name: "Radio Pass", Active: true})
+	at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	for i, minutes := range []int{2, 0, 1} {
+		deliveredAt := at.Add(time.Duration(minutes) * time.Minute)
+		s.AddOrder(data.Order{
+			ID:             fmt.Sprintf("o-%d", i),
+			ProductID:      "p-1",
+			RobloxUsername: "Roblox_Fan99",
+			Quantity:       1,
+			Status:         data.OrderStatusDelivered,
+			DeliveredAt:    &deliveredAt,
+		})
+	}
+	s.AddOrder(data.Order{ID: "o-pending", ProductID: "p-1", Status: data.OrderStatusPaid})
+	m := s.Models().Order
+	orderIDs := func(sales []*data.Sale) []string {
+		var ids []string
+		for _, sale := range sales {
+			ids = append(ids, sale.OrderID)
+		}
+		return ids
+	}
+
+	t.Run("recent sales newest first", func(t *testing.T) {
+		sales, err := m.RecentSales(2)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"o-0", "o-2"}, orderIDs(sales))
+		assert.Equal(t, "Radio Pass", sales[0].Product.Name)
+	})
+
+	t.Run("sales since a time oldest first", func(t *testing.T) {
+		sales, err := m.SalesSince(at.Add(time.Minute), 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"o-2", "o-0"}, orderIDs(sales))
+	})
+
+	t.Run("sales after an order", func(t *testing.T) {
+		sales, err := m.SalesAfter("o-1", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"o-2", "o-0"}, orderIDs(sales))
+
+		sales, err = m.SalesAfter("o-0", 10)
+		require.NoError(t, err)
+		assert.Empty(t, sales)
+
+		_, err = m.SalesAfter("o-pending", 10)
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
+
+func TestOrderModelTransition(t *testing.T) {
+	s := New()
+	s.AddProduct(data.Product{ID: "p-1", Name: "Radio Pass", Active: true})
+	s.AddSupplierAccount(data.SupplierAccount{ID: "acct-1", Name: "Main", MaxConcurrency: 1, Active: true}, 1000)
+	models := s.Models()
+
+	order := data.Order{ProductID: "p-1", RobloxUsername: "Roblox_Fan99", Quantity: 1, Robux: 100}
+	require.NoError(t, models.Order.Insert(&order, time.Now().Add(time.Hour)))
+	require.NoError(t, models.Payment.Insert(&data.Payment{OrderID: order.ID, Provider: "fake", ChargeID: "ch-1", Status: payment.StatusPending}))
+
+	t.Run("rejects transitions the actor may not make", func(t *testing.T) {
+		_, err := models.Order.Transition(order.ID, data.OrderChange{To: data.OrderStatusDelivering, Actor: fulfillment.ActorSystem})
+		assert.ErrorIs(t, err, fulfillment.ErrInvalidTransition)
+
+		_, err = models.Order.Transition("missing", data.OrderChange{To: data.OrderStatusPaid, Actor: fulfillment.ActorPayment})
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	_, _, err := models.Payment.ApplyEvent(&data.PaymentEvent{Provider: "fake", EventID: "evt-1", ChargeID: "ch-1", Status: payment.StatusPaid, OccurredAt: baseTime})
+	require.NoError(t, err)
+
+	t.Run("claims paid orders due for delivery", func(t *testing.T) {
+		claimed, err := models.Order.ClaimForDelivery("acct-1", time.Now(), 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, order.ID, claimed[0].ID)
+		assert.Equal(t, data.OrderStatusDelivering, claimed[0].Status)
+		assert.Equal(t, 1, claimed[0].Delivery.Attempts)
+		assert.Equal(t, "acct-1", *claimed[0].Delivery.SupplierAccountID)
+
+		claimed, err = models.Order.ClaimForDelivery("acct-2", time.Now(), 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+	})
+
+	t.Run("tracks delivery attempts", func(t *testing.T) {
+		retryAt := time.Now().Add(time.Minute)
+		got, err := models.Order.Transition(order.ID, data.OrderChange{To: data.OrderStatusPaid, Actor: fulfillment.ActorSystem, RetryAt: &retryAt, Error: "roblox timed out"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, got.Delivery.Attempts)
+		assert.Equal(t, "roblox timed out", *got.Delivery.LastError)
+
+		claimed, err := models.Order.ClaimForDelivery("acct-1", time.Now(), 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+		claimed, err = models.Order.ClaimForDelivery("acct-1", retryAt, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, 2, claimed[0].Delivery.Attempts)
+	})
+
+	t.Run("resets attempts on retry", func(t *testing.T) {
+		got, err := models.Order.Transition(order.ID, data.OrderChange{To: data.OrderStatusPaid, Actor: fulfillment.ActorAdmin, ResetAttempts: true})
+		require.NoError(t, err)
+		assert.Zero(t, got.Delivery.Attempts)
+		assert.Nil(t, got.Delivery.LastError)
+		assert.Nil(t, got.Delivery.NextAttemptAt)
+	})
+
+	t.Run("delivers", func(t *testing.T) {
+		got, err := models.Order.Transition(order.ID, data.OrderChange{To: data.OrderStatusDelivered, Actor: fulfillment.ActorAdmin, Reference: "gp-1"})
+		require.NoError(t, err)
+		assert.NotNil(t, got.DeliveredAt)
+		assert.Equal(t, "gp-1", *got.Delivery.Reference)
+
+		sales, err := models.Order.RecentSales(10)
+		require.NoError(t, err)
+		assert.Len(t, sales, 1)
+	})
+
+	t.Run("records the history", func(t *testing.T) {
+		history, err := models.Order.History(order.ID)
+		require.NoError(t, err)
+
+		var steps []string
+		for _, tr := range history {
+			steps = append(steps, tr.Actor+":"+tr.ToStatus)
+		}
+		assert.Equal(t, []string{"customer:pending_payment", "payment:paid", "system:delivering", "system:paid", "system:delivering", "admin:paid", "admin:delivered"}, steps)
+		assert.Nil(t, history[0].FromStatus)
+		assert.Equal(t, data.OrderStatusDelivering, *history[3].FromStatus)
+	})
+
+	t.Run("lists by status", func(t *testing.T) {
+		delivered, err := models.Order.ListByStatus(data.OrderStatusDelivered, 10)
+		require.NoError(t, err)
+		assert.Len(t, delivered, 1)
+
+		paid, err := models.Order.ListByStatus(data.OrderStatusPaid, 10)
+		require.NoError(t, err)
+		assert.Empty(t, paid)
+	})
+
+	t.Run("dead letters orders out of attempts", func(t *testing.T) {
+		failed := data.Order{ProductID: "p-1", RobloxUsername: "Roblox_Fan99", Quantity: 1, Robux: 100}
+		require.NoError(t, models.Order.Insert(&failed, time.Now().Add(time.Hour)))
+		require.NoError(t, models.Payment.Insert(&data.Payment{OrderID: failed.ID, Provider: "fake", ChargeID: "ch-2", Status: payment.StatusPending}))
+		_, _, err := models.Payment.ApplyEvent(&data.PaymentEvent{Provider: "fake", EventID: "evt-2", ChargeID: "ch-2", Status: payment.StatusPaid, OccurredAt: baseTime})
+		require.NoError(t, err)
+		_, err = models.Order.ClaimForDelivery("acct-1", time.Now(), 10)
+		require.NoError(t, err)
+
+		_, err = models.Order.Transition(failed.ID, data.OrderChange{To: data.OrderStatusFailed, Actor: fulfillment.ActorSystem, Error: "gamepass not found", DeadLetter: true})
+		require.NoError(t, err)
+
+		letters, err := models.Order.DeadLetters(true, 10)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, failed.ID, letters[0].OrderID)
+		assert.Equal(t, "acct-1", *letters[0].SupplierAccountID)
+		assert.Equal(t, 1, letters[0].Attempts)
+		assert.Equal(t, "gamepass not found", letters[0].Error)
+		assert.Nil(t, letters[0].ResolvedAt)
+
+		_, err = models.Order.Transition(failed.ID, data.OrderChange{To: data.OrderStatusPaid, Actor: fulfillment.ActorAdmin, ResetAttempts: true})
+		require.NoError(t, err)
+
+		letters, err = models.Order.DeadLetters(true, 10)
+		require.NoError(t, err)
+		assert.Empty(t, letters)
+		letters, err = models.Order.DeadLetters(false, 10)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.NotNil(t, letters[0].ResolvedAt)
+	})
+}
+
+func TestPaymentModel(t *testing.T) {
+	s := New()
+	s.AddProduct(data.Product{ID: "p-1", Name: "Radio Pass", Active: true})
+	s.AddSupplierAccount(data.SupplierAccount{ID: "acct-1", Name: "Main", MaxConcurrency: 1, Active: true}, 1000)
+	models := s.Models()
+
+	order := data.Order{ProductID: "p-1", RobloxUsername: "Roblox_Fan99", Quantity: 1, Robux: 100, SubtotalIDR: pricing.Rupiah(13700)}
+	require.NoError(t, models.Order.Insert(&order, time.Now().Add(time.Hour)))
+	assert.Equal(t, data.OrderStatusPendingPayment, order.Status)
+
+	p := data.Payment{
+		OrderID:   order.ID,
+		Provider:  "fake",
+		ChargeID:  "ch-1",
+		Method:    payment.MethodQRIS,
+		AmountIDR: order.TotalIDR,
+		Status:    payment.StatusPending,
+		ExpiresAt: baseTime.Add(time.Hour),
+	}
+	require.NoError(t, models.Payment.Insert(&p))
+	assert.Error(t, models.Payment.Insert(&data.Payment{OrderID: order.ID, Provider: "fake", ChargeID: "ch-2"}))
+
+	orderStatus := func() string {
+		got, err := models.Order.Get(order.ID)
+		require.NoError(t, err)
+		return got.Status
+	}
+
+	t.Run("lists pending payments", func(t *testing.T) {
+		pending, err := models.Payment.Pending(time.Now().Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, "ch-1", pending[0].ChargeID)
+	})
+
+	t.Run("refuses a paid event for another amount", func(t *testing.T) {
+		event := &data.PaymentEvent{Provider: "fake", EventID: "evt-0", ChargeID: "ch-1", Status: payment.StatusPaid, Amount: pricing.Rupiah(100), OccurredAt: baseTime}
+
+		got, applied, err := models.Payment.ApplyEvent(event)
+		assert.ErrorIs(t, err, data.ErrPaymentAmountMismatch)
+		assert.False(t, applied)
+		assert.Equal(t, payment.StatusPending, got.Status)
+		assert.Equal(t, data.OrderStatusPendingPayment, orderStatus())
+
+		_, applied, err = models.Payment.ApplyEvent(event)
+		require.NoError(t, err)
+		assert.False(t, applied)
+	})
+
+	t.Run("applies each event once", func(t *testing.T) {
+		event := &data.PaymentEvent{Provider: "fake", EventID: "evt-1", ChargeID: "ch-1", Status: payment.StatusPaid, Amount: order.TotalIDR, OccurredAt: baseTime}
+
+		got, applied, err := models.Payment.ApplyEvent(event)
+		require.NoError(t, err)
+		assert.True(t, applied)
+		assert.Equal(t, payment.StatusPaid, got.Status)
+		assert.Equal(t, &baseTime, got.PaidAt)
+		assert.Equal(t, data.OrderStatusPaid, orderStatus())
+
+		_, applied, err = models.Payment.ApplyEvent(event)
+		require.NoError(t, err)
+		assert.False(t, applied)
+	})
+
+	t.Run("ignores invalid transitions", func(t *testing.T) {
+		_, applied, err := models.Payment.ApplyEvent(&data.PaymentEvent{Provider: "fake", EventID: "evt-2", ChargeID: "ch-1", Status: payment.StatusExpired})
+		require.NoError(t, err)
+		assert.False(t, applied)
+		assert.Equal(t, data.OrderStatusPaid, orderStatus())
+	})
+
+	t.Run("refunds the order", func(t *testing.T) {
+		_, applied, err := models.Payment.ApplyEvent(&data.PaymentEvent{Provider: "fake", EventID: "evt-3", ChargeID: "ch-1", Status: payment.StatusRefunded})
+		require.NoError(t, err)
+		assert.True(t, applied)
+		assert.Equal(t, data.OrderStatusRefunded, orderStatus())
+	})
+
+	t.Run("unknown charge", func(t *testing.T) {
+		_, _, err := models.Payment.ApplyEvent(&data.PaymentEvent{Provider: "fake", EventID: "evt-4", ChargeID: "ch-404", Status: payment.StatusPaid})
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
+
+func TestStockModel(t *testing.T) {
+	s := New()
+	s.AddProduct(data.Product{ID: "p-1", Name: "Radio Pass", Active: true})
+	s.AddSupplierAccount(data.SupplierAccount{ID: "acct-1", Name: "Main", MaxConcurrency: 1, Active: true}, 1000)
+	s.AddSupplierAccount(data.SupplierAccount{ID: "acct-2", Name: "Backup", MaxConcurrency: 1, Active: true}, 300)
+	models := s.Models()
+
+	balance := func(t *testing.T, id string) *data.StockBalance {
+		t.Helper()
+		b, err := models.Stock.Balance(id)
+		require.NoError(t, err)
+		return b
+	}
+
+	t.Run("reserves from the account with the most available", func(t *testing.T) {
+		order := data.Order{ProductID: "p-1", RobloxUsername: "Roblox_Fan99", Quantity: 1, Robux: 400}
+		require.NoError(t, models.Order.Insert(&order, baseTime.Add(time.Hour)))
+
+		assert.Equal(t, "acct-1", *order.Delivery.SupplierAccountID)
+		b := balance(t, "acct-1")
+		assert.Equal(t, 600, b.Available)
+		assert.Equal(t, 400, b.Reserved)
+		assert.Equal(t, 1000, b.OnHand)
+
+		available, err := models.Stock.Available()
+		require.NoError(t, err)
+		assert.Equal(t, 600, available)
+	})
+
+	t.Run("rejects orders no account can cover", func(t *testing.T) {
+		order := data.Order{ProductID: "p-1", RobloxUsername: "Roblox_Fan99", Quantity: 1, Robux: 700}
+		assert.ErrorIs(t, models.Order.Insert(&order, baseTime.Add(time.Hour)), data.ErrOutOfStock)
+	})
+
+	t.Run("releases expired reservations", func(t *testing.T) {
+		released, err := models.Stock.ReleaseExpired(baseTime.Add(2 * time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, released)
+		assert.Equal(t, 1000, balance(t, "acct-1").Available)
+
+		released, err = models.Stock.ReleaseExpired(baseTime.Add(2 * time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, released)
+	})
+
+	t.Run("consumes reservations on delivery", func(t *testing.T) {
+		order := data.Order{ProductID: "p-1", RobloxUsername: "Roblox_Fan99", Quantity: 1, Robux: 250}
+		require.NoError(t, models.Order.Insert(&order, baseTime.Add(time.Hour)))
+		require.NoError(t, models.Payment.Insert(&data.Payment{OrderID: order.ID, Provider: "fake", ChargeID: "ch-stock", Status: payment.StatusPending}))
+		_, _, err := models.Payment.ApplyEvent(&data.PaymentEvent{Provider: "fake", EventID: "evt-stock", ChargeID: "ch-stock", Status: payment.StatusPaid, OccurredAt: baseTime})
+		require.NoError(t, err)
+
+		reservation, err := models.Stock.Reservation(order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, data.ReservationHeld, reservation.Status)
+		assert.Nil(t, reservation.ExpiresAt)
+
+		_, err = models.Order.Transition(order.ID, data.OrderChange{To: data.OrderStatusDelivered, Actor: fulfillment.ActorAdmin, Reference: "gp-1"})
+		require.NoError(t, err)
+
+		b := balance(t, "acct-1")
+		assert.Equal(t, 750, b.Available)
+		assert.Zero(t, b.Reserved)
+		assert.Equal(t, 250, b.Delivered)
+		assert.Equal(t, 750, b.OnHand)
+	})
+
+	t.Run("reconciles with the roblox balance", func(t *testing.T) {
+		rec, err := models.Stock.Reconcile("acct-1", 700, "counted")
+		require.NoError(t, err)
+		assert.Equal(t, 750, rec.Expected)
+		assert.Equal(t, -50, rec.Difference)
+		require.NotNil(t, rec.Entry)
+		assert.Equal(t, data.LedgerAdjustment, rec.Entry.Debit)
+		assert.Equal(t, 700, rec.Balance.Available)
+		assert.Equal(t, -50, rec.Balance.Adjusted)
+
+		rec, err = models.Stock.Reconcile("acct-1", 700, "")
+		require.NoError(t, err)
+		assert.Nil(t, rec.Entry)
+
+		_, err = models.Stock.Reconcile("missing", 0, "")
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("lists the ledger newest first", func(t *testing.T) {
+		entries, err := models.Stock.Entries("acct-1", 100)
+		require.NoError(t, err)
+
+		var kinds []string
+		for _, e := range entries {
+			kinds = append(kinds, e.Kind)
+		}
+		assert.Equal(t, []string{"adjustment", "consumption", "reservation", "release", "reservation", "purchase"}, kinds)
+	})
+}
+
+func TestVoucherModel(t *testing.T) {
+	s := New()
+	s.AddProduct(data.Product{ID: "p-1", Name: "Radio Pass", Category: data.ProductCategoryGamepass, Active: true})
+	s.AddSupplierAccount(data.SupplierAccount{ID: "acct-1", Name: "Main", MaxConcurrency: 1, Active: true}, 10000)
+	models := s.Models()
+	product, err := models.Product.Get("p-1")
+	require.NoError(t, err)
+
+	voucher := data.Voucher{Code: " mayo10 ", PercentOff: utility.SetPtrValue(10), ProductIDs: []string{"p-1"}, UsageLimit: utility.SetPtrValue(2), PerUserLimit: utility.SetPtrValue(1), Active: true}
+	require.NoError(t, models.Voucher.Insert(&voucher))
+
+	order := func(username string) data.Order {
+		return data.Order{ProductID: "p-1", RobloxUsername: username, Quantity: 1, Robux: 100, SubtotalIDR: pricing.Rupiah(20000), VoucherID: &voucher.ID}
+	}
+
+	t.Run("stores codes upper-case and unique", func(t *testing.T) {
+		assert.Equal(t, "MAYO10", voucher.Code)
+
+		err := models.Voucher.Insert(&data.Voucher{Code: "Mayo10", AmountOffIDR: utility.SetPtrValue(pricing.Rupiah(1000)), Active: true})
+		assert.ErrorIs(t, err, data.ErrDuplicateVoucherCode)
+
+		err = models.Voucher.Insert(&data.Voucher{Code: "GHOST", PercentOff: utility.SetPtrValue(5), ProductIDs: []string{"missing"}, Active: true})
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("applies by code", func(t *testing.T) {
+		got, discount, err := models.Voucher.Apply("mayo10", data.VoucherUse{Product: product, RobloxUsername: "Roblox_Fan99", SubtotalIDR: pricing.Rupiah(20000), At: baseTime})
+		require.NoError(t, err)
+		assert.Equal(t, voucher.ID, got.ID)
+		assert.Equal(t, pricing.Rupiah(2000), discount)
+
+		_, _, err = models.Voucher.Apply("NOPE", data.VoucherUse{Product: product, At: baseTime})
+		var voucherErr *data.VoucherError
+		require.ErrorAs(t, err, &voucherErr)
+		assert.Equal(t, data.VoucherNotFound, voucherErr.Reason)
+	})
+
+	t.Run("redeems with the order", func(t *testing.T) {
+		first := order("Roblox_Fan99")
+		require.NoError(t, models.Order.Insert(&first, baseTime.Add(time.Hour)))
+		assert.Equal(t, pricing.Rupiah(2000), first.DiscountIDR)
+		assert.Equal(t, pricing.Rupiah(18000), first.TotalIDR)
+
+		again := order("roblox_fan99")
+		var voucherErr *data.VoucherError
+		require.ErrorAs(t, models.Order.Insert(&again, baseTime.Add(time.Hour)), &voucherErr)
+		assert.Equal(t, data.VoucherUserLimit, voucherErr.Reason)
+
+		second := order("builderman")
+		require.NoError(t, models.Order.Insert(&second, baseTime.Add(time.Hour)))
+
+		third := order("guest")
+		require.ErrorAs(t, models.Order.Insert(&third, baseTime.Add(time.Hour)), &voucherErr)
+		assert.Equal(t, data.VoucherUsedUp, voucherErr.Reason)
+
+		got, err := models.Voucher.Get(voucher.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got.Redemptions)
+
+		// A failed order gives its redemption back.
+		_, err = models.Order.Transition(first.ID, data.OrderChange{To: data.OrderStatusFailed, Actor: fulfillment.ActorPayment})
+		require.NoError(t, err)
+		require.NoError(t, models.Order.Insert(&third, baseTime.Add(time.Hour)))
+	})
+
+	t.Run("updates every field", func(t *testing.T) {
+		voucher.PercentOff = nil
+		voucher.AmountOffIDR = utility.SetPtrValue(pricing.Rupiah(3000))
+		voucher.ProductIDs = nil
+		require.NoError(t, models.Voucher.Update(&voucher))
+		assert.Empty(t, voucher.ProductIDs)
+		assert.Equal(t, 2, voucher.Redemptions)
+
+		err := models.Voucher.Update(&data.Voucher{ID: "missing", Code: "GONE"})
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
+
+func TestFlashSaleModel(t *testing.T) {
+	s := New()
+	s.now = func() time.Time { return baseTime }
+	s.AddProduct(data.Product{ID: "p-1", Name: "VIP", Category: data.ProductCategoryGamepass, Active: true})
+	s.AddProduct(data.Product{ID: "p-2", Name: "Radio Pass", Category: data.ProductCategoryGamepass, Active: true})
+	s.AddSupplierAccount(data.SupplierAccount{ID: "acct-1", Name: "Main", MaxConcurrency: 1, Active: true}, 10000)
+	models := s.Models()
+
+	sale := data.FlashSale{
+		Name:     "Payday",
+		StartsAt: baseTime.Add(-time.Hour),
+		EndsAt:   baseTime.Add(time.Hour),
+		Active:   true,
+		Items: []*data.FlashSaleItem{
+			{ProductID: "p-2", PriceIDR: pricing.Rupiah(30000), Quantity: 5},
+			{ProductID: "p-1", PriceIDR: pricing.Rupiah(20000), Quantity: 3},
+		},
+	}
+	require.NoError(t, models.FlashSale.Insert(&sale))
+	upcoming := data.FlashSale{Name: "Tomorrow", StartsAt: baseTime.Add(time.Hour), EndsAt: baseTime.Add(2 * time.Hour), Active: true}
+	require.NoError(t, models.FlashSale.Insert(&upcoming))
+	item := sale.Items[0]
+
+	order := func(quantity int) data.Order {
+		return data.Order{ProductID: "p-1", RobloxUsername: "builderman", Quantity: quantity, Robux: 100 * quantity, SubtotalIDR: pricing.Rupiah(90000), FlashSaleItemID: &item.ID}
+	}
+
+	t.Run("stores the items cheapest first", func(t *testing.T) {
+		require.Len(t, sale.Items, 2)
+		assert.Equal(t, "p-1", item.ProductID)
+		assert.Equal(t, sale.ID, item.FlashSaleID)
+
+		err := models.FlashSale.Insert(&data.FlashSale{Name: "Ghost", Items: []*data.FlashSaleItem{{ProductID: "missing", PriceIDR: 100, Quantity: 1}}})
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+
+	t.Run("lists the live sales", func(t *testing.T) {
+		live, err := models.FlashSale.Live(baseTime)
+		require.NoError(t, err)
+		require.Len(t, live, 1)
+		assert.Equal(t, sale.ID, live[0].ID)
+
+		all, err := models.FlashSale.GetAll()
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, upcoming.ID, all[0].ID)
+	})
+
+	t.Run("prices orders at the sale price while units remain", func(t *testing.T) {
+		first := order(2)
+		require.NoError(t, models.Order.Insert(&first, baseTime.Add(time.Hour)))
+		assert.Equal(t, pricing.Rupiah(40000), first.SubtotalIDR)
+		assert.Equal(t, pricing.Rupiah(40000), first.TotalIDR)
+
+		second := order(2)
+		assert.ErrorIs(t, models.Order.Insert(&second, baseTime.Add(time.Hour)), data.ErrFlashSaleSoldOut)
+
+		got, err := models.FlashSale.Get(sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got.Items[0].Sold)
+
+		// A failed order gives its units back.
+		_, err = models.Order.Transition(first.ID, data.OrderChange{To: data.OrderStatusFailed, Actor: fulfillment.ActorPayment})
+		require.NoError(t, err)
+		require.NoError(t, models.Order.Insert(&second, baseTime.Add(time.Hour)))
+	})
+
+	t.Run("leaves the units when the order cannot be stored", func(t *testing.T) {
+		big := order(1)
+		big.Robux = 100000
+		assert.ErrorIs(t, models.Order.Insert(&big, baseTime.Add(time.Hour)), data.ErrOutOfStock)
+
+		got, err := models.FlashSale.Get(sale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got.Items[0].Sold)
+	})
+
+	t.Run("rejects ended sales", func(t *testing.T) {
+		s.now = func() time.Time { return baseTime.Add(time.Hour) }
+		defer func() { s.now = func() time.Time { return baseTime } }()
+
+		late := order(1)
+		assert.ErrorIs(t, models.Order.Insert(&late, baseTime.Add(2*time.Hour)), data.ErrFlashSaleSoldOut)
+	})
+
+	t.Run("updates keeping the units sold", func(t *testing.T) {
+		sale.Items = []*data.FlashSaleItem{{ProductID: "p-1", PriceIDR: pricing.Rupiah(25000), Quantity: 10}}
+		require.NoError(t, models.FlashSale.Update(&sale))
+		require.Len(t, sale.Items, 1)
+		assert.Equal(t, item.ID, sale.Items[0].ID)
+		assert.Equal(t, 2, sale.Items[0].Sold)
+		assert.Equal(t, pricing.Rupiah(25000), sale.Items[0].PriceIDR)
+
+		err := models.FlashSale.Update(&data.FlashSale{ID: "missing"})
+		assert.ErrorIs(t, err, data.ErrRecordNotFound)
+	})
+}
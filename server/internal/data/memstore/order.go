@@ -0,0 +1,304 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+)
+
+type OrderModel struct {
+	store *Store
+}
+
+func (m OrderModel) Insert(order *data.Order, reserveUntil time.Time) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	// REFERENCES products(id)
+	if _, ok := m.store.products[order.ProductID]; !ok {
+		return errForeignKey
+	}
+
+	now := m.store.now()
+	order.ID = newID()
+	order.Status = data.OrderStatusPendingPayment
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	if err := m.store.claimFlashSale(order); err != nil {
+		return err
+	}
+	if err := m.store.redeemVoucher(order); err != nil {
+		return err
+	}
+	if err := m.store.reserveStock(order, reserveUntil); err != nil {
+		return err
+	}
+	m.store.takeFlashSale(order)
+	m.store.orders = append(m.store.orders, *order)
+	m.store.orderTransitions = append(m.store.orderTransitions, data.OrderTransition{
+		ID:        newID(),
+		OrderID:   order.ID,
+		ToStatus:  order.Status,
+		Actor:     fulfillment.ActorCustomer,
+		CreatedAt: now,
+	})
+	return nil
+}
+
+func (m OrderModel) Get(id string) (*data.Order, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	i := m.store.orderIndex(id)
+	if i == -1 {
+		return nil, data.ErrRecordNotFound
+	}
+	order := m.store.orders[i]
+	return &order, nil
+}
+
+func (m OrderModel) Transition(id string, change data.OrderChange) (*data.Order, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	return m.store.transitionOrder(id, change)
+}
+
+// transitionOrder is OrderModel.Transition, shared with the payment events
+// that move orders. The caller must hold the lock.
+func (s *Store) transitionOrder(id string, change data.OrderChange) (*data.Order, error) {
+	i := s.orderIndex(id)
+	if i == -1 {
+		return nil, data.ErrRecordNotFound
+	}
+	order := &s.orders[i]
+
+	paid := slices.ContainsFunc(s.payments, func(p data.Payment) bool { return p.OrderID == id && p.PaidAt != nil })
+	if err := fulfillment.Check(fulfillment.State{Status: order.Status, Paid: paid}, change.To, change.Actor); err != nil {
+		return nil, err
+	}
+	from := order.Status
+
+	now := s.now()
+	order.Status = change.To
+	order.DeliveredAt = nil
+	if change.To == data.OrderStatusDelivered {
+		order.DeliveredAt = &now
+	}
+	if change.ResetAttempts {
+		order.Delivery.Attempts = 0
+		order.Delivery.LastError = nil
+	}
+	if change.StartAttempt {
+		order.Delivery.Attempts++
+	}
+	order.Delivery.NextAttemptAt = change.RetryAt
+	if change.Error != "" {
+		order.Delivery.LastError = &change.Error
+	}
+	if change.Reference != "" {
+		order.Delivery.Reference = &change.Reference
+	}
+	order.UpdatedAt = now
+
+	transition := data.OrderTransition{
+		ID:         newID(),
+		OrderID:    id,
+		FromStatus: &from,
+		ToStatus:   change.To,
+		Actor:      change.Actor,
+		CreatedAt:  now,
+	}
+	if change.Reason != "" {
+		transition.Reason = &change.Reason
+	}
+	s.orderTransitions = append(s.orderTransitions, transition)
+	s.syncReservation(order)
+	s.syncFlashSale(order, from)
+
+	if from == data.OrderStatusFailed {
+		for i := range s.deadLetters {
+			if s.deadLetters[i].OrderID == id && s.deadLetters[i].ResolvedAt == nil {
+				s.deadLetters[i].ResolvedAt = &now
+			}
+		}
+	}
+	if change.DeadLetter {
+		letter := data.DeadLetter{
+			ID:                newID(),
+			OrderID:           id,
+			SupplierAccountID: order.Delivery.SupplierAccountID,
+			Attempts:          order.Delivery.Attempts,
+			CreatedAt:         now,
+		}
+		if order.Delivery.LastError != nil {
+			letter.Error = *order.Delivery.LastError
+		}
+		s.deadLetters = append(s.deadLetters, letter)
+	}
+
+	copied := *order
+	return &copied, nil
+}
+
+func (m OrderModel) ClaimForDelivery(supplierAccountID string, now time.Time, limit int) ([]*data.Order, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	due := m.store.filterOrders(func(o data.Order) bool {
+		return o.Status == data.OrderStatusPaid && (o.Delivery.NextAttemptAt == nil || !o.Delivery.NextAttemptAt.After(now)) &&
+			(o.Delivery.SupplierAccountID == nil || *o.Delivery.SupplierAccountID == supplierAccountID)
+	})
+
+	// ORDER BY created_at ASC, id ASC
+	slices.SortFunc(due, func(a, b *data.Order) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	claimed := []*data.Order{}
+	for _, order := range due[:min(limit, len(due))] {
+		order, err := m.store.transitionOrder(order.ID, data.OrderChange{
+			To:           data.OrderStatusDelivering,
+			Actor:        fulfillment.ActorSystem,
+			StartAttempt: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		i := m.store.orderIndex(order.ID)
+		m.store.orders[i].Delivery.SupplierAccountID = &supplierAccountID
+		order.Delivery.SupplierAccountID = &supplierAccountID
+		claimed = append(claimed, order)
+	}
+	return claimed, nil
+}
+
+func (m OrderModel) ListByStatus(status string, limit int) ([]*data.Order, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	orders := m.store.filterOrders(func(o data.Order) bool { return status == "" || o.Status == status })
+
+	// ORDER BY updated_at ASC, id ASC
+	slices.SortFunc(orders, func(a, b *data.Order) int {
+		return cmp.Or(a.UpdatedAt.Compare(b.UpdatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return orders[:min(limit, len(orders))], nil
+}
+
+func (m OrderModel) History(id string) ([]*data.OrderTransition, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	// Appended in created_at order.
+	transitions := []*data.OrderTransition{}
+	for _, t := range m.store.orderTransitions {
+		if t.OrderID == id {
+			transitions = append(transitions, &t)
+		}
+	}
+	return transitions, nil
+}
+
+func (m OrderModel) DeadLetters(open bool, limit int) ([]*data.DeadLetter, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	letters := []*data.DeadLetter{}
+	for _, l := range m.store.deadLetters {
+		if !open || l.ResolvedAt == nil {
+			letters = append(letters, &l)
+		}
+	}
+	// ORDER BY created_at DESC, id DESC
+	slices.SortFunc(letters, func(a, b *data.DeadLetter) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return letters[:min(limit, len(letters))], nil
+}
+
+// filterOrders returns copies of the orders matching keep. The caller must
+// hold the lock.
+func (s *Store) filterOrders(keep func(data.Order) bool) []*data.Order {
+	orders := []*data.Order{}
+	for _, order := range s.orders {
+		if keep(order) {
+			orders = append(orders, &order)
+		}
+	}
+	return orders
+}
+
+// orderIndex returns the index of order id in s.orders, -1 if there is
+// none. The caller must hold the lock.
+func (s *Store) orderIndex(id string) int {
+	return slices.IndexFunc(s.orders, func(o data.Order) bool { return o.ID == id })
+}
+
+func (m OrderModel) RecentSales(limit int) ([]*data.Sale, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	sales := m.store.sales(func(*data.Sale) bool { return true })
+	// ORDER BY o.delivered_at DESC, o.id DESC
+	slices.Reverse(sales)
+	return sales[:min(limit, len(sales))], nil
+}
+
+func (m OrderModel) SalesSince(since time.Time, limit int) ([]*data.Sale, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	sales := m.store.sales(func(s *data.Sale) bool { return !s.DeliveredAt.Before(since) })
+	return sales[:min(limit, len(sales))], nil
+}
+
+func (m OrderModel) SalesAfter(orderID string, limit int) ([]*data.Sale, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	all := m.store.sales(func(*data.Sale) bool { return true })
+	i := slices.IndexFunc(all, func(s *data.Sale) bool { return s.OrderID == orderID })
+	if i == -1 {
+		return nil, data.ErrRecordNotFound
+	}
+	sales := all[i+1:]
+	return sales[:min(limit, len(sales))], nil
+}
+
+// sales returns the delivered orders matching keep with their products,
+// oldest first. The caller must hold the lock.
+func (s *Store) sales(keep func(*data.Sale) bool) []*data.Sale {
+	sales := []*data.Sale{}
+	for _, order := range s.orders {
+		if order.Status != data.OrderStatusDelivered || order.DeliveredAt == nil {
+			continue
+		}
+		// JOIN products
+		product, ok := s.products[order.ProductID]
+		if !ok {
+			continue
+		}
+		sale := &data.Sale{
+			OrderID:        order.ID,
+			RobloxUsername: order.RobloxUsername,
+			Quantity:       order.Quantity,
+			Robux:          order.Robux,
+			TotalIDR:       order.TotalIDR,
+			DeliveredAt:    *order.DeliveredAt,
+			Product:        &product,
+		}
+		if keep(sale) {
+			sales = append(sales, sale)
+		}
+	}
+
+	// ORDER BY o.delivered_at ASC, o.id ASC
+	slices.SortFunc(sales, func(a, b *data.Sale) int {
+		return cmp.Or(a.DeliveredAt.Compare(b.DeliveredAt), cmp.Compare(a.OrderID, b.OrderID))
+	})
+	return sales
+}
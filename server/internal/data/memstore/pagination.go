@@ -0,0 +1,14 @@
+package memstore
+
+// paginate returns the requested page and the total number of rows, with
+// the semantics of `count(*) OVER() ... LIMIT $1 OFFSET $2`: a page past
+// the end has no rows, so it also reports zero total records.
+func paginate[T any](rows []T, page, pageSize int) ([]T, int) {
+	offset := (page - 1) * pageSize
+	if offset < 0 || offset >= len(rows) {
+		return nil, 0
+	}
+
+	end := min(offset+pageSize, len(rows))
+	return rows[offset:end], len(rows)
+}
@@ -0,0 +1,114 @@
+package memstore
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+)
+
+type PaymentModel struct {
+	store *Store
+}
+
+func (m PaymentModel) Insert(p *data.Payment) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	// REFERENCES orders(id), UNIQUE (order_id), UNIQUE (provider, charge_id)
+	if m.store.orderIndex(p.OrderID) == -1 {
+		return errForeignKey
+	}
+	for _, existing := range m.store.payments {
+		if existing.OrderID == p.OrderID || (existing.Provider == p.Provider && existing.ChargeID == p.ChargeID) {
+			return errUniqueViolation
+		}
+	}
+
+	now := m.store.now()
+	p.ID = newID()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	m.store.payments = append(m.store.payments, *p)
+	return nil
+}
+
+func (m PaymentModel) GetByOrderID(orderID string) (*data.Payment, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	for _, p := range m.store.payments {
+		if p.OrderID == orderID {
+			return &p, nil
+		}
+	}
+	return nil, data.ErrRecordNotFound
+}
+
+func (m PaymentModel) Pending(before time.Time, limit int) ([]*data.Payment, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	payments := []*data.Payment{}
+	for _, p := range m.store.payments {
+		if p.Status == payment.StatusPending && p.CreatedAt.Before(before) {
+			payments = append(payments, &p)
+		}
+	}
+
+	// ORDER BY created_at ASC
+	slices.SortStableFunc(payments, func(a, b *data.Payment) int {
+		return cmp.Compare(a.CreatedAt.UnixNano(), b.CreatedAt.UnixNano())
+	})
+	return payments[:min(limit, len(payments))], nil
+}
+
+func (m PaymentModel) ApplyEvent(event *data.PaymentEvent) (*data.Payment, bool, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	i := slices.IndexFunc(m.store.payments, func(p data.Payment) bool {
+		return p.Provider == event.Provider && p.ChargeID == event.ChargeID
+	})
+	if i == -1 {
+		return nil, false, data.ErrRecordNotFound
+	}
+	p := &m.store.payments[i]
+
+	id := paymentEventID{event.Provider, event.EventID}
+	if _, seen := m.store.paymentEvents[id]; seen {
+		copied := *p
+		return &copied, false, nil
+	}
+	m.store.paymentEvents[id] = struct{}{}
+
+	if !payment.CanTransition(p.Status, event.Status) {
+		copied := *p
+		return &copied, false, nil
+	}
+	if event.Status == payment.StatusPaid && event.Amount != p.AmountIDR {
+		copied := *p
+		return &copied, false, data.ErrPaymentAmountMismatch
+	}
+
+	now := m.store.now()
+	p.Status = event.Status
+	if event.Status == payment.StatusPaid {
+		paidAt := event.OccurredAt
+		p.PaidAt = &paidAt
+	}
+	p.UpdatedAt = now
+
+	change := data.OrderChange{To: data.OrderStatusOnPayment(event.Status), Actor: fulfillment.ActorPayment, Reason: "payment event " + event.EventID}
+	_, err := m.store.transitionOrder(p.OrderID, change)
+	if err != nil && !errors.Is(err, fulfillment.ErrInvalidTransition) && !errors.Is(err, fulfillment.ErrNotPaid) {
+		return nil, false, err
+	}
+
+	copied := *p
+	return &copied, true, nil
+}
@@ -0,0 +1,35 @@
+package memstore
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+type RateTableModel struct {
+	store *Store
+}
+
+func (m RateTableModel) Current(at time.Time) (*pricing.Table, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	var current *pricing.Table
+	for i, table := range m.store.rateTables {
+		if table.EffectiveFrom.After(at) {
+			continue
+		}
+		if current == nil || table.EffectiveFrom.After(current.EffectiveFrom) {
+			current = &m.store.rateTables[i]
+		}
+	}
+	if current == nil {
+		return nil, data.ErrRecordNotFound
+	}
+
+	table := *current
+	table.Tiers = slices.Clone(table.Tiers)
+	return &table, nil
+}
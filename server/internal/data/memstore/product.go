@@ -0,0 +1,41 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type ProductModel struct {
+	store *Store
+}
+
+func (m ProductModel) GetAllActive() ([]*data.Product, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	products := []*data.Product{}
+	for _, product := range m.store.products {
+		if product.Active {
+			products = append(products, &product)
+		}
+	}
+
+	// ORDER BY name ASC, id ASC
+	slices.SortFunc(products, func(a, b *data.Product) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return products, nil
+}
+
+func (m ProductModel) Get(id string) (*data.Product, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	product, ok := m.store.products[id]
+	if !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return &product, nil
+}
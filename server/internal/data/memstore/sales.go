@@ -0,0 +1,71 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+type SalesModel struct {
+	store *Store
+}
+
+func (m SalesModel) Refresh(upto time.Time) (int, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if !m.store.salesThrough.Before(upto) {
+		return 0, nil
+	}
+
+	added := 0
+	for _, order := range m.store.orders {
+		if order.Status != data.OrderStatusDelivered || order.DeliveredAt == nil {
+			continue
+		}
+		at := *order.DeliveredAt
+		if !at.After(m.store.salesThrough) || at.After(upto) {
+			continue
+		}
+		key := salesKey{order.ProductID, at.In(utility.Jakarta).Format(time.DateOnly)}
+		m.store.productSales[key] += order.Quantity
+		added++
+	}
+	m.store.salesThrough = upto
+	return added, nil
+}
+
+func (m SalesModel) BestSellers(from string, limit int) ([]*data.BestSeller, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	sold := make(map[string]int)
+	for key, n := range m.store.productSales {
+		if from == "" || key.date >= from {
+			sold[key.productID] += n
+		}
+	}
+
+	sellers := []*data.BestSeller{}
+	for id, n := range sold {
+		// JOIN products WHERE p.active AND s.sold > 0
+		product, ok := m.store.products[id]
+		if !ok || !product.Active || n <= 0 {
+			continue
+		}
+		sellers = append(sellers, &data.BestSeller{Product: &product, Sold: n})
+	}
+
+	// ORDER BY s.sold DESC, p.name ASC, p.id ASC
+	slices.SortFunc(sellers, func(a, b *data.BestSeller) int {
+		return cmp.Or(
+			cmp.Compare(b.Sold, a.Sold),
+			cmp.Compare(a.Product.Name, b.Product.Name),
+			cmp.Compare(a.Product.ID, b.Product.ID),
+		)
+	})
+	return sellers[:min(limit, len(sellers))], nil
+}
@@ -0,0 +1,212 @@
+package memstore
+
+import (
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// NewSeeded returns a Store with the demo data of the
+// seed_user_testimoni_and_faq, rate_tables_schema, products_schema and
+// later migrations, so --storage=memory serves the same responses as a freshly
+// migrated database.
+func NewSeeded() *Store {
+	s := New()
+	now := time.Now().UTC()
+
+	s.AddUser(data.User{
+		ID:          "550e8400-e29b-41d4-a716-446655440001",
+		Username:    "RobloxMaster99",
+		Email:       "robloxmaster99@email.com",
+		ImageUrl:    "/black-hair-boy.png",
+		AddressLine: "Jl. Sudirman No. 123",
+		City:        "Jakarta Selatan",
+		Province:    "DKI Jakarta",
+		PostalCode:  "12190",
+		Country:     "Indonesia",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	s.AddUser(data.User{
+		ID:          "550e8400-e29b-41d4-a716-446655440002",
+		Username:    "GamerGirl2024",
+		Email:       "gamergirl2024@email.com",
+		ImageUrl:    "/black-hair-boy.png",
+		AddressLine: "Jl. Gatot Subroto No. 45",
+		City:        "Surabaya",
+		Province:    "Jawa Timur",
+		PostalCode:  "60271",
+		Country:     "Indonesia",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	s.AddUser(data.User{
+		ID:          "550e8400-e29b-41d4-a716-446655440003",
+		Username:    "ProPlayer88",
+		Email:       "proplayer88@email.com",
+		ImageUrl:    "/black-hair-boy.png",
+		AddressLine: "Jl. Raya Bandung No. 78",
+		City:        "Bandung",
+		Province:    "Jawa Barat",
+		PostalCode:  "40115",
+		Country:     "Indonesia",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	s.AddUser(data.User{
+		ID:          "550e8400-e29b-41d4-a716-446655440004",
+		Username:    "RobuxHunter",
+		Email:       "robuxhunter@email.com",
+		ImageUrl:    "/black-hair-boy.png",
+		AddressLine: "Jl. Diponegoro No. 234",
+		City:        "Semarang",
+		Province:    "Jawa Tengah",
+		PostalCode:  "50241",
+		Country:     "Indonesia",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	s.AddUser(data.User{
+		ID:          "550e8400-e29b-41d4-a716-446655440005",
+		Username:    "BuilderKing",
+		Email:       "builderking@email.com",
+		ImageUrl:    "/black-hair-boy.png",
+		AddressLine: "Jl. Ahmad Yani No. 567",
+		City:        "Medan",
+		Province:    "Sumatera Utara",
+		PostalCode:  "20151",
+		Country:     "Indonesia",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+
+	s.AddTestimoni(data.Testimoni{
+		ID:        "660e8400-e29b-41d4-a716-446655440001",
+		UserID:    "550e8400-e29b-41d4-a716-446655440001",
+		Testimoni: "Mayobox is the best! I bought Robux here and the process was super fast. Within minutes, my Robux was already in my account. Highly recommended for all Roblox players!",
+		IconURL:   "/mayo-testimoni-icon-1.png",
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	s.AddTestimoni(data.Testimoni{
+		ID:        "660e8400-e29b-41d4-a716-446655440002",
+		UserID:    "550e8400-e29b-41d4-a716-446655440002",
+		Testimoni: "Amazing service! The prices are competitive and customer support is very responsive. I had a question about my order and they helped me right away. Will definitely buy again!",
+		IconURL:   "/mayo-testimoni-icon-2.png",
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	s.AddTestimoni(data.Testimoni{
+		ID:        "660e8400-e29b-41d4-a716-446655440003",
+		UserID:    "550e8400-e29b-41d4-a716-446655440003",
+		Testimoni: "Trusted seller! I have been using Mayobox for 6 months now and never had any issues. The transaction is secure and they always deliver on time. Five stars!",
+		IconURL:   "/mayo-testimoni-icon-1.png",
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	s.AddTestimoni(data.Testimoni{
+		ID:        "660e8400-e29b-41d4-a716-446655440004",
+		UserID:    "550e8400-e29b-41d4-a716-446655440004",
+		Testimoni: "Fast and reliable! I needed Robux urgently for a limited item and Mayobox delivered in less than 5 minutes. The payment methods are also very convenient. Thank you Mayobox!",
+		IconURL:   "/mayo-testimoni-icon-2.png",
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	s.AddTestimoni(data.Testimoni{
+		ID:        "660e8400-e29b-41d4-a716-446655440005",
+		UserID:    "550e8400-e29b-41d4-a716-446655440005",
+		Testimoni: "Great experience overall! The website is easy to navigate and the checkout process is smooth. I appreciate the transparent pricing with no hidden fees. Definitely my go-to store for Roblox items!",
+		IconURL:   "/mayo-testimoni-icon-1.png",
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+
+	s.AddFAQ(
+		data.FAQ{ID: "770e8400-e29b-41d4-a716-446655440001", Question: "How long does it take to receive my Robux after payment?", DisplayOrder: 1, CreatedAt: now, UpdatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440001", Short: "Usually 5-15 minutes", Long: "Most orders are processed automatically and delivered within 5-15 minutes after payment confirmation. During peak hours, it may take up to 30 minutes.", DisplayOrder: 1, CreatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440002", Short: "Instant for most cases", Long: "We use an automated system that delivers your Robux instantly in most cases. However, manual verification may be required for first-time buyers or large orders, which can take up to 1 hour.", DisplayOrder: 2, CreatedAt: now},
+	)
+	s.AddFAQ(
+		data.FAQ{ID: "770e8400-e29b-41d4-a716-446655440002", Question: "What payment methods do you accept?", DisplayOrder: 2, CreatedAt: now, UpdatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440003", Short: "Bank Transfer, E-Wallet, Credit/Debit Card", Long: "We accept various payment methods including Bank Transfer (BCA, Mandiri, BNI, BRI), E-Wallets (GoPay, OVO, DANA, ShopeePay), and Credit/Debit Cards (Visa, Mastercard).", DisplayOrder: 1, CreatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440004", Short: "QRIS also available", Long: "You can also pay using QRIS for a quick and easy transaction. Simply scan the QR code with your banking app or e-wallet and complete the payment.", DisplayOrder: 2, CreatedAt: now},
+	)
+	s.AddFAQ(
+		data.FAQ{ID: "770e8400-e29b-41d4-a716-446655440003", Question: "Is it safe to buy Robux from Mayobox?", DisplayOrder: 3, CreatedAt: now, UpdatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440005", Short: "Yes, 100% safe and legal", Long: "Mayobox only uses official Roblox methods to deliver Robux. We never ask for your password and all transactions are protected with SSL encryption. Your account safety is our priority.", DisplayOrder: 1, CreatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440006", Short: "Trusted by thousands", Long: "We have served thousands of satisfied customers with a 4.9/5 rating. All our processes comply with Roblox Terms of Service, ensuring your account remains safe.", DisplayOrder: 2, CreatedAt: now},
+	)
+	s.AddFAQ(
+		data.FAQ{ID: "770e8400-e29b-41d4-a716-446655440004", Question: "What should I do if I do not receive my order?", DisplayOrder: 4, CreatedAt: now, UpdatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440007", Short: "Contact our customer support", Long: "If you have not received your order within the estimated time, please contact our customer support through WhatsApp or email with your order ID. We will check and resolve the issue immediately.", DisplayOrder: 1, CreatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440008", Short: "Check your spam folder", Long: "Sometimes, order confirmation emails may land in your spam folder. Please check there first. Also, ensure you provided the correct Roblox username during checkout.", DisplayOrder: 2, CreatedAt: now},
+	)
+	s.AddFAQ(
+		data.FAQ{ID: "770e8400-e29b-41d4-a716-446655440005", Question: "Can I get a refund if I change my mind?", DisplayOrder: 5, CreatedAt: now, UpdatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440009", Short: "Refunds available before delivery", Long: "You can request a refund if the Robux has not been delivered yet. Once delivered, refunds are not possible as Robux cannot be reversed. Please contact support within 1 hour of purchase.", DisplayOrder: 1, CreatedAt: now},
+		data.FAQAnswer{ID: "880e8400-e29b-41d4-a716-446655440010", Short: "Store credit as alternative", Long: "If you are not eligible for a refund, we can offer store credit that you can use for future purchases. This credit never expires and can be used for any product on Mayobox.", DisplayOrder: 2, CreatedAt: now},
+	)
+
+	// rate_tables_schema migration
+	s.AddRateTable(pricing.Table{
+		ID:            "7d3f5a10-1c2b-4e8f-9a6d-5b4c3a2e1f01",
+		EffectiveFrom: time.Date(2026, 1, 1, 0, 0, 0, 0, time.FixedZone("WIB", 7*60*60)),
+		Tiers: []pricing.Tier{
+			{MinRobux: 1, IDRPerRobux: 14000},
+			{MinRobux: 1000, IDRPerRobux: 13700},
+			{MinRobux: 10000, IDRPerRobux: 13500},
+		},
+		RoundingStep: 100,
+		RoundingMode: pricing.RoundNearest,
+	})
+
+	// products_schema migration
+	for _, product := range []data.Product{
+		{ID: "990e8400-e29b-41d4-a716-446655440001", Name: "80 Robux", Category: data.ProductCategoryRobux, Robux: 80, IconURL: "/products/mayo-with-glass.png", FeaturedWeight: 1},
+		{ID: "990e8400-e29b-41d4-a716-446655440002", Name: "400 Robux", Category: data.ProductCategoryRobux, Robux: 400, IconURL: "/products/mayo-with-glass.png", FeaturedWeight: 2},
+		{ID: "990e8400-e29b-41d4-a716-446655440003", Name: "1053 Robux", Category: data.ProductCategoryRobux, Robux: 1053, IconURL: "/products/mayo-with-glass.png", FeaturedWeight: 3},
+		{ID: "990e8400-e29b-41d4-a716-446655440004", Name: "2200 Robux", Category: data.ProductCategoryRobux, Robux: 2200, IconURL: "/products/mayo-with-glass.png", FeaturedWeight: 2},
+		{ID: "990e8400-e29b-41d4-a716-446655440005", Name: "4500 Robux", Category: data.ProductCategoryRobux, Robux: 4500, IconURL: "/products/mayo-with-glass.png", FeaturedWeight: 1},
+		{ID: "990e8400-e29b-41d4-a716-446655440006", Name: "Search & Rescue", Category: data.ProductCategoryGamepass, Robux: 850, IconURL: "/products/sky-people.png", FeaturedWeight: 3},
+		{ID: "990e8400-e29b-41d4-a716-446655440007", Name: "VIP Server Pass", Category: data.ProductCategoryGamepass, Robux: 499, IconURL: "/products/sky-people.png", FeaturedWeight: 2},
+		{ID: "990e8400-e29b-41d4-a716-446655440008", Name: "Double Coins", Category: data.ProductCategoryGamepass, Robux: 199, IconURL: "/products/sky-people.png", FeaturedWeight: 2},
+		{ID: "990e8400-e29b-41d4-a716-446655440009", Name: "Speed Coil", Category: data.ProductCategoryGamepass, Robux: 150, IconURL: "/products/sky-people.png", FeaturedWeight: 1},
+		{ID: "990e8400-e29b-41d4-a716-446655440010", Name: "Pet Slot Expansion", Category: data.ProductCategoryGamepass, Robux: 350, IconURL: "/products/sky-people.png", FeaturedWeight: 1},
+		{ID: "990e8400-e29b-41d4-a716-446655440011", Name: "Radio Pass", Category: data.ProductCategoryGamepass, Robux: 100, IconURL: "/products/sky-people.png", FeaturedWeight: 1},
+		{ID: "990e8400-e29b-41d4-a716-446655440012", Name: "Legendary Crate", Category: data.ProductCategoryGamepass, Robux: 1200, IconURL: "/products/sky-people.png", FeaturedWeight: 2},
+	} {
+		product.Active = true
+		product.CreatedAt = now
+		product.UpdatedAt = now
+		s.AddProduct(product)
+	}
+
+	// supplier_accounts, from the delivery_workers_schema migration. The
+	// demo stock is memory-only, the migrations start every account empty.
+	s.AddSupplierAccount(data.SupplierAccount{
+		ID:             newID(),
+		Name:           "Main",
+		MaxConcurrency: 1,
+		Active:         true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}, 100000)
+
+	// vouchers, from the vouchers_schema migration.
+	s.AddVoucher(data.Voucher{
+		ID:             newID(),
+		Code:           "MAYO10",
+		Description:    "10% off, up to Rp20.000",
+		PercentOff:     utility.SetPtrValue(10),
+		MaxDiscountIDR: utility.SetPtrValue(pricing.Rupiah(20000)),
+		MinSpendIDR:    pricing.Rupiah(50000),
+		PerUserLimit:   utility.SetPtrValue(1),
+		Active:         true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+
+	return s
+}
@@ -0,0 +1,243 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type StockModel struct {
+	store *Store
+}
+
+func (m StockModel) Balances() ([]*data.StockBalance, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	balances := []*data.StockBalance{}
+	for id := range m.store.supplierAccounts {
+		balances = append(balances, m.store.balance(id))
+	}
+	// ORDER BY a.name ASC, a.id ASC
+	slices.SortFunc(balances, func(a, b *data.StockBalance) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.SupplierAccountID, b.SupplierAccountID))
+	})
+	return balances, nil
+}
+
+func (m StockModel) Balance(id string) (*data.StockBalance, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	if _, ok := m.store.supplierAccounts[id]; !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return m.store.balance(id), nil
+}
+
+func (m StockModel) Available() (int, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	available := 0
+	for id, account := range m.store.supplierAccounts {
+		if account.Active {
+			available = max(available, m.store.balance(id).Available)
+		}
+	}
+	return available, nil
+}
+
+func (m StockModel) Purchase(id string, robux int, note string) (*data.LedgerEntry, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.supplierAccounts[id]; !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return m.store.post(id, data.LedgerPurchase, data.LedgerAvailable, data.LedgerSupplier, robux, nil, note), nil
+}
+
+func (m StockModel) Reconcile(id string, actual int, note string) (*data.Reconciliation, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.supplierAccounts[id]; !ok {
+		return nil, data.ErrRecordNotFound
+	}
+
+	expected := m.store.balance(id).OnHand
+	rec := &data.Reconciliation{Expected: expected, Actual: actual, Difference: actual - expected}
+	switch {
+	case rec.Difference > 0:
+		rec.Entry = m.store.post(id, data.LedgerAdjusted, data.LedgerAvailable, data.LedgerAdjustment, rec.Difference, nil, note)
+	case rec.Difference < 0:
+		rec.Entry = m.store.post(id, data.LedgerAdjusted, data.LedgerAdjustment, data.LedgerAvailable, -rec.Difference, nil, note)
+	}
+	rec.Balance = m.store.balance(id)
+	return rec, nil
+}
+
+func (m StockModel) Entries(id string, limit int) ([]*data.LedgerEntry, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	// ORDER BY created_at DESC, appended in created_at order
+	entries := []*data.LedgerEntry{}
+	for _, entry := range slices.Backward(m.store.ledger) {
+		if entry.SupplierAccountID == id && len(entries) < limit {
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m StockModel) ReleaseExpired(now time.Time) (int, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	released := 0
+	for orderID, r := range m.store.reservations {
+		if r.Status != data.ReservationHeld || r.ExpiresAt == nil || r.ExpiresAt.After(now) {
+			continue
+		}
+		m.store.moveReservation(orderID, data.LedgerRelease, data.LedgerAvailable, data.LedgerReserved, data.ReservationReleased, "reservation expired")
+		released++
+	}
+	return released, nil
+}
+
+func (m StockModel) Reservation(orderID string) (*data.Reservation, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	r, ok := m.store.reservations[orderID]
+	if !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return &r, nil
+}
+
+// balance returns the buckets of the supplier account id. The caller must
+// hold the lock.
+func (s *Store) balance(id string) *data.StockBalance {
+	account := s.supplierAccounts[id]
+	buckets := s.balances[id]
+	return &data.StockBalance{
+		SupplierAccountID: id,
+		Name:              account.Name,
+		Active:            account.Active,
+		Purchased:         -buckets[data.LedgerSupplier],
+		Available:         buckets[data.LedgerAvailable],
+		Reserved:          buckets[data.LedgerReserved],
+		Delivered:         buckets[data.LedgerDelivered],
+		Adjusted:          -buckets[data.LedgerAdjustment],
+		OnHand:            buckets[data.LedgerAvailable] + buckets[data.LedgerReserved],
+	}
+}
+
+// post appends a ledger entry and adds it to the balances. The caller must
+// hold the lock.
+func (s *Store) post(accountID, kind, debit, credit string, amount int, orderID *string, note string) *data.LedgerEntry {
+	entry := data.LedgerEntry{
+		ID:                newID(),
+		SupplierAccountID: accountID,
+		Kind:              kind,
+		Debit:             debit,
+		Credit:            credit,
+		Amount:            amount,
+		OrderID:           orderID,
+		CreatedAt:         s.now(),
+	}
+	if note != "" {
+		entry.Note = &note
+	}
+	s.ledger = append(s.ledger, entry)
+
+	buckets, ok := s.balances[accountID]
+	if !ok {
+		buckets = map[string]int{}
+		s.balances[accountID] = buckets
+	}
+	buckets[debit] += amount
+	buckets[credit] -= amount
+	return &entry
+}
+
+// reserveStock is the reservation made by OrderModel.Insert. The caller
+// must hold the lock.
+func (s *Store) reserveStock(order *data.Order, expiresAt time.Time) error {
+	var best *data.StockBalance
+	for id, account := range s.supplierAccounts {
+		if !account.Active {
+			continue
+		}
+		// ORDER BY b.available DESC, b.id ASC
+		b := s.balance(id)
+		if b.Available >= order.Robux && (best == nil || cmp.Or(cmp.Compare(best.Available, b.Available), cmp.Compare(b.SupplierAccountID, best.SupplierAccountID)) < 0) {
+			best = b
+		}
+	}
+	if best == nil {
+		return data.ErrOutOfStock
+	}
+
+	now := s.now()
+	s.reservations[order.ID] = data.Reservation{
+		OrderID:           order.ID,
+		SupplierAccountID: best.SupplierAccountID,
+		Robux:             order.Robux,
+		Status:            data.ReservationHeld,
+		ExpiresAt:         &expiresAt,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	s.post(best.SupplierAccountID, data.LedgerReservation, data.LedgerReserved, data.LedgerAvailable, order.Robux, &order.ID, "")
+	order.Delivery.SupplierAccountID = &best.SupplierAccountID
+	return nil
+}
+
+// syncReservation mirrors the Postgres syncReservation. The caller must
+// hold the lock.
+func (s *Store) syncReservation(order *data.Order) {
+	r, ok := s.reservations[order.ID]
+	if !ok {
+		return
+	}
+
+	switch order.Status {
+	case data.OrderStatusPaid:
+		switch r.Status {
+		case data.ReservationHeld:
+			r.ExpiresAt = nil
+			r.UpdatedAt = s.now()
+			s.reservations[order.ID] = r
+		case data.ReservationReleased:
+			s.moveReservation(order.ID, data.LedgerReservation, data.LedgerReserved, data.LedgerAvailable, data.ReservationHeld, "")
+		}
+	case data.OrderStatusDelivered:
+		switch r.Status {
+		case data.ReservationHeld:
+			s.moveReservation(order.ID, data.LedgerConsumption, data.LedgerDelivered, data.LedgerReserved, data.ReservationConsumed, "")
+		case data.ReservationReleased:
+			s.moveReservation(order.ID, data.LedgerConsumption, data.LedgerDelivered, data.LedgerAvailable, data.ReservationConsumed, "")
+		}
+	case data.OrderStatusFailed, data.OrderStatusRefunded:
+		if r.Status == data.ReservationHeld {
+			s.moveReservation(order.ID, data.LedgerRelease, data.LedgerAvailable, data.LedgerReserved, data.ReservationReleased, "")
+		}
+	}
+}
+
+// moveReservation sets the status of the reservation of orderID and posts
+// its Robux. The caller must hold the lock.
+func (s *Store) moveReservation(orderID, kind, debit, credit, status, note string) {
+	r := s.reservations[orderID]
+	r.Status = status
+	r.ExpiresAt = nil
+	r.UpdatedAt = s.now()
+	s.reservations[orderID] = r
+	s.post(r.SupplierAccountID, kind, debit, credit, r.Robux, &orderID, note)
+}
@@ -0,0 +1,69 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type SupplierAccountModel struct {
+	store *Store
+}
+
+func (m SupplierAccountModel) GetAll() ([]*data.SupplierAccount, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	accounts := []*data.SupplierAccount{}
+	for _, account := range m.store.supplierAccounts {
+		accounts = append(accounts, &account)
+	}
+	// ORDER BY name ASC, id ASC
+	slices.SortFunc(accounts, func(a, b *data.SupplierAccount) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return accounts, nil
+}
+
+func (m SupplierAccountModel) Get(id string) (*data.SupplierAccount, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	account, ok := m.store.supplierAccounts[id]
+	if !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return &account, nil
+}
+
+func (m SupplierAccountModel) Insert(account *data.SupplierAccount) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	now := m.store.now()
+	account.ID = newID()
+	account.CreatedAt = now
+	account.UpdatedAt = now
+	m.store.supplierAccounts[account.ID] = *account
+	return nil
+}
+
+func (m SupplierAccountModel) Update(account *data.SupplierAccount) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	stored, ok := m.store.supplierAccounts[account.ID]
+	if !ok {
+		return data.ErrRecordNotFound
+	}
+	stored.Name = account.Name
+	stored.MaxConcurrency = account.MaxConcurrency
+	stored.Active = account.Active
+	stored.UpdatedAt = m.store.now()
+	m.store.supplierAccounts[account.ID] = stored
+
+	account.CreatedAt = stored.CreatedAt
+	account.UpdatedAt = stored.UpdatedAt
+	return nil
+}
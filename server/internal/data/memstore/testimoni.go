@@ -0,0 +1,73 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type TestimoniModel struct {
+	store *Store
+}
+
+func (m TestimoniModel) GetAll(param data.TestimoniGetAllParam) ([]*data.TestimoniWithUser, *data.Metadata, error) {
+	testimonies, totalRecords := paginate(m.all(), param.Page, param.PageSize)
+
+	metadata := data.NewMetadata(totalRecords, param.Page, param.PageSize)
+	return testimonies, &metadata, nil
+}
+
+func (m TestimoniModel) Stream(ctx context.Context, fn func(*data.TestimoniWithUser) error) error {
+	for _, item := range m.all() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m TestimoniModel) UpdateIconURL(id, iconURL string) (*data.Testimoni, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	for i, t := range m.store.testimonies {
+		if t.ID == id {
+			t.IconURL = iconURL
+			t.UpdatedAt = m.store.now()
+			m.store.testimonies[i] = t
+			return &t, nil
+		}
+	}
+	return nil, data.ErrRecordNotFound
+}
+
+// all returns every listed testimoni in GetAll order.
+func (m TestimoniModel) all() []*data.TestimoniWithUser {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	// INNER JOIN users
+	var rows []*data.TestimoniWithUser
+	for _, t := range m.store.testimonies {
+		user, ok := m.store.users[t.UserID]
+		if !ok {
+			continue
+		}
+		rows = append(rows, &data.TestimoniWithUser{Testimoni: t, User: user})
+	}
+
+	// ORDER BY t.created_at DESC, t.id ASC
+	slices.SortStableFunc(rows, func(a, b *data.TestimoniWithUser) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return rows
+}
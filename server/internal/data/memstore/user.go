@@ -0,0 +1,23 @@
+package memstore
+
+import (
+	"github.com/ucok-man/mayobox-server/internal/data"
+)
+
+type UserModel struct {
+	store *Store
+}
+
+func (m UserModel) UpdateImageURL(id, imageURL string) (*data.User, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	user, ok := m.store.users[id]
+	if !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	user.ImageUrl = imageURL
+	user.UpdatedAt = m.store.now()
+	m.store.users[id] = user
+	return &user, nil
+}
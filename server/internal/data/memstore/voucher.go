@@ -0,0 +1,182 @@
+package memstore
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/ucok-man/mayobox-server/internal/data"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+type VoucherModel struct {
+	store *Store
+}
+
+func (m VoucherModel) GetAll() ([]*data.Voucher, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	vouchers := []*data.Voucher{}
+	for id := range m.store.vouchers {
+		vouchers = append(vouchers, m.store.voucher(id))
+	}
+	// ORDER BY v.created_at DESC, v.id DESC
+	slices.SortFunc(vouchers, func(a, b *data.Voucher) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	return vouchers, nil
+}
+
+func (m VoucherModel) Get(id string) (*data.Voucher, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	if _, ok := m.store.vouchers[id]; !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return m.store.voucher(id), nil
+}
+
+func (m VoucherModel) Apply(code string, use data.VoucherUse) (*data.Voucher, pricing.Money, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	code = data.NormalizeVoucherCode(code)
+	for id, voucher := range m.store.vouchers {
+		if voucher.Code != code {
+			continue
+		}
+		v := m.store.voucher(id)
+		if err := m.store.checkVoucher(v, use); err != nil {
+			return nil, 0, err
+		}
+		return v, v.Discount(use.SubtotalIDR), nil
+	}
+	return nil, 0, &data.VoucherError{Reason: data.VoucherNotFound}
+}
+
+func (m VoucherModel) Insert(voucher *data.Voucher) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	voucher.Code = data.NormalizeVoucherCode(voucher.Code)
+	if err := m.store.checkVoucherWrite(voucher); err != nil {
+		return err
+	}
+
+	now := m.store.now()
+	voucher.ID = newID()
+	voucher.CreatedAt = now
+	voucher.UpdatedAt = now
+	voucher.Redemptions = 0
+	m.store.vouchers[voucher.ID] = *voucher
+	return nil
+}
+
+func (m VoucherModel) Update(voucher *data.Voucher) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	stored, ok := m.store.vouchers[voucher.ID]
+	if !ok {
+		return data.ErrRecordNotFound
+	}
+	voucher.Code = data.NormalizeVoucherCode(voucher.Code)
+	if err := m.store.checkVoucherWrite(voucher); err != nil {
+		return err
+	}
+
+	voucher.CreatedAt = stored.CreatedAt
+	voucher.UpdatedAt = m.store.now()
+	m.store.vouchers[voucher.ID] = *voucher
+	*voucher = *m.store.voucher(voucher.ID)
+	return nil
+}
+
+// checkVoucherWrite stands in for the unique code and the product foreign
+// keys of a voucher about to be stored, and sorts its product IDs the way
+// they are read back. The caller must hold the lock.
+func (s *Store) checkVoucherWrite(voucher *data.Voucher) error {
+	for id, other := range s.vouchers {
+		if id != voucher.ID && other.Code == voucher.Code {
+			return data.ErrDuplicateVoucherCode
+		}
+	}
+	// REFERENCES products(id)
+	for _, id := range voucher.ProductIDs {
+		if _, ok := s.products[id]; !ok {
+			return data.ErrRecordNotFound
+		}
+	}
+	voucher.ProductIDs = slices.Compact(slices.Sorted(slices.Values(voucher.ProductIDs)))
+	if voucher.ProductIDs == nil {
+		voucher.ProductIDs = []string{}
+	}
+	return nil
+}
+
+// voucher returns a copy of the voucher with id and its redemptions. The
+// caller must hold the lock.
+func (s *Store) voucher(id string) *data.Voucher {
+	voucher := s.vouchers[id]
+	voucher.ProductIDs = slices.Clone(voucher.ProductIDs)
+	if voucher.ProductIDs == nil {
+		voucher.ProductIDs = []string{}
+	}
+	voucher.Redemptions, _ = s.voucherRedemptions(id, "")
+	return &voucher
+}
+
+// voucherRedemptions counts the orders using the voucher with id, in
+// total and by buyer. The caller must hold the lock.
+func (s *Store) voucherRedemptions(id, buyer string) (used, usedByBuyer int) {
+	for _, order := range s.orders {
+		if order.VoucherID == nil || *order.VoucherID != id {
+			continue
+		}
+		// status NOT IN ('failed', 'refunded')
+		if order.Status == data.OrderStatusFailed || order.Status == data.OrderStatusRefunded {
+			continue
+		}
+		used++
+		if strings.EqualFold(order.RobloxUsername, buyer) {
+			usedByBuyer++
+		}
+	}
+	return used, usedByBuyer
+}
+
+// checkVoucher reports whether voucher applies to use. The caller must
+// hold the lock.
+func (s *Store) checkVoucher(voucher *data.Voucher, use data.VoucherUse) error {
+	if err := voucher.Check(use); err != nil {
+		return err
+	}
+	used, usedByBuyer := s.voucherRedemptions(voucher.ID, use.RobloxUsername)
+	return voucher.CheckLimits(used, usedByBuyer)
+}
+
+// redeemVoucher applies the voucher of order, setting its discount and
+// total from its subtotal. The caller must hold the lock.
+func (s *Store) redeemVoucher(order *data.Order) error {
+	if order.VoucherID == nil {
+		order.DiscountIDR = 0
+		order.TotalIDR = order.SubtotalIDR
+		return nil
+	}
+
+	if _, ok := s.vouchers[*order.VoucherID]; !ok {
+		return &data.VoucherError{Reason: data.VoucherNotFound}
+	}
+	voucher := s.voucher(*order.VoucherID)
+	product := s.products[order.ProductID]
+
+	use := data.VoucherUse{Product: &product, RobloxUsername: order.RobloxUsername, SubtotalIDR: order.SubtotalIDR, At: s.now()}
+	if err := s.checkVoucher(voucher, use); err != nil {
+		return err
+	}
+	order.DiscountIDR = voucher.Discount(order.SubtotalIDR)
+	order.TotalIDR = order.SubtotalIDR - order.DiscountIDR
+	return nil
+}
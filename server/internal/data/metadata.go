@@ -22,6 +22,12 @@ func calculateMetadata(totalRecords, page, pageSize int) Metadata {
 	}
 }
 
+// NewMetadata exposes calculateMetadata to Modeler implementations outside
+// this package, so they paginate the same way.
+func NewMetadata(totalRecords, page, pageSize int) Metadata {
+	return calculateMetadata(totalRecords, page, pageSize)
+}
+
 func calculatePageOffset(page, pageSize int) int {
 	offset := (page - 1) * pageSize
 	return offset
@@ -1,8 +1,12 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/pricing"
 )
 
 var (
@@ -12,20 +16,157 @@ var (
 
 type TestimoniModeler interface {
 	GetAll(param TestimoniGetAllParam) ([]*TestimoniWithUser, *Metadata, error)
+	// Stream calls fn for every testimoni in GetAll order without loading
+	// them all into memory. It stops at the first error fn returns.
+	Stream(ctx context.Context, fn func(*TestimoniWithUser) error) error
+	UpdateIconURL(id, iconURL string) (*Testimoni, error)
+}
+
+type UserModeler interface {
+	UpdateImageURL(id, imageURL string) (*User, error)
 }
 
 type FAQModeler interface {
 	GetAll() ([]*FAQWithAnswers, *Metadata, error)
 }
 
+type ProductModeler interface {
+	GetAllActive() ([]*Product, error)
+	Get(id string) (*Product, error)
+}
+
+type FeaturedModeler interface {
+	GetPicks(day string) ([]*FeaturedPick, error)
+	SavePicks(day string, picks []*FeaturedPick) (bool, error)
+	RecentProductIDs(from, to string) ([]string, error)
+	GetCalendar(from, to string) ([]*FeaturedCalendarEntry, error)
+	// SetCalendar returns ErrRecordNotFound when a product does not exist.
+	SetCalendar(day string, productIDs []string) ([]*FeaturedCalendarEntry, error)
+}
+
+type OrderModeler interface {
+	// Insert returns ErrOutOfStock when no supplier account has the Robux
+	// of the order available, ErrFlashSaleSoldOut when its flash sale item
+	// has ended or sold out, and a *VoucherError when the voucher of the
+	// order does not apply.
+	Insert(order *Order, reserveUntil time.Time) error
+	Get(id string) (*Order, error)
+	// Transition returns ErrRecordNotFound when the order does not exist,
+	// and an error wrapping fulfillment.ErrInvalidTransition or
+	// fulfillment.ErrNotPaid when the change is not allowed.
+	Transition(id string, change OrderChange) (*Order, error)
+	ClaimForDelivery(supplierAccountID string, now time.Time, limit int) ([]*Order, error)
+	ListByStatus(status string, limit int) ([]*Order, error)
+	History(id string) ([]*OrderTransition, error)
+	DeadLetters(open bool, limit int) ([]*DeadLetter, error)
+	RecentSales(limit int) ([]*Sale, error)
+	SalesSince(since time.Time, limit int) ([]*Sale, error)
+	// SalesAfter returns ErrRecordNotFound when orderID is not a sale.
+	SalesAfter(orderID string, limit int) ([]*Sale, error)
+}
+
+type SupplierAccountModeler interface {
+	GetAll() ([]*SupplierAccount, error)
+	Get(id string) (*SupplierAccount, error)
+	Insert(account *SupplierAccount) error
+	// Update returns ErrRecordNotFound when the account does not exist.
+	Update(account *SupplierAccount) error
+}
+
+type StockModeler interface {
+	Balances() ([]*StockBalance, error)
+	// Balance, Purchase and Reconcile return ErrRecordNotFound when the
+	// supplier account does not exist.
+	Balance(id string) (*StockBalance, error)
+	Available() (int, error)
+	Purchase(id string, robux int, note string) (*LedgerEntry, error)
+	Reconcile(id string, actual int, note string) (*Reconciliation, error)
+	Entries(id string, limit int) ([]*LedgerEntry, error)
+	ReleaseExpired(now time.Time) (int, error)
+	// Reservation returns ErrRecordNotFound when the order has none.
+	Reservation(orderID string) (*Reservation, error)
+}
+
+type VoucherModeler interface {
+	GetAll() ([]*Voucher, error)
+	Get(id string) (*Voucher, error)
+	// Apply returns a *VoucherError when the voucher does not apply.
+	Apply(code string, use VoucherUse) (*Voucher, pricing.Money, error)
+	// Insert and Update return ErrDuplicateVoucherCode when another
+	// voucher has the code, and ErrRecordNotFound when a product or the
+	// voucher does not exist.
+	Insert(voucher *Voucher) error
+	Update(voucher *Voucher) error
+}
+
+type FlashSaleModeler interface {
+	GetAll() ([]*FlashSale, error)
+	Get(id string) (*FlashSale, error)
+	Live(at time.Time) ([]*FlashSale, error)
+	// Insert and Update return ErrRecordNotFound when a product or the
+	// flash sale does not exist.
+	Insert(sale *FlashSale) error
+	Update(sale *FlashSale) error
+}
+
+type PaymentModeler interface {
+	Insert(p *Payment) error
+	GetByOrderID(orderID string) (*Payment, error)
+	Pending(before time.Time, limit int) ([]*Payment, error)
+	// ApplyEvent returns ErrRecordNotFound when no payment has the charge,
+	// and ErrPaymentAmountMismatch with the payment when a paid event
+	// reports another amount.
+	ApplyEvent(event *PaymentEvent) (*Payment, bool, error)
+}
+
+type SalesModeler interface {
+	Refresh(upto time.Time) (int, error)
+	BestSellers(from string, limit int) ([]*BestSeller, error)
+}
+
+type RateTableModeler interface {
+	Current(at time.Time) (*pricing.Table, error)
+}
+
+type IdempotencyKeyModeler interface {
+	Claim(rec *IdempotencyKey) (*IdempotencyKey, bool, error)
+	Complete(rec *IdempotencyKey) error
+	Release(scope, key string) error
+	DeleteExpired() (int64, error)
+}
+
 type Models struct {
-	Testimoni TestimoniModeler
-	FAQ       FAQModeler
+	User            UserModeler
+	Testimoni       TestimoniModeler
+	FAQ             FAQModeler
+	Product         ProductModeler
+	Featured        FeaturedModeler
+	Order           OrderModeler
+	SupplierAccount SupplierAccountModeler
+	Stock           StockModeler
+	Voucher         VoucherModeler
+	FlashSale       FlashSaleModeler
+	Payment         PaymentModeler
+	Sales           SalesModeler
+	RateTable       RateTableModeler
+	IdempotencyKey  IdempotencyKeyModeler
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Testimoni: TestimoniModel{db: db},
-		FAQ:       FAQModel{db: db},
+		User:            UserModel{db: db},
+		Testimoni:       TestimoniModel{db: db},
+		FAQ:             FAQModel{db: db},
+		Product:         ProductModel{db: db},
+		Featured:        FeaturedModel{db: db},
+		Order:           OrderModel{db: db},
+		SupplierAccount: SupplierAccountModel{db: db},
+		Stock:           StockModel{db: db},
+		Voucher:         VoucherModel{db: db},
+		FlashSale:       FlashSaleModel{db: db},
+		Payment:         PaymentModel{db: db},
+		Sales:           SalesModel{db: db},
+		RateTable:       RateTableModel{db: db},
+		IdempotencyKey:  IdempotencyKeyModel{db: db},
 	}
 }
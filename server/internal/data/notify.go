@@ -0,0 +1,42 @@
+package data
+
+import (
+	"context"
+
+	"github.com/jackc/pgx"
+)
+
+// ChangesChannel is the channel the notify_table_change trigger publishes
+// the name of every changed table on.
+const ChangesChannel = "table_changes"
+
+// ListenForChanges opens a dedicated connection, LISTENs on ChangesChannel
+// and calls onChange with the table name of every notification. onConnect
+// is called once the listener is in place, so callers can drop state that
+// may have changed while no listener was running. It returns when ctx is
+// cancelled or the connection fails.
+func ListenForChanges(ctx context.Context, dsn string, onConnect func(), onChange func(table string)) error {
+	cfg, err := pgx.ParseConnectionString(dsn)
+	if err != nil {
+		return err
+	}
+
+	conn, err := pgx.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Listen(ChangesChannel); err != nil {
+		return err
+	}
+	onConnect()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		onChange(notification.Payload)
+	}
+}
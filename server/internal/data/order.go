@@ -0,0 +1,653 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+// Order statuses, see package fulfillment for the transitions between them.
+const (
+	OrderStatusPendingPayment = fulfillment.StatusPendingPayment
+	OrderStatusPaid           = fulfillment.StatusPaid
+	OrderStatusDelivering     = fulfillment.StatusDelivering
+	OrderStatusDelivered      = fulfillment.StatusDelivered
+	OrderStatusFailed         = fulfillment.StatusFailed
+	OrderStatusRefunded       = fulfillment.StatusRefunded
+)
+
+type Order struct {
+	ID        string  `json:"id"`
+	ProductID string  `json:"productId"`
+	UserID    *string `json:"userId"`
+	// RobloxUsername is the account the Robux are delivered to.
+	RobloxUsername string `json:"robloxUsername"`
+	Quantity       int    `json:"quantity"`
+	Robux          int    `json:"robux"`
+	// SubtotalIDR is the price before the voucher, DiscountIDR what the
+	// voucher takes off it and TotalIDR what the buyer pays.
+	SubtotalIDR pricing.Money `json:"subtotalIdr"`
+	DiscountIDR pricing.Money `json:"discountIdr"`
+	TotalIDR    pricing.Money `json:"totalIdr"`
+	VoucherID   *string       `json:"voucherId"`
+	// FlashSaleItemID is the flash sale item the order is priced at,
+	// nil for the rate table price.
+	FlashSaleItemID *string    `json:"flashSaleItemId"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeliveredAt     *time.Time `json:"deliveredAt"`
+	// Delivery is only shown to admins.
+	Delivery OrderDelivery `json:"-"`
+}
+
+// OrderDelivery tracks the attempts to deliver an order.
+type OrderDelivery struct {
+	// Attempts counts the attempts since the order was paid or last
+	// retried by an admin.
+	Attempts int `json:"attempts"`
+	// NextAttemptAt is when a paid order is next tried, nil for at once.
+	NextAttemptAt *time.Time `json:"nextAttemptAt"`
+	LastError     *string    `json:"lastError"`
+	// Reference identifies the delivery at Roblox.
+	Reference *string `json:"reference"`
+	// SupplierAccountID is the account of the latest attempt.
+	SupplierAccountID *string `json:"supplierAccountId"`
+}
+
+// DeadLetter is an order whose delivery attempts ran out. It is resolved
+// once the order leaves failed.
+type DeadLetter struct {
+	ID                string     `json:"id"`
+	OrderID           string     `json:"orderId"`
+	SupplierAccountID *string    `json:"supplierAccountId"`
+	Attempts          int        `json:"attempts"`
+	Error             string     `json:"error"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	ResolvedAt        *time.Time `json:"resolvedAt"`
+}
+
+// OrderTransition is a change of status of an order. FromStatus is nil for
+// the creation of the order.
+type OrderTransition struct {
+	ID         string    `json:"id"`
+	OrderID    string    `json:"orderId"`
+	FromStatus *string   `json:"fromStatus"`
+	ToStatus   string    `json:"toStatus"`
+	Actor      string    `json:"actor"`
+	Reason     *string   `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// OrderChange moves an order to status To, on behalf of Actor, one of the
+// fulfillment.Actor* values. Empty strings leave the field unset.
+type OrderChange struct {
+	To     string
+	Actor  string
+	Reason string
+	// StartAttempt counts a delivery attempt.
+	StartAttempt bool
+	// ResetAttempts clears the attempts and the last error.
+	ResetAttempts bool
+	// RetryAt is when a paid order is next tried, nil for at once.
+	RetryAt   *time.Time
+	Error     string
+	Reference string
+	// DeadLetter records the order as a dead letter, for a move to failed
+	// once attempts run out.
+	DeadLetter bool
+}
+
+// Sale is a delivered order with its product.
+type Sale struct {
+	OrderID        string        `json:"orderId"`
+	RobloxUsername string        `json:"robloxUsername"`
+	Quantity       int           `json:"quantity"`
+	Robux          int           `json:"robux"`
+	TotalIDR       pricing.Money `json:"totalIdr"`
+	DeliveredAt    time.Time     `json:"deliveredAt"`
+	Product        *Product      `json:"product"`
+}
+
+type OrderModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+const orderColumns = `
+		id,
+		product_id,
+		user_id,
+		roblox_username,
+		quantity,
+		robux,
+		subtotal_idr,
+		discount_idr,
+		total_idr,
+		voucher_id,
+		flash_sale_item_id,
+		status,
+		created_at,
+		updated_at,
+		delivered_at,
+		delivery_attempts,
+		next_attempt_at,
+		last_error,
+		delivery_reference,
+		supplier_account_id`
+
+// qualifiedOrderColumns are orderColumns qualified by the alias o, for
+// queries joining orders to a table with the same column names.
+const qualifiedOrderColumns = `
+		o.id,
+		o.product_id,
+		o.user_id,
+		o.roblox_username,
+		o.quantity,
+		o.robux,
+		o.subtotal_idr,
+		o.discount_idr,
+		o.total_idr,
+		o.voucher_id,
+		o.flash_sale_item_id,
+		o.status,
+		o.created_at,
+		o.updated_at,
+		o.delivered_at,
+		o.delivery_attempts,
+		o.next_attempt_at,
+		o.last_error,
+		o.delivery_reference,
+		o.supplier_account_id`
+
+const saleColumns = `
+		o.id,
+		o.roblox_username,
+		o.quantity,
+		o.robux,
+		o.total_idr,
+		o.delivered_at,
+		p.id,
+		p.name,
+		p.category,
+		p.robux,
+		p.icon_url,
+		p.active,
+		p.featured_weight,
+		p.created_at,
+		p.updated_at`
+
+// Insert stores a new order, sets its ID, status and timestamps, and
+// reserves its Robux until reserveUntil. The creation is the first
+// transition in the order's history. An order of a flash sale item claims
+// its units and is priced at the item's price. The total is the subtotal
+// less the discount of the order's voucher, which is redeemed along with
+// it. It returns ErrOutOfStock, storing nothing, when no supplier account
+// has the Robux available, ErrFlashSaleSoldOut when the flash sale item
+// has ended or sold out, and a *VoucherError when the voucher no longer
+// applies.
+func (m OrderModel) Insert(order *Order, reserveUntil time.Time) error {
+	query := `
+	WITH o AS (
+		INSERT INTO orders (product_id, user_id, roblox_username, quantity, robux, subtotal_idr, discount_idr, total_idr, voucher_id, flash_sale_item_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, status, created_at, updated_at
+	), t AS (
+		INSERT INTO order_transitions (order_id, to_status, actor, created_at)
+		SELECT id, status, 'customer', created_at FROM o
+	)
+	SELECT id, status, created_at, updated_at FROM o;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := claimFlashSale(ctx, tx, order); err != nil {
+		return err
+	}
+	if err := redeemVoucher(ctx, tx, order); err != nil {
+		return err
+	}
+
+	args := []any{order.ProductID, order.UserID, order.RobloxUsername, order.Quantity, order.Robux, order.SubtotalIDR, order.DiscountIDR, order.TotalIDR, order.VoucherID, order.FlashSaleItemID}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&order.ID, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if err := reserveStock(ctx, tx, order, reserveUntil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Get returns the order with id.
+func (m OrderModel) Get(id string) (*Order, error) {
+	query := `
+	SELECT` + orderColumns + `
+	FROM orders
+	WHERE id = $1;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	order, err := scanOrder(m.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return order, err
+}
+
+// Transition applies change to the order with id and records it in the
+// order's history. It returns ErrRecordNotFound when there is no such
+// order, and an error wrapping fulfillment.ErrInvalidTransition or
+// fulfillment.ErrNotPaid when the change is not allowed.
+func (m OrderModel) Transition(id string, change OrderChange) (*Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := transitionOrder(ctx, tx, id, change)
+	if err != nil {
+		return nil, err
+	}
+	return order, tx.Commit()
+}
+
+// transitionOrder is Transition within tx, shared with the payment events
+// that move orders.
+func transitionOrder(ctx context.Context, tx *sql.Tx, id string, change OrderChange) (*Order, error) {
+	// Locks the order, so the delivery loop, payment events and admins
+	// change it in turn.
+	order, err := scanOrder(tx.QueryRowContext(ctx, `
+	SELECT`+orderColumns+`
+	FROM orders
+	WHERE id = $1
+	FOR UPDATE;`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	var paid bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM payments WHERE order_id = $1 AND paid_at IS NOT NULL);`, id).Scan(&paid)
+	if err != nil {
+		return nil, err
+	}
+	if err := fulfillment.Check(fulfillment.State{Status: order.Status, Paid: paid}, change.To, change.Actor); err != nil {
+		return nil, err
+	}
+	from := order.Status
+
+	query := `
+	UPDATE orders SET
+		status = $2,
+		delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END,
+		delivery_attempts = CASE WHEN $3 THEN 0 ELSE delivery_attempts END + CASE WHEN $4 THEN 1 ELSE 0 END,
+		next_attempt_at = $5,
+		last_error = CASE WHEN $6 <> '' THEN $6 WHEN $3 THEN NULL ELSE last_error END,
+		delivery_reference = COALESCE(NULLIF($7, ''), delivery_reference),
+		updated_at = NOW()
+	WHERE id = $1
+	RETURNING` + orderColumns + `;`
+
+	args := []any{id, change.To, change.ResetAttempts, change.StartAttempt, change.RetryAt, change.Error, change.Reference}
+	order, err = scanOrder(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO order_transitions (order_id, from_status, to_status, actor, reason)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''));`, id, from, change.To, change.Actor, change.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syncReservation(ctx, tx, order); err != nil {
+		return nil, err
+	}
+	if err := syncFlashSale(ctx, tx, order, from); err != nil {
+		return nil, err
+	}
+
+	if from == OrderStatusFailed {
+		_, err = tx.ExecContext(ctx, `
+		UPDATE delivery_dead_letters SET resolved_at = NOW()
+		WHERE order_id = $1 AND resolved_at IS NULL;`, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if change.DeadLetter {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO delivery_dead_letters (order_id, supplier_account_id, attempts, error)
+		VALUES ($1, $2, $3, COALESCE($4, ''));`, id, order.Delivery.SupplierAccountID, order.Delivery.Attempts, order.Delivery.LastError)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// ClaimForDelivery moves up to limit paid orders whose next attempt is due
+// at now, oldest first, to delivering with supplierAccountID and counts
+// the attempt. Orders whose Robux are reserved on another account are left
+// to it. Orders locked by another claim are skipped, so every order
+// is claimed by one worker however many instances run.
+func (m OrderModel) ClaimForDelivery(supplierAccountID string, now time.Time, limit int) ([]*Order, error) {
+	// The claim is one transition for every order, checked once.
+	err := fulfillment.Check(fulfillment.State{Status: OrderStatusPaid}, OrderStatusDelivering, fulfillment.ActorSystem)
+	if err != nil {
+		return nil, err
+	}
+
+	// The outer SELECT sees orders as they were before the claim, so the
+	// claimed rows come from RETURNING, qualified since due has an id too.
+	query := `
+	WITH due AS (
+		SELECT id
+		FROM orders
+		WHERE status = 'paid'
+			AND (next_attempt_at IS NULL OR next_attempt_at <= $2)
+			AND (supplier_account_id IS NULL OR supplier_account_id = $1)
+		ORDER BY created_at ASC, id ASC
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	), claimed AS (
+		UPDATE orders o SET
+			status = 'delivering',
+			delivery_attempts = o.delivery_attempts + 1,
+			next_attempt_at = NULL,
+			supplier_account_id = $1,
+			updated_at = NOW()
+		FROM due
+		WHERE o.id = due.id
+		RETURNING` + qualifiedOrderColumns + `
+	), t AS (
+		INSERT INTO order_transitions (order_id, from_status, to_status, actor)
+		SELECT id, 'paid', 'delivering', 'system' FROM claimed
+	)
+	SELECT` + orderColumns + `
+	FROM claimed
+	ORDER BY created_at ASC, id ASC;`
+
+	return m.queryOrders(query, supplierAccountID, now, limit)
+}
+
+// ListByStatus returns up to limit orders in status, or in any status when
+// it is empty. The least recently updated come first, so orders stuck the
+// longest lead the list.
+func (m OrderModel) ListByStatus(status string, limit int) ([]*Order, error) {
+	query := `
+	SELECT` + orderColumns + `
+	FROM orders
+	WHERE status = $1 OR $1 = ''
+	ORDER BY updated_at ASC, id ASC
+	LIMIT $2;`
+
+	return m.queryOrders(query, status, limit)
+}
+
+// History returns the transitions of the order with id, oldest first.
+func (m OrderModel) History(id string) ([]*OrderTransition, error) {
+	query := `
+	SELECT
+		id,
+		order_id,
+		from_status,
+		to_status,
+		actor,
+		reason,
+		created_at
+	FROM order_transitions
+	WHERE order_id = $1
+	ORDER BY created_at ASC, id ASC;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []*OrderTransition{}
+	for rows.Next() {
+		var t OrderTransition
+		err := rows.Scan(&t.ID, &t.OrderID, &t.FromStatus, &t.ToStatus, &t.Actor, &t.Reason, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, &t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// DeadLetters returns up to limit dead letters, newest first, only the
+// unresolved ones when open is set.
+func (m OrderModel) DeadLetters(open bool, limit int) ([]*DeadLetter, error) {
+	query := `
+	SELECT
+		id,
+		order_id,
+		supplier_account_id,
+		attempts,
+		error,
+		created_at,
+		resolved_at
+	FROM delivery_dead_letters
+	WHERE resolved_at IS NULL OR NOT $1
+	ORDER BY created_at DESC, id DESC
+	LIMIT $2;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, open, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	letters := []*DeadLetter{}
+	for rows.Next() {
+		var l DeadLetter
+		err := rows.Scan(&l.ID, &l.OrderID, &l.SupplierAccountID, &l.Attempts, &l.Error, &l.CreatedAt, &l.ResolvedAt)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, &l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+func (m OrderModel) queryOrders(query string, args ...any) ([]*Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func scanOrder(row rowScanner) (*Order, error) {
+	var order Order
+	err := row.Scan(
+		&order.ID,
+		&order.ProductID,
+		&order.UserID,
+		&order.RobloxUsername,
+		&order.Quantity,
+		&order.Robux,
+		&order.SubtotalIDR,
+		&order.DiscountIDR,
+		&order.TotalIDR,
+		&order.VoucherID,
+		&order.FlashSaleItemID,
+		&order.Status,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&order.DeliveredAt,
+		&order.Delivery.Attempts,
+		&order.Delivery.NextAttemptAt,
+		&order.Delivery.LastError,
+		&order.Delivery.Reference,
+		&order.Delivery.SupplierAccountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// RecentSales returns the latest limit sales, newest first.
+func (m OrderModel) RecentSales(limit int) ([]*Sale, error) {
+	query := `
+	SELECT` + saleColumns + `
+	FROM orders o
+	JOIN products p
+		ON p.id = o.product_id
+	WHERE o.status = 'delivered'
+	ORDER BY o.delivered_at DESC, o.id DESC
+	LIMIT $1;`
+
+	return m.querySales(query, limit)
+}
+
+// SalesSince returns up to limit sales delivered at or after since, oldest
+// first.
+func (m OrderModel) SalesSince(since time.Time, limit int) ([]*Sale, error) {
+	query := `
+	SELECT` + saleColumns + `
+	FROM orders o
+	JOIN products p
+		ON p.id = o.product_id
+	WHERE o.status = 'delivered'
+		AND o.delivered_at >= $1
+	ORDER BY o.delivered_at ASC, o.id ASC
+	LIMIT $2;`
+
+	return m.querySales(query, since, limit)
+}
+
+// SalesAfter returns up to limit sales delivered after the sale of orderID,
+// oldest first. It returns ErrRecordNotFound when orderID is not a sale.
+func (m OrderModel) SalesAfter(orderID string, limit int) ([]*Sale, error) {
+	query := `
+	WITH after AS (
+		SELECT delivered_at, id
+		FROM orders
+		WHERE id = $1 AND status = 'delivered'
+	)
+	SELECT` + saleColumns + `
+	FROM after a
+	JOIN orders o
+		ON (o.delivered_at, o.id) > (a.delivered_at, a.id)
+	JOIN products p
+		ON p.id = o.product_id
+	WHERE o.status = 'delivered'
+	ORDER BY o.delivered_at ASC, o.id ASC
+	LIMIT $2;`
+
+	sales, err := m.querySales(query, orderID, limit)
+	if err != nil || len(sales) > 0 {
+		return sales, err
+	}
+
+	// No sales either way, tell an unknown order from a caught up one.
+	var exists bool
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	err = m.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND status = 'delivered');`, orderID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRecordNotFound
+	}
+	return sales, nil
+}
+
+func (m OrderModel) querySales(query string, args ...any) ([]*Sale, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := []*Sale{}
+	for rows.Next() {
+		sale := Sale{Product: &Product{}}
+		err := rows.Scan(
+			&sale.OrderID,
+			&sale.RobloxUsername,
+			&sale.Quantity,
+			&sale.Robux,
+			&sale.TotalIDR,
+			&sale.DeliveredAt,
+			&sale.Product.ID,
+			&sale.Product.Name,
+			&sale.Product.Category,
+			&sale.Product.Robux,
+			&sale.Product.IconURL,
+			&sale.Product.Active,
+			&sale.Product.FeaturedWeight,
+			&sale.Product.CreatedAt,
+			&sale.Product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sales = append(sales, &sale)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
@@ -0,0 +1,256 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/fulfillment"
+	"github.com/ucok-man/mayobox-server/internal/payment"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+// Payment is the charge created at the payment provider for an order. Its
+// Status takes the payment.Status* values.
+type Payment struct {
+	ID           string               `json:"id"`
+	OrderID      string               `json:"orderId"`
+	Provider     string               `json:"provider"`
+	ChargeID     string               `json:"chargeId"`
+	Method       string               `json:"method"`
+	AmountIDR    pricing.Money        `json:"amountIdr"`
+	Status       string               `json:"status"`
+	Instructions payment.Instructions `json:"instructions"`
+	ExpiresAt    time.Time            `json:"expiresAt"`
+	PaidAt       *time.Time           `json:"paidAt"`
+	CreatedAt    time.Time            `json:"createdAt"`
+	UpdatedAt    time.Time            `json:"updatedAt"`
+}
+
+// ErrPaymentAmountMismatch is returned by ApplyEvent for a paid event of
+// another amount than the payment's.
+var ErrPaymentAmountMismatch = errors.New("data: paid amount does not match the payment")
+
+// PaymentEvent is a status change of a charge reported by its provider.
+// Amount is the amount the provider charged, checked against the payment
+// when the event reports it paid.
+type PaymentEvent struct {
+	Provider   string
+	EventID    string
+	ChargeID   string
+	Status     string
+	Amount     pricing.Money
+	OccurredAt time.Time
+}
+
+// OrderStatusOnPayment returns the status an order moves to when its
+// payment reaches status. The order moves only if fulfillment allows it:
+// a delivered order keeps its status when refunded, it is settled by hand.
+func OrderStatusOnPayment(status string) string {
+	switch status {
+	case payment.StatusPaid:
+		return OrderStatusPaid
+	case payment.StatusExpired, payment.StatusFailed:
+		return OrderStatusFailed
+	case payment.StatusRefunded:
+		return OrderStatusRefunded
+	default:
+		return ""
+	}
+}
+
+type PaymentModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+const paymentColumns = `
+		id,
+		order_id,
+		provider,
+		charge_id,
+		method,
+		amount_idr,
+		status,
+		instructions,
+		expires_at,
+		paid_at,
+		created_at,
+		updated_at`
+
+// Insert stores a new payment and sets its ID and timestamps.
+func (m PaymentModel) Insert(p *Payment) error {
+	query := `
+	INSERT INTO payments (order_id, provider, charge_id, method, amount_idr, status, instructions, expires_at, paid_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8, $9)
+	RETURNING id, created_at, updated_at;`
+
+	instructions, err := json.Marshal(p.Instructions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := []any{p.OrderID, p.Provider, p.ChargeID, p.Method, p.AmountIDR, p.Status, string(instructions), p.ExpiresAt, p.PaidAt}
+	return m.db.QueryRowContext(ctx, query, args...).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// GetByOrderID returns the payment of an order.
+func (m PaymentModel) GetByOrderID(orderID string) (*Payment, error) {
+	query := `
+	SELECT` + paymentColumns + `
+	FROM payments
+	WHERE order_id = $1;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	p, err := scanPayment(m.db.QueryRowContext(ctx, query, orderID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return p, err
+}
+
+// Pending returns up to limit pending payments created before before,
+// oldest first.
+func (m PaymentModel) Pending(before time.Time, limit int) ([]*Payment, error) {
+	query := `
+	SELECT` + paymentColumns + `
+	FROM payments
+	WHERE status = 'pending' AND created_at < $1
+	ORDER BY created_at ASC
+	LIMIT $2;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []*Payment{}
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ApplyEvent records event and moves the payment of its charge, and the
+// order of the payment, to the reported status. It returns the payment and
+// whether anything changed: an event seen before, or one reporting a
+// status the payment cannot move to, is recorded without effect. A paid
+// event of another amount is recorded without effect too, and returns
+// ErrPaymentAmountMismatch with the payment. It returns ErrRecordNotFound
+// when no payment has the charge.
+func (m PaymentModel) ApplyEvent(event *PaymentEvent) (*Payment, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// Locks the payment, so deliveries of the same charge apply in turn.
+	p, err := scanPayment(tx.QueryRowContext(ctx, `
+	SELECT`+paymentColumns+`
+	FROM payments
+	WHERE provider = $1 AND charge_id = $2
+	FOR UPDATE;`, event.Provider, event.ChargeID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, ErrRecordNotFound
+		}
+		return nil, false, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO payment_events (provider, event_id, charge_id, status, occurred_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (provider, event_id) DO NOTHING;`,
+		event.Provider, event.EventID, event.ChargeID, event.Status, event.OccurredAt)
+	if err != nil {
+		return nil, false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return p, false, err
+	}
+
+	if !payment.CanTransition(p.Status, event.Status) {
+		return p, false, tx.Commit()
+	}
+	if event.Status == payment.StatusPaid && event.Amount != p.AmountIDR {
+		if err := tx.Commit(); err != nil {
+			return nil, false, err
+		}
+		return p, false, ErrPaymentAmountMismatch
+	}
+
+	err = tx.QueryRowContext(ctx, `
+	UPDATE payments SET
+		status = $2,
+		paid_at = CASE WHEN $2 = 'paid' THEN $3 ELSE paid_at END,
+		updated_at = NOW()
+	WHERE id = $1
+	RETURNING status, paid_at, updated_at;`, p.ID, event.Status, event.OccurredAt).Scan(&p.Status, &p.PaidAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	change := OrderChange{To: OrderStatusOnPayment(event.Status), Actor: fulfillment.ActorPayment, Reason: "payment event " + event.EventID}
+	_, err = transitionOrder(ctx, tx, p.OrderID, change)
+	if err != nil && !errors.Is(err, fulfillment.ErrInvalidTransition) && !errors.Is(err, fulfillment.ErrNotPaid) {
+		return nil, false, err
+	}
+
+	return p, true, tx.Commit()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row rowScanner) (*Payment, error) {
+	var (
+		p            Payment
+		instructions []byte
+	)
+	err := row.Scan(
+		&p.ID,
+		&p.OrderID,
+		&p.Provider,
+		&p.ChargeID,
+		&p.Method,
+		&p.AmountIDR,
+		&p.Status,
+		&instructions,
+		&p.ExpiresAt,
+		&p.PaidAt,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(instructions, &p.Instructions); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,80 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+type RateTableModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// Current returns the rate table in effect at, the one with the latest
+// effective_from not after it. It returns ErrRecordNotFound before the
+// first table takes effect.
+func (m RateTableModel) Current(at time.Time) (*pricing.Table, error) {
+	query := `
+	SELECT
+		rt.id,
+		rt.effective_from,
+		rt.rounding_step,
+		rt.rounding_mode,
+		tr.min_robux,
+		tr.idr_per_robux
+	FROM rate_tables rt
+	JOIN rate_tiers tr
+		ON tr.rate_table_id = rt.id
+	WHERE rt.id = (
+		SELECT id FROM rate_tables
+		WHERE effective_from <= $1
+		ORDER BY effective_from DESC
+		LIMIT 1
+	)
+	ORDER BY tr.min_robux ASC;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var table *pricing.Table
+	for rows.Next() {
+		var (
+			t    pricing.Table
+			tier pricing.Tier
+		)
+		err := rows.Scan(
+			&t.ID,
+			&t.EffectiveFrom,
+			&t.RoundingStep,
+			&t.RoundingMode,
+			&tier.MinRobux,
+			&tier.IDRPerRobux,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if table == nil {
+			table = &t
+		}
+		table.Tiers = append(table.Tiers, tier)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return nil, ErrRecordNotFound
+	}
+	return table, nil
+}
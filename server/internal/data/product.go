@@ -0,0 +1,109 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Product categories. Robux is the amount delivered for a robux package
+// and the price of the item for a gamepass.
+const (
+	ProductCategoryRobux    = "robux"
+	ProductCategoryGamepass = "gamepass"
+)
+
+type Product struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Robux    int    `json:"robux"`
+	IconURL  string `json:"iconUrl"`
+	Active   bool   `json:"active"`
+	// FeaturedWeight is the relative chance of being drawn as a Product of
+	// the Day, zero to only feature it through the calendar.
+	FeaturedWeight int       `json:"featuredWeight"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type ProductModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// GetAllActive returns the products on sale, by name.
+func (m ProductModel) GetAllActive() ([]*Product, error) {
+	query := `
+	SELECT id, name, category, robux, icon_url, active, featured_weight, created_at, updated_at
+	FROM products
+	WHERE active
+	ORDER BY name ASC, id ASC;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*Product{}
+	for rows.Next() {
+		var product Product
+		err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Category,
+			&product.Robux,
+			&product.IconURL,
+			&product.Active,
+			&product.FeaturedWeight,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, &product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// Get returns the product with id, active or not.
+func (m ProductModel) Get(id string) (*Product, error) {
+	query := `
+	SELECT id, name, category, robux, icon_url, active, featured_weight, created_at, updated_at
+	FROM products
+	WHERE id = $1;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var product Product
+	err := m.db.QueryRowContext(ctx, query, id).Scan(
+		&product.ID,
+		&product.Name,
+		&product.Category,
+		&product.Robux,
+		&product.IconURL,
+		&product.Active,
+		&product.FeaturedWeight,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &product, nil
+}
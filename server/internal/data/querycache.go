@@ -0,0 +1,95 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/cache"
+)
+
+// QueryCache keeps the results of the public, rarely changing reads in
+// memory. Cached values are shared between callers and must not be
+// modified.
+type QueryCache struct {
+	testimonies *cache.Cache[TestimoniGetAllParam, testimoniPage]
+	faqs        *cache.Cache[struct{}, faqList]
+}
+
+type testimoniPage struct {
+	testimonies []*TestimoniWithUser
+	metadata    *Metadata
+}
+
+type faqList struct {
+	faqs     []*FAQWithAnswers
+	metadata *Metadata
+}
+
+// NewQueryCache returns a cache holding up to size results per model, each
+// for at most ttl.
+func NewQueryCache(size int, ttl time.Duration) *QueryCache {
+	return &QueryCache{
+		testimonies: cache.New[TestimoniGetAllParam, testimoniPage](size, ttl),
+		faqs:        cache.New[struct{}, faqList](1, ttl),
+	}
+}
+
+// Wrap returns models whose testimony and FAQ reads go through the cache.
+func (c *QueryCache) Wrap(models Models) Models {
+	models.Testimoni = cachedTestimoniModel{next: models.Testimoni, cache: c.testimonies}
+	models.FAQ = cachedFAQModel{next: models.FAQ, cache: c.faqs}
+	return models
+}
+
+// Invalidate evicts the results that read from table.
+func (c *QueryCache) Invalidate(table string) {
+	switch table {
+	case "testimonies", "users":
+		c.testimonies.Purge()
+	case "faqs", "faq_answers":
+		c.faqs.Purge()
+	}
+}
+
+// Purge evicts every result, e.g. after notifications may have been missed.
+func (c *QueryCache) Purge() {
+	c.testimonies.Purge()
+	c.faqs.Purge()
+}
+
+type cachedTestimoniModel struct {
+	next  TestimoniModeler
+	cache *cache.Cache[TestimoniGetAllParam, testimoniPage]
+}
+
+func (m cachedTestimoniModel) GetAll(param TestimoniGetAllParam) ([]*TestimoniWithUser, *Metadata, error) {
+	page, err := m.cache.GetOrLoad(param, func() (testimoniPage, error) {
+		testimonies, metadata, err := m.next.GetAll(param)
+		return testimoniPage{testimonies: testimonies, metadata: metadata}, err
+	})
+	return page.testimonies, page.metadata, err
+}
+
+// Stream is not cached, exports always read the current rows.
+func (m cachedTestimoniModel) Stream(ctx context.Context, fn func(*TestimoniWithUser) error) error {
+	return m.next.Stream(ctx, fn)
+}
+
+// UpdateIconURL writes through, the NOTIFY of the change evicts the cached
+// pages.
+func (m cachedTestimoniModel) UpdateIconURL(id, iconURL string) (*Testimoni, error) {
+	return m.next.UpdateIconURL(id, iconURL)
+}
+
+type cachedFAQModel struct {
+	next  FAQModeler
+	cache *cache.Cache[struct{}, faqList]
+}
+
+func (m cachedFAQModel) GetAll() ([]*FAQWithAnswers, *Metadata, error) {
+	list, err := m.cache.GetOrLoad(struct{}{}, func() (faqList, error) {
+		faqs, metadata, err := m.next.GetAll()
+		return faqList{faqs: faqs, metadata: metadata}, err
+	})
+	return list.faqs, list.metadata, err
+}
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingTestimoniModel struct{ calls int }
+
+func (m *countingTestimoniModel) GetAll(param TestimoniGetAllParam) ([]*TestimoniWithUser, *Metadata, error) {
+	m.calls++
+	metadata := calculateMetadata(1, param.Page, param.PageSize)
+	return []*TestimoniWithUser{{Testimoni: Testimoni{ID: "t-1"}}}, &metadata, nil
+}
+
+func (m *countingTestimoniModel) Stream(ctx context.Context, fn func(*TestimoniWithUser) error) error {
+	return nil
+}
+
+func (m *countingTestimoniModel) UpdateIconURL(id, iconURL string) (*Testimoni, error) {
+	return &Testimoni{ID: id, IconURL: iconURL}, nil
+}
+
+type countingFAQModel struct{ calls int }
+
+func (m *countingFAQModel) GetAll() ([]*FAQWithAnswers, *Metadata, error) {
+	m.calls++
+	return []*FAQWithAnswers{{FAQ: FAQ{ID: "f-1"}}}, nil, nil
+}
+
+func TestQueryCache(t *testing.T) {
+	setup := func() (*QueryCache, Models, *countingTestimoniModel, *countingFAQModel) {
+		testimonies, faqs := &countingTestimoniModel{}, &countingFAQModel{}
+		c := NewQueryCache(10, time.Minute)
+		return c, c.Wrap(Models{Testimoni: testimonies, FAQ: faqs}), testimonies, faqs
+	}
+
+	t.Run("caches reads per parameter", func(t *testing.T) {
+		_, models, testimonies, faqs := setup()
+
+		for range 3 {
+			items, metadata, err := models.Testimoni.GetAll(TestimoniGetAllParam{Page: 1, PageSize: 10})
+			require.NoError(t, err)
+			assert.Len(t, items, 1)
+			assert.Equal(t, 1, metadata.CurrentPage)
+
+			_, _, err = models.FAQ.GetAll()
+			require.NoError(t, err)
+		}
+		_, metadata, err := models.Testimoni.GetAll(TestimoniGetAllParam{Page: 2, PageSize: 10})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, metadata.CurrentPage)
+		assert.Equal(t, 2, testimonies.calls)
+		assert.Equal(t, 1, faqs.calls)
+	})
+
+	t.Run("invalidates the results reading a table", func(t *testing.T) {
+		c, models, testimonies, faqs := setup()
+		models.Testimoni.GetAll(TestimoniGetAllParam{Page: 1, PageSize: 10})
+		models.FAQ.GetAll()
+
+		c.Invalidate("users")
+		models.Testimoni.GetAll(TestimoniGetAllParam{Page: 1, PageSize: 10})
+		models.FAQ.GetAll()
+		assert.Equal(t, 2, testimonies.calls)
+		assert.Equal(t, 1, faqs.calls)
+
+		c.Invalidate("faq_answers")
+		models.Testimoni.GetAll(TestimoniGetAllParam{Page: 1, PageSize: 10})
+		models.FAQ.GetAll()
+		assert.Equal(t, 2, testimonies.calls)
+		assert.Equal(t, 2, faqs.calls)
+
+		c.Invalidate("idempotency_keys")
+		c.Purge()
+		models.Testimoni.GetAll(TestimoniGetAllParam{Page: 1, PageSize: 10})
+		models.FAQ.GetAll()
+		assert.Equal(t, 3, testimonies.calls)
+		assert.Equal(t, 3, faqs.calls)
+	})
+}
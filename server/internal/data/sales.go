@@ -0,0 +1,145 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// BestSeller is a product with the units delivered in a window.
+type BestSeller struct {
+	Product *Product `json:"product"`
+	Sold    int      `json:"sold"`
+}
+
+type SalesModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// Refresh adds the orders delivered since the last refresh, up to upto, to
+// the daily sales rollup and returns how many it added. Orders must not be
+// marked delivered with a time before upto after it has run, so callers
+// pass a time a little in the past to let running transactions commit.
+func (m SalesModel) Refresh(upto time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Locks the watermark, so replicas refresh one after another.
+	var through time.Time
+	var fresh bool
+	err = tx.QueryRowContext(ctx, `
+	SELECT GREATEST(through, '1970-01-01'::timestamptz), through < $1
+	FROM rollup_watermarks
+	WHERE name = 'product_sales'
+	FOR UPDATE;`, upto).Scan(&through, &fresh)
+	if err != nil {
+		return 0, err
+	}
+	if !fresh {
+		return 0, nil
+	}
+
+	query := `
+	WITH delivered AS (
+		SELECT
+			product_id,
+			(delivered_at AT TIME ZONE 'Asia/Jakarta')::date AS date,
+			quantity
+		FROM orders
+		WHERE status = 'delivered'
+			AND delivered_at > $1
+			AND delivered_at <= $2
+	), rolled AS (
+		INSERT INTO product_sales_daily (product_id, date, sold)
+		SELECT product_id, date, SUM(quantity)
+		FROM delivered
+		GROUP BY product_id, date
+		ON CONFLICT (product_id, date)
+			DO UPDATE SET sold = product_sales_daily.sold + EXCLUDED.sold
+	)
+	SELECT COUNT(*) FROM delivered;`
+
+	var added int
+	if err := tx.QueryRowContext(ctx, query, through, upto).Scan(&added); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE rollup_watermarks SET through = $1 WHERE name = 'product_sales';`, upto)
+	if err != nil {
+		return 0, err
+	}
+
+	return added, tx.Commit()
+}
+
+// BestSellers returns the active products with the most units delivered
+// since from, a date in Asia/Jakarta formatted as 2006-01-02, or of all
+// time when from is empty. Products without sales are left out.
+func (m SalesModel) BestSellers(from string, limit int) ([]*BestSeller, error) {
+	query := `
+	SELECT
+		p.id,
+		p.name,
+		p.category,
+		p.robux,
+		p.icon_url,
+		p.active,
+		p.featured_weight,
+		p.created_at,
+		p.updated_at,
+		s.sold
+	FROM (
+		SELECT product_id, SUM(sold)::integer AS sold
+		FROM product_sales_daily
+		WHERE date >= COALESCE(NULLIF($1, '')::date, '-infinity'::date)
+		GROUP BY product_id
+	) s
+	JOIN products p
+		ON p.id = s.product_id
+	WHERE p.active AND s.sold > 0
+	ORDER BY s.sold DESC, p.name ASC, p.id ASC
+	LIMIT $2;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, from, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sellers := []*BestSeller{}
+	for rows.Next() {
+		seller := BestSeller{Product: &Product{}}
+		err := rows.Scan(
+			&seller.Product.ID,
+			&seller.Product.Name,
+			&seller.Product.Category,
+			&seller.Product.Robux,
+			&seller.Product.IconURL,
+			&seller.Product.Active,
+			&seller.Product.FeaturedWeight,
+			&seller.Product.CreatedAt,
+			&seller.Product.UpdatedAt,
+			&seller.Sold,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sellers = append(sellers, &seller)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sellers, nil
+}
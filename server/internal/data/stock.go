@@ -0,0 +1,482 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrOutOfStock is returned when no active supplier account has the Robux
+// of an order available.
+var ErrOutOfStock = errors.New("data: not enough robux in stock")
+
+// Ledger buckets. Every supplier account has each of them, and a ledger
+// entry moves Robux from its credit bucket to its debit bucket.
+const (
+	// LedgerSupplier is where purchased Robux come from. Its balance is
+	// minus the Robux ever bought.
+	LedgerSupplier   = "supplier"
+	LedgerAvailable  = "available"
+	LedgerReserved   = "reserved"
+	LedgerDelivered  = "delivered"
+	LedgerAdjustment = "adjustment"
+)
+
+// Ledger entry kinds.
+const (
+	LedgerPurchase    = "purchase"
+	LedgerReservation = "reservation"
+	LedgerRelease     = "release"
+	LedgerConsumption = "consumption"
+	// LedgerAdjusted corrects the available Robux to the Roblox balance.
+	LedgerAdjusted = "adjustment"
+)
+
+// Reservation statuses.
+const (
+	ReservationHeld     = "held"
+	ReservationReleased = "released"
+	ReservationConsumed = "consumed"
+)
+
+// LedgerEntry moves Amount Robux of a supplier account from the Credit
+// bucket to the Debit bucket.
+type LedgerEntry struct {
+	ID                string    `json:"id"`
+	SupplierAccountID string    `json:"supplierAccountId"`
+	Kind              string    `json:"kind"`
+	Debit             string    `json:"debit"`
+	Credit            string    `json:"credit"`
+	Amount            int       `json:"amount"`
+	OrderID           *string   `json:"orderId"`
+	Note              *string   `json:"note"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// StockBalance is the Robux of a supplier account by bucket. Purchased
+// plus Adjusted always equals Available, Reserved and Delivered together.
+type StockBalance struct {
+	SupplierAccountID string `json:"supplierAccountId"`
+	Name              string `json:"name"`
+	Active            bool   `json:"active"`
+	Purchased         int    `json:"purchased"`
+	Available         int    `json:"available"`
+	Reserved          int    `json:"reserved"`
+	Delivered         int    `json:"delivered"`
+	Adjusted          int    `json:"adjusted"`
+	// OnHand is what the Roblox account should hold: the Robux available
+	// and reserved.
+	OnHand int `json:"onHand"`
+}
+
+// Reservation holds the Robux of an order on a supplier account.
+type Reservation struct {
+	OrderID           string     `json:"orderId"`
+	SupplierAccountID string     `json:"supplierAccountId"`
+	Robux             int        `json:"robux"`
+	Status            string     `json:"status"`
+	ExpiresAt         *time.Time `json:"expiresAt"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// Reconciliation compares the Robux a supplier account should hold with
+// its balance at Roblox. Entry is the adjustment that makes up the
+// difference, nil when there is none.
+type Reconciliation struct {
+	Expected   int           `json:"expected"`
+	Actual     int           `json:"actual"`
+	Difference int           `json:"difference"`
+	Entry      *LedgerEntry  `json:"entry"`
+	Balance    *StockBalance `json:"balance"`
+}
+
+type StockModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// stockBalanceSelect reads the buckets robux_ledger keeps in
+// robux_balances with each entry.
+const stockBalanceSelect = `
+	SELECT
+		a.id,
+		a.name,
+		a.active,
+		-b.supplier,
+		b.available,
+		b.reserved,
+		b.delivered,
+		-b.adjustment
+	FROM supplier_accounts a
+	JOIN robux_balances b ON b.supplier_account_id = a.id`
+
+const ledgerColumns = `
+		id,
+		supplier_account_id,
+		kind,
+		debit,
+		credit,
+		amount,
+		order_id,
+		note,
+		created_at`
+
+// Balances returns the stock of every supplier account, by name.
+func (m StockModel) Balances() ([]*StockBalance, error) {
+	query := stockBalanceSelect + `
+	ORDER BY a.name ASC, a.id ASC;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []*StockBalance{}
+	for rows.Next() {
+		balance, err := scanStockBalance(rows)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// Balance returns the stock of the supplier account with id.
+func (m StockModel) Balance(id string) (*StockBalance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return stockBalance(ctx, m.db, id)
+}
+
+// Available returns the most Robux a single order can be sold now: the
+// largest available balance of an active supplier account.
+func (m StockModel) Available() (int, error) {
+	query := `
+	SELECT COALESCE(MAX(b.available), 0)
+	FROM supplier_accounts a
+	JOIN robux_balances b ON b.supplier_account_id = a.id
+	WHERE a.active;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var available int
+	err := m.db.QueryRowContext(ctx, query).Scan(&available)
+	return available, err
+}
+
+// Purchase records robux bought for the supplier account with id.
+func (m StockModel) Purchase(id string, robux int, note string) (*LedgerEntry, error) {
+	query := `
+	INSERT INTO robux_ledger (supplier_account_id, kind, debit, credit, amount, note)
+	SELECT id, 'purchase', 'available', 'supplier', $2, NULLIF($3, '')
+	FROM supplier_accounts
+	WHERE id = $1
+	RETURNING` + ledgerColumns + `;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	entry, err := scanLedgerEntry(m.db.QueryRowContext(ctx, query, id, robux, note))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return entry, err
+}
+
+// Reconcile compares the Robux the supplier account with id should hold
+// with actual, its balance at Roblox, and adjusts the available Robux by
+// the difference.
+func (m StockModel) Reconcile(id string, actual int, note string) (*Reconciliation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locks the balance against ledger entries of the account meanwhile.
+	err = tx.QueryRowContext(ctx, `SELECT supplier_account_id FROM robux_balances WHERE supplier_account_id = $1 FOR UPDATE;`, id).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	balance, err := stockBalance(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Reconciliation{Expected: balance.OnHand, Actual: actual, Difference: actual - balance.OnHand}
+
+	if rec.Difference != 0 {
+		debit, credit, amount := LedgerAvailable, LedgerAdjustment, rec.Difference
+		if amount < 0 {
+			debit, credit, amount = LedgerAdjustment, LedgerAvailable, -amount
+		}
+		rec.Entry, err = scanLedgerEntry(tx.QueryRowContext(ctx, `
+		INSERT INTO robux_ledger (supplier_account_id, kind, debit, credit, amount, note)
+		VALUES ($1, 'adjustment', $2, $3, $4, NULLIF($5, ''))
+		RETURNING`+ledgerColumns+`;`, id, debit, credit, amount, note))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rec.Balance, err = stockBalance(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return rec, tx.Commit()
+}
+
+// Entries returns up to limit ledger entries of the supplier account with
+// id, newest first.
+func (m StockModel) Entries(id string, limit int) ([]*LedgerEntry, error) {
+	query := `
+	SELECT` + ledgerColumns + `
+	FROM robux_ledger
+	WHERE supplier_account_id = $1
+	ORDER BY created_at DESC, id DESC
+	LIMIT $2;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*LedgerEntry{}
+	for rows.Next() {
+		entry, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReleaseExpired releases the reservations of unpaid orders that expired
+// by now, and returns how many it released.
+func (m StockModel) ReleaseExpired(now time.Time) (int, error) {
+	query := `
+	WITH expired AS (
+		UPDATE stock_reservations SET
+			status = 'released',
+			updated_at = NOW()
+		WHERE status = 'held' AND expires_at <= $1
+		RETURNING order_id, supplier_account_id, robux
+	), l AS (
+		INSERT INTO robux_ledger (supplier_account_id, kind, debit, credit, amount, order_id, note)
+		SELECT supplier_account_id, 'release', 'available', 'reserved', robux, order_id, 'reservation expired'
+		FROM expired
+	)
+	SELECT COUNT(*) FROM expired;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var released int
+	err := m.db.QueryRowContext(ctx, query, now).Scan(&released)
+	return released, err
+}
+
+// Reservation returns the reservation of the order with id.
+func (m StockModel) Reservation(orderID string) (*Reservation, error) {
+	query := `
+	SELECT order_id, supplier_account_id, robux, status, expires_at, created_at, updated_at
+	FROM stock_reservations
+	WHERE order_id = $1;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var r Reservation
+	err := m.db.QueryRowContext(ctx, query, orderID).Scan(&r.OrderID, &r.SupplierAccountID, &r.Robux, &r.Status, &r.ExpiresAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func stockBalance(ctx context.Context, db queryRower, id string) (*StockBalance, error) {
+	query := stockBalanceSelect + `
+	WHERE a.id = $1;`
+
+	balance, err := scanStockBalance(db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return balance, err
+}
+
+// reserveStock holds the Robux of order until expiresAt on the active
+// supplier account with the most available, and delivers the order from
+// it. It returns ErrOutOfStock when no account has enough.
+func reserveStock(ctx context.Context, tx *sql.Tx, order *Order, expiresAt time.Time) error {
+	// Locks the balance of the chosen account only. A balance another
+	// checkout changed meanwhile is checked again once unlocked, and the
+	// next account is taken when it no longer has enough, so concurrent
+	// checkouts never oversell.
+	var accountID string
+	err := tx.QueryRowContext(ctx, `
+	SELECT b.supplier_account_id
+	FROM robux_balances b
+	JOIN supplier_accounts a ON a.id = b.supplier_account_id
+	WHERE a.active AND b.available >= $1
+	ORDER BY b.available DESC, b.supplier_account_id ASC
+	LIMIT 1
+	FOR UPDATE OF b;`, order.Robux).Scan(&accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOutOfStock
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO stock_reservations (order_id, supplier_account_id, robux, expires_at)
+	VALUES ($1, $2, $3, $4);`, order.ID, accountID, order.Robux, expiresAt)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO robux_ledger (supplier_account_id, kind, debit, credit, amount, order_id)
+	VALUES ($1, 'reservation', 'reserved', 'available', $2, $3);`, accountID, order.Robux, order.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE orders SET supplier_account_id = $2 WHERE id = $1;`, order.ID, accountID)
+	if err != nil {
+		return err
+	}
+
+	order.Delivery.SupplierAccountID = &accountID
+	return nil
+}
+
+// syncReservation moves the Robux reserved for order along with its new
+// status: paid holds them until delivery, delivered consumes them, failed
+// and refunded release them.
+func syncReservation(ctx context.Context, tx *sql.Tx, order *Order) error {
+	var r Reservation
+	err := tx.QueryRowContext(ctx, `
+	SELECT supplier_account_id, robux, status
+	FROM stock_reservations
+	WHERE order_id = $1
+	FOR UPDATE;`, order.ID).Scan(&r.SupplierAccountID, &r.Robux, &r.Status)
+	if err != nil {
+		// Placed before stock was tracked.
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	move := func(kind, debit, credit, status string) error {
+		_, err := tx.ExecContext(ctx, `
+		UPDATE stock_reservations SET status = $2, expires_at = NULL, updated_at = NOW()
+		WHERE order_id = $1;`, order.ID, status)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO robux_ledger (supplier_account_id, kind, debit, credit, amount, order_id)
+		VALUES ($1, $2, $3, $4, $5, $6);`, r.SupplierAccountID, kind, debit, credit, r.Robux, order.ID)
+		return err
+	}
+
+	switch order.Status {
+	case OrderStatusPaid:
+		switch r.Status {
+		case ReservationHeld:
+			_, err := tx.ExecContext(ctx, `UPDATE stock_reservations SET expires_at = NULL, updated_at = NOW() WHERE order_id = $1;`, order.ID)
+			return err
+		case ReservationReleased:
+			// Paid orders are owed their Robux, so they are reserved again
+			// even beyond the available balance.
+			return move(LedgerReservation, LedgerReserved, LedgerAvailable, ReservationHeld)
+		}
+	case OrderStatusDelivered:
+		switch r.Status {
+		case ReservationHeld:
+			return move(LedgerConsumption, LedgerDelivered, LedgerReserved, ReservationConsumed)
+		case ReservationReleased:
+			return move(LedgerConsumption, LedgerDelivered, LedgerAvailable, ReservationConsumed)
+		}
+	case OrderStatusFailed, OrderStatusRefunded:
+		if r.Status == ReservationHeld {
+			return move(LedgerRelease, LedgerAvailable, LedgerReserved, ReservationReleased)
+		}
+	}
+	return nil
+}
+
+func scanStockBalance(row rowScanner) (*StockBalance, error) {
+	var b StockBalance
+	err := row.Scan(
+		&b.SupplierAccountID,
+		&b.Name,
+		&b.Active,
+		&b.Purchased,
+		&b.Available,
+		&b.Reserved,
+		&b.Delivered,
+		&b.Adjusted,
+	)
+	if err != nil {
+		return nil, err
+	}
+	b.OnHand = b.Available + b.Reserved
+	return &b, nil
+}
+
+func scanLedgerEntry(row rowScanner) (*LedgerEntry, error) {
+	var e LedgerEntry
+	err := row.Scan(
+		&e.ID,
+		&e.SupplierAccountID,
+		&e.Kind,
+		&e.Debit,
+		&e.Credit,
+		&e.Amount,
+		&e.OrderID,
+		&e.Note,
+		&e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
@@ -0,0 +1,133 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// SupplierAccount is a Roblox account Robux are delivered from.
+type SupplierAccount struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// MaxConcurrency caps the deliveries from the account running at once.
+	MaxConcurrency int       `json:"maxConcurrency"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type SupplierAccountModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+const supplierAccountColumns = `
+		id,
+		name,
+		max_concurrency,
+		active,
+		created_at,
+		updated_at`
+
+// GetAll returns every supplier account, active or not, by name.
+func (m SupplierAccountModel) GetAll() ([]*SupplierAccount, error) {
+	query := `
+	SELECT` + supplierAccountColumns + `
+	FROM supplier_accounts
+	ORDER BY name ASC, id ASC;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []*SupplierAccount{}
+	for rows.Next() {
+		account, err := scanSupplierAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Get returns the supplier account with id.
+func (m SupplierAccountModel) Get(id string) (*SupplierAccount, error) {
+	query := `
+	SELECT` + supplierAccountColumns + `
+	FROM supplier_accounts
+	WHERE id = $1;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	account, err := scanSupplierAccount(m.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return account, err
+}
+
+// Insert stores a new supplier account and sets its ID and timestamps.
+func (m SupplierAccountModel) Insert(account *SupplierAccount) error {
+	query := `
+	INSERT INTO supplier_accounts (name, max_concurrency, active)
+	VALUES ($1, $2, $3)
+	RETURNING id, created_at, updated_at;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := []any{account.Name, account.MaxConcurrency, account.Active}
+	return m.db.QueryRowContext(ctx, query, args...).Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt)
+}
+
+// Update saves the name, concurrency and state of a supplier account.
+func (m SupplierAccountModel) Update(account *SupplierAccount) error {
+	query := `
+	UPDATE supplier_accounts SET
+		name = $2,
+		max_concurrency = $3,
+		active = $4,
+		updated_at = NOW()
+	WHERE id = $1
+	RETURNING created_at, updated_at;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := []any{account.ID, account.Name, account.MaxConcurrency, account.Active}
+	err := m.db.QueryRowContext(ctx, query, args...).Scan(&account.CreatedAt, &account.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRecordNotFound
+	}
+	return err
+}
+
+func scanSupplierAccount(row rowScanner) (*SupplierAccount, error) {
+	var account SupplierAccount
+	err := row.Scan(
+		&account.ID,
+		&account.Name,
+		&account.MaxConcurrency,
+		&account.Active,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
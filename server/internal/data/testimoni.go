@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 )
 
@@ -125,3 +126,84 @@ func (m TestimoniModel) GetAll(param TestimoniGetAllParam) ([]*TestimoniWithUser
 	metadata := calculateMetadata(totalRecords, param.Page, param.PageSize)
 	return testimonies, &metadata, nil
 }
+
+func (m TestimoniModel) Stream(ctx context.Context, fn func(*TestimoniWithUser) error) error {
+	query := `
+	SELECT
+		t.id, t.user_id, t.testimoni, t.icon_url, t.created_at, t.updated_at,
+		u.id, u.username, u.email, u.image_url, u.address_line, u.city,
+		u.province, u.postal_code, u.country, u.created_at, u.updated_at
+	FROM testimonies t
+	INNER JOIN users u ON t.user_id = u.id
+	ORDER BY t.created_at DESC, t.id ASC;`
+
+	// No timeout, the export runs as long as the client keeps reading.
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item TestimoniWithUser
+
+		err := rows.Scan(
+			&item.Testimoni.ID,
+			&item.Testimoni.UserID,
+			&item.Testimoni.Testimoni,
+			&item.Testimoni.IconURL,
+			&item.Testimoni.CreatedAt,
+			&item.Testimoni.UpdatedAt,
+
+			&item.User.ID,
+			&item.User.Username,
+			&item.User.Email,
+			&item.User.ImageUrl,
+			&item.User.AddressLine,
+			&item.User.City,
+			&item.User.Province,
+			&item.User.PostalCode,
+			&item.User.Country,
+			&item.User.CreatedAt,
+			&item.User.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// UpdateIconURL returns ErrRecordNotFound when no testimoni has id.
+func (m TestimoniModel) UpdateIconURL(id, iconURL string) (*Testimoni, error) {
+	query := `
+	UPDATE testimonies
+	SET icon_url = $2, updated_at = NOW()
+	WHERE id = $1
+	RETURNING id, user_id, testimoni, icon_url, created_at, updated_at;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var testimoni Testimoni
+	err := m.db.QueryRowContext(ctx, query, id, iconURL).Scan(
+		&testimoni.ID,
+		&testimoni.UserID,
+		&testimoni.Testimoni,
+		&testimoni.IconURL,
+		&testimoni.CreatedAt,
+		&testimoni.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &testimoni, nil
+}
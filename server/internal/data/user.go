@@ -1,6 +1,11 @@
 package data
 
-import "time"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
 
 type User struct {
 	ID       string `json:"id"`
@@ -18,3 +23,47 @@ type User struct {
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
+
+type UserModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// UpdateImageURL returns ErrRecordNotFound when no user has id.
+func (m UserModel) UpdateImageURL(id, imageURL string) (*User, error) {
+	query := `
+	UPDATE users
+	SET image_url = $2, updated_at = NOW()
+	WHERE id = $1
+	RETURNING
+		id, username, email, image_url,
+		COALESCE(address_line, ''), COALESCE(city, ''), COALESCE(province, ''),
+		COALESCE(postal_code, ''), COALESCE(country, ''),
+		created_at, updated_at;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var user User
+	err := m.db.QueryRowContext(ctx, query, id, imageURL).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.ImageUrl,
+		&user.AddressLine,
+		&user.City,
+		&user.Province,
+		&user.PostalCode,
+		&user.Country,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,445 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+// ErrDuplicateVoucherCode is returned when another voucher has the code.
+var ErrDuplicateVoucherCode = errors.New("data: voucher code already exists")
+
+// VoucherMinTotal is the least an order costs after its discount, Rp1.000,
+// so every order still has a charge to pay.
+const VoucherMinTotal = pricing.Money(1000 * 100)
+
+// Reasons a voucher does not apply to an order.
+const (
+	VoucherNotFound   = "not_found"
+	VoucherInactive   = "inactive"
+	VoucherNotStarted = "not_started"
+	VoucherExpired    = "expired"
+	VoucherMinSpend   = "min_spend"
+	VoucherExcluded   = "product_excluded"
+	VoucherUsedUp     = "usage_limit"
+	VoucherUserLimit  = "user_limit"
+)
+
+// VoucherError is returned when a voucher does not apply to an order.
+type VoucherError struct {
+	Reason string
+	// MinSpend is the least subtotal the voucher applies to, set when
+	// Reason is VoucherMinSpend.
+	MinSpend pricing.Money
+}
+
+func (e *VoucherError) Error() string {
+	return "data: voucher not applicable: " + e.Reason
+}
+
+// Voucher is a promo code taking PercentOff percent or AmountOffIDR off
+// the subtotal of an order. Exactly one of them is set.
+type Voucher struct {
+	ID string `json:"id"`
+	// Code is stored upper-case and matched case-insensitively.
+	Code         string         `json:"code"`
+	Description  string         `json:"description"`
+	PercentOff   *int           `json:"percentOff"`
+	AmountOffIDR *pricing.Money `json:"amountOffIdr"`
+	// MaxDiscountIDR caps the discount of a percent voucher.
+	MaxDiscountIDR *pricing.Money `json:"maxDiscountIdr"`
+	MinSpendIDR    pricing.Money  `json:"minSpendIdr"`
+	// Category and ProductIDs restrict the voucher to products of the
+	// category and to the products listed, when set.
+	Category   *string  `json:"category"`
+	ProductIDs []string `json:"productIds"`
+	// UsageLimit caps the orders using the voucher, PerUserLimit the
+	// orders of one Roblox username. Failed and refunded orders do not
+	// count.
+	UsageLimit   *int       `json:"usageLimit"`
+	PerUserLimit *int       `json:"perUserLimit"`
+	StartsAt     *time.Time `json:"startsAt"`
+	EndsAt       *time.Time `json:"endsAt"`
+	Active       bool       `json:"active"`
+	// Redemptions counts the orders using the voucher.
+	Redemptions int       `json:"redemptions"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// VoucherUse is an order a voucher is applied to.
+type VoucherUse struct {
+	Product *Product
+	// RobloxUsername is the buyer the per-user limit counts, empty to skip
+	// the limit.
+	RobloxUsername string
+	SubtotalIDR    pricing.Money
+	At             time.Time
+}
+
+// Check reports whether v applies to use, apart from its usage limits.
+func (v *Voucher) Check(use VoucherUse) error {
+	switch {
+	case !v.Active:
+		return &VoucherError{Reason: VoucherInactive}
+	case v.StartsAt != nil && use.At.Before(*v.StartsAt):
+		return &VoucherError{Reason: VoucherNotStarted}
+	case v.EndsAt != nil && !use.At.Before(*v.EndsAt):
+		return &VoucherError{Reason: VoucherExpired}
+	case v.Category != nil && *v.Category != use.Product.Category:
+		return &VoucherError{Reason: VoucherExcluded}
+	case len(v.ProductIDs) > 0 && !slices.Contains(v.ProductIDs, use.Product.ID):
+		return &VoucherError{Reason: VoucherExcluded}
+	case use.SubtotalIDR < v.MinSpendIDR:
+		return &VoucherError{Reason: VoucherMinSpend, MinSpend: v.MinSpendIDR}
+	}
+	return nil
+}
+
+// CheckLimits reports whether v can be used again, given the orders
+// already using it in total and by the buyer.
+func (v *Voucher) CheckLimits(used, usedByBuyer int) error {
+	if v.UsageLimit != nil && used >= *v.UsageLimit {
+		return &VoucherError{Reason: VoucherUsedUp}
+	}
+	if v.PerUserLimit != nil && usedByBuyer >= *v.PerUserLimit {
+		return &VoucherError{Reason: VoucherUserLimit}
+	}
+	return nil
+}
+
+// Discount returns what v takes off subtotal, in whole rupiah. It never
+// brings the total below VoucherMinTotal.
+func (v *Voucher) Discount(subtotal pricing.Money) pricing.Money {
+	var discount pricing.Money
+	switch {
+	case v.PercentOff != nil:
+		discount = subtotal * pricing.Money(*v.PercentOff) / 100
+		if v.MaxDiscountIDR != nil {
+			discount = min(discount, *v.MaxDiscountIDR)
+		}
+	case v.AmountOffIDR != nil:
+		discount = *v.AmountOffIDR
+	}
+	discount = min(discount, subtotal-VoucherMinTotal)
+	discount -= discount % pricing.Rupiah(1)
+	return max(discount, 0)
+}
+
+// NormalizeVoucherCode returns code the way it is stored.
+func NormalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+type VoucherModel struct {
+	db *sql.DB
+}
+
+/* ---------------------------- METHOD ---------------------------- */
+
+// voucherRedemptions counts the orders using the voucher v.
+const voucherRedemptions = `(
+		SELECT COUNT(*) FROM orders o
+		WHERE o.voucher_id = v.id AND o.status NOT IN ('failed', 'refunded')
+	)`
+
+var voucherColumns = `
+		v.id,
+		v.code,
+		v.description,
+		v.percent_off,
+		v.amount_off_idr,
+		v.max_discount_idr,
+		v.min_spend_idr,
+		v.category,
+		COALESCE((SELECT string_agg(vp.product_id::text, ',' ORDER BY vp.product_id) FROM voucher_products vp WHERE vp.voucher_id = v.id), ''),
+		v.usage_limit,
+		v.per_user_limit,
+		v.starts_at,
+		v.ends_at,
+		v.active,
+		` + voucherRedemptions + `,
+		v.created_at,
+		v.updated_at`
+
+// GetAll returns every voucher, newest first.
+func (m VoucherModel) GetAll() ([]*Voucher, error) {
+	query := `
+	SELECT` + voucherColumns + `
+	FROM vouchers v
+	ORDER BY v.created_at DESC, v.id DESC;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vouchers := []*Voucher{}
+	for rows.Next() {
+		voucher, err := scanVoucher(rows)
+		if err != nil {
+			return nil, err
+		}
+		vouchers = append(vouchers, voucher)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return vouchers, nil
+}
+
+// Get returns the voucher with id.
+func (m VoucherModel) Get(id string) (*Voucher, error) {
+	query := `
+	SELECT` + voucherColumns + `
+	FROM vouchers v
+	WHERE v.id = $1;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	voucher, err := scanVoucher(m.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	return voucher, err
+}
+
+// Apply returns the voucher with code and the discount it gives use,
+// counting its usage limits. It returns a *VoucherError when the voucher
+// does not apply.
+func (m VoucherModel) Apply(code string, use VoucherUse) (*Voucher, pricing.Money, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	voucher, err := scanVoucher(tx.QueryRowContext(ctx, `
+	SELECT`+voucherColumns+`
+	FROM vouchers v
+	WHERE v.code = $1;`, NormalizeVoucherCode(code)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, &VoucherError{Reason: VoucherNotFound}
+		}
+		return nil, 0, err
+	}
+	if err := checkVoucher(ctx, tx, voucher, use); err != nil {
+		return nil, 0, err
+	}
+	return voucher, voucher.Discount(use.SubtotalIDR), nil
+}
+
+// Insert stores a new voucher with its product restrictions and sets its
+// ID and timestamps. It returns ErrDuplicateVoucherCode when the code is
+// taken and ErrRecordNotFound when a product does not exist.
+func (m VoucherModel) Insert(voucher *Voucher) error {
+	query := `
+	INSERT INTO vouchers (code, description, percent_off, amount_off_idr, max_discount_idr, min_spend_idr, category, usage_limit, per_user_limit, starts_at, ends_at, active)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	RETURNING id, created_at, updated_at;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	voucher.Code = NormalizeVoucherCode(voucher.Code)
+	args := []any{voucher.Code, voucher.Description, voucher.PercentOff, voucher.AmountOffIDR, voucher.MaxDiscountIDR, voucher.MinSpendIDR, voucher.Category, voucher.UsageLimit, voucher.PerUserLimit, voucher.StartsAt, voucher.EndsAt, voucher.Active}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&voucher.ID, &voucher.CreatedAt, &voucher.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateVoucherCode
+		}
+		return err
+	}
+	if err := setVoucherProducts(ctx, tx, voucher); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update saves every field of a voucher but its redemptions. It returns
+// ErrRecordNotFound when the voucher or a product does not exist, and
+// ErrDuplicateVoucherCode when another voucher has the code.
+func (m VoucherModel) Update(voucher *Voucher) error {
+	query := `
+	UPDATE vouchers SET
+		code = $2,
+		description = $3,
+		percent_off = $4,
+		amount_off_idr = $5,
+		max_discount_idr = $6,
+		min_spend_idr = $7,
+		category = $8,
+		usage_limit = $9,
+		per_user_limit = $10,
+		starts_at = $11,
+		ends_at = $12,
+		active = $13,
+		updated_at = NOW()
+	WHERE id = $1
+	RETURNING created_at, updated_at;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	voucher.Code = NormalizeVoucherCode(voucher.Code)
+	args := []any{voucher.ID, voucher.Code, voucher.Description, voucher.PercentOff, voucher.AmountOffIDR, voucher.MaxDiscountIDR, voucher.MinSpendIDR, voucher.Category, voucher.UsageLimit, voucher.PerUserLimit, voucher.StartsAt, voucher.EndsAt, voucher.Active}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&voucher.CreatedAt, &voucher.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		case isUniqueViolation(err):
+			return ErrDuplicateVoucherCode
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM voucher_products WHERE voucher_id = $1;`, voucher.ID); err != nil {
+		return err
+	}
+	if err := setVoucherProducts(ctx, tx, voucher); err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `SELECT `+voucherRedemptions+` FROM vouchers v WHERE v.id = $1;`, voucher.ID).Scan(&voucher.Redemptions)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func setVoucherProducts(ctx context.Context, tx *sql.Tx, voucher *Voucher) error {
+	if voucher.ProductIDs == nil {
+		voucher.ProductIDs = []string{}
+	}
+	slices.Sort(voucher.ProductIDs)
+	voucher.ProductIDs = slices.Compact(voucher.ProductIDs)
+
+	for _, id := range voucher.ProductIDs {
+		_, err := tx.ExecContext(ctx, `INSERT INTO voucher_products (voucher_id, product_id) VALUES ($1, $2);`, voucher.ID, id)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return ErrRecordNotFound
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// checkVoucher reports whether voucher applies to use, counting the
+// orders already using it in tx.
+func checkVoucher(ctx context.Context, tx *sql.Tx, voucher *Voucher, use VoucherUse) error {
+	if err := voucher.Check(use); err != nil {
+		return err
+	}
+
+	var usedByBuyer int
+	err := tx.QueryRowContext(ctx, `
+	SELECT COUNT(*)
+	FROM orders
+	WHERE voucher_id = $1 AND status NOT IN ('failed', 'refunded') AND lower(roblox_username) = lower($2);`,
+		voucher.ID, use.RobloxUsername).Scan(&usedByBuyer)
+	if err != nil {
+		return err
+	}
+	return voucher.CheckLimits(voucher.Redemptions, usedByBuyer)
+}
+
+// redeemVoucher applies the voucher of order in tx, setting its discount
+// and total from its subtotal. The voucher row stays locked until tx
+// ends, so concurrent checkouts count each other's redemptions.
+func redeemVoucher(ctx context.Context, tx *sql.Tx, order *Order) error {
+	if order.VoucherID == nil {
+		order.DiscountIDR = 0
+		order.TotalIDR = order.SubtotalIDR
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `SELECT 1 FROM vouchers WHERE id = $1 FOR UPDATE;`, *order.VoucherID)
+	if err != nil {
+		return err
+	}
+	voucher, err := scanVoucher(tx.QueryRowContext(ctx, `
+	SELECT`+voucherColumns+`
+	FROM vouchers v
+	WHERE v.id = $1;`, *order.VoucherID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &VoucherError{Reason: VoucherNotFound}
+		}
+		return err
+	}
+
+	var product Product
+	err = tx.QueryRowContext(ctx, `SELECT id, category FROM products WHERE id = $1;`, order.ProductID).Scan(&product.ID, &product.Category)
+	if err != nil {
+		return err
+	}
+
+	use := VoucherUse{Product: &product, RobloxUsername: order.RobloxUsername, SubtotalIDR: order.SubtotalIDR, At: time.Now()}
+	if err := checkVoucher(ctx, tx, voucher, use); err != nil {
+		return err
+	}
+	order.DiscountIDR = voucher.Discount(order.SubtotalIDR)
+	order.TotalIDR = order.SubtotalIDR - order.DiscountIDR
+	return nil
+}
+
+func scanVoucher(row rowScanner) (*Voucher, error) {
+	var voucher Voucher
+	var productIDs string
+	err := row.Scan(
+		&voucher.ID,
+		&voucher.Code,
+		&voucher.Description,
+		&voucher.PercentOff,
+		&voucher.AmountOffIDR,
+		&voucher.MaxDiscountIDR,
+		&voucher.MinSpendIDR,
+		&voucher.Category,
+		&productIDs,
+		&voucher.UsageLimit,
+		&voucher.PerUserLimit,
+		&voucher.StartsAt,
+		&voucher.EndsAt,
+		&voucher.Active,
+		&voucher.Redemptions,
+		&voucher.CreatedAt,
+		&voucher.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	voucher.ProductIDs = []string{}
+	if productIDs != "" {
+		voucher.ProductIDs = strings.Split(productIDs, ",")
+	}
+	return &voucher, nil
+}
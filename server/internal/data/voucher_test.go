@@ -0,0 +1,95 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+func TestVoucherCheck(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	robux := &Product{ID: "p-1", Category: ProductCategoryRobux}
+	use := VoucherUse{Product: robux, SubtotalIDR: pricing.Rupiah(60000), At: now}
+
+	reason := func(err error) string {
+		if err == nil {
+			return ""
+		}
+		return err.(*VoucherError).Reason
+	}
+
+	t.Run("applies when every rule is met", func(t *testing.T) {
+		v := Voucher{Active: true, MinSpendIDR: pricing.Rupiah(50000), Category: utility.SetPtrValue(ProductCategoryRobux), ProductIDs: []string{"p-1"}}
+
+		assert.NoError(t, v.Check(use))
+	})
+
+	t.Run("rejects inactive vouchers", func(t *testing.T) {
+		v := Voucher{}
+
+		assert.Equal(t, VoucherInactive, reason(v.Check(use)))
+	})
+
+	t.Run("rejects outside the validity window", func(t *testing.T) {
+		v := Voucher{Active: true, StartsAt: utility.SetPtrValue(now.Add(time.Hour))}
+		assert.Equal(t, VoucherNotStarted, reason(v.Check(use)))
+
+		v = Voucher{Active: true, EndsAt: &now}
+		assert.Equal(t, VoucherExpired, reason(v.Check(use)))
+	})
+
+	t.Run("rejects other products", func(t *testing.T) {
+		v := Voucher{Active: true, Category: utility.SetPtrValue(ProductCategoryGamepass)}
+		assert.Equal(t, VoucherExcluded, reason(v.Check(use)))
+
+		v = Voucher{Active: true, ProductIDs: []string{"p-2"}}
+		assert.Equal(t, VoucherExcluded, reason(v.Check(use)))
+	})
+
+	t.Run("rejects subtotals below the minimum spend", func(t *testing.T) {
+		v := Voucher{Active: true, MinSpendIDR: pricing.Rupiah(100000)}
+
+		err := v.Check(use)
+		assert.Equal(t, VoucherMinSpend, reason(err))
+		assert.Equal(t, pricing.Rupiah(100000), err.(*VoucherError).MinSpend)
+	})
+
+	t.Run("counts usage limits", func(t *testing.T) {
+		v := Voucher{UsageLimit: utility.SetPtrValue(10), PerUserLimit: utility.SetPtrValue(1)}
+
+		assert.NoError(t, v.CheckLimits(9, 0))
+		assert.Equal(t, VoucherUsedUp, reason(v.CheckLimits(10, 0)))
+		assert.Equal(t, VoucherUserLimit, reason(v.CheckLimits(9, 1)))
+	})
+}
+
+func TestVoucherDiscount(t *testing.T) {
+	t.Run("takes a percentage in whole rupiah", func(t *testing.T) {
+		v := Voucher{PercentOff: utility.SetPtrValue(10)}
+
+		assert.Equal(t, pricing.Rupiah(14426), v.Discount(pricing.Rupiah(144261)))
+	})
+
+	t.Run("caps percentages", func(t *testing.T) {
+		v := Voucher{PercentOff: utility.SetPtrValue(10), MaxDiscountIDR: utility.SetPtrValue(pricing.Rupiah(5000))}
+
+		assert.Equal(t, pricing.Rupiah(5000), v.Discount(pricing.Rupiah(144261)))
+	})
+
+	t.Run("takes a fixed amount", func(t *testing.T) {
+		v := Voucher{AmountOffIDR: utility.SetPtrValue(pricing.Rupiah(5000))}
+
+		assert.Equal(t, pricing.Rupiah(5000), v.Discount(pricing.Rupiah(144261)))
+	})
+
+	t.Run("keeps the minimum total", func(t *testing.T) {
+		v := Voucher{PercentOff: utility.SetPtrValue(100)}
+		assert.Equal(t, pricing.Rupiah(9000), v.Discount(pricing.Rupiah(10000)))
+
+		v = Voucher{AmountOffIDR: utility.SetPtrValue(pricing.Rupiah(5000))}
+		assert.Zero(t, v.Discount(pricing.Rupiah(800)))
+	})
+}
@@ -0,0 +1,117 @@
+// Package featured picks the Product of the Day set. Editors can fix the
+// picks of a day in a calendar; the remaining slots are drawn at random,
+// weighted per product, preferring products that were not featured
+// recently.
+package featured
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// Sources of a pick.
+const (
+	SourceCalendar = "calendar"
+	SourceRandom   = "random"
+)
+
+// Candidate is a product that can be drawn. Products with a zero weight
+// are only featured through the calendar.
+type Candidate struct {
+	ProductID string
+	Weight    int
+}
+
+type Pick struct {
+	ProductID string
+	Source    string
+}
+
+// Day returns the date of t in Asia/Jakarta, formatted as 2006-01-02.
+func Day(t time.Time) string {
+	return t.In(utility.Jakarta).Format(time.DateOnly)
+}
+
+// AddDays returns the date days after day, which is formatted as
+// 2006-01-02.
+func AddDays(day string, days int) (string, error) {
+	t, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return "", err
+	}
+	return t.AddDate(0, 0, days).Format(time.DateOnly), nil
+}
+
+// Choose returns up to n picks: the calendar entries in their order, then
+// candidates drawn with probability proportional to their weight. Recent
+// products are only drawn once every other candidate is picked.
+func Choose(n int, calendar []string, candidates []Candidate, recent []string, rng *rand.Rand) []Pick {
+	picks := make([]Pick, 0, n)
+	picked := make(map[string]bool, n)
+	for _, id := range calendar {
+		if len(picks) == n {
+			return picks
+		}
+		if !picked[id] {
+			picks = append(picks, Pick{ProductID: id, Source: SourceCalendar})
+			picked[id] = true
+		}
+	}
+
+	var fresh, stale []Candidate
+	for _, c := range candidates {
+		switch {
+		case c.Weight <= 0 || picked[c.ProductID]:
+		case slices.Contains(recent, c.ProductID):
+			stale = append(stale, c)
+		default:
+			fresh = append(fresh, c)
+		}
+	}
+
+	for _, pool := range [][]Candidate{fresh, stale} {
+		for _, id := range draw(pool, n-len(picks), rng) {
+			picks = append(picks, Pick{ProductID: id, Source: SourceRandom})
+		}
+	}
+	return picks
+}
+
+// draw samples n candidates without replacement, each with probability
+// proportional to its weight (Efraimidis-Spirakis): every candidate gets the
+// key -ln(u)/weight and the n smallest keys win.
+func draw(pool []Candidate, n int, rng *rand.Rand) []string {
+	if n <= 0 || len(pool) == 0 {
+		return nil
+	}
+
+	type keyed struct {
+		id  string
+		key float64
+	}
+	keys := make([]keyed, len(pool))
+	for i, c := range pool {
+		// 1-Float64() is in (0, 1], so the log is finite.
+		keys[i] = keyed{c.ProductID, -math.Log(1-rng.Float64()) / float64(c.Weight)}
+	}
+	slices.SortFunc(keys, func(a, b keyed) int {
+		switch {
+		case a.key < b.key:
+			return -1
+		case a.key > b.key:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	ids := make([]string, 0, min(n, len(keys)))
+	for _, k := range keys[:min(n, len(keys))] {
+		ids = append(ids, k.id)
+	}
+	return ids
+}
@@ -0,0 +1,77 @@
+package featured
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ids(picks []Pick) []string {
+	var out []string
+	for _, p := range picks {
+		out = append(out, p.ProductID)
+	}
+	return out
+}
+
+func TestChoose(t *testing.T) {
+	candidates := []Candidate{{"a", 1}, {"b", 1}, {"c", 1}, {"d", 1}}
+
+	t.Run("puts calendar entries first", func(t *testing.T) {
+		picks := Choose(3, []string{"c", "x", "c"}, candidates, nil, rand.New(rand.NewPCG(1, 2)))
+
+		require.Len(t, picks, 3)
+		assert.Equal(t, Pick{ProductID: "c", Source: SourceCalendar}, picks[0])
+		assert.Equal(t, Pick{ProductID: "x", Source: SourceCalendar}, picks[1])
+		assert.Equal(t, SourceRandom, picks[2].Source)
+		assert.NotContains(t, []string{"c", "x"}, picks[2].ProductID)
+	})
+
+	t.Run("caps the calendar at n", func(t *testing.T) {
+		picks := Choose(1, []string{"a", "b"}, candidates, nil, rand.New(rand.NewPCG(1, 2)))
+
+		assert.Equal(t, []string{"a"}, ids(picks))
+	})
+
+	t.Run("draws recent products last", func(t *testing.T) {
+		for seed := range uint64(50) {
+			picks := Choose(3, nil, candidates, []string{"a", "b"}, rand.New(rand.NewPCG(seed, 0)))
+
+			require.Len(t, picks, 3)
+			assert.ElementsMatch(t, []string{"c", "d"}, ids(picks)[:2])
+			assert.Contains(t, []string{"a", "b"}, picks[2].ProductID)
+		}
+	})
+
+	t.Run("skips zero weights and returns fewer when out of candidates", func(t *testing.T) {
+		picks := Choose(5, nil, []Candidate{{"a", 0}, {"b", 2}}, nil, rand.New(rand.NewPCG(1, 2)))
+
+		assert.Equal(t, []string{"b"}, ids(picks))
+	})
+
+	t.Run("draws proportionally to weight", func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(7, 7))
+		heavy := 0
+		for range 10000 {
+			picks := Choose(1, nil, []Candidate{{"light", 1}, {"heavy", 9}}, nil, rng)
+			if picks[0].ProductID == "heavy" {
+				heavy++
+			}
+		}
+
+		assert.InDelta(t, 9000, heavy, 200)
+	})
+}
+
+func TestDay(t *testing.T) {
+	// 17:00 UTC is already the next day in Jakarta.
+	assert.Equal(t, "2026-10-20", Day(time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2026-10-19", Day(time.Date(2026, 10, 19, 16, 59, 0, 0, time.UTC)))
+
+	day, err := AddDays("2026-03-01", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-02-28", day)
+}
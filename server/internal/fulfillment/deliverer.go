@@ -0,0 +1,46 @@
+package fulfillment
+
+import (
+	"context"
+	"crypto/rand"
+)
+
+// Delivery is an attempt to deliver the Robux of an order.
+type Delivery struct {
+	OrderID        string
+	RobloxUsername string
+	Robux          int
+	// SupplierAccountID is the account to deliver from.
+	SupplierAccountID string
+	// Attempt counts from 1.
+	Attempt int
+}
+
+// Receipt proves a delivery.
+type Receipt struct {
+	// Reference identifies the delivery at Roblox, e.g. the ID of the
+	// gamepass purchase or group payout.
+	Reference string
+}
+
+// Deliverer hands Robux to buyers. Errors are retried as the Policy says,
+// unless wrapped with Permanent.
+type Deliverer interface {
+	Name() string
+	Deliver(ctx context.Context, d Delivery) (*Receipt, error)
+}
+
+// Fake delivers every order at once without contacting Roblox, for
+// development and tests.
+type Fake struct{}
+
+func (Fake) Name() string {
+	return "fake"
+}
+
+func (Fake) Deliver(ctx context.Context, _ Delivery) (*Receipt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &Receipt{Reference: "fake_" + rand.Text()}, nil
+}
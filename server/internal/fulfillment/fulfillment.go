@@ -0,0 +1,114 @@
+// Package fulfillment models the life of an order, from checkout to the
+// Robux landing on the buyer's account, as a state machine:
+//
+//	pending_payment → paid → delivering → delivered
+//	                    ↑         │
+//	                    └─ retry ─┤
+//	                              ↓
+//	                   failed / refunded
+//
+// Every change of status goes through Check, which allows only the
+// transitions in the table below, each to the actors that may make it.
+// Failed delivery attempts are retried as a Policy says.
+package fulfillment
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Order statuses. An order is completed once it is delivered; delivered
+// and refunded orders never change again.
+const (
+	StatusPendingPayment = "pending_payment"
+	StatusPaid           = "paid"
+	StatusDelivering     = "delivering"
+	StatusDelivered      = "delivered"
+	StatusFailed         = "failed"
+	StatusRefunded       = "refunded"
+)
+
+// Actors recorded with every transition.
+const (
+	// ActorCustomer places orders.
+	ActorCustomer = "customer"
+	// ActorPayment is the payment provider, through its webhooks or the
+	// reconciler.
+	ActorPayment = "payment"
+	// ActorSystem is the delivery loop.
+	ActorSystem = "system"
+	// ActorAdmin retries and resolves orders by hand.
+	ActorAdmin = "admin"
+)
+
+var (
+	ErrInvalidTransition = errors.New("fulfillment: transition not allowed")
+	// ErrNotPaid guards the transitions that hand out Robux or money.
+	ErrNotPaid = errors.New("fulfillment: order was never paid")
+)
+
+// State is what the guards know of an order.
+type State struct {
+	Status string
+	// Paid reports whether the payment of the order was ever settled,
+	// including payments refunded since.
+	Paid bool
+}
+
+type edge struct {
+	from, to string
+}
+
+type rule struct {
+	actors []string
+	// paid requires State.Paid.
+	paid bool
+}
+
+var transitions = map[edge]rule{
+	{StatusPendingPayment, StatusPaid}:   {actors: []string{ActorPayment}},
+	{StatusPendingPayment, StatusFailed}: {actors: []string{ActorPayment}},
+
+	{StatusPaid, StatusDelivering}: {actors: []string{ActorSystem}},
+	// An admin moving a paid order to paid clears its retry backoff.
+	{StatusPaid, StatusPaid}:      {actors: []string{ActorAdmin}},
+	{StatusPaid, StatusDelivered}: {actors: []string{ActorAdmin}},
+	{StatusPaid, StatusFailed}:    {actors: []string{ActorAdmin}},
+	{StatusPaid, StatusRefunded}:  {actors: []string{ActorPayment, ActorAdmin}},
+
+	{StatusDelivering, StatusDelivered}: {actors: []string{ActorSystem, ActorAdmin}},
+	// Back to paid schedules another attempt. Admins use it for attempts
+	// that never finished, e.g. when the process died mid delivery.
+	{StatusDelivering, StatusPaid}:   {actors: []string{ActorSystem, ActorAdmin}},
+	{StatusDelivering, StatusFailed}: {actors: []string{ActorSystem, ActorAdmin}},
+
+	// Failed orders whose payment never settled stay failed.
+	{StatusFailed, StatusPaid}:      {actors: []string{ActorAdmin}, paid: true},
+	{StatusFailed, StatusDelivered}: {actors: []string{ActorAdmin}, paid: true},
+	{StatusFailed, StatusRefunded}:  {actors: []string{ActorPayment, ActorAdmin}, paid: true},
+}
+
+// Check reports whether actor may move an order in state s to status to.
+// It returns an error wrapping ErrInvalidTransition or ErrNotPaid if not.
+func Check(s State, to, actor string) error {
+	r, ok := transitions[edge{s.Status, to}]
+	if !ok || !slices.Contains(r.actors, actor) {
+		return fmt.Errorf("%w: %s cannot move an order from %s to %s", ErrInvalidTransition, actor, s.Status, to)
+	}
+	if r.paid && !s.Paid {
+		return fmt.Errorf("%w: cannot move it from %s to %s", ErrNotPaid, s.Status, to)
+	}
+	return nil
+}
+
+// Next returns the statuses actor may move an order in state s to.
+func Next(s State, actor string) []string {
+	next := []string{}
+	for _, to := range []string{StatusPendingPayment, StatusPaid, StatusDelivering, StatusDelivered, StatusFailed, StatusRefunded} {
+		if Check(s, to, actor) == nil {
+			next = append(next, to)
+		}
+	}
+	return next
+}
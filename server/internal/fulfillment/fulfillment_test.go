@@ -0,0 +1,45 @@
+package fulfillment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	t.Run("follows the happy path", func(t *testing.T) {
+		assert.NoError(t, Check(State{Status: StatusPendingPayment}, StatusPaid, ActorPayment))
+		assert.NoError(t, Check(State{Status: StatusPaid, Paid: true}, StatusDelivering, ActorSystem))
+		assert.NoError(t, Check(State{Status: StatusDelivering, Paid: true}, StatusDelivered, ActorSystem))
+	})
+
+	t.Run("allows each transition only to its actors", func(t *testing.T) {
+		assert.ErrorIs(t, Check(State{Status: StatusPendingPayment}, StatusPaid, ActorAdmin), ErrInvalidTransition)
+		assert.ErrorIs(t, Check(State{Status: StatusPaid, Paid: true}, StatusDelivering, ActorAdmin), ErrInvalidTransition)
+		assert.ErrorIs(t, Check(State{Status: StatusPaid, Paid: true}, StatusPaid, ActorSystem), ErrInvalidTransition)
+		assert.NoError(t, Check(State{Status: StatusPaid, Paid: true}, StatusPaid, ActorAdmin))
+	})
+
+	t.Run("never changes settled orders", func(t *testing.T) {
+		for _, from := range []string{StatusDelivered, StatusRefunded} {
+			for _, actor := range []string{ActorCustomer, ActorPayment, ActorSystem, ActorAdmin} {
+				assert.Empty(t, Next(State{Status: from, Paid: true}, actor), "%s by %s", from, actor)
+			}
+		}
+	})
+
+	t.Run("recovers failed orders only when paid", func(t *testing.T) {
+		unpaid := State{Status: StatusFailed}
+		assert.ErrorIs(t, Check(unpaid, StatusPaid, ActorAdmin), ErrNotPaid)
+		assert.ErrorIs(t, Check(unpaid, StatusRefunded, ActorPayment), ErrNotPaid)
+		assert.Empty(t, Next(unpaid, ActorAdmin))
+
+		paid := State{Status: StatusFailed, Paid: true}
+		assert.Equal(t, []string{StatusPaid, StatusDelivered, StatusRefunded}, Next(paid, ActorAdmin))
+	})
+
+	t.Run("lists the statuses an actor may move to", func(t *testing.T) {
+		assert.Equal(t, []string{StatusPaid, StatusDelivered, StatusFailed}, Next(State{Status: StatusDelivering, Paid: true}, ActorAdmin))
+		assert.Equal(t, []string{StatusPaid, StatusFailed}, Next(State{Status: StatusPendingPayment}, ActorPayment))
+	})
+}
@@ -0,0 +1,74 @@
+package fulfillment
+
+import (
+	"errors"
+	"time"
+)
+
+// Policy decides whether a failed delivery attempt is tried again, and
+// when. Attempts back off exponentially from BaseDelay up to MaxDelay.
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Retry returns the delay before the next attempt after attempt, the
+// number of attempts made so far, failed with err. It returns false when
+// err is permanent or no attempts are left.
+func (p Policy) Retry(attempt int, err error) (time.Duration, bool) {
+	if IsPermanent(err) || attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	var after *retryAfterError
+	if errors.As(err, &after) {
+		return after.delay, true
+	}
+	return p.Backoff(attempt), true
+}
+
+// Backoff returns the delay after attempt: BaseDelay, doubled for every
+// attempt after the first, at most MaxDelay.
+func (p Policy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for range max(attempt-1, 0) {
+		if delay >= p.MaxDelay/2 {
+			return p.MaxDelay
+		}
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a failure retrying cannot fix, e.g. a gamepass
+// priced wrong by the buyer.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// RetryAfter marks err as a failure to retry after delay instead of the
+// policy's backoff, e.g. when Roblox rate limits the supplier account.
+func RetryAfter(err error, delay time.Duration) error {
+	return &retryAfterError{err: err, delay: delay}
+}
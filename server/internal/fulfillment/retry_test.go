@@ -0,0 +1,45 @@
+package fulfillment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, BaseDelay: 30 * time.Second, MaxDelay: 3 * time.Minute}
+	errTimeout := errors.New("roblox timed out")
+
+	t.Run("backs off exponentially up to the max", func(t *testing.T) {
+		assert.Equal(t, 30*time.Second, policy.Backoff(1))
+		assert.Equal(t, time.Minute, policy.Backoff(2))
+		assert.Equal(t, 2*time.Minute, policy.Backoff(3))
+		assert.Equal(t, 3*time.Minute, policy.Backoff(4))
+		assert.Equal(t, 3*time.Minute, policy.Backoff(60))
+	})
+
+	t.Run("retries until attempts run out", func(t *testing.T) {
+		delay, ok := policy.Retry(2, errTimeout)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, delay)
+
+		_, ok = policy.Retry(5, errTimeout)
+		assert.False(t, ok)
+	})
+
+	t.Run("gives up on permanent errors", func(t *testing.T) {
+		err := Permanent(errTimeout)
+
+		_, ok := policy.Retry(1, err)
+		assert.False(t, ok)
+		assert.ErrorIs(t, err, errTimeout)
+	})
+
+	t.Run("honours the delay asked for", func(t *testing.T) {
+		delay, ok := policy.Retry(1, RetryAfter(errTimeout, 10*time.Minute))
+		assert.True(t, ok)
+		assert.Equal(t, 10*time.Minute, delay)
+	})
+}
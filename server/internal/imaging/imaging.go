@@ -0,0 +1,184 @@
+// Package imaging re-encodes uploaded images and renders their thumbnails.
+// Decoding and encoding again drops everything but the pixels, so EXIF and
+// other metadata, including GPS positions, never reach the stored files.
+// The EXIF orientation of a JPEG is applied to the pixels first.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// MaxPixels bounds the decoded size of an image, so a small, highly
+// compressed upload cannot exhaust memory.
+const MaxPixels = 40_000_000
+
+// jpegQuality is used for photos, other formats are stored as PNG.
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("imaging: unsupported image format")
+	ErrCorrupt           = errors.New("imaging: corrupt image")
+	ErrTooManyPixels     = fmt.Errorf("imaging: image is larger than %d pixels", MaxPixels)
+)
+
+// Image is an encoded image.
+type Image struct {
+	Data        []byte
+	ContentType string
+	// Ext is the file extension without the dot, e.g. jpg.
+	Ext    string
+	Width  int
+	Height int
+}
+
+// Result holds the re-encoded image and one square thumbnail per size.
+type Result struct {
+	Original   Image
+	Thumbnails map[int]Image
+}
+
+// Process decodes a PNG, JPEG or GIF (its first frame), scales it down to
+// fit maxSide and renders a center-cropped square thumbnail for each of
+// thumbSizes. JPEGs stay JPEGs, everything else becomes PNG to keep
+// transparency.
+func Process(data []byte, maxSide int, thumbSizes ...int) (*Result, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	var src image.Image
+	switch format {
+	case "jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	img := toRGBA(src)
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	encode := encodePNG
+	if format == "jpeg" {
+		encode = encodeJPEG
+	}
+
+	w, h := fit(img.Bounds().Dx(), img.Bounds().Dy(), maxSide)
+	original, err := encode(resize(img, w, h))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Original: original, Thumbnails: make(map[int]Image, len(thumbSizes))}
+	square := cropSquare(img)
+	for _, size := range thumbSizes {
+		thumb, err := encode(resize(square, size, size))
+		if err != nil {
+			return nil, err
+		}
+		result.Thumbnails[size] = thumb
+	}
+	return result, nil
+}
+
+func encodeJPEG(img *image.RGBA) (Image, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return Image{}, fmt.Errorf("imaging: %w", err)
+	}
+	return Image{Data: buf.Bytes(), ContentType: "image/jpeg", Ext: "jpg", Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}, nil
+}
+
+func encodePNG(img *image.RGBA) (Image, error) {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		return Image{}, fmt.Errorf("imaging: %w", err)
+	}
+	return Image{Data: buf.Bytes(), ContentType: "image/png", Ext: "png", Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}, nil
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// fit returns the size of a w x h image scaled down, keeping its aspect
+// ratio, so neither side exceeds maxSide. Smaller images keep their size.
+func fit(w, h, maxSide int) (int, int) {
+	if maxSide <= 0 || (w <= maxSide && h <= maxSide) {
+		return w, h
+	}
+	if w >= h {
+		return maxSide, max(1, h*maxSide/w)
+	}
+	return max(1, w*maxSide/h), maxSide
+}
+
+func cropSquare(img *image.RGBA) *image.RGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return img.SubImage(image.Rect(x, y, x+side, y+side)).(*image.RGBA)
+}
+
+// resize scales img to w x h. Each destination pixel averages the source
+// pixels it covers, which keeps downscaled images free of aliasing.
+// Upscaling repeats pixels.
+func resize(img *image.RGBA, w, h int) *image.RGBA {
+	b := img.Bounds()
+	if b.Dx() == w && b.Dy() == h && b.Min == (image.Point{}) {
+		return img
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for dy := range h {
+		y0 := b.Min.Y + dy*b.Dy()/h
+		y1 := max(y0+1, b.Min.Y+(dy+1)*b.Dy()/h)
+		for dx := range w {
+			x0 := b.Min.X + dx*b.Dx()/w
+			x1 := max(x0+1, b.Min.X+(dx+1)*b.Dx()/w)
+
+			var r, g, bl, a, n uint32
+			for y := y0; y < y1; y++ {
+				row := img.Pix[img.PixOffset(x0, y):img.PixOffset(x1, y)]
+				for i := 0; i < len(row); i += 4 {
+					r += uint32(row[i])
+					g += uint32(row[i+1])
+					bl += uint32(row[i+2])
+					a += uint32(row[i+3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8((r + n/2) / n)
+			dst.Pix[i+1] = uint8((g + n/2) / n)
+			dst.Pix[i+2] = uint8((bl + n/2) / n)
+			dst.Pix[i+3] = uint8((a + n/2) / n)
+		}
+	}
+	return dst
+}
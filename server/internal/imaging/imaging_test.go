@@ -0,0 +1,208 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves returns a w x h image, red on the left half and blue on the right.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+// withEXIF inserts an APP1 segment holding orientation, followed by extra
+// bytes, right after the SOI marker of a JPEG.
+func withEXIF(t *testing.T, jpg []byte, orientation uint16, extra string) []byte {
+	t.Helper()
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(exifOrientationTag))
+	binary.Write(&tiff, binary.BigEndian, uint16(3)) // SHORT
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, orientation)
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString(extra)
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeTestJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	return buf.Bytes()
+}
+
+func assertColor(t *testing.T, want color.RGBA, got color.Color, msgAndArgs ...any) {
+	t.Helper()
+	r, g, b, _ := got.RGBA()
+	wr, wg, wb, _ := want.RGBA()
+	near := func(a, b uint32) bool { return max(a, b)-min(a, b) < 0x2000 }
+	assert.True(t, near(r, wr) && near(g, wg) && near(b, wb), "want %v, got %v %v", want, got, msgAndArgs)
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("scales down and renders square thumbnails", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, halves(400, 200)))
+
+		result, err := Process(buf.Bytes(), 100, 32, 64)
+
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", result.Original.ContentType)
+		assert.Equal(t, "png", result.Original.Ext)
+		assert.Equal(t, [2]int{100, 50}, [2]int{result.Original.Width, result.Original.Height})
+		require.Len(t, result.Thumbnails, 2)
+
+		thumb, err := png.Decode(bytes.NewReader(result.Thumbnails[64].Data))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 64), thumb.Bounds())
+		// The center crop keeps both halves.
+		assertColor(t, red, thumb.At(0, 32))
+		assertColor(t, blue, thumb.At(63, 32))
+	})
+
+	t.Run("keeps small images at their size", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, halves(10, 10)))
+
+		result, err := Process(buf.Bytes(), 100, 32)
+
+		require.NoError(t, err)
+		assert.Equal(t, 10, result.Original.Width)
+		assert.Equal(t, 32, result.Thumbnails[32].Width)
+	})
+
+	t.Run("applies the EXIF orientation and strips metadata", func(t *testing.T) {
+		data := withEXIF(t, encodeTestJPEG(t, halves(32, 16)), 6, "GPS 6.2088 S 106.8456 E")
+
+		result, err := Process(data, 100)
+
+		require.NoError(t, err)
+		assert.Equal(t, "image/jpeg", result.Original.ContentType)
+		assert.NotContains(t, string(result.Original.Data), "Exif")
+		assert.NotContains(t, string(result.Original.Data), "GPS")
+
+		img, err := jpeg.Decode(bytes.NewReader(result.Original.Data))
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, 16, 32), img.Bounds())
+		// Rotated clockwise, the left (red) half ends up on top.
+		assertColor(t, red, img.At(8, 4))
+		assertColor(t, blue, img.At(8, 28))
+	})
+
+	t.Run("reads the first GIF frame as PNG", func(t *testing.T) {
+		palette := color.Palette{red, blue}
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 8), palette)
+		var buf bytes.Buffer
+		require.NoError(t, gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{10, 10}}))
+
+		result, err := Process(buf.Bytes(), 100)
+
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", result.Original.ContentType)
+	})
+
+	t.Run("rejects other data", func(t *testing.T) {
+		_, err := Process([]byte("%PDF-1.4"), 100)
+
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+
+	t.Run("rejects truncated images", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, halves(8, 8)))
+
+		_, err := Process(buf.Bytes()[:40], 100)
+
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+
+	t.Run("rejects images with too many pixels", func(t *testing.T) {
+		// A valid header is enough, the pixels are never decoded.
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+		data := buf.Bytes()
+		binary.BigEndian.PutUint32(data[16:], 10000) // IHDR width
+		binary.BigEndian.PutUint32(data[20:], 10000) // IHDR height
+		binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+		_, err := Process(data, 100)
+
+		assert.ErrorIs(t, err, ErrTooManyPixels)
+	})
+}
+
+func TestOrient(t *testing.T) {
+	// 2 x 1 image: red, blue.
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		bounds      image.Rectangle
+		redAt       image.Point
+	}{
+		{1, image.Rect(0, 0, 2, 1), image.Pt(0, 0)},
+		{2, image.Rect(0, 0, 2, 1), image.Pt(1, 0)},
+		{3, image.Rect(0, 0, 2, 1), image.Pt(1, 0)},
+		{4, image.Rect(0, 0, 2, 1), image.Pt(0, 0)},
+		{5, image.Rect(0, 0, 1, 2), image.Pt(0, 0)},
+		{6, image.Rect(0, 0, 1, 2), image.Pt(0, 0)},
+		{7, image.Rect(0, 0, 1, 2), image.Pt(0, 1)},
+		{8, image.Rect(0, 0, 1, 2), image.Pt(0, 1)},
+	}
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+
+		assert.Equal(t, tt.bounds, got.Bounds(), "orientation %d", tt.orientation)
+		assert.Equal(t, red, got.RGBAAt(tt.redAt.X, tt.redAt.Y), "orientation %d", tt.orientation)
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	jpg := encodeTestJPEG(t, halves(4, 4))
+
+	assert.Equal(t, 1, jpegOrientation(jpg))
+	assert.Equal(t, 8, jpegOrientation(withEXIF(t, jpg, 8, "")))
+	assert.Equal(t, 1, jpegOrientation(withEXIF(t, jpg, 42, "")))
+	assert.Equal(t, 1, jpegOrientation(withEXIF(t, jpg, 6, "")[:12]))
+}
@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testUser struct {
+	ID        string       `json:"id"`
+	Email     string       `json:"email" validate:"required,email"`
+	Nickname  string       `json:"nickname,omitempty"`
+	Age       *int         `json:"age" validate:"omitempty,min=13,max=120"`
+	Role      string       `json:"role" validate:"required,oneof=admin member"`
+	Address   *testAddress `json:"address"`
+	Tags      []*string    `json:"tags"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Secret    string       `json:"-"`
+}
+
+type testWithEmbedded struct {
+	testUser
+	Score float64 `json:"score"`
+}
+
+type testQuery struct {
+	Pagination struct {
+		Page *int `query:"page" validate:"omitempty,min=1"`
+	}
+	Search string `query:"q" validate:"required" doc:"full text search"`
+}
+
+func TestDocumentSchema(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+
+	t.Run("named structs are stored as components", func(t *testing.T) {
+		ref := doc.Schema(testUser{})
+		assert.Equal(t, "#/components/schemas/testUser", ref.Ref)
+		require.Contains(t, doc.Components.Schemas, "testUser")
+		require.Contains(t, doc.Components.Schemas, "testAddress")
+	})
+
+	t.Run("json and validate tags are applied", func(t *testing.T) {
+		s := doc.Components.Schemas["testUser"]
+
+		assert.NotContains(t, s.Properties, "Secret")
+		assert.Equal(t, "email", s.Properties["email"].Format)
+		assert.Equal(t, []any{"admin", "member"}, s.Properties["role"].Enum)
+		assert.Equal(t, 13.0, *s.Properties["age"].Minimum)
+		assert.True(t, s.Properties["age"].Nullable)
+		assert.Equal(t, "date-time", s.Properties["createdAt"].Format)
+		assert.Equal(t, "string", s.Properties["tags"].Items.Type)
+		assert.Equal(t, []string{"id", "email", "role", "tags", "createdAt"}, s.Required)
+	})
+
+	t.Run("nullable references are wrapped in allOf", func(t *testing.T) {
+		s := doc.Components.Schemas["testUser"].Properties["address"]
+		assert.True(t, s.Nullable)
+		require.Len(t, s.AllOf, 1)
+		assert.Equal(t, "#/components/schemas/testAddress", s.AllOf[0].Ref)
+	})
+
+	t.Run("embedded structs are flattened", func(t *testing.T) {
+		doc.Schema(testWithEmbedded{})
+		s := doc.Components.Schemas["testWithEmbedded"]
+		assert.Contains(t, s.Properties, "email")
+		assert.Contains(t, s.Properties, "score")
+	})
+
+	t.Run("Define overrides the generated schema", func(t *testing.T) {
+		type custom map[string]string
+		doc.Define(custom{}, &Schema{Type: "string"})
+		assert.Equal(t, "string", doc.Schema(custom{}).Type)
+	})
+}
+
+func TestQueryParameters(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	params := doc.QueryParameters(testQuery{})
+
+	require.Len(t, params, 2)
+	assert.Equal(t, "page", params[0].Name)
+	assert.Equal(t, "query", params[0].In)
+	assert.False(t, params[0].Required)
+	assert.False(t, params[0].Schema.Nullable)
+	assert.Equal(t, 1.0, *params[0].Schema.Minimum)
+
+	assert.Equal(t, "q", params[1].Name)
+	assert.True(t, params[1].Required)
+	assert.Equal(t, "full text search", params[1].Description)
+}
+
+func TestHeaderParameters(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	params := doc.HeaderParameters(struct {
+		LastEventID *string `header:"Last-Event-ID" validate:"omitempty,uuid"`
+		Page        int     `query:"page"`
+	}{})
+
+	require.Len(t, params, 1)
+	assert.Equal(t, "Last-Event-ID", params[0].Name)
+	assert.Equal(t, "header", params[0].In)
+	assert.False(t, params[0].Required)
+}
+
+func TestDocumentPaths(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	op := &Operation{OperationID: "getOrder", Responses: map[string]*Response{"200": {Description: "ok"}}}
+
+	doc.Add(http.MethodGet, "/v1/orders/:id", op)
+
+	assert.Contains(t, doc.Paths, "/v1/orders/{id}")
+	assert.Same(t, op, doc.Operation(http.MethodGet, "/v1/orders/:id"))
+	assert.Same(t, op, doc.Operation(http.MethodGet, "/v1/orders/{id}"))
+	assert.Nil(t, doc.Operation(http.MethodPost, "/v1/orders/:id"))
+}
+
+func TestMarshalYAML(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1.0"})
+	doc.Add(http.MethodGet, "/", &Operation{
+		OperationID: "root",
+		Responses:   map[string]*Response{"200": {Description: "ok"}, "404": ResponseRef("NotFound")},
+	})
+
+	out, err := doc.MarshalYAML()
+	require.NoError(t, err)
+
+	yamlDoc := string(out)
+	assert.True(t, strings.HasPrefix(yamlDoc, "openapi: 3.0.3\n"))
+	assert.Contains(t, yamlDoc, `version: "1.0"`)
+	assert.Contains(t, yamlDoc, `"200":`)
+	assert.Contains(t, yamlDoc, `$ref: '#/components/responses/NotFound'`)
+}
+
+func TestValidateResponse(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	doc.Components.Responses["NotFound"] = &Response{
+		Description: "not found",
+		Content:     JSON(Object(map[string]*Schema{"error": {Type: "string"}})),
+	}
+	doc.Add(http.MethodGet, "/users/:id", &Operation{
+		OperationID: "getUser",
+		Responses: map[string]*Response{
+			"200": {
+				Description: "ok",
+				Headers:     map[string]*Header{"ETag": {Required: true, Schema: &Schema{Type: "string"}}, "X-Optional": {Schema: &Schema{Type: "string"}}},
+				Content:     JSON(Object(map[string]*Schema{"data": doc.Schema(testUser{})})),
+			},
+			"404": ResponseRef("NotFound"),
+		},
+	})
+
+	jsonHeader := http.Header{"Content-Type": {"application/json; charset=utf-8"}, "Etag": {`"abc"`}}
+	valid := `{"data":{"id":"1","email":"a@b.c","role":"admin","age":null,"address":null,"tags":["x"],"createdAt":"2026-01-20T04:41:27Z"}}`
+
+	t.Run("accepts a conforming response", func(t *testing.T) {
+		err := doc.ValidateResponse(http.MethodGet, "/users/:id", 200, jsonHeader, []byte(valid))
+		assert.NoError(t, err)
+	})
+
+	t.Run("resolves response references", func(t *testing.T) {
+		err := doc.ValidateResponse(http.MethodGet, "/users/{id}", 404, jsonHeader, []byte(`{"error":"gone"}`))
+		assert.NoError(t, err)
+	})
+
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		errMsg string
+	}{
+		{name: "undocumented status", status: 500, header: jsonHeader, body: `{}`, errMsg: "status 500 is not documented"},
+		{name: "missing header", status: 200, header: http.Header{"Content-Type": {"application/json"}}, body: valid, errMsg: "missing header ETag"},
+		{name: "wrong content type", status: 404, header: http.Header{"Content-Type": {"text/plain"}}, body: `x`, errMsg: "Content-Type text/plain is not documented"},
+		{name: "missing required property", status: 200, header: jsonHeader, body: `{"data":{"id":"1"}}`, errMsg: `missing required property "email"`},
+		{name: "wrong type", status: 200, header: jsonHeader, body: strings.Replace(valid, `"tags":["x"]`, `"tags":"x"`, 1), errMsg: "$.data.tags: expected array"},
+		{name: "value outside enum", status: 200, header: jsonHeader, body: strings.Replace(valid, `"admin"`, `"root"`, 1), errMsg: "is not one of"},
+		{name: "invalid date-time", status: 200, header: jsonHeader, body: strings.Replace(valid, `2026-01-20T04:41:27Z`, `yesterday`, 1), errMsg: "is not a date-time"},
+		{name: "undocumented property", status: 200, header: jsonHeader, body: strings.Replace(valid, `"id":"1"`, `"id":"1","extra":true`, 1), errMsg: `undocumented property "extra"`},
+		{name: "null for non nullable", status: 200, header: jsonHeader, body: strings.Replace(valid, `"tags":["x"]`, `"tags":null`, 1), errMsg: "must not be null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.ValidateResponse(http.MethodGet, "/users/:id", tt.status, tt.header, []byte(tt.body))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	t.Run("unknown operation", func(t *testing.T) {
+		err := doc.ValidateResponse(http.MethodDelete, "/users/:id", 200, jsonHeader, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no operation")
+	})
+}
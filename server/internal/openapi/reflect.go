@@ -0,0 +1,314 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	durationType   = reflect.TypeFor[time.Duration]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+type reflector struct {
+	schemas   map[string]*Schema
+	names     map[reflect.Type]string
+	overrides map[reflect.Type]*Schema
+}
+
+func newReflector(schemas map[string]*Schema) *reflector {
+	return &reflector{
+		schemas:   schemas,
+		names:     make(map[reflect.Type]string),
+		overrides: make(map[reflect.Type]*Schema),
+	}
+}
+
+func (r *reflector) define(v any, schema *Schema) {
+	r.overrides[reflect.TypeOf(v)] = schema
+}
+
+func (r *reflector) schemaOf(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return r.typeSchema(reflect.TypeOf(v))
+}
+
+func (r *reflector) typeSchema(t reflect.Type) *Schema {
+	if s, ok := r.overrides[t]; ok {
+		return s
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "duration in nanoseconds"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := *r.typeSchema(t.Elem())
+		if s.Ref != "" {
+			// Siblings of $ref are ignored in OpenAPI 3.0, wrap it instead.
+			return &Schema{Nullable: true, AllOf: []*Schema{&s}}
+		}
+		s.Nullable = true
+		return &s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.typeSchema(elemType(t))}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.typeSchema(elemType(t))}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		name := r.componentName(t)
+		if _, ok := r.schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate.
+			r.schemas[name] = &Schema{}
+			*r.schemas[name] = *r.structSchema(t)
+		}
+		return Ref(name)
+	default:
+		return &Schema{}
+	}
+}
+
+// elemType returns the element type of a slice or map. Pointer elements
+// are dereferenced, the models never put nil entries in collections.
+func elemType(t reflect.Type) reflect.Type {
+	elem := t.Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return elem
+}
+
+// componentName picks the type name, prefixed with the package name when
+// two packages declare the same type name.
+func (r *reflector) componentName(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	for other, used := range r.names {
+		if used == name && other != t {
+			pkg := t.PkgPath()
+			pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+			break
+		}
+	}
+	r.names[t] = name
+	return name
+}
+
+func (r *reflector) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.collectFields(t, s)
+	if len(s.Properties) == 0 {
+		s.Properties = nil
+	}
+	return s
+}
+
+// collectFields adds the JSON properties of t to s. Embedded structs are
+// flattened the same way encoding/json does.
+func (r *reflector) collectFields(t reflect.Type, s *Schema) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Fields of embedded structs are promoted even when the embedded
+		// type itself is unexported.
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.collectFields(ft, s)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := r.typeSchema(field.Type)
+		if desc := field.Tag.Get("doc"); desc != "" {
+			prop = withDescription(prop, desc)
+		}
+
+		rules := parseValidate(field.Tag.Get("validate"))
+		prop = applyRules(prop, rules)
+
+		optional := strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero")
+		if rules.required || (!optional && !rules.present && field.Type.Kind() != reflect.Pointer) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+func (r *reflector) parameters(v any, tag string) []*Parameter {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var params []*Parameter
+	r.collectParameters(t, tag, &params)
+	return params
+}
+
+func (r *reflector) collectParameters(t reflect.Type, tag string, params *[]*Parameter) {
+	in := map[string]string{"query": "query", "param": "path", "header": "header"}[tag]
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := field.Tag.Get(tag)
+
+		if name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				r.collectParameters(ft, tag, params)
+			}
+			continue
+		}
+
+		rules := parseValidate(field.Tag.Get("validate"))
+		schema := applyRules(r.typeSchema(field.Type), rules)
+		schema.Nullable = false
+
+		*params = append(*params, &Parameter{
+			Name:        name,
+			In:          in,
+			Description: field.Tag.Get("doc"),
+			Required:    rules.required || in == "path",
+			Schema:      schema,
+		})
+	}
+}
+
+func withDescription(s *Schema, desc string) *Schema {
+	if s.Ref != "" {
+		// Siblings of $ref are ignored in OpenAPI 3.0, wrap it instead.
+		return &Schema{Description: desc, AllOf: []*Schema{s}}
+	}
+	c := *s
+	c.Description = desc
+	return &c
+}
+
+type validateRules struct {
+	present  bool
+	required bool
+	min, max *float64
+	oneof    []string
+	format   string
+}
+
+func parseValidate(tag string) validateRules {
+	rules := validateRules{present: tag != ""}
+	for rule := range strings.SplitSeq(tag, ",") {
+		if rule == "dive" {
+			// Rules after dive apply to the elements.
+			break
+		}
+
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			rules.required = true
+		case "min", "gte":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				rules.min = &f
+			}
+		case "max", "lte":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				rules.max = &f
+			}
+		case "oneof":
+			rules.oneof = strings.Fields(value)
+		case "email":
+			rules.format = "email"
+		case "url", "http_url":
+			rules.format = "uri"
+		case "uuid", "uuid4":
+			rules.format = "uuid"
+		}
+	}
+	return rules
+}
+
+func applyRules(s *Schema, rules validateRules) *Schema {
+	if s.Ref != "" || s.AllOf != nil {
+		return s
+	}
+
+	c := *s
+	switch c.Type {
+	case "integer", "number":
+		c.Minimum, c.Maximum = rules.min, rules.max
+	case "string":
+		c.MinLength, c.MaxLength = toInt(rules.min), toInt(rules.max)
+	case "array":
+		c.MinItems, c.MaxItems = toInt(rules.min), toInt(rules.max)
+	}
+
+	if rules.format != "" && c.Type == "string" {
+		c.Format = rules.format
+	}
+
+	for _, v := range rules.oneof {
+		if c.Type == "integer" {
+			if n, err := strconv.Atoi(v); err == nil {
+				c.Enum = append(c.Enum, n)
+				continue
+			}
+		}
+		c.Enum = append(c.Enum, v)
+	}
+	return &c
+}
+
+func toInt(f *float64) *int {
+	if f == nil {
+		return nil
+	}
+	n := int(*f)
+	return &n
+}
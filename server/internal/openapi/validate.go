@@ -0,0 +1,262 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateResponse checks a response against the operation registered for
+// method and path: the status code must be documented, the Content-Type and
+// declared headers must match and a JSON body must conform to the schema.
+func (d *Document) ValidateResponse(method, path string, status int, header http.Header, body []byte) error {
+	op := d.Operation(method, path)
+	if op == nil {
+		return fmt.Errorf("no operation for %s %s", method, PathFromEcho(path))
+	}
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if response, ok = op.Responses["default"]; !ok {
+			return fmt.Errorf("%s %s: status %d is not documented", method, PathFromEcho(path), status)
+		}
+	}
+	response, err := d.resolveResponse(response)
+	if err != nil {
+		return err
+	}
+
+	for name, h := range response.Headers {
+		if h.Required && header.Get(name) == "" {
+			return fmt.Errorf("%s %s %d: missing header %s", method, PathFromEcho(path), status, name)
+		}
+	}
+
+	if len(response.Content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("%s %s %d: unexpected body", method, PathFromEcho(path), status)
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("%s %s %d: invalid Content-Type: %w", method, PathFromEcho(path), status, err)
+	}
+	content, ok := response.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("%s %s %d: Content-Type %s is not documented", method, PathFromEcho(path), status, mediaType)
+	}
+
+	if !strings.HasSuffix(mediaType, "json") {
+		return nil
+	}
+	if err := d.ValidateJSON(content.Schema, body); err != nil {
+		return fmt.Errorf("%s %s %d: %w", method, PathFromEcho(path), status, err)
+	}
+	return nil
+}
+
+// ValidateJSON checks that body conforms to schema.
+func (d *Document) ValidateJSON(schema *Schema, body []byte) error {
+	var value any
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("body is not valid JSON: %w", err)
+	}
+	return d.validate(schema, value, "$")
+}
+
+func (d *Document) resolveResponse(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name := strings.TrimPrefix(r.Ref, "#/components/responses/")
+	resolved, ok := d.Components.Responses[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved response reference %s", r.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *Document) resolveSchema(s *Schema) (*Schema, error) {
+	for s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		resolved, ok := d.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved schema reference %s", s.Ref)
+		}
+		s = resolved
+	}
+	return s, nil
+}
+
+func (d *Document) validate(s *Schema, value any, at string) error {
+	s, err := d.resolveSchema(s)
+	if err != nil {
+		return err
+	}
+
+	if value == nil {
+		if s.Nullable || isEmptySchema(s) {
+			return nil
+		}
+		return fmt.Errorf("%s: must not be null", at)
+	}
+
+	for _, sub := range s.AllOf {
+		if err := d.validate(sub, value, at); err != nil {
+			return err
+		}
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", at, value, s.Enum)
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "object":
+		return d.validateObject(s, value, at)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, value)
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items", at, *s.MinItems)
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			return fmt.Errorf("%s: expected at most %d items", at, *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, value)
+		}
+		return validateString(s, str, at)
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected %s, got %T", at, s.Type, value)
+		}
+		return validateNumber(s, num, at)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, value)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: unsupported schema type %q", at, s.Type)
+	}
+}
+
+func (d *Document) validateObject(s *Schema, value any, at string) error {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: expected object, got %T", at, value)
+	}
+
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", at, name)
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prop, ok := s.Properties[k]
+		switch {
+		case ok:
+		case s.AdditionalProperties != nil:
+			prop = s.AdditionalProperties
+		case len(s.Properties) > 0:
+			return fmt.Errorf("%s: undocumented property %q", at, k)
+		default:
+			continue
+		}
+		if err := d.validate(prop, obj[k], at+"."+k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateString(s *Schema, str string, at string) error {
+	if s.MinLength != nil && len([]rune(str)) < *s.MinLength {
+		return fmt.Errorf("%s: shorter than %d characters", at, *s.MinLength)
+	}
+	if s.MaxLength != nil && len([]rune(str)) > *s.MaxLength {
+		return fmt.Errorf("%s: longer than %d characters", at, *s.MaxLength)
+	}
+
+	switch s.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+			return fmt.Errorf("%s: %q is not a date-time", at, str)
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, str); err != nil {
+			return fmt.Errorf("%s: %q is not a date", at, str)
+		}
+	case "uuid":
+		if len(str) != 36 || strings.Count(str, "-") != 4 {
+			return fmt.Errorf("%s: %q is not a uuid", at, str)
+		}
+	}
+	return nil
+}
+
+func validateNumber(s *Schema, num json.Number, at string) error {
+	if s.Type == "integer" {
+		if _, err := num.Int64(); err != nil {
+			return fmt.Errorf("%s: %s is not an integer", at, num)
+		}
+	}
+
+	f, err := num.Float64()
+	if err != nil {
+		return fmt.Errorf("%s: %s is not a number", at, num)
+	}
+	if s.Minimum != nil && f < *s.Minimum {
+		return fmt.Errorf("%s: %s is less than %v", at, num, *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		return fmt.Errorf("%s: %s is greater than %v", at, num, *s.Maximum)
+	}
+	return nil
+}
+
+func inEnum(enum []any, value any) bool {
+	if num, ok := value.(json.Number); ok {
+		value = num.String()
+	}
+	return slices.ContainsFunc(enum, func(e any) bool {
+		return fmt.Sprint(e) == fmt.Sprint(value)
+	})
+}
+
+func isEmptySchema(s *Schema) bool {
+	return s.Type == "" && len(s.AllOf) == 0 && len(s.Enum) == 0
+}
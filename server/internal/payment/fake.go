@@ -0,0 +1,184 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fake is an in-memory Provider for development and tests. Its charges
+// stay pending until Simulate pays, expires or fails them, which returns
+// the webhook the gateway would have sent for the change.
+type Fake struct {
+	secret string
+	now    func() time.Time
+
+	mu      sync.Mutex
+	charges map[string]*Charge
+	// byReference finds the charge of a reference, so creating it again
+	// returns the same charge like the gateway does.
+	byReference map[string]string
+}
+
+func NewFake(webhookSecret string) *Fake {
+	return &Fake{
+		secret:      webhookSecret,
+		now:         time.Now,
+		charges:     make(map[string]*Charge),
+		byReference: make(map[string]string),
+	}
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) CreateCharge(_ context.Context, req ChargeRequest) (*Charge, error) {
+	method, ok := gatewayMethods[req.Method]
+	if !ok {
+		return nil, ErrUnsupportedMethod
+	}
+	if _, err := wholeRupiah(req.Amount); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if id, ok := f.byReference[req.Reference]; ok {
+		copied := *f.charges[id]
+		return &copied, nil
+	}
+
+	id := "fake_" + rand.Text()
+	var instructions Instructions
+	switch method.Type {
+	case "QRIS":
+		instructions.QRString = "00020101021226590016ID.MAYOBOX.FAKE01" + id
+	case "VIRTUAL_ACCOUNT":
+		instructions.Bank = strings.ToLower(method.ChannelCode)
+		instructions.VANumber = fmt.Sprintf("8808%012d", len(f.charges)+1)
+	case "EWALLET":
+		instructions.CheckoutURL = "https://fake.payment.invalid/checkout/" + id
+	}
+
+	charge := &Charge{
+		ID:           id,
+		Reference:    req.Reference,
+		Method:       req.Method,
+		Amount:       req.Amount,
+		Status:       StatusPending,
+		Instructions: instructions,
+		ExpiresAt:    req.ExpiresAt,
+	}
+	f.charges[id] = charge
+	f.byReference[req.Reference] = id
+
+	copied := *charge
+	return &copied, nil
+}
+
+func (f *Fake) GetCharge(_ context.Context, id string) (*Charge, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	charge, ok := f.charges[id]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	copied := *charge
+	return &copied, nil
+}
+
+func (f *Fake) Refund(_ context.Context, id, _ string) (*Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	charge, ok := f.charges[id]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	if !CanTransition(charge.Status, StatusRefunded) {
+		return nil, ErrRefundNotSupported
+	}
+	charge.Status = StatusRefunded
+
+	return &Refund{
+		ID:       "fake_refund_" + rand.Text(),
+		ChargeID: id,
+		Amount:   charge.Amount,
+		Status:   StatusRefunded,
+	}, nil
+}
+
+func (f *Fake) Expire(_ context.Context, id string) (*Charge, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	charge, ok := f.charges[id]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	if CanTransition(charge.Status, StatusExpired) {
+		charge.Status = StatusExpired
+	}
+	copied := *charge
+	return &copied, nil
+}
+
+func (f *Fake) ParseWebhook(header http.Header, body []byte) (*Event, error) {
+	return parseWebhook(f.secret, header, body, f.now())
+}
+
+// Simulate moves a charge to status as if the customer paid, let it
+// expire or the payment failed, and returns the signed webhook reporting
+// it.
+func (f *Fake) Simulate(id, status string) (http.Header, []byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	charge, ok := f.charges[id]
+	if !ok {
+		return nil, nil, ErrChargeNotFound
+	}
+	if !CanTransition(charge.Status, status) {
+		return nil, nil, ErrInvalidTransition
+	}
+
+	now := f.now()
+	charge.Status = status
+	if status == StatusPaid {
+		charge.PaidAt = &now
+	}
+
+	wire := gatewayEvent{
+		ID:      "fake_evt_" + rand.Text(),
+		Type:    "charge." + strings.ToLower(gatewayStatus(status)),
+		Created: now.UTC(),
+		Data: gatewayCharge{
+			ID:            charge.ID,
+			ReferenceID:   charge.Reference,
+			Amount:        int64(charge.Amount) / 100,
+			Currency:      "IDR",
+			Status:        gatewayStatus(status),
+			PaymentMethod: gatewayMethods[charge.Method],
+			Actions: gatewayActions{
+				QRString:    charge.Instructions.QRString,
+				VANumber:    charge.Instructions.VANumber,
+				CheckoutURL: charge.Instructions.CheckoutURL,
+			},
+			ExpiresAt: charge.ExpiresAt.UTC(),
+			PaidAt:    charge.PaidAt,
+		},
+	}
+	body, err := json.Marshal(wire)
+	if err != nil {
+		return nil, nil, fmt.Errorf("payment: encode webhook: %w", err)
+	}
+	return Sign(f.secret, body, now), body, nil
+}
@@ -0,0 +1,121 @@
+package payment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+func TestFake(t *testing.T) {
+	ctx := context.Background()
+	req := ChargeRequest{
+		Reference: "order-1",
+		Amount:    pricing.Rupiah(144261),
+		Method:    MethodQRIS,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	t.Run("creates one charge per reference", func(t *testing.T) {
+		fake := NewFake(testWebhookSecret)
+
+		charge, err := fake.CreateCharge(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, StatusPending, charge.Status)
+		assert.NotEmpty(t, charge.Instructions.QRString)
+
+		again, err := fake.CreateCharge(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, charge, again)
+
+		va, err := fake.CreateCharge(ctx, ChargeRequest{Reference: "order-2", Amount: 100, Method: MethodVAMandiri})
+		require.NoError(t, err)
+		assert.Equal(t, "mandiri", va.Instructions.Bank)
+		assert.Len(t, va.Instructions.VANumber, 16)
+	})
+
+	t.Run("simulates signed webhooks", func(t *testing.T) {
+		fake := NewFake(testWebhookSecret)
+		charge, err := fake.CreateCharge(ctx, req)
+		require.NoError(t, err)
+
+		header, body, err := fake.Simulate(charge.ID, StatusPaid)
+		require.NoError(t, err)
+
+		event, err := fake.ParseWebhook(header, body)
+		require.NoError(t, err)
+		assert.Equal(t, charge.ID, event.ChargeID)
+		assert.Equal(t, "order-1", event.Reference)
+		assert.Equal(t, StatusPaid, event.Status)
+		assert.Equal(t, req.Amount, event.Amount)
+
+		paid, err := fake.GetCharge(ctx, charge.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusPaid, paid.Status)
+		assert.NotNil(t, paid.PaidAt)
+
+		// The gateway only signs webhooks with its own secret.
+		_, err = NewFake("other").ParseWebhook(header, body)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("follows the charge lifecycle", func(t *testing.T) {
+		fake := NewFake(testWebhookSecret)
+		charge, err := fake.CreateCharge(ctx, req)
+		require.NoError(t, err)
+
+		_, err = fake.Refund(ctx, charge.ID, "")
+		assert.ErrorIs(t, err, ErrRefundNotSupported)
+
+		_, _, err = fake.Simulate(charge.ID, StatusExpired)
+		require.NoError(t, err)
+		_, _, err = fake.Simulate(charge.ID, StatusPaid)
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+
+		_, _, err = fake.Simulate("fake_missing", StatusPaid)
+		assert.ErrorIs(t, err, ErrChargeNotFound)
+	})
+
+	t.Run("expires pending charges only", func(t *testing.T) {
+		fake := NewFake(testWebhookSecret)
+		pending, err := fake.CreateCharge(ctx, req)
+		require.NoError(t, err)
+		paid, err := fake.CreateCharge(ctx, ChargeRequest{Reference: "order-2", Amount: 100, Method: MethodQRIS})
+		require.NoError(t, err)
+		_, _, err = fake.Simulate(paid.ID, StatusPaid)
+		require.NoError(t, err)
+
+		expired, err := fake.Expire(ctx, pending.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusExpired, expired.Status)
+		_, _, err = fake.Simulate(pending.ID, StatusPaid)
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+
+		kept, err := fake.Expire(ctx, paid.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusPaid, kept.Status)
+
+		_, err = fake.Expire(ctx, "fake_missing")
+		assert.ErrorIs(t, err, ErrChargeNotFound)
+	})
+
+	t.Run("refunds paid charges", func(t *testing.T) {
+		fake := NewFake(testWebhookSecret)
+		charge, err := fake.CreateCharge(ctx, req)
+		require.NoError(t, err)
+		_, _, err = fake.Simulate(charge.ID, StatusPaid)
+		require.NoError(t, err)
+
+		refund, err := fake.Refund(ctx, charge.ID, "delivery failed")
+		require.NoError(t, err)
+		assert.Equal(t, StatusRefunded, refund.Status)
+		assert.Equal(t, req.Amount, refund.Amount)
+
+		refunded, err := fake.GetCharge(ctx, charge.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusRefunded, refunded.Status)
+	})
+}
@@ -0,0 +1,330 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+// GatewayConfig points at the payment gateway's API.
+type GatewayConfig struct {
+	// BaseURL is the API root, e.g. https://api.sandbox.example.co.id.
+	BaseURL string
+	// ServerKey authenticates requests as the user of HTTP basic auth.
+	ServerKey string
+	// WebhookSecret is the key the gateway signs its webhooks with.
+	WebhookSecret string
+}
+
+// Gateway is a payment gateway in the style of the Indonesian ones: a JSON
+// API authenticated with a server key, charges for QRIS, bank virtual
+// accounts and e-wallets in whole rupiah, and signed webhooks for every
+// status change.
+type Gateway struct {
+	cfg     GatewayConfig
+	baseURL *url.URL
+	client  *http.Client
+	now     func() time.Time
+}
+
+// NewGateway uses client for the requests, http.DefaultClient when nil.
+func NewGateway(cfg GatewayConfig, client *http.Client) (*Gateway, error) {
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil || baseURL.Host == "" {
+		return nil, fmt.Errorf("payment: invalid gateway URL %q", cfg.BaseURL)
+	}
+	if cfg.ServerKey == "" || cfg.WebhookSecret == "" {
+		return nil, fmt.Errorf("payment: gateway server key and webhook secret are required")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Gateway{cfg: cfg, baseURL: baseURL, client: client, now: time.Now}, nil
+}
+
+// GatewayError is an error response of the gateway.
+type GatewayError struct {
+	StatusCode int
+	Code       string `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *GatewayError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("payment: gateway responded %d", e.StatusCode)
+	}
+	return fmt.Sprintf("payment: gateway responded %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (g *Gateway) Name() string {
+	return "gateway"
+}
+
+func (g *Gateway) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	method, ok := gatewayMethods[req.Method]
+	if !ok {
+		return nil, ErrUnsupportedMethod
+	}
+	amount, err := wholeRupiah(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	body := gatewayCharge{
+		ReferenceID:   req.Reference,
+		Amount:        amount,
+		Currency:      "IDR",
+		PaymentMethod: method,
+		Description:   req.Description,
+		ExpiresAt:     req.ExpiresAt.UTC(),
+	}
+
+	var charge gatewayCharge
+	// The gateway answers a repeated Idempotency-Key with the first charge.
+	if err := g.do(ctx, http.MethodPost, "/v1/charges", req.Reference, body, &charge); err != nil {
+		return nil, err
+	}
+	return charge.toCharge()
+}
+
+func (g *Gateway) GetCharge(ctx context.Context, id string) (*Charge, error) {
+	var charge gatewayCharge
+	err := g.do(ctx, http.MethodGet, "/v1/charges/"+url.PathEscape(id), "", nil, &charge)
+	if err != nil {
+		var gwErr *GatewayError
+		if errors.As(err, &gwErr) && gwErr.StatusCode == http.StatusNotFound {
+			return nil, ErrChargeNotFound
+		}
+		return nil, err
+	}
+	return charge.toCharge()
+}
+
+func (g *Gateway) Refund(ctx context.Context, id, reason string) (*Refund, error) {
+	body := struct {
+		Reason string `json:"reason,omitempty"`
+	}{reason}
+
+	var refund struct {
+		ID       string `json:"id"`
+		ChargeID string `json:"charge_id"`
+		Amount   int64  `json:"amount"`
+		Status   string `json:"status"`
+	}
+	err := g.do(ctx, http.MethodPost, "/v1/charges/"+url.PathEscape(id)+"/refunds", "refund-"+id, body, &refund)
+	if err != nil {
+		var gwErr *GatewayError
+		if errors.As(err, &gwErr) && gwErr.StatusCode == http.StatusNotFound {
+			return nil, ErrChargeNotFound
+		}
+		return nil, err
+	}
+
+	status := StatusPending
+	switch refund.Status {
+	case "SUCCEEDED":
+		status = StatusRefunded
+	case "FAILED":
+		status = StatusFailed
+	}
+	return &Refund{
+		ID:       refund.ID,
+		ChargeID: refund.ChargeID,
+		Amount:   pricing.Rupiah(refund.Amount),
+		Status:   status,
+	}, nil
+}
+
+func (g *Gateway) Expire(ctx context.Context, id string) (*Charge, error) {
+	var charge gatewayCharge
+	err := g.do(ctx, http.MethodPost, "/v1/charges/"+url.PathEscape(id)+"/expire", "expire-"+id, struct{}{}, &charge)
+	if err != nil {
+		var gwErr *GatewayError
+		if errors.As(err, &gwErr) && gwErr.StatusCode == http.StatusNotFound {
+			return nil, ErrChargeNotFound
+		}
+		return nil, err
+	}
+	return charge.toCharge()
+}
+
+func (g *Gateway) ParseWebhook(header http.Header, body []byte) (*Event, error) {
+	return parseWebhook(g.cfg.WebhookSecret, header, body, g.now())
+}
+
+func (g *Gateway) do(ctx context.Context, method, path, idempotencyKey string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("payment: encode request: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	target := *g.baseURL
+	target.Path = strings.TrimSuffix(target.Path, "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return fmt.Errorf("payment: %w", err)
+	}
+	req.SetBasicAuth(g.cfg.ServerKey, "")
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("payment: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		gwErr := &GatewayError{StatusCode: res.StatusCode}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(gwErr)
+		return gwErr
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("payment: decode response: %w", err)
+	}
+	return nil
+}
+
+/* ------------------------- WIRE FORMAT -------------------------- */
+
+// gatewayCharge is a charge as the gateway's API and webhooks encode it.
+// Fake uses the same encoding, so it exercises the same webhook parsing.
+type gatewayCharge struct {
+	ID            string         `json:"id,omitempty"`
+	ReferenceID   string         `json:"reference_id"`
+	Amount        int64          `json:"amount"`
+	Currency      string         `json:"currency"`
+	Status        string         `json:"status,omitempty"`
+	PaymentMethod gatewayMethod  `json:"payment_method"`
+	Actions       gatewayActions `json:"actions"`
+	Description   string         `json:"description,omitempty"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	PaidAt        *time.Time     `json:"paid_at,omitempty"`
+}
+
+type gatewayMethod struct {
+	Type        string `json:"type"`
+	ChannelCode string `json:"channel_code,omitempty"`
+}
+
+type gatewayActions struct {
+	QRString    string `json:"qr_string,omitempty"`
+	VANumber    string `json:"va_number,omitempty"`
+	CheckoutURL string `json:"checkout_url,omitempty"`
+}
+
+type gatewayEvent struct {
+	ID      string        `json:"id"`
+	Type    string        `json:"type"`
+	Created time.Time     `json:"created"`
+	Data    gatewayCharge `json:"data"`
+}
+
+var gatewayMethods = map[string]gatewayMethod{
+	MethodQRIS:             {Type: "QRIS"},
+	MethodVABCA:            {Type: "VIRTUAL_ACCOUNT", ChannelCode: "BCA"},
+	MethodVABNI:            {Type: "VIRTUAL_ACCOUNT", ChannelCode: "BNI"},
+	MethodVABRI:            {Type: "VIRTUAL_ACCOUNT", ChannelCode: "BRI"},
+	MethodVAMandiri:        {Type: "VIRTUAL_ACCOUNT", ChannelCode: "MANDIRI"},
+	MethodEWalletGoPay:     {Type: "EWALLET", ChannelCode: "GOPAY"},
+	MethodEWalletOVO:       {Type: "EWALLET", ChannelCode: "OVO"},
+	MethodEWalletDANA:      {Type: "EWALLET", ChannelCode: "DANA"},
+	MethodEWalletShopeePay: {Type: "EWALLET", ChannelCode: "SHOPEEPAY"},
+}
+
+var gatewayStatuses = map[string]string{
+	"PENDING":   StatusPending,
+	"SUCCEEDED": StatusPaid,
+	"EXPIRED":   StatusExpired,
+	"FAILED":    StatusFailed,
+	"REFUNDED":  StatusRefunded,
+}
+
+func gatewayStatus(status string) string {
+	for wire, s := range gatewayStatuses {
+		if s == status {
+			return wire
+		}
+	}
+	return ""
+}
+
+func (c gatewayCharge) toCharge() (*Charge, error) {
+	status, ok := gatewayStatuses[c.Status]
+	if !ok {
+		return nil, fmt.Errorf("payment: unknown charge status %q", c.Status)
+	}
+
+	method := ""
+	for m, gm := range gatewayMethods {
+		if gm == c.PaymentMethod {
+			method = m
+		}
+	}
+	if method == "" {
+		return nil, fmt.Errorf("payment: unknown payment method %+v", c.PaymentMethod)
+	}
+
+	instructions := Instructions{
+		QRString:    c.Actions.QRString,
+		VANumber:    c.Actions.VANumber,
+		CheckoutURL: c.Actions.CheckoutURL,
+	}
+	if c.PaymentMethod.Type == "VIRTUAL_ACCOUNT" {
+		instructions.Bank = strings.ToLower(c.PaymentMethod.ChannelCode)
+	}
+
+	return &Charge{
+		ID:           c.ID,
+		Reference:    c.ReferenceID,
+		Method:       method,
+		Amount:       pricing.Rupiah(c.Amount),
+		Status:       status,
+		Instructions: instructions,
+		ExpiresAt:    c.ExpiresAt,
+		PaidAt:       c.PaidAt,
+	}, nil
+}
+
+func parseWebhook(secret string, header http.Header, body []byte, now time.Time) (*Event, error) {
+	if err := Verify(secret, header, body, now); err != nil {
+		return nil, err
+	}
+
+	var event gatewayEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" || event.Data.ID == "" {
+		return nil, ErrMalformedWebhook
+	}
+	charge, err := event.Data.toCharge()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedWebhook, err)
+	}
+
+	return &Event{
+		ID:         event.ID,
+		ChargeID:   charge.ID,
+		Reference:  charge.Reference,
+		Status:     charge.Status,
+		Amount:     charge.Amount,
+		OccurredAt: event.Created,
+	}, nil
+}
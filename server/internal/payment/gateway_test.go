@@ -0,0 +1,229 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+const (
+	testServerKey     = "sk_test_0123456789"
+	testWebhookSecret = "whsec_test_0123456789"
+)
+
+func newTestGateway(t *testing.T, handler http.HandlerFunc) *Gateway {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, ok := r.BasicAuth(); !ok || user != testServerKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error_code":"INVALID_API_KEY","message":"invalid server key"}`)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	gw, err := NewGateway(GatewayConfig{BaseURL: srv.URL + "/api", ServerKey: testServerKey, WebhookSecret: testWebhookSecret}, srv.Client())
+	require.NoError(t, err)
+	return gw
+}
+
+func TestNewGateway(t *testing.T) {
+	_, err := NewGateway(GatewayConfig{BaseURL: "not a url", ServerKey: "k", WebhookSecret: "s"}, nil)
+	assert.Error(t, err)
+
+	_, err = NewGateway(GatewayConfig{BaseURL: "https://api.example.co.id"}, nil)
+	assert.Error(t, err)
+}
+
+func TestGatewayCreateCharge(t *testing.T) {
+	expires := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+
+	t.Run("creates a virtual account charge", func(t *testing.T) {
+		gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/api/v1/charges", r.URL.Path)
+			assert.Equal(t, "order-1", r.Header.Get("Idempotency-Key"))
+
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "order-1", body["reference_id"])
+			assert.Equal(t, float64(144261), body["amount"])
+			assert.Equal(t, "IDR", body["currency"])
+			assert.Equal(t, map[string]any{"type": "VIRTUAL_ACCOUNT", "channel_code": "BCA"}, body["payment_method"])
+
+			io.WriteString(w, `{
+				"id": "ch_123",
+				"reference_id": "order-1",
+				"amount": 144261,
+				"currency": "IDR",
+				"status": "PENDING",
+				"payment_method": {"type": "VIRTUAL_ACCOUNT", "channel_code": "BCA"},
+				"actions": {"va_number": "8808123456789012"},
+				"expires_at": "2026-10-19T14:00:00Z"
+			}`)
+		})
+
+		charge, err := gw.CreateCharge(context.Background(), ChargeRequest{
+			Reference: "order-1",
+			Amount:    pricing.Rupiah(144261),
+			Method:    MethodVABCA,
+			ExpiresAt: expires,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, &Charge{
+			ID:           "ch_123",
+			Reference:    "order-1",
+			Method:       MethodVABCA,
+			Amount:       pricing.Rupiah(144261),
+			Status:       StatusPending,
+			Instructions: Instructions{Bank: "bca", VANumber: "8808123456789012"},
+			ExpiresAt:    expires,
+		}, charge)
+	})
+
+	t.Run("rejects what the gateway cannot charge", func(t *testing.T) {
+		gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Error("unexpected request")
+		})
+
+		_, err := gw.CreateCharge(context.Background(), ChargeRequest{Reference: "order-1", Amount: 14426150, Method: MethodQRIS})
+		assert.ErrorIs(t, err, ErrFractionalAmount)
+
+		_, err = gw.CreateCharge(context.Background(), ChargeRequest{Reference: "order-1", Amount: 100, Method: "cash"})
+		assert.ErrorIs(t, err, ErrUnsupportedMethod)
+	})
+
+	t.Run("returns gateway errors", func(t *testing.T) {
+		gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error_code":"CHANNEL_NOT_ACTIVATED","message":"BCA is not activated"}`)
+		})
+
+		_, err := gw.CreateCharge(context.Background(), ChargeRequest{Reference: "order-1", Amount: 100, Method: MethodVABCA})
+
+		var gwErr *GatewayError
+		require.ErrorAs(t, err, &gwErr)
+		assert.Equal(t, http.StatusBadRequest, gwErr.StatusCode)
+		assert.Equal(t, "CHANNEL_NOT_ACTIVATED", gwErr.Code)
+	})
+}
+
+func TestGatewayGetCharge(t *testing.T) {
+	gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/charges/ch_123" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error_code":"DATA_NOT_FOUND","message":"charge not found"}`)
+			return
+		}
+		io.WriteString(w, `{
+			"id": "ch_123",
+			"reference_id": "order-1",
+			"amount": 50000,
+			"currency": "IDR",
+			"status": "SUCCEEDED",
+			"payment_method": {"type": "EWALLET", "channel_code": "GOPAY"},
+			"actions": {"checkout_url": "https://pay.example.co.id/ch_123"},
+			"expires_at": "2026-10-19T14:00:00Z",
+			"paid_at": "2026-10-19T13:05:00Z"
+		}`)
+	})
+
+	charge, err := gw.GetCharge(context.Background(), "ch_123")
+	require.NoError(t, err)
+	assert.Equal(t, StatusPaid, charge.Status)
+	assert.Equal(t, MethodEWalletGoPay, charge.Method)
+	assert.Equal(t, Instructions{CheckoutURL: "https://pay.example.co.id/ch_123"}, charge.Instructions)
+	require.NotNil(t, charge.PaidAt)
+
+	_, err = gw.GetCharge(context.Background(), "ch_404")
+	assert.ErrorIs(t, err, ErrChargeNotFound)
+}
+
+func TestGatewayRefund(t *testing.T) {
+	gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/charges/ch_123/refunds", r.URL.Path)
+		assert.Equal(t, "refund-ch_123", r.Header.Get("Idempotency-Key"))
+		io.WriteString(w, `{"id":"rf_1","charge_id":"ch_123","amount":50000,"status":"PENDING"}`)
+	})
+
+	refund, err := gw.Refund(context.Background(), "ch_123", "delivery failed")
+	require.NoError(t, err)
+	assert.Equal(t, &Refund{ID: "rf_1", ChargeID: "ch_123", Amount: pricing.Rupiah(50000), Status: StatusPending}, refund)
+}
+
+func TestGatewayExpire(t *testing.T) {
+	gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/charges/ch_123/expire" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error_code":"DATA_NOT_FOUND","message":"charge not found"}`)
+			return
+		}
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "expire-ch_123", r.Header.Get("Idempotency-Key"))
+		io.WriteString(w, `{
+			"id": "ch_123",
+			"reference_id": "order-1",
+			"amount": 50000,
+			"currency": "IDR",
+			"status": "EXPIRED",
+			"payment_method": {"type": "QRIS"},
+			"expires_at": "2026-10-19T14:00:00Z"
+		}`)
+	})
+
+	charge, err := gw.Expire(context.Background(), "ch_123")
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, charge.Status)
+
+	_, err = gw.Expire(context.Background(), "ch_404")
+	assert.ErrorIs(t, err, ErrChargeNotFound)
+}
+
+func TestGatewayParseWebhook(t *testing.T) {
+	gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {})
+	now := time.Date(2026, 10, 19, 13, 5, 0, 0, time.UTC)
+	gw.now = func() time.Time { return now }
+
+	body := []byte(`{
+		"id": "evt_1",
+		"type": "charge.succeeded",
+		"created": "2026-10-19T13:05:00Z",
+		"data": {
+			"id": "ch_123",
+			"reference_id": "order-1",
+			"amount": 50000,
+			"currency": "IDR",
+			"status": "SUCCEEDED",
+			"payment_method": {"type": "QRIS"},
+			"expires_at": "2026-10-19T14:00:00Z"
+		}
+	}`)
+
+	event, err := gw.ParseWebhook(Sign(testWebhookSecret, body, now), body)
+	require.NoError(t, err)
+	assert.Equal(t, &Event{
+		ID:         "evt_1",
+		ChargeID:   "ch_123",
+		Reference:  "order-1",
+		Status:     StatusPaid,
+		Amount:     pricing.Rupiah(50000),
+		OccurredAt: now,
+	}, event)
+
+	_, err = gw.ParseWebhook(Sign("other", body, now), body)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	malformed := []byte(`{"id":"evt_2"}`)
+	_, err = gw.ParseWebhook(Sign(testWebhookSecret, malformed, now), malformed)
+	assert.ErrorIs(t, err, ErrMalformedWebhook)
+}
@@ -0,0 +1,159 @@
+// Package payment charges customers through a payment gateway. A Provider
+// creates charges for QRIS, bank virtual accounts and e-wallets, reports
+// their status, refunds them and signs the webhooks it sends when a
+// charge changes.
+//
+// Gateway talks to an Indonesian payment gateway. Fake keeps charges in
+// memory and produces the same signed webhooks on demand, so the whole
+// flow can run offline.
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/pricing"
+)
+
+var (
+	ErrChargeNotFound     = errors.New("payment: charge not found")
+	ErrInvalidSignature   = errors.New("payment: invalid webhook signature")
+	ErrUnsupportedMethod  = errors.New("payment: unsupported method")
+	ErrFractionalAmount   = errors.New("payment: amount must be whole rupiah")
+	ErrInvalidTransition  = errors.New("payment: invalid status transition")
+	ErrMalformedWebhook   = errors.New("payment: malformed webhook")
+	ErrRefundNotSupported = errors.New("payment: charge cannot be refunded")
+)
+
+// Charge statuses. A pending charge ends up paid, expired or failed, and a
+// paid one may be refunded.
+const (
+	StatusPending  = "pending"
+	StatusPaid     = "paid"
+	StatusExpired  = "expired"
+	StatusFailed   = "failed"
+	StatusRefunded = "refunded"
+)
+
+// Payment methods.
+const (
+	MethodQRIS             = "qris"
+	MethodVABCA            = "va_bca"
+	MethodVABNI            = "va_bni"
+	MethodVABRI            = "va_bri"
+	MethodVAMandiri        = "va_mandiri"
+	MethodEWalletGoPay     = "ewallet_gopay"
+	MethodEWalletOVO       = "ewallet_ovo"
+	MethodEWalletDANA      = "ewallet_dana"
+	MethodEWalletShopeePay = "ewallet_shopeepay"
+)
+
+// Methods lists every payment method.
+var Methods = []string{
+	MethodQRIS,
+	MethodVABCA,
+	MethodVABNI,
+	MethodVABRI,
+	MethodVAMandiri,
+	MethodEWalletGoPay,
+	MethodEWalletOVO,
+	MethodEWalletDANA,
+	MethodEWalletShopeePay,
+}
+
+// Provider is a payment gateway.
+type Provider interface {
+	// Name identifies the provider in stored payments and webhook URLs.
+	Name() string
+	// CreateCharge asks the customer to pay. Creating a charge again for
+	// the same Reference returns the existing one.
+	CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	// GetCharge returns the current state of a charge, ErrChargeNotFound
+	// when the provider does not know it.
+	GetCharge(ctx context.Context, id string) (*Charge, error)
+	// Refund returns the full amount of a paid charge.
+	Refund(ctx context.Context, id, reason string) (*Refund, error)
+	// Expire ends a pending charge so it can no longer be paid, and
+	// returns it. A charge that already left pending is returned as it is.
+	Expire(ctx context.Context, id string) (*Charge, error)
+	// ParseWebhook verifies the signature of a webhook and decodes its
+	// event. It returns ErrInvalidSignature for webhooks not signed by the
+	// provider.
+	ParseWebhook(header http.Header, body []byte) (*Event, error)
+}
+
+// ChargeRequest describes a charge to create.
+type ChargeRequest struct {
+	// Reference is the merchant's ID of the purchase, e.g. an order ID.
+	Reference   string
+	Amount      pricing.Money
+	Method      string
+	Description string
+	ExpiresAt   time.Time
+}
+
+// Instructions tell the customer how to pay. Which fields are set depends
+// on the method.
+type Instructions struct {
+	// QRString is the QRIS payload to render as a QR code.
+	QRString string `json:"qrString,omitempty"`
+	Bank     string `json:"bank,omitempty"`
+	// VANumber is the virtual account to transfer to.
+	VANumber string `json:"vaNumber,omitempty"`
+	// CheckoutURL opens the e-wallet app or its web checkout.
+	CheckoutURL string `json:"checkoutUrl,omitempty"`
+}
+
+type Charge struct {
+	ID           string
+	Reference    string
+	Method       string
+	Amount       pricing.Money
+	Status       string
+	Instructions Instructions
+	ExpiresAt    time.Time
+	PaidAt       *time.Time
+}
+
+type Refund struct {
+	ID       string
+	ChargeID string
+	Amount   pricing.Money
+	// Status is StatusRefunded once the money is returned, StatusPending
+	// while the provider is still processing it.
+	Status string
+}
+
+// Event reports a change of a charge. Providers may deliver an event more
+// than once; ID stays the same.
+type Event struct {
+	ID         string
+	ChargeID   string
+	Reference  string
+	Status     string
+	Amount     pricing.Money
+	OccurredAt time.Time
+}
+
+// CanTransition reports whether a charge may move from status from to to.
+func CanTransition(from, to string) bool {
+	switch from {
+	case StatusPending:
+		return slices.Contains([]string{StatusPaid, StatusExpired, StatusFailed}, to)
+	case StatusPaid:
+		return to == StatusRefunded
+	default:
+		return false
+	}
+}
+
+// wholeRupiah converts m to rupiah, which is all the gateways take.
+func wholeRupiah(m pricing.Money) (int64, error) {
+	if m%100 != 0 {
+		return 0, ErrFractionalAmount
+	}
+	return int64(m) / 100, nil
+}
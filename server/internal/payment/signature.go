@@ -0,0 +1,60 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhooks are signed with HMAC-SHA256 over "<timestamp>.<body>", so a
+// captured webhook cannot be replayed once the timestamp is older than
+// SignatureTolerance.
+const (
+	SignatureHeader    = "X-Callback-Signature"
+	TimestampHeader    = "X-Callback-Timestamp"
+	SignatureTolerance = 5 * time.Minute
+)
+
+// Sign returns the headers that sign body at t with secret.
+func Sign(secret string, body []byte, t time.Time) http.Header {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	header := http.Header{}
+	header.Set(TimestampHeader, timestamp)
+	header.Set(SignatureHeader, signature(secret, timestamp, body))
+	return header
+}
+
+// Verify checks that header signs body with secret and was sent within
+// SignatureTolerance of now.
+func Verify(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get(TimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	got, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(signature(secret, timestamp, body))
+	if !hmac.Equal(got, want) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
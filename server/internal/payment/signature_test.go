@@ -0,0 +1,42 @@
+package payment
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+	const secret = "whsec_test_secret"
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC)
+	header := Sign(secret, body, now)
+
+	t.Run("accepts a fresh signature", func(t *testing.T) {
+		assert.NoError(t, Verify(secret, header, body, now.Add(time.Minute)))
+	})
+
+	t.Run("rejects tampering", func(t *testing.T) {
+		assert.ErrorIs(t, Verify(secret, header, []byte(`{"id":"evt_2"}`), now), ErrInvalidSignature)
+		assert.ErrorIs(t, Verify("other_secret", header, body, now), ErrInvalidSignature)
+
+		shifted := header.Clone()
+		shifted.Set(TimestampHeader, "1792400000")
+		assert.ErrorIs(t, Verify(secret, shifted, body, time.Unix(1792400000, 0)), ErrInvalidSignature)
+	})
+
+	t.Run("rejects replays", func(t *testing.T) {
+		assert.ErrorIs(t, Verify(secret, header, body, now.Add(SignatureTolerance+time.Second)), ErrInvalidSignature)
+		assert.ErrorIs(t, Verify(secret, header, body, now.Add(-SignatureTolerance-time.Second)), ErrInvalidSignature)
+	})
+
+	t.Run("rejects missing headers", func(t *testing.T) {
+		assert.ErrorIs(t, Verify(secret, http.Header{}, body, now), ErrInvalidSignature)
+
+		unsigned := header.Clone()
+		unsigned.Del(SignatureHeader)
+		assert.ErrorIs(t, Verify(secret, unsigned, body, now), ErrInvalidSignature)
+	})
+}
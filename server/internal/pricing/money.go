@@ -0,0 +1,53 @@
+package pricing
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of rupiah in minor units (sen), 100 to the rupiah.
+// Prices are computed on integers only, so totals never drift the way
+// float64 sums do.
+type Money int64
+
+// Rupiah returns v whole rupiah as Money.
+func Rupiah(v int64) Money {
+	return Money(v * 100)
+}
+
+// String formats m the Indonesian way, e.g. Rp144.261 or Rp136,50.
+func (m Money) String() string {
+	var b strings.Builder
+	v := int64(m)
+	if v < 0 {
+		b.WriteByte('-')
+		v = -v
+	}
+	b.WriteString("Rp")
+
+	whole := strconv.FormatInt(v/100, 10)
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(digit)
+	}
+	if sen := v % 100; sen != 0 {
+		b.WriteByte(',')
+		if sen < 10 {
+			b.WriteByte('0')
+		}
+		b.WriteString(strconv.FormatInt(sen, 10))
+	}
+	return b.String()
+}
+
+// MarshalJSON encodes m as its minor units with the formatted amount:
+// {"minor":14426100,"display":"Rp144.261"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Minor   int64  `json:"minor"`
+		Display string `json:"display"`
+	}{int64(m), m.String()})
+}
@@ -0,0 +1,146 @@
+// Package pricing converts Robux to rupiah. A rate table sets the price of
+// one Robux per volume tier and how totals are rounded. A new table takes
+// over from its effective date, so price changes can be scheduled ahead.
+//
+// Robux are delivered by buying a gamepass the customer puts up for sale.
+// Roblox keeps a 30% cut of every sale, so the gamepass has to be priced
+// above the Robux the customer should receive.
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ucok-man/mayobox-server/internal/utility"
+)
+
+// MarketplaceCutPercent is the share of a gamepass sale Roblox keeps.
+const MarketplaceCutPercent = 30
+
+var ErrInvalidRobux = errors.New("pricing: robux must be positive")
+
+// Rounding modes of a total.
+const (
+	RoundNearest = "nearest"
+	RoundUp      = "up"
+	RoundDown    = "down"
+)
+
+// Tier prices every Robux of an order of at least MinRobux.
+type Tier struct {
+	MinRobux    int   `json:"minRobux"`
+	IDRPerRobux Money `json:"idrPerRobux"`
+}
+
+// Table is a rate table. Tiers are sorted by MinRobux and the first starts
+// at 1.
+type Table struct {
+	ID            string    `json:"id"`
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+	Tiers         []Tier    `json:"tiers"`
+	// Totals are rounded to a multiple of RoundingStep with RoundingMode.
+	RoundingStep Money  `json:"roundingStep"`
+	RoundingMode string `json:"roundingMode"`
+}
+
+// Quote is the price of an order of Robux.
+type Quote struct {
+	Robux int `json:"robux"`
+	// GamepassPrice is the price the gamepass must be put up for so the
+	// customer receives Robux after the marketplace cut.
+	GamepassPrice int       `json:"gamepassPrice"`
+	IDRPerRobux   Money     `json:"idrPerRobux"`
+	Total         Money     `json:"total"`
+	RateTableID   string    `json:"rateTableId"`
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+}
+
+// Price is a price in both currencies the store shows.
+type Price struct {
+	Robux int   `json:"robux"`
+	IDR   Money `json:"idr"`
+}
+
+// Validate reports whether t can price every order.
+func (t *Table) Validate() error {
+	if len(t.Tiers) == 0 || t.Tiers[0].MinRobux != 1 {
+		return fmt.Errorf("pricing: rate table %s must have a tier starting at 1 robux", t.ID)
+	}
+	for i, tier := range t.Tiers {
+		if tier.IDRPerRobux <= 0 {
+			return fmt.Errorf("pricing: rate table %s has a non-positive rate", t.ID)
+		}
+		if i > 0 && tier.MinRobux <= t.Tiers[i-1].MinRobux {
+			return fmt.Errorf("pricing: rate table %s tiers are not sorted", t.ID)
+		}
+	}
+	if t.RoundingStep <= 0 {
+		return fmt.Errorf("pricing: rate table %s has a non-positive rounding step", t.ID)
+	}
+	switch t.RoundingMode {
+	case RoundNearest, RoundUp, RoundDown:
+		return nil
+	default:
+		return fmt.Errorf("pricing: rate table %s has unknown rounding mode %q", t.ID, t.RoundingMode)
+	}
+}
+
+// Quote prices robux with the tier the order falls in.
+func (t *Table) Quote(robux int) (*Quote, error) {
+	if robux <= 0 {
+		return nil, ErrInvalidRobux
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	rate := t.Tiers[0].IDRPerRobux
+	for _, tier := range t.Tiers {
+		if robux >= tier.MinRobux {
+			rate = tier.IDRPerRobux
+		}
+	}
+
+	return &Quote{
+		Robux:         robux,
+		GamepassPrice: GamepassPrice(robux),
+		IDRPerRobux:   rate,
+		Total:         round(Money(robux)*rate, t.RoundingStep, t.RoundingMode),
+		RateTableID:   t.ID,
+		EffectiveFrom: t.EffectiveFrom,
+	}, nil
+}
+
+// Price returns the rupiah price of robux alongside it.
+func (t *Table) Price(robux int) (Price, error) {
+	quote, err := t.Quote(robux)
+	if err != nil {
+		return Price{}, err
+	}
+	return Price{Robux: robux, IDR: quote.Total}, nil
+}
+
+// GamepassPrice returns the lowest gamepass price that pays out at least
+// robux after the marketplace cut.
+func GamepassPrice(robux int) int {
+	keep := 100 - MarketplaceCutPercent
+	return (robux*100 + keep - 1) / keep
+}
+
+// Payout returns the Robux a gamepass sale at price pays out. Roblox
+// rounds the payout down.
+func Payout(price int) int {
+	return price * (100 - MarketplaceCutPercent) / 100
+}
+
+func round(m, step Money, mode string) Money {
+	switch mode {
+	case RoundUp:
+		return (m + step - 1) / step * step
+	case RoundDown:
+		return m / step * step
+	default:
+		return Money(utility.DivRound(int64(m), int64(step))) * step
+	}
+}
@@ -0,0 +1,138 @@
+package pricing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTable() *Table {
+	return &Table{
+		ID:            "table-1",
+		EffectiveFrom: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Tiers: []Tier{
+			{MinRobux: 1, IDRPerRobux: Rupiah(140)},
+			{MinRobux: 1000, IDRPerRobux: Rupiah(137)},
+			{MinRobux: 10000, IDRPerRobux: 13350},
+		},
+		RoundingStep: Rupiah(1),
+		RoundingMode: RoundNearest,
+	}
+}
+
+func TestTableQuote(t *testing.T) {
+	tests := []struct {
+		name  string
+		robux int
+		rate  Money
+		total Money
+	}{
+		{name: "first tier", robux: 999, rate: Rupiah(140), total: Rupiah(139860)},
+		{name: "tier boundary", robux: 1000, rate: Rupiah(137), total: Rupiah(137000)},
+		{name: "landing card", robux: 1053, rate: Rupiah(137), total: Rupiah(144261)},
+		{name: "fractional rate rounds to the step", robux: 10001, rate: 13350, total: Rupiah(1335134)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := testTable().Quote(tt.robux)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.rate, quote.IDRPerRobux)
+			assert.Equal(t, tt.total, quote.Total)
+			assert.Equal(t, "table-1", quote.RateTableID)
+		})
+	}
+
+	t.Run("includes the gamepass price", func(t *testing.T) {
+		quote, err := testTable().Quote(1053)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1505, quote.GamepassPrice)
+	})
+
+	t.Run("prices in both currencies", func(t *testing.T) {
+		price, err := testTable().Price(850)
+
+		require.NoError(t, err)
+		assert.Equal(t, Price{Robux: 850, IDR: Rupiah(119000)}, price)
+	})
+
+	t.Run("rejects non-positive robux", func(t *testing.T) {
+		_, err := testTable().Quote(0)
+
+		assert.ErrorIs(t, err, ErrInvalidRobux)
+	})
+
+	t.Run("rejects invalid tables", func(t *testing.T) {
+		noBase := testTable()
+		noBase.Tiers = noBase.Tiers[1:]
+		unsorted := testTable()
+		unsorted.Tiers[1], unsorted.Tiers[2] = unsorted.Tiers[2], unsorted.Tiers[1]
+		noStep := testTable()
+		noStep.RoundingStep = 0
+		badMode := testTable()
+		badMode.RoundingMode = "banker"
+
+		for _, table := range []*Table{noBase, unsorted, noStep, badMode} {
+			_, err := table.Quote(10)
+
+			assert.Error(t, err)
+		}
+	})
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		mode     string
+		in, want Money
+	}{
+		{RoundNearest, 14426149, 14426100},
+		{RoundNearest, 14426150, 14426200},
+		{RoundUp, 14426101, 14426200},
+		{RoundUp, 14426100, 14426100},
+		{RoundDown, 14426199, 14426100},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, round(tt.in, Rupiah(1), tt.mode), "%s %d", tt.mode, tt.in)
+	}
+
+	assert.Equal(t, Rupiah(144500), round(Rupiah(144261), Rupiah(500), RoundUp))
+}
+
+func TestGamepassPrice(t *testing.T) {
+	for robux := 1; robux <= 5000; robux++ {
+		price := GamepassPrice(robux)
+
+		require.GreaterOrEqual(t, Payout(price), robux, "robux %d", robux)
+		require.Less(t, Payout(price-1), robux, "robux %d is not the lowest price", robux)
+	}
+
+	assert.Equal(t, 1429, GamepassPrice(1000))
+	assert.Equal(t, 1000, Payout(1429))
+}
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{0, "Rp0"},
+		{Rupiah(999), "Rp999"},
+		{Rupiah(144261), "Rp144.261"},
+		{Rupiah(73703644), "Rp73.703.644"},
+		{13650, "Rp136,50"},
+		{5, "Rp0,05"},
+		{-Rupiah(1000), "-Rp1.000"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.in.String())
+	}
+
+	raw, err := json.Marshal(Rupiah(144261))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"minor":14426100,"display":"Rp144.261"}`, string(raw))
+}
@@ -0,0 +1,43 @@
+package serializer
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Binder binds MessagePack request bodies the way echo.DefaultBinder binds
+// JSON ones, and leaves every other content type to echo.DefaultBinder.
+type Binder struct {
+	echo.DefaultBinder
+}
+
+func NewBinder() *Binder {
+	return &Binder{}
+}
+
+func (b *Binder) Bind(i any, c echo.Context) error {
+	req := c.Request()
+	if !isMediaType(req.Header.Get(echo.HeaderContentType), MIMEMessagePack) {
+		return b.DefaultBinder.Bind(i, c)
+	}
+
+	if err := b.BindPathParams(c, i); err != nil {
+		return err
+	}
+	// Like echo.DefaultBinder, query parameters only bind for methods that
+	// usually have no body.
+	if req.Method == http.MethodGet || req.Method == http.MethodDelete || req.Method == http.MethodHead {
+		if err := b.BindQueryParams(c, i); err != nil {
+			return err
+		}
+	}
+	if req.ContentLength == 0 {
+		return nil
+	}
+
+	if err := DeserializeMessagePack(c, i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
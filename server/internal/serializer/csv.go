@@ -0,0 +1,130 @@
+package serializer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// UnknownColumnError is returned when a requested CSV column is not a field
+// of the listed items.
+type UnknownColumnError struct {
+	Column string
+}
+
+func (e *UnknownColumnError) Error() string {
+	return fmt.Sprintf("unknown column %s", e.Column)
+}
+
+// csvRows returns the items of a flat list response: a JSON array of
+// objects, or the "data" array of an envelope. Other keys of the envelope,
+// like the pagination metadata, have no place in CSV and are dropped.
+func csvRows(value any) ([]orderedObject, bool) {
+	if obj, ok := value.(orderedObject); ok {
+		value, ok = obj.get("data")
+		if !ok {
+			return nil, false
+		}
+	}
+
+	items, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	rows := make([]orderedObject, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(orderedObject)
+		if !ok {
+			return nil, false
+		}
+		rows = append(rows, flatten("", obj, nil))
+	}
+	return rows, true
+}
+
+// flatten names nested fields by their path, e.g. user.username. Arrays
+// stay JSON encoded in a single cell.
+func flatten(prefix string, obj orderedObject, into orderedObject) orderedObject {
+	for _, m := range obj {
+		key := prefix + m.key
+		if nested, ok := m.value.(orderedObject); ok {
+			into = flatten(key+".", nested, into)
+			continue
+		}
+		into = append(into, member{key: key, value: m.value})
+	}
+	return into
+}
+
+// writeCSV writes a header row and one record per row. Without columns
+// every field is written, in the order the fields first appear.
+func writeCSV(w io.Writer, rows []orderedObject, columns []string) error {
+	var fields []string
+	for _, row := range rows {
+		for _, m := range row {
+			if !slices.Contains(fields, m.key) {
+				fields = append(fields, m.key)
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		columns = fields
+	} else if len(rows) > 0 {
+		for _, column := range columns {
+			if !slices.Contains(fields, column) {
+				return &UnknownColumnError{Column: column}
+			}
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			value, _ := row.get(column)
+			cell, err := csvCell(value)
+			if err != nil {
+				return err
+			}
+			record[i] = cell
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvCell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		if v {
+			return "true", nil
+		}
+		return "false", nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+// parseColumns splits the comma separated columns query parameter.
+func parseColumns(param string) []string {
+	var columns []string
+	for column := range strings.SplitSeq(param, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
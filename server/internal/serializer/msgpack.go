@@ -0,0 +1,418 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MessagePack is produced from and converted to JSON, so values keep their
+// json tags and MarshalJSON methods, and maps keep their key order. Times
+// are therefore RFC 3339 strings; incoming MessagePack timestamps are
+// accepted wherever a JSON time string is.
+
+// maxMessagePackDepth bounds nesting so a crafted body cannot exhaust the
+// stack.
+const maxMessagePackDepth = 1000
+
+// MessagePackSyntaxError reports malformed MessagePack at byte Offset.
+type MessagePackSyntaxError struct {
+	msg    string
+	Offset int
+}
+
+func (e *MessagePackSyntaxError) Error() string { return e.msg }
+
+// MarshalMessagePack encodes v as MessagePack.
+func MarshalMessagePack(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseOrdered(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := encodeMessagePack(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messagePackToJSON converts a single MessagePack value to JSON. Trailing
+// bytes are returned as errTrailingData.
+func messagePackToJSON(data []byte) ([]byte, error) {
+	d := &msgpackDecoder{data: data}
+	var out bytes.Buffer
+	if err := d.value(&out, 0); err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, errTrailingData
+	}
+	return out.Bytes(), nil
+}
+
+var errTrailingData = errors.New("trailing data")
+
+/* --------------------------- ENCODING --------------------------- */
+
+func encodeMessagePack(buf *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		return encodeNumber(buf, v)
+	case string:
+		encodeString(buf, v)
+	case []any:
+		writeLength(buf, len(v), 0x90, 16, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := encodeMessagePack(buf, item); err != nil {
+				return err
+			}
+		}
+	case orderedObject:
+		writeLength(buf, len(v), 0x80, 16, 0xde, 0xdf)
+		for _, m := range v {
+			encodeString(buf, m.key)
+			if err := encodeMessagePack(buf, m.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("messagepack: unsupported value %T", value)
+	}
+	return nil
+}
+
+func encodeNumber(buf *bytes.Buffer, n json.Number) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		encodeInt(buf, i)
+		return nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, u))
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte(0xcb)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	return nil
+}
+
+func encodeInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= 0x7f:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(i)})
+	case i >= 0 && i <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(i)))
+	case i >= 0 && i <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(i)))
+	case i >= math.MinInt8 && i < 0:
+		buf.Write([]byte{0xd0, byte(int8(i))})
+	case i >= math.MinInt16 && i < 0:
+		buf.WriteByte(0xd1)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+	case i >= math.MinInt32 && i < 0:
+		buf.WriteByte(0xd2)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+	default:
+		buf.WriteByte(0xd3)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+func encodeString(buf *bytes.Buffer, s string) {
+	if len(s) < 32 {
+		buf.WriteByte(0xa0 | byte(len(s)))
+	} else if len(s) <= math.MaxUint8 {
+		buf.Write([]byte{0xd9, byte(len(s))})
+	} else {
+		writeLength(buf, len(s), 0, 0, 0xda, 0xdb)
+	}
+	buf.WriteString(s)
+}
+
+// writeLength writes a container header: the fix form when n < fixMax,
+// otherwise the 16 or 32 bit form.
+func writeLength(buf *bytes.Buffer, n int, fix byte, fixMax int, code16, code32 byte) {
+	switch {
+	case n < fixMax:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(code32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+/* --------------------------- DECODING --------------------------- */
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) syntaxError(format string, args ...any) error {
+	return &MessagePackSyntaxError{msg: "messagepack: " + fmt.Sprintf(format, args...), Offset: d.pos}
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, d.syntaxError("unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// value converts the next value to JSON written to out.
+func (d *msgpackDecoder) value(out *bytes.Buffer, depth int) error {
+	if depth > maxMessagePackDepth {
+		return d.syntaxError("exceeded max depth")
+	}
+
+	start, err := d.read(1)
+	if err != nil {
+		return err
+	}
+	code := start[0]
+
+	switch {
+	case code <= 0x7f:
+		out.WriteString(strconv.Itoa(int(code)))
+		return nil
+	case code >= 0xe0:
+		out.WriteString(strconv.Itoa(int(int8(code))))
+		return nil
+	case code&0xf0 == 0x80:
+		return d.object(out, int(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return d.array(out, int(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		return d.str(out, int(code&0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		out.WriteString("null")
+	case 0xc2:
+		out.WriteString("false")
+	case 0xc3:
+		out.WriteString("true")
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (code - 0xc4))
+		if err != nil {
+			return err
+		}
+		b, err := d.read(int(n))
+		if err != nil {
+			return err
+		}
+		// []byte fields take base64 strings in JSON.
+		writeJSONString(out, base64.StdEncoding.EncodeToString(b))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (code - 0xc7))
+		if err != nil {
+			return err
+		}
+		return d.ext(out, int(n))
+	case 0xca:
+		bits, err := d.uint(4)
+		if err != nil {
+			return err
+		}
+		return d.float(out, float64(math.Float32frombits(uint32(bits))), 32)
+	case 0xcb:
+		bits, err := d.uint(8)
+		if err != nil {
+			return err
+		}
+		return d.float(out, math.Float64frombits(bits), 64)
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (code - 0xcc))
+		if err != nil {
+			return err
+		}
+		out.WriteString(strconv.FormatUint(n, 10))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		n, err := d.uint(size)
+		if err != nil {
+			return err
+		}
+		// sign extend from the encoded width
+		shift := 64 - 8*size
+		out.WriteString(strconv.FormatInt(int64(n<<shift)>>shift, 10))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(out, 1<<(code-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		size := 1 << (code - 0xd9)
+		n, err := d.uint(size)
+		if err != nil {
+			return err
+		}
+		return d.str(out, int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (code - 0xdc))
+		if err != nil {
+			return err
+		}
+		return d.array(out, int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (code - 0xde))
+		if err != nil {
+			return err
+		}
+		return d.object(out, int(n), depth)
+	default:
+		d.pos--
+		return d.syntaxError("invalid code 0x%02x", code)
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) str(out *bytes.Buffer, n int) error {
+	b, err := d.read(n)
+	if err != nil {
+		return err
+	}
+	writeJSONString(out, string(b))
+	return nil
+}
+
+func (d *msgpackDecoder) float(out *bytes.Buffer, f float64, bitSize int) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return d.syntaxError("unsupported float value %v", f)
+	}
+	out.WriteString(strconv.FormatFloat(f, 'g', -1, bitSize))
+	return nil
+}
+
+func (d *msgpackDecoder) array(out *bytes.Buffer, n int, depth int) error {
+	// Every element takes at least one byte, reject lengths the data
+	// cannot hold before looping over them.
+	if n > len(d.data)-d.pos {
+		return d.syntaxError("unexpected end of data")
+	}
+
+	out.WriteByte('[')
+	for i := range n {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if err := d.value(out, depth+1); err != nil {
+			return err
+		}
+	}
+	out.WriteByte(']')
+	return nil
+}
+
+func (d *msgpackDecoder) object(out *bytes.Buffer, n int, depth int) error {
+	if 2*n > len(d.data)-d.pos {
+		return d.syntaxError("unexpected end of data")
+	}
+
+	out.WriteByte('{')
+	for i := range n {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+
+		keyStart := out.Len()
+		if err := d.value(out, depth+1); err != nil {
+			return err
+		}
+		if out.Bytes()[keyStart] != '"' {
+			return d.syntaxError("map key must be a string")
+		}
+
+		out.WriteByte(':')
+		if err := d.value(out, depth+1); err != nil {
+			return err
+		}
+	}
+	out.WriteByte('}')
+	return nil
+}
+
+// ext accepts the timestamp extension (type -1) only.
+func (d *msgpackDecoder) ext(out *bytes.Buffer, n int) error {
+	typ, err := d.read(1)
+	if err != nil {
+		return err
+	}
+	b, err := d.read(n)
+	if err != nil {
+		return err
+	}
+	if int8(typ[0]) != -1 {
+		return d.syntaxError("unsupported extension type %d", int8(typ[0]))
+	}
+
+	var sec, nsec int64
+	switch n {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(b))
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		nsec, sec = int64(v>>34), int64(v&(1<<34-1))
+	case 12:
+		nsec, sec = int64(binary.BigEndian.Uint32(b)), int64(binary.BigEndian.Uint64(b[4:]))
+	default:
+		return d.syntaxError("invalid timestamp length %d", n)
+	}
+	writeJSONString(out, time.Unix(sec, nsec).UTC().Format(time.RFC3339Nano))
+	return nil
+}
+
+func writeJSONString(out *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	out.Write(b)
+}
@@ -0,0 +1,156 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalMessagePack(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []byte
+	}{
+		{"nil", nil, []byte{0xc0}},
+		{"bools", []bool{true, false}, []byte{0x92, 0xc3, 0xc2}},
+		{"positive fixint", 5, []byte{0x05}},
+		{"negative fixint", -3, []byte{0xfd}},
+		{"uint8", 200, []byte{0xcc, 0xc8}},
+		{"uint16", 1000, []byte{0xcd, 0x03, 0xe8}},
+		{"int8", -100, []byte{0xd0, 0x9c}},
+		{"int32", -100000, []byte{0xd2, 0xff, 0xfe, 0x79, 0x60}},
+		{"uint64", uint64(1 << 63), []byte{0xcf, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{"float", 1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", "hi", []byte{0xa2, 'h', 'i'}},
+		{"str8", strings.Repeat("a", 40), append([]byte{0xd9, 40}, strings.Repeat("a", 40)...)},
+		{"map keeps field order", struct {
+			B int `json:"b"`
+			A int `json:"a"`
+		}{1, 2}, []byte{0x82, 0xa1, 'b', 0x01, 0xa1, 'a', 0x02}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalMessagePack(tt.value)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("uses 16 bit headers for large containers", func(t *testing.T) {
+		got, err := MarshalMessagePack(make([]int, 20))
+
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xdc, 0x00, 0x14}, got[:3])
+		assert.Len(t, got, 3+20)
+	})
+}
+
+func TestMessagePackRoundTrip(t *testing.T) {
+	type nested struct {
+		Tags []string `json:"tags"`
+	}
+	type payload struct {
+		Name    string         `json:"name"`
+		Count   int            `json:"count"`
+		Ratio   float64        `json:"ratio"`
+		Missing *string        `json:"missing"`
+		At      time.Time      `json:"at"`
+		Nested  nested         `json:"nested"`
+		Extra   map[string]int `json:"extra"`
+	}
+	in := payload{
+		Name:   strings.Repeat("x", 300),
+		Count:  -70000,
+		Ratio:  0.25,
+		At:     time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		Nested: nested{Tags: []string{"a", "b"}},
+		Extra:  map[string]int{"k": 1},
+	}
+
+	b, err := MarshalMessagePack(in)
+	require.NoError(t, err)
+	raw, err := messagePackToJSON(b)
+	require.NoError(t, err)
+
+	var out payload
+	require.NoError(t, json.Unmarshal(raw, &out))
+	assert.Equal(t, in, out)
+}
+
+func TestDeserializeMessagePack(t *testing.T) {
+	type target struct {
+		Level string `json:"level"`
+		Count int    `json:"count"`
+		Data  []byte `json:"data"`
+		At    time.Time
+	}
+	deserialize := func(body []byte) (target, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, MIMEMessagePack)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+
+		var out target
+		err := DeserializeMessagePack(c, &out)
+		return out, err
+	}
+
+	t.Run("decodes into structs", func(t *testing.T) {
+		body := []byte{0x84,
+			0xa5, 'l', 'e', 'v', 'e', 'l', 0xa4, 'w', 'a', 'r', 'n',
+			0xa5, 'c', 'o', 'u', 'n', 't', 0xd1, 0xfc, 0x18,
+			0xa4, 'd', 'a', 't', 'a', 0xc4, 0x02, 0x01, 0x02,
+			// timestamp 32: 2026-01-01T00:00:00Z
+			0xa2, 'A', 't', 0xd6, 0xff, 0x69, 0x55, 0xb9, 0x00,
+		}
+
+		out, err := deserialize(body)
+
+		require.NoError(t, err)
+		assert.Equal(t, "warn", out.Level)
+		assert.Equal(t, -1000, out.Count)
+		assert.Equal(t, []byte{1, 2}, out.Data)
+		assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), out.At)
+	})
+
+	errorTests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"empty body", nil, "body must not be empty"},
+		{"truncated", []byte{0x81, 0xa5, 'l', 'e'}, "body contains badly-formed MessagePack (at byte 2)"},
+		{"invalid code", []byte{0xc1}, "body contains badly-formed MessagePack (at byte 0)"},
+		{"non-string key", []byte{0x81, 0x01, 0x02}, "body contains badly-formed MessagePack (at byte 2)"},
+		{"wrong type", []byte{0x81, 0xa5, 'c', 'o', 'u', 'n', 't', 0xa1, 'x'}, "body contains incorrect MessagePack type for field count"},
+		{"unknown key", []byte{0x81, 0xa3, 'f', 'o', 'o', 0x01}, `body contains unknown key "foo"`},
+		{"two values", []byte{0x80, 0x80}, "body must only contain a single MessagePack value"},
+		{"too large", append([]byte{0xdb, 0x00, 0x10, 0x00, 0x01}, make([]byte, 1<<20)...), "body must not be larger than 1048576 bytes"},
+		{"huge length claim", []byte{0xdd, 0xff, 0xff, 0xff, 0xff}, "body contains badly-formed MessagePack (at byte 5)"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := deserialize(tt.body)
+
+			require.Error(t, err)
+			assert.Equal(t, tt.want, err.Error())
+		})
+	}
+
+	t.Run("rejects deep nesting", func(t *testing.T) {
+		_, err := deserialize(bytes.Repeat([]byte{0x91}, maxMessagePackDepth+2))
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "badly-formed MessagePack")
+	})
+}
@@ -0,0 +1,68 @@
+package serializer
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	MIMEApplicationJSON = echo.MIMEApplicationJSON
+	MIMEMessagePack     = echo.MIMEApplicationMsgpack
+	MIMETextCSV         = "text/csv"
+)
+
+// Negotiate returns the offer the Accept header prefers. Ties go to the
+// earlier offer; an empty header, or one accepting none of the offers,
+// gets offers[0] rather than a 406.
+func Negotiate(accept string, offers ...string) string {
+	best, bestQ := offers[0], 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(accept, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the q value of the most specific media range in
+// accept that matches offer.
+func acceptQuality(accept, offer string) float64 {
+	offerType, _, _ := strings.Cut(offer, "/")
+
+	q, specificity := 0.0, -1
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if mediaType == "application/x-msgpack" {
+			mediaType = MIMEMessagePack
+		}
+
+		var s int
+		switch {
+		case mediaType == offer:
+			s = 2
+		case mediaType == offerType+"/*":
+			s = 1
+		case mediaType == "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+
+		specificity, q = s, 1
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+	return q
+}
@@ -0,0 +1,122 @@
+package serializer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{MIMEApplicationJSON, MIMEMessagePack, MIMETextCSV}
+
+	for accept, want := range map[string]string{
+		"":                                       MIMEApplicationJSON,
+		"*/*":                                    MIMEApplicationJSON,
+		"application/msgpack":                    MIMEMessagePack,
+		"application/x-msgpack":                  MIMEMessagePack,
+		"text/csv":                               MIMETextCSV,
+		"text/*":                                 MIMETextCSV,
+		"text/html":                              MIMEApplicationJSON,
+		"application/json;q=0.5, text/csv":       MIMETextCSV,
+		"application/*;q=0.9, application/json":  MIMEApplicationJSON,
+		"application/msgpack, */*;q=0.1":         MIMEMessagePack,
+		"text/csv;q=0, */*":                      MIMEApplicationJSON,
+		"application/json, application/msgpack":  MIMEApplicationJSON,
+		"application/problem+json, text/csv;q=1": MIMETextCSV,
+	} {
+		assert.Equal(t, want, Negotiate(accept, offers...), accept)
+	}
+}
+
+func TestSerializeNegotiation(t *testing.T) {
+	type item struct {
+		ID   string `json:"id"`
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+		Tags []string `json:"tags"`
+	}
+	list := map[string]any{
+		"data":     []item{{ID: "1", Tags: []string{"a"}}, {ID: "2"}},
+		"metadata": map[string]int{"total": 2},
+	}
+	list["data"].([]item)[0].User.Name = "ann"
+
+	serialize := func(accept, target string, v any) (*httptest.ResponseRecorder, error) {
+		e := echo.New()
+		e.JSONSerializer = New()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(echo.HeaderAccept, accept)
+		rec := httptest.NewRecorder()
+		return rec, e.NewContext(req, rec).JSON(http.StatusOK, v)
+	}
+
+	t.Run("defaults to json", func(t *testing.T) {
+		rec, err := serialize("", "/", list)
+
+		require.NoError(t, err)
+		assert.Equal(t, MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, echo.HeaderAccept, rec.Header().Get(echo.HeaderVary))
+		assert.Contains(t, rec.Body.String(), `"metadata"`)
+	})
+
+	t.Run("writes messagepack", func(t *testing.T) {
+		rec, err := serialize(MIMEMessagePack, "/", list)
+
+		require.NoError(t, err)
+		assert.Equal(t, MIMEMessagePack, rec.Header().Get(echo.HeaderContentType))
+		raw, err := messagePackToJSON(rec.Body.Bytes())
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"data":[{"id":"1","user":{"name":"ann"},"tags":["a"]},{"id":"2","user":{"name":""},"tags":null}],"metadata":{"total":2}}`, string(raw))
+	})
+
+	t.Run("writes flat lists as csv", func(t *testing.T) {
+		rec, err := serialize(MIMETextCSV, "/", list)
+
+		require.NoError(t, err)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "id,user.name,tags\n1,ann,\"[\"\"a\"\"]\"\n2,,\n", rec.Body.String())
+	})
+
+	t.Run("selects csv columns", func(t *testing.T) {
+		rec, err := serialize(MIMETextCSV, "/?columns=user.name,%20id", list)
+
+		require.NoError(t, err)
+		assert.Equal(t, "user.name,id\nann,1\n,2\n", rec.Body.String())
+	})
+
+	t.Run("rejects unknown csv columns", func(t *testing.T) {
+		rec, err := serialize(MIMETextCSV, "/?columns=id,secret", list)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		assert.Equal(t, "unknown column secret", httpErr.Message)
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("falls back to json for values that are not lists", func(t *testing.T) {
+		rec, err := serialize(MIMETextCSV, "/", map[string]any{"data": map[string]string{"level": "info"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+	})
+
+	t.Run("keeps other json content types", func(t *testing.T) {
+		e := echo.New()
+		e.JSONSerializer = New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAccept, MIMEMessagePack)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Response().Header().Set(echo.HeaderContentType, "application/problem+json")
+
+		require.NoError(t, e.JSONSerializer.Serialize(ctx, map[string]int{"status": 404}, ""))
+		assert.Equal(t, "application/problem+json", rec.Header().Get(echo.HeaderContentType))
+		assert.JSONEq(t, `{"status":404}`, rec.Body.String())
+	})
+}
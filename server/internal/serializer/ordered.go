@@ -0,0 +1,95 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// orderedObject is a JSON object that keeps the key order of its source,
+// which map[string]any does not.
+type orderedObject []member
+
+type member struct {
+	key   string
+	value any
+}
+
+// parseOrdered decodes JSON into nil, bool, json.Number, string, []any and
+// orderedObject values.
+func parseOrdered(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return parseOrderedValue(dec)
+}
+
+func parseOrderedValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '[':
+		items := []any{}
+		for dec.More() {
+			item, err := parseOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		return items, err
+	case '{':
+		obj := orderedObject{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: tok.(string), value: value})
+		}
+		_, err := dec.Token()
+		return obj, err
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %v", delim)
+	}
+}
+
+func (o orderedObject) get(key string) (any, bool) {
+	for _, m := range o {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+	return nil, false
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(&buf, m.key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -1,16 +1,42 @@
 package serializer
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 )
 
+// DefaultBodyLimit limits request bodies of every format, unless the route
+// sets another limit with SetBodyLimit.
+const DefaultBodyLimit = 1_048_576
+
+const bodyLimitKey = "serializer.body_limit"
+
+// SetBodyLimit changes the maximum body size, in bytes, of the request.
+func SetBodyLimit(c echo.Context, limit int64) {
+	c.Set(bodyLimitKey, limit)
+}
+
+// BodyLimit returns the maximum body size of the request.
+func BodyLimit(c echo.Context) int64 {
+	if limit, ok := c.Get(bodyLimitKey).(int64); ok {
+		return limit
+	}
+	return DefaultBodyLimit
+}
+
+// JSONSerializer writes ctx.JSON responses in the format the Accept header
+// prefers: JSON (the default), MessagePack, or CSV for flat list responses
+// (columns chosen with ?columns=a,b). Responses that already have another
+// Content-Type, like application/problem+json, are always JSON.
 type JSONSerializer struct{}
 
 func New() JSONSerializer {
@@ -18,62 +44,170 @@ func New() JSONSerializer {
 }
 
 func (js JSONSerializer) Serialize(c echo.Context, i any, indent string) error {
-	enc := json.NewEncoder(c.Response())
+	res := c.Response()
+	if res.Committed || !isMediaType(res.Header().Get(echo.HeaderContentType), MIMEApplicationJSON) {
+		return serializeJSON(res, i, indent)
+	}
+
+	if !slices.Contains(res.Header().Values(echo.HeaderVary), echo.HeaderAccept) {
+		res.Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	}
+
+	switch Negotiate(c.Request().Header.Get(echo.HeaderAccept), MIMEApplicationJSON, MIMEMessagePack, MIMETextCSV) {
+	case MIMEMessagePack:
+		b, err := MarshalMessagePack(i)
+		if err != nil {
+			return err
+		}
+		res.Header().Set(echo.HeaderContentType, MIMEMessagePack)
+		_, err = res.Write(b)
+		return err
+
+	case MIMETextCSV:
+		ok, err := serializeCSV(c, i)
+		if ok || err != nil {
+			return err
+		}
+	}
+
+	return serializeJSON(res, i, indent)
+}
+
+func serializeJSON(w io.Writer, i any, indent string) error {
+	enc := json.NewEncoder(w)
 	if indent != "" {
 		enc.SetIndent("", indent)
 	}
 	return enc.Encode(i)
 }
 
+// serializeCSV writes i as CSV and reports false, writing nothing, when i
+// is not a list of objects.
+func serializeCSV(c echo.Context, i any) (bool, error) {
+	raw, err := json.Marshal(i)
+	if err != nil {
+		return false, err
+	}
+	value, err := parseOrdered(raw)
+	if err != nil {
+		return false, err
+	}
+	rows, ok := csvRows(value)
+	if !ok {
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+	err = writeCSV(&buf, rows, parseColumns(c.QueryParam("columns")))
+	if err != nil {
+		var columnErr *UnknownColumnError
+		if errors.As(err, &columnErr) {
+			return true, echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+		return true, err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMETextCSV+"; charset=utf-8")
+	_, err = c.Response().Write(buf.Bytes())
+	return true, err
+}
+
 func (d JSONSerializer) Deserialize(c echo.Context, i any) error {
-	maxBytes := 1_048_576
-	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, int64(maxBytes))
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, BodyLimit(c))
 
 	dec := json.NewDecoder(c.Request().Body)
 	dec.DisallowUnknownFields()
 
 	err := dec.Decode(i)
 	if err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		var invalidUnmarshalError *json.InvalidUnmarshalError
-		var maxBytesError *http.MaxBytesError
-
-		switch {
-		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		return decodeError(err, "JSON")
+	}
 
-		case errors.Is(err, io.ErrUnexpectedEOF):
-			return errors.New("body contains badly-formed JSON")
+	err = dec.Decode(&struct{}{})
+	if err != io.EOF {
+		return errors.New("body must only contain a single JSON value")
+	}
 
-		case errors.As(err, &unmarshalTypeError):
-			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %s", unmarshalTypeError.Field)
-			}
-			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+	return nil
+}
 
-		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
+// DeserializeMessagePack decodes a MessagePack request body into i, with
+// the same rules and error messages as the JSON body.
+func DeserializeMessagePack(c echo.Context, i any) error {
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, BodyLimit(c))
 
-		case strings.HasPrefix(err.Error(), "json: unknown field "):
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return decodeError(err, "MessagePack")
+	}
+	if len(data) == 0 {
+		return decodeError(io.EOF, "MessagePack")
+	}
 
-		case errors.As(err, &maxBytesError):
-			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+	raw, err := messagePackToJSON(data)
+	if errors.Is(err, errTrailingData) {
+		return errors.New("body must only contain a single MessagePack value")
+	}
+	if err != nil {
+		return decodeError(err, "MessagePack")
+	}
 
-		case errors.As(err, &invalidUnmarshalError):
-			panic(err)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(i); err != nil {
+		return decodeError(err, "MessagePack")
+	}
+	return nil
+}
 
-		default:
-			return err
+// decodeError turns a decoding error into a message for the client.
+// Character offsets are only meaningful for JSON, the MessagePack body was
+// converted before decoding.
+func decodeError(err error, format string) error {
+	var syntaxError *json.SyntaxError
+	var msgpackSyntaxError *MessagePackSyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var invalidUnmarshalError *json.InvalidUnmarshalError
+	var maxBytesError *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &syntaxError):
+		return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+
+	case errors.As(err, &msgpackSyntaxError):
+		return fmt.Errorf("body contains badly-formed MessagePack (at byte %d)", msgpackSyntaxError.Offset)
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("body contains badly-formed %s", format)
+
+	case errors.As(err, &unmarshalTypeError):
+		if unmarshalTypeError.Field != "" {
+			return fmt.Errorf("body contains incorrect %s type for field %s", format, unmarshalTypeError.Field)
 		}
-	}
+		if format != "JSON" {
+			return fmt.Errorf("body contains incorrect %s type", format)
+		}
+		return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
 
-	err = dec.Decode(&struct{}{})
-	if err != io.EOF {
-		return errors.New("body must only contain a single JSON value")
+	case errors.Is(err, io.EOF):
+		return errors.New("body must not be empty")
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return fmt.Errorf("body contains unknown key %s", fieldName)
+
+	case errors.As(err, &maxBytesError):
+		return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+
+	case errors.As(err, &invalidUnmarshalError):
+		panic(err)
+
+	default:
+		return err
 	}
+}
 
-	return nil
+func isMediaType(contentType, mediaType string) bool {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	return err == nil && parsed == mediaType
 }
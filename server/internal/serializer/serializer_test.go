@@ -126,3 +126,25 @@ func TestJSONDeserializer_ErrorHandling(t *testing.T) {
 		assert.Equal(t, 30, result.Age)
 	})
 }
+
+func TestBodyLimit(t *testing.T) {
+	js := New()
+
+	t.Run("defaults to DefaultBodyLimit", func(t *testing.T) {
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+
+		assert.Equal(t, int64(DefaultBodyLimit), BodyLimit(c))
+	})
+
+	t.Run("deserialize uses the route limit", func(t *testing.T) {
+		body := strings.NewReader(`{"name": "` + strings.Repeat("a", 64) + `"}`)
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", body), httptest.NewRecorder())
+		SetBodyLimit(c, 32)
+
+		var result map[string]string
+		err := js.Deserialize(c, &result)
+
+		require.Error(t, err)
+		assert.Equal(t, "body must not be larger than 32 bytes", err.Error())
+	})
+}
@@ -0,0 +1,57 @@
+package serializer
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONArrayStream writes {"<key>": [...]} one element at a time, so large
+// lists can be sent without holding them in memory.
+type JSONArrayStream struct {
+	w       io.Writer
+	enc     *json.Encoder
+	key     string
+	started bool
+}
+
+func NewJSONArrayStream(w io.Writer, key string) *JSONArrayStream {
+	return &JSONArrayStream{w: w, enc: json.NewEncoder(w), key: key}
+}
+
+// Write appends v to the array.
+func (s *JSONArrayStream) Write(v any) error {
+	sep := ","
+	if !s.started {
+		if err := s.open(); err != nil {
+			return err
+		}
+		sep = ""
+	}
+
+	if _, err := io.WriteString(s.w, sep); err != nil {
+		return err
+	}
+	return s.enc.Encode(v)
+}
+
+// Close ends the array and the object around it.
+func (s *JSONArrayStream) Close() error {
+	if !s.started {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(s.w, "]}\n")
+	return err
+}
+
+func (s *JSONArrayStream) open() error {
+	s.started = true
+
+	key, err := json.Marshal(s.key)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(s.w, "{"+string(key)+":[")
+	return err
+}
@@ -0,0 +1,34 @@
+package serializer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONArrayStream(t *testing.T) {
+	t.Run("writes every element inside the key", func(t *testing.T) {
+		var buf strings.Builder
+		stream := NewJSONArrayStream(&buf, "data")
+
+		require.NoError(t, stream.Write(map[string]int{"a": 1}))
+		require.NoError(t, stream.Write(map[string]int{"a": 2}))
+		require.NoError(t, stream.Close())
+
+		var decoded map[string][]map[string]int
+		require.NoError(t, json.Unmarshal([]byte(buf.String()), &decoded))
+		assert.Equal(t, []map[string]int{{"a": 1}, {"a": 2}}, decoded["data"])
+	})
+
+	t.Run("writes an empty array without elements", func(t *testing.T) {
+		var buf strings.Builder
+		stream := NewJSONArrayStream(&buf, "data")
+
+		require.NoError(t, stream.Close())
+
+		assert.JSONEq(t, `{"data":[]}`, buf.String())
+	})
+}
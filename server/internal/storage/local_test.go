@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	t.Run("puts, serves and deletes objects", func(t *testing.T) {
+		dir := t.TempDir()
+		l, err := NewLocal(filepath.Join(dir, "media"), "http://localhost:4000/media/")
+		require.NoError(t, err)
+
+		require.NoError(t, l.Put(context.Background(), "avatars/a.png", []byte("png"), "image/png"))
+		data, err := fs.ReadFile(l.FS(), "avatars/a.png")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("png"), data)
+		assert.Equal(t, "http://localhost:4000/media/avatars/a.png", l.URL("avatars/a.png"))
+
+		entries, err := os.ReadDir(filepath.Join(dir, "media", "avatars"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary files are removed")
+
+		require.NoError(t, l.Delete(context.Background(), "avatars/a.png"))
+		_, err = fs.Stat(l.FS(), "avatars/a.png")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		require.NoError(t, l.Delete(context.Background(), "avatars/a.png"))
+	})
+
+	t.Run("rejects keys outside the directory", func(t *testing.T) {
+		l, err := NewLocal(t.TempDir(), "/media")
+		require.NoError(t, err)
+
+		for _, key := range []string{"", "/etc/passwd", "../up.png", `a\..\b.png`} {
+			assert.ErrorIs(t, l.Put(context.Background(), key, nil, "image/png"), ErrInvalidKey, key)
+			assert.ErrorIs(t, l.Delete(context.Background(), key), ErrInvalidKey, key)
+		}
+	})
+}
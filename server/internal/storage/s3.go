@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// S3Config points at a bucket of any S3 compatible service (AWS, MinIO,
+// Cloudflare R2, ...).
+type S3Config struct {
+	// Endpoint is the service URL, e.g. https://s3.ap-southeast-1.amazonaws.com.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket as Endpoint/Bucket instead of as the
+	// Bucket.Endpoint subdomain. Most self-hosted services need it.
+	PathStyle bool
+	// PublicURL is where objects are read from, e.g. a CDN. It defaults to
+	// the bucket URL.
+	PublicURL string
+}
+
+// S3 stores objects in a bucket, signing requests with AWS Signature
+// Version 4. Objects must be publicly readable through the bucket policy
+// or PublicURL.
+type S3 struct {
+	cfg       S3Config
+	bucketURL *url.URL
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 uses client for the requests, http.DefaultClient when nil.
+func NewS3(cfg S3Config, client *http.Client) (*S3, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("storage: S3 bucket and region are required")
+	}
+
+	bucketURL := *endpoint
+	if cfg.PathStyle {
+		bucketURL.Path = strings.TrimSuffix(bucketURL.Path, "/") + "/" + cfg.Bucket
+	} else {
+		bucketURL.Host = cfg.Bucket + "." + bucketURL.Host
+	}
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = bucketURL.String()
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3{cfg: cfg, bucketURL: &bucketURL, client: client, now: time.Now}, nil
+}
+
+// S3Error is an error response of the service.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("storage: S3 responded %d", e.StatusCode)
+	}
+	return fmt.Sprintf("storage: S3 responded %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	return s.do(ctx, http.MethodPut, key, header, data)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	// S3 answers 204 for missing keys as well.
+	return s.do(ctx, http.MethodDelete, key, http.Header{}, nil)
+}
+
+func (s *S3) URL(key string) string {
+	return joinURL(s.cfg.PublicURL, key)
+}
+
+func (s *S3) do(ctx context.Context, method, key string, header http.Header, body []byte) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	target := *s.bucketURL
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	req.Header = header
+	req.ContentLength = int64(len(body))
+	s.sign(req, body)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+
+	s3Err := &S3Error{StatusCode: res.StatusCode}
+	xml.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(s3Err)
+	return s3Err
+}
+
+const (
+	sigAlgorithm  = "AWS4-HMAC-SHA256"
+	sigTimeFormat = "20060102T150405Z"
+)
+
+// sign adds the Signature Version 4 Authorization header, signing the host
+// and every header already set on req.
+func (s *S3) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format(sigTimeFormat)
+	day := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	scope := day + "/" + s.cfg.Region + "/s3/aws4_request"
+	canonical, signedHeaders := canonicalRequest(req, payloadHash)
+	stringToSign := sigAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigAlgorithm, s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalRequest returns the canonical form of req and the list of
+// signed headers.
+func canonicalRequest(req *http.Request, payloadHash string) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, v := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(v, true))
+		}
+	}
+	slices.Sort(params)
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	signedHeaders := strings.Join(names, ";")
+	return strings.Join([]string{
+		req.Method,
+		uriEncode(path, false),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+// uriEncode percent-encodes everything but the unreserved characters, as
+// Signature Version 4 requires.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,37 @@
+// Package storage keeps uploaded files, either in a local directory or in an
+// S3 compatible bucket, and hands out their public URLs.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// ErrInvalidKey is returned for keys that are empty, absolute or contain
+// "." or ".." elements.
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage stores objects under slash separated keys such as
+// avatars/3f2a.jpg.
+type Storage interface {
+	// Put stores data under key, replacing any existing object.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of key.
+	URL(key string) string
+}
+
+func validKey(key string) error {
+	if !fs.ValidPath(key) || key == "." || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// joinURL appends key to base, which may or may not end with a slash.
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
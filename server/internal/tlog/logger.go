@@ -1,9 +1,12 @@
 package tlog
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/labstack/gommon/log"
 	"go.uber.org/zap"
@@ -18,7 +21,9 @@ type Logger struct {
 	logger *zap.Logger
 	sugar  *zap.SugaredLogger
 	output io.Writer
-	level  log.Lvl
+	// level is a handle shared with derived loggers and with the zap cores
+	// built by this package, so SetLevel applies everywhere at once.
+	level  zap.AtomicLevel
 	prefix string
 	mu     sync.RWMutex
 }
@@ -27,25 +32,116 @@ func NewLogger(logger *zap.Logger) *Logger {
 	return &Logger{
 		logger: logger,
 		sugar:  logger.Sugar(),
-		level:  log.INFO,
+		level:  zap.NewAtomicLevelAt(zapcore.InfoLevel),
 		output: os.Stdout,
 	}
 }
 
-func NewProduction() (*Logger, error) {
-	logger, err := zap.NewProduction(zap.AddCallerSkip(1))
-	if err != nil {
-		return nil, err
+// New builds a logger that fans every entry out to all sinks.
+func New(sinks ...Sink) *Logger {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for _, sink := range sinks {
+		cores = append(cores, sink.core(level))
+	}
+
+	logger := NewLogger(zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddCallerSkip(1)))
+	logger.level = level
+	if len(sinks) > 0 {
+		logger.output = sinks[0].Writer
 	}
-	return NewLogger(logger), nil
+	return logger
+}
+
+func NewProduction() (*Logger, error) {
+	return build(zap.NewProductionConfig())
 }
 
 func NewDevelopment() (*Logger, error) {
-	logger, err := zap.NewDevelopment(zap.AddCallerSkip(1))
+	return build(zap.NewDevelopmentConfig())
+}
+
+func build(cfg zap.Config) (*Logger, error) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	cfg.Level = level
+
+	logger, err := cfg.Build(zap.AddCallerSkip(1))
 	if err != nil {
 		return nil, err
 	}
-	return NewLogger(logger), nil
+
+	l := NewLogger(logger)
+	l.level = level
+	return l, nil
+}
+
+// ParseLevel converts a level name (debug, info, warn, error, off) to log.Lvl.
+func ParseLevel(s string) (log.Lvl, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return log.DEBUG, nil
+	case "info":
+		return log.INFO, nil
+	case "warn", "warning":
+		return log.WARN, nil
+	case "error":
+		return log.ERROR, nil
+	case "off":
+		return log.OFF, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// toZapLevel maps a gommon level to zap. OFF keeps only panic and fatal
+// entries, which terminate the program and should never be silent.
+func toZapLevel(lvl log.Lvl) zapcore.Level {
+	switch lvl {
+	case log.DEBUG:
+		return zapcore.DebugLevel
+	case log.INFO:
+		return zapcore.InfoLevel
+	case log.WARN:
+		return zapcore.WarnLevel
+	case log.ERROR:
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.PanicLevel
+	}
+}
+
+func fromZapLevel(lvl zapcore.Level) log.Lvl {
+	switch {
+	case lvl <= zapcore.DebugLevel:
+		return log.DEBUG
+	case lvl == zapcore.InfoLevel:
+		return log.INFO
+	case lvl == zapcore.WarnLevel:
+		return log.WARN
+	case lvl == zapcore.ErrorLevel:
+		return log.ERROR
+	default:
+		return log.OFF
+	}
+}
+
+// LevelString is the inverse of ParseLevel.
+func LevelString(lvl log.Lvl) string {
+	switch lvl {
+	case log.DEBUG:
+		return "debug"
+	case log.INFO:
+		return "info"
+	case log.WARN:
+		return "warn"
+	case log.ERROR:
+		return "error"
+	case log.OFF:
+		return "off"
+	default:
+		return fmt.Sprintf("level(%d)", lvl)
+	}
 }
 
 func Must(logger *Logger, err error) *Logger {
@@ -61,14 +157,13 @@ func (l *Logger) Output() io.Writer {
 	return l.output
 }
 
+// SetOutput replaces every sink with a single JSON sink writing to w.
 func (l *Logger) SetOutput(w io.Writer) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.output = w
 
-	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
-	writeSyncer := zapcore.AddSync(w)
-	core := zapcore.NewCore(encoder, writeSyncer, zapcore.DebugLevel)
+	core := JSONSink(w).core(l.level)
 	l.logger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 	l.sugar = l.logger.Sugar()
 }
@@ -86,28 +181,51 @@ func (l *Logger) SetPrefix(p string) {
 }
 
 func (l *Logger) Level() log.Lvl {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.level
+	return fromZapLevel(l.level.Level())
 }
 
 func (l *Logger) SetLevel(v log.Lvl) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.level = v
+	l.level.SetLevel(toZapLevel(v))
 }
 
 func (l *Logger) SetHeader(h string) {}
 
 func (l *Logger) WithSkipCaller(skip int) *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	logger := l.logger.WithOptions(zap.AddCallerSkip(skip))
 	return &Logger{
 		logger: logger,
 		sugar:  logger.Sugar(),
-		level:  log.INFO,
+		level:  l.level,
+		prefix: l.prefix,
 		output: l.output,
 	}
+}
+
+// Sampled returns a logger that, per message and level, writes the first
+// entries in every tick and then only every thereafter-th entry. Use it for
+// high-volume messages such as the per-request log. A zero first disables
+// sampling.
+func (l *Logger) Sampled(tick time.Duration, first, thereafter int) *Logger {
+	if first <= 0 {
+		return l
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 
+	logger := l.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, tick, first, thereafter)
+	}))
+	return &Logger{
+		logger: logger,
+		sugar:  logger.Sugar(),
+		level:  l.level,
+		prefix: l.prefix,
+		output: l.output,
+	}
 }
 
 func (l *Logger) Print(i ...any) {
@@ -236,9 +354,7 @@ func (l *Logger) Write(p []byte) (n int, err error) {
 
 // shouldLog checks if the message should be logged based on level
 func (l *Logger) shouldLog(lvl log.Lvl) bool {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return lvl >= l.level
+	return l.level.Enabled(toZapLevel(lvl))
 }
 
 // withPrefix add prefix field to logger
@@ -2,6 +2,7 @@ package tlog
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/labstack/gommon/log"
@@ -132,3 +133,30 @@ func TestLogger(t *testing.T) {
 		assert.Contains(t, out.String(), "warn message")
 	})
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected log.Lvl
+	}{
+		{input: "debug", expected: log.DEBUG},
+		{input: "INFO", expected: log.INFO},
+		{input: "warn", expected: log.WARN},
+		{input: " error ", expected: log.ERROR},
+		{input: "off", expected: log.OFF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lvl, err := ParseLevel(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, lvl)
+			assert.Equal(t, strings.ToLower(strings.TrimSpace(tt.input)), LevelString(lvl))
+		})
+	}
+
+	t.Run("unknown level returns error", func(t *testing.T) {
+		_, err := ParseLevel("verbose")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,67 @@
+package tlog
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// RingBuffer keeps the most recent log entries in memory. It is meant to be
+// used as the writer of a JSONSink, where every Write is one entry.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries [][]byte
+	next    int
+	full    bool
+}
+
+func NewRingBuffer(size int) *RingBuffer {
+	if size < 1 {
+		size = 1
+	}
+	return &RingBuffer{entries: make([][]byte, size)}
+}
+
+func (rb *RingBuffer) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	rb.entries[rb.next] = entry
+	rb.next = (rb.next + 1) % len(rb.entries)
+	if rb.next == 0 {
+		rb.full = true
+	}
+	return len(p), nil
+}
+
+// Entries returns up to limit of the newest entries, oldest first. A limit
+// of zero or less returns everything in the buffer.
+func (rb *RingBuffer) Entries(limit int) []json.RawMessage {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	count := rb.next
+	if rb.full {
+		count = len(rb.entries)
+	}
+	if limit > 0 && limit < count {
+		count = limit
+	}
+
+	result := make([]json.RawMessage, 0, count)
+	start := rb.next - count
+	for i := range count {
+		idx := (start + i + len(rb.entries)) % len(rb.entries)
+		result = append(result, json.RawMessage(trimNewline(rb.entries[idx])))
+	}
+	return result
+}
+
+func trimNewline(p []byte) []byte {
+	for len(p) > 0 && (p[len(p)-1] == '\n' || p[len(p)-1] == '\r') {
+		p = p[:len(p)-1]
+	}
+	return p
+}
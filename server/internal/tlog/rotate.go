@@ -0,0 +1,250 @@
+package tlog
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// maxPruneInterval bounds how long an expired backup outlives MaxAge when
+// the file is too quiet to rotate.
+const maxPruneInterval = time.Hour
+
+type RotateConfig struct {
+	Filename string
+	// MaxSize is the size in bytes after which the file is rotated.
+	MaxSize int64
+	// MaxAge removes backups older than this. Zero keeps them forever.
+	MaxAge time.Duration
+	// MaxBackups is the number of backups kept. Zero keeps all of them.
+	MaxBackups int
+}
+
+// RotatingFile is an io.Writer that rotates the underlying file once it
+// grows past MaxSize. Backups are named <name>-<timestamp><ext> next to the
+// active file, with a -<n> suffix when the timestamp is taken. They are
+// pruned by age and count when the file is opened, after every rotation
+// and, with MaxAge set, periodically until Close.
+type RotatingFile struct {
+	cfg  RotateConfig
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	now  func() time.Time
+	done chan struct{}
+}
+
+func OpenRotatingFile(cfg RotateConfig) (*RotatingFile, error) {
+	if cfg.Filename == "" {
+		return nil, errors.New("rotating file requires a filename")
+	}
+	if cfg.MaxSize <= 0 {
+		return nil, errors.New("rotating file requires a positive max size")
+	}
+
+	rf := &RotatingFile{cfg: cfg, now: time.Now, done: make(chan struct{})}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	rf.prune()
+
+	if cfg.MaxAge > 0 {
+		go rf.pruneEvery(min(cfg.MaxAge, maxPruneInterval))
+	}
+	return rf, nil
+}
+
+// pruneEvery prunes the backups at each interval until Close.
+func (rf *RotatingFile) pruneEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rf.done:
+			return
+		case <-ticker.C:
+			rf.mu.Lock()
+			rf.prune()
+			rf.mu.Unlock()
+		}
+	}
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.cfg.MaxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) Sync() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.file.Sync()
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	select {
+	case <-rf.done:
+	default:
+		close(rf.done)
+	}
+	return rf.file.Close()
+}
+
+func (rf *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.cfg.Filename), 0o755); err != nil {
+		return fmt.Errorf("creating log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(rf.cfg.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+
+	backup := rf.backupName(rf.now())
+	if err := os.Rename(rf.cfg.Filename, backup); err != nil {
+		return fmt.Errorf("rotating log file: %w", err)
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	rf.prune()
+	return nil
+}
+
+// prune removes backups beyond MaxBackups or older than MaxAge. Failures
+// are ignored, a leftover backup must never stop logging.
+func (rf *RotatingFile) prune() {
+	backups := rf.backups()
+
+	var remove []string
+	if rf.cfg.MaxBackups > 0 && len(backups) > rf.cfg.MaxBackups {
+		remove = append(remove, backups[:len(backups)-rf.cfg.MaxBackups]...)
+		backups = backups[len(backups)-rf.cfg.MaxBackups:]
+	}
+
+	if rf.cfg.MaxAge > 0 {
+		cutoff := rf.now().Add(-rf.cfg.MaxAge)
+		for _, name := range backups {
+			if ts, ok := rf.backupTime(name); ok && ts.Before(cutoff) {
+				remove = append(remove, name)
+			}
+		}
+	}
+
+	for _, name := range remove {
+		os.Remove(name)
+	}
+}
+
+// backupName returns an unused backup name for a rotation at t. Rotations
+// within the same millisecond get a -<n> suffix, so no backup is replaced.
+func (rf *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(rf.cfg.Filename)
+	stamp := strings.TrimSuffix(rf.cfg.Filename, ext) + "-" + t.UTC().Format(backupTimeFormat)
+
+	name := stamp + ext
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(name); errors.Is(err, fs.ErrNotExist) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d%s", stamp, n, ext)
+	}
+}
+
+// backups lists existing backups, oldest first.
+func (rf *RotatingFile) backups() []string {
+	ext := filepath.Ext(rf.cfg.Filename)
+	pattern := strings.TrimSuffix(rf.cfg.Filename, ext) + "-*" + ext
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+
+	type backup struct {
+		name string
+		time time.Time
+		n    int
+	}
+	var found []backup
+	for _, name := range matches {
+		if ts, n, ok := rf.parseBackup(name); ok {
+			found = append(found, backup{name, ts, n})
+		}
+	}
+	slices.SortFunc(found, func(a, b backup) int {
+		return cmp.Or(a.time.Compare(b.time), cmp.Compare(a.n, b.n))
+	})
+
+	backups := make([]string, 0, len(found))
+	for _, b := range found {
+		backups = append(backups, b.name)
+	}
+	return backups
+}
+
+func (rf *RotatingFile) backupTime(name string) (time.Time, bool) {
+	ts, _, ok := rf.parseBackup(name)
+	return ts, ok
+}
+
+// parseBackup returns the rotation time and suffix of a backup name, 0
+// when it has no suffix.
+func (rf *RotatingFile) parseBackup(name string) (time.Time, int, bool) {
+	ext := filepath.Ext(rf.cfg.Filename)
+	prefix := strings.TrimSuffix(rf.cfg.Filename, ext) + "-"
+
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+	n := 0
+	if len(stamp) > len(backupTimeFormat) {
+		suffix, ok := strings.CutPrefix(stamp[len(backupTimeFormat):], "-")
+		var err error
+		if n, err = strconv.Atoi(suffix); !ok || err != nil || n < 1 {
+			return time.Time{}, 0, false
+		}
+		stamp = stamp[:len(backupTimeFormat)]
+	}
+	ts, err := time.Parse(backupTimeFormat, stamp)
+	return ts, n, err == nil
+}
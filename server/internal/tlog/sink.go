@@ -0,0 +1,40 @@
+package tlog
+
+import (
+	"io"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink is a single log destination used by New.
+type Sink struct {
+	Encoder zapcore.Encoder
+	Writer  io.Writer
+	// MinLevel optionally raises the minimum level for this sink above the
+	// logger level, e.g. to keep debug entries out of a file.
+	MinLevel zapcore.LevelEnabler
+}
+
+// ConsoleSink writes human readable, colored entries. Intended for a
+// terminal during development.
+func ConsoleSink(w io.Writer) Sink {
+	cfg := zap.NewDevelopmentEncoderConfig()
+	cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	return Sink{Encoder: zapcore.NewConsoleEncoder(cfg), Writer: w}
+}
+
+// JSONSink writes one JSON object per entry.
+func JSONSink(w io.Writer) Sink {
+	return Sink{Encoder: zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), Writer: w}
+}
+
+func (s Sink) core(level zap.AtomicLevel) zapcore.Core {
+	enabler := zapcore.LevelEnabler(level)
+	if s.MinLevel != nil {
+		enabler = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+			return level.Enabled(lvl) && s.MinLevel.Enabled(lvl)
+		})
+	}
+	return zapcore.NewCore(s.Encoder, zapcore.AddSync(s.Writer), enabler)
+}
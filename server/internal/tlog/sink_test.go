@@ -0,0 +1,220 @@
+package tlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewWithSinks(t *testing.T) {
+	t.Run("fans out to every sink", func(t *testing.T) {
+		var console, jsonOut bytes.Buffer
+		logger := New(ConsoleSink(&console), JSONSink(&jsonOut))
+
+		logger.Infoj(JSON{"message": "hello", "user_id": 1})
+
+		assert.Contains(t, console.String(), "hello")
+		assert.Contains(t, jsonOut.String(), `"msg":"hello"`)
+		assert.Contains(t, jsonOut.String(), `"user_id":1`)
+	})
+
+	t.Run("SetLevel applies to every sink", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := New(JSONSink(&out))
+
+		logger.Debug("hidden")
+		assert.Empty(t, out.String())
+
+		logger.SetLevel(log.DEBUG)
+		logger.Debug("visible")
+		assert.Contains(t, out.String(), "visible")
+	})
+
+	t.Run("SetLevel applies to derived loggers", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := New(JSONSink(&out))
+		derived := logger.WithSkipCaller(1)
+
+		logger.SetLevel(log.ERROR)
+		derived.Warn("hidden")
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("sink min level filters only that sink", func(t *testing.T) {
+		var all, errorsOnly bytes.Buffer
+		errSink := JSONSink(&errorsOnly)
+		errSink.MinLevel = zapcore.ErrorLevel
+		logger := New(JSONSink(&all), errSink)
+
+		logger.Info("info message")
+
+		assert.Contains(t, all.String(), "info message")
+		assert.Empty(t, errorsOnly.String())
+	})
+
+	t.Run("SetOutput keeps the configured level", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := New()
+		logger.SetLevel(log.DEBUG)
+		logger.SetOutput(&out)
+
+		logger.Debug("debug message")
+		assert.Contains(t, out.String(), "debug message")
+	})
+}
+
+func TestSampled(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(JSONSink(&out)).Sampled(time.Minute, 2, 0)
+
+	for range 5 {
+		logger.Info("request")
+	}
+
+	assert.Equal(t, 2, strings.Count(out.String(), `"msg":"request"`))
+}
+
+func TestRingBuffer(t *testing.T) {
+	t.Run("keeps only the newest entries", func(t *testing.T) {
+		rb := NewRingBuffer(3)
+		for _, v := range []string{"1", "2", "3", "4", "5"} {
+			rb.Write([]byte(v + "\n"))
+		}
+
+		entries := rb.Entries(0)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "3", string(entries[0]))
+		assert.Equal(t, "5", string(entries[2]))
+	})
+
+	t.Run("limit returns the newest entries", func(t *testing.T) {
+		rb := NewRingBuffer(5)
+		for _, v := range []string{"1", "2", "3"} {
+			rb.Write([]byte(v))
+		}
+
+		entries := rb.Entries(2)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "2", string(entries[0]))
+		assert.Equal(t, "3", string(entries[1]))
+	})
+
+	t.Run("works as a json sink", func(t *testing.T) {
+		rb := NewRingBuffer(10)
+		logger := New(JSONSink(rb))
+
+		logger.Warnj(JSON{"message": "disk almost full"})
+
+		entries := rb.Entries(0)
+		require.Len(t, entries, 1)
+		assert.Contains(t, string(entries[0]), `"msg":"disk almost full"`)
+	})
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("rotates when max size is exceeded", func(t *testing.T) {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "api.log")
+
+		rf, err := OpenRotatingFile(RotateConfig{Filename: name, MaxSize: 10})
+		require.NoError(t, err)
+		defer rf.Close()
+
+		tick := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		rf.now = func() time.Time { tick = tick.Add(time.Second); return tick }
+
+		rf.Write([]byte("12345678\n"))
+		rf.Write([]byte("abcdefgh\n"))
+
+		backups := rf.backups()
+		require.Len(t, backups, 1)
+
+		current, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "abcdefgh\n", string(current))
+	})
+
+	t.Run("prunes backups by count and age", func(t *testing.T) {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "api.log")
+
+		rf, err := OpenRotatingFile(RotateConfig{Filename: name, MaxSize: 1, MaxBackups: 2, MaxAge: time.Hour})
+		require.NoError(t, err)
+		defer rf.Close()
+
+		tick := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		rf.now = func() time.Time { return tick }
+
+		for range 4 {
+			tick = tick.Add(time.Second)
+			rf.Write([]byte("x"))
+		}
+		assert.Len(t, rf.backups(), 2)
+
+		tick = tick.Add(2 * time.Hour)
+		rf.Write([]byte("x"))
+		assert.Len(t, rf.backups(), 1)
+	})
+	t.Run("keeps every backup rotated within a millisecond", func(t *testing.T) {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "api.log")
+
+		rf, err := OpenRotatingFile(RotateConfig{Filename: name, MaxSize: 1})
+		require.NoError(t, err)
+		defer rf.Close()
+
+		tick := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		rf.now = func() time.Time { return tick }
+
+		for _, line := range []string{"a", "b", "c", "d"} {
+			rf.Write([]byte(line))
+		}
+
+		backups := rf.backups()
+		require.Len(t, backups, 3)
+		for i, want := range []string{"a", "b", "c"} {
+			content, err := os.ReadFile(backups[i])
+			require.NoError(t, err)
+			assert.Equal(t, want, string(content))
+		}
+	})
+
+	t.Run("prunes expired backups when opened", func(t *testing.T) {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "api.log")
+		expired := filepath.Join(dir, "api-"+time.Now().Add(-2*time.Hour).UTC().Format(backupTimeFormat)+".log")
+		require.NoError(t, os.WriteFile(expired, []byte("x"), 0o644))
+
+		rf, err := OpenRotatingFile(RotateConfig{Filename: name, MaxSize: 1 << 20, MaxAge: time.Hour})
+		require.NoError(t, err)
+		defer rf.Close()
+
+		assert.NoFileExists(t, expired)
+	})
+
+	t.Run("prunes expired backups of a quiet file", func(t *testing.T) {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "api.log")
+
+		rf, err := OpenRotatingFile(RotateConfig{Filename: name, MaxSize: 1 << 20, MaxAge: 50 * time.Millisecond})
+		require.NoError(t, err)
+		defer rf.Close()
+
+		expired := filepath.Join(dir, "api-"+time.Now().UTC().Format(backupTimeFormat)+"-1.log")
+		require.NoError(t, os.WriteFile(expired, []byte("x"), 0o644))
+		require.Len(t, rf.backups(), 1)
+
+		assert.Eventually(t, func() bool {
+			_, err := os.Stat(expired)
+			return os.IsNotExist(err)
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
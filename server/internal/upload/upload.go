@@ -0,0 +1,184 @@
+// Package upload reads multipart/form-data requests, checking every file
+// against the size and content type allowed for its form field. The type is
+// sniffed from the content, the client supplied Content-Type is ignored.
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// maxValueBytes limits each non-file form value.
+const maxValueBytes = 64 << 10
+
+// ErrNotMultipart is returned for requests that are not multipart/form-data.
+var ErrNotMultipart = errors.New("body must be multipart/form-data")
+
+// Rule describes the file accepted in one form field.
+type Rule struct {
+	Field    string
+	MaxSize  int64
+	Types    []string // MIME types, e.g. image/png
+	Required bool
+}
+
+type File struct {
+	Field    string
+	Filename string
+	// ContentType is the sniffed type, one of the rule's Types.
+	ContentType string
+	Data        []byte
+}
+
+type Form struct {
+	Values map[string]string
+	Files  map[string]*File
+}
+
+// FieldErrors holds a message per form field, in the shape handlers report
+// validation errors.
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for field, msg := range e {
+		parts = append(parts, field+": "+msg)
+	}
+	return "upload: " + strings.Join(parts, "; ")
+}
+
+// Parse reads the whole multipart body, which may not exceed maxBytes.
+// Files in fields without a rule are rejected. Problems with individual
+// files are returned together as FieldErrors; other errors describe a
+// malformed body.
+func Parse(w http.ResponseWriter, req *http.Request, maxBytes int64, rules ...Rule) (*Form, error) {
+	req.Body = http.MaxBytesReader(w, req.Body, maxBytes)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, ErrNotMultipart
+	}
+
+	byField := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		byField[rule.Field] = rule
+	}
+
+	form := &Form{Values: map[string]string{}, Files: map[string]*File{}}
+	fieldErrs := FieldErrors{}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, bodyError(err)
+		}
+
+		field := part.FormName()
+		if part.FileName() == "" {
+			value, err := readLimited(part, maxValueBytes)
+			if err != nil {
+				return nil, bodyError(err)
+			}
+			if value == nil {
+				fieldErrs[field] = fmt.Sprintf("must not be longer than %d bytes", maxValueBytes)
+				continue
+			}
+			form.Values[field] = string(value)
+			continue
+		}
+
+		rule, ok := byField[field]
+		switch {
+		case !ok:
+			fieldErrs[field] = "does not accept files"
+		case form.Files[field] != nil:
+			fieldErrs[field] = "must contain a single file"
+		}
+		if _, failed := fieldErrs[field]; failed {
+			// Drain the part so the rest of the body can still be read.
+			if _, err := io.Copy(io.Discard, part); err != nil {
+				return nil, bodyError(err)
+			}
+			continue
+		}
+
+		data, err := readLimited(part, rule.MaxSize)
+		if err != nil {
+			return nil, bodyError(err)
+		}
+		if data == nil {
+			fieldErrs[field] = fmt.Sprintf("must not be larger than %d bytes", rule.MaxSize)
+			continue
+		}
+
+		detected := mimetype.Detect(data)
+		if !isAny(detected, rule.Types) {
+			fieldErrs[field] = "must be one of " + strings.Join(rule.Types, ", ")
+			continue
+		}
+
+		form.Files[field] = &File{
+			Field:       field,
+			Filename:    part.FileName(),
+			ContentType: baseType(detected.String()),
+			Data:        data,
+		}
+	}
+
+	for _, rule := range rules {
+		if _, failed := fieldErrs[rule.Field]; rule.Required && !failed && form.Files[rule.Field] == nil {
+			fieldErrs[rule.Field] = "must be provided"
+		}
+	}
+	if len(fieldErrs) > 0 {
+		return nil, fieldErrs
+	}
+	return form, nil
+}
+
+// readLimited reads r, returning nil data when it holds more than limit
+// bytes. The rest of an oversized part is discarded.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > limit {
+		_, err := io.Copy(io.Discard, r)
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isAny reports whether detected is one of types, ignoring parameters like
+// the charset of text/plain.
+func isAny(detected *mimetype.MIME, types []string) bool {
+	for _, t := range types {
+		if detected.Is(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func baseType(mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	return base
+}
+
+// bodyError keeps the message of the JSON body limit for oversized bodies.
+func bodyError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+	}
+	return fmt.Errorf("body contains badly-formed multipart data: %w", err)
+}
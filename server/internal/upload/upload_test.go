@@ -0,0 +1,142 @@
+package upload
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type part struct {
+	field, filename string
+	data            []byte
+}
+
+func multipartRequest(t *testing.T, parts ...part) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var err error
+		if p.filename == "" {
+			err = mw.WriteField(p.field, string(p.data))
+		} else {
+			var w io.Writer
+			w, err = mw.CreateFormFile(p.field, p.filename)
+			if err == nil {
+				_, err = w.Write(p.data)
+			}
+		}
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))))
+	return buf.Bytes()
+}
+
+var imageRule = Rule{Field: "avatar", MaxSize: 1024, Types: []string{"image/png", "image/jpeg"}, Required: true}
+
+func TestParse(t *testing.T) {
+	t.Run("reads files and values", func(t *testing.T) {
+		req := multipartRequest(t,
+			part{field: "name", data: []byte("budi")},
+			part{field: "avatar", filename: "me.png", data: pngBytes(t)},
+		)
+
+		form, err := Parse(httptest.NewRecorder(), req, 1<<20, imageRule)
+
+		require.NoError(t, err)
+		assert.Equal(t, "budi", form.Values["name"])
+		require.Contains(t, form.Files, "avatar")
+		assert.Equal(t, "me.png", form.Files["avatar"].Filename)
+		assert.Equal(t, "image/png", form.Files["avatar"].ContentType)
+		assert.Equal(t, pngBytes(t), form.Files["avatar"].Data)
+	})
+
+	t.Run("sniffs the type instead of trusting the filename", func(t *testing.T) {
+		req := multipartRequest(t, part{field: "avatar", filename: "me.png", data: []byte("%PDF-1.4\n%fake")})
+
+		_, err := Parse(httptest.NewRecorder(), req, 1<<20, imageRule)
+
+		assert.Equal(t, FieldErrors{"avatar": "must be one of image/png, image/jpeg"}, err)
+	})
+
+	t.Run("accepts text with a charset", func(t *testing.T) {
+		rule := Rule{Field: "attachment", MaxSize: 1024, Types: []string{"text/plain"}}
+		req := multipartRequest(t, part{field: "attachment", filename: "log.txt", data: []byte("hello world")})
+
+		form, err := Parse(httptest.NewRecorder(), req, 1<<20, rule)
+
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain", form.Files["attachment"].ContentType)
+	})
+
+	t.Run("reports field errors together", func(t *testing.T) {
+		req := multipartRequest(t,
+			part{field: "avatar", filename: "big.png", data: append(pngBytes(t), make([]byte, 2048)...)},
+			part{field: "other", filename: "x.png", data: pngBytes(t)},
+		)
+
+		_, err := Parse(httptest.NewRecorder(), req, 1<<20, imageRule)
+
+		assert.Equal(t, FieldErrors{
+			"avatar": "must not be larger than 1024 bytes",
+			"other":  "does not accept files",
+		}, err)
+	})
+
+	t.Run("rejects a second file in the same field", func(t *testing.T) {
+		req := multipartRequest(t,
+			part{field: "avatar", filename: "a.png", data: pngBytes(t)},
+			part{field: "avatar", filename: "b.png", data: pngBytes(t)},
+		)
+
+		_, err := Parse(httptest.NewRecorder(), req, 1<<20, imageRule)
+
+		assert.Equal(t, FieldErrors{"avatar": "must contain a single file"}, err)
+	})
+
+	t.Run("requires required files", func(t *testing.T) {
+		req := multipartRequest(t, part{field: "name", data: []byte("budi")})
+
+		_, err := Parse(httptest.NewRecorder(), req, 1<<20, imageRule)
+
+		assert.Equal(t, FieldErrors{"avatar": "must be provided"}, err)
+	})
+
+	t.Run("limits the whole body", func(t *testing.T) {
+		req := multipartRequest(t, part{field: "name", data: []byte(strings.Repeat("a", 4096))})
+
+		_, err := Parse(httptest.NewRecorder(), req, 1024, imageRule)
+
+		require.Error(t, err)
+		assert.Equal(t, "body must not be larger than 1024 bytes", err.Error())
+	})
+
+	t.Run("rejects other content types", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		_, err := Parse(httptest.NewRecorder(), req, 1<<20, imageRule)
+
+		assert.ErrorIs(t, err, ErrNotMultipart)
+	})
+}
@@ -1,6 +1,22 @@
 package utility
 
-import "math"
+import (
+	"math"
+	"time"
+)
+
+// Jakarta is the Asia/Jakarta time zone the store's days start in. It
+// falls back to the fixed UTC+7 offset Jakarta has kept since 1964 when
+// the system has no time zone database.
+var Jakarta = loadLocation("Asia/Jakarta", 7*60*60)
+
+func loadLocation(name string, offset int) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone(name, offset)
+	}
+	return loc
+}
 
 type mapFunc[E any] func(E) E
 
@@ -23,6 +39,50 @@ func DerefOrDefault[T any](ptr *T, defaultVal T) T {
 	return *ptr
 }
 
-func Round2(v float64) float64 {
-	return math.Round(v*100) / 100
+// ToMinor converts an amount to integer minor units with the given number
+// of decimals (2 for cents), rounding half away from zero. Convert once at
+// the boundary and keep money math on the integers, where 0.1 + 0.2 is
+// exact.
+func ToMinor(v float64, decimals int) int64 {
+	return int64(math.Round(v * math.Pow10(decimals)))
+}
+
+// DivRound returns a / b rounded half away from zero. It panics when b is
+// zero.
+func DivRound(a, b int64) int64 {
+	q, r := a/b, a%b
+	if r == 0 {
+		return q
+	}
+	// |2r| >= |b| means the remainder is at least half of b.
+	if 2*abs(r) >= abs(b) {
+		if (a < 0) != (b < 0) {
+			return q - 1
+		}
+		return q + 1
+	}
+	return q
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// MaskName hides the middle of a name shown publicly, keeping up to three
+// leading and two trailing characters: "Roblox_Fan99" becomes "Rob***99".
+// Three asterisks replace any number of characters, so the length of the
+// name is not revealed either.
+func MaskName(name string) string {
+	runes := []rune(name)
+	n := len(runes)
+	if n < 2 {
+		return "***"
+	}
+
+	head := min(3, max(1, n/3))
+	tail := min(2, n/4)
+	return string(runes[:head]) + "***" + string(runes[n-tail:])
 }
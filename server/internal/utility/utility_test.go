@@ -193,58 +193,73 @@ func TestDerefOrDefault(t *testing.T) {
 	}
 }
 
-func TestRound2(t *testing.T) {
+func TestToMinor(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    float64
-		expected float64
+		decimals int
+		expected int64
 	}{
-		{
-			name:     "round up",
-			input:    3.456,
-			expected: 3.46,
-		},
-		{
-			name:     "round down",
-			input:    3.454,
-			expected: 3.45,
-		},
-		{
-			name:     "already rounded",
-			input:    3.45,
-			expected: 3.45,
-		},
-		{
-			name:     "zero",
-			input:    0.0,
-			expected: 0.0,
-		},
-		{
-			name:     "negative round up",
-			input:    -3.456,
-			expected: -3.46,
-		},
-		{
-			name:     "negative round down",
-			input:    -3.454,
-			expected: -3.45,
-		},
-		{
-			name:     "very small number",
-			input:    0.00001,
-			expected: 0.00,
-		},
-		{
-			name:     "round 0.005",
-			input:    0.005,
-			expected: 0.01,
-		},
+		{name: "round up", input: 3.456, decimals: 2, expected: 346},
+		{name: "round down", input: 3.454, decimals: 2, expected: 345},
+		{name: "already rounded", input: 3.45, decimals: 2, expected: 345},
+		{name: "zero", input: 0, decimals: 2, expected: 0},
+		{name: "negative round up", input: -3.456, decimals: 2, expected: -346},
+		{name: "negative round down", input: -3.454, decimals: 2, expected: -345},
+		{name: "very small number", input: 0.00001, decimals: 2, expected: 0},
+		{name: "round 0.005", input: 0.005, decimals: 2, expected: 1},
+		{name: "sum of float cents", input: 0.1 + 0.2, decimals: 2, expected: 30},
+		{name: "no decimals", input: 144261.4, decimals: 0, expected: 144261},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Round2(tt.input)
-			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expected, ToMinor(tt.input, tt.decimals))
+		})
+	}
+}
+
+func TestDivRound(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     int64
+		expected int64
+	}{
+		{name: "exact", a: 10, b: 5, expected: 2},
+		{name: "round down", a: 14, b: 10, expected: 1},
+		{name: "half rounds up", a: 15, b: 10, expected: 2},
+		{name: "odd divisor", a: 5, b: 3, expected: 2},
+		{name: "negative half rounds away from zero", a: -15, b: 10, expected: -2},
+		{name: "negative round down", a: -14, b: 10, expected: -1},
+		{name: "negative divisor", a: 15, b: -10, expected: -2},
+		{name: "both negative", a: -15, b: -10, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DivRound(tt.a, tt.b))
+		})
+	}
+}
+
+func TestMaskName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "Roblox_Fan99", expected: "Rob***99"},
+		{input: "Budi12345", expected: "Bud***45"},
+		{input: "mayo_gg", expected: "ma***g"},
+		{input: "abcd", expected: "a***d"},
+		{input: "ab", expected: "a***"},
+		{input: "a", expected: "***"},
+		{input: "", expected: "***"},
+		{input: "Déjàvu01", expected: "Dé***01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MaskName(tt.input))
 		})
 	}
 }
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Publishes the changed table on the table_changes channel so every API
+-- replica can evict its cached query results.
+CREATE OR REPLACE FUNCTION notify_table_change() RETURNS TRIGGER AS $$
+BEGIN
+  PERFORM pg_notify('table_changes', TG_TABLE_NAME);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_notify_change
+  AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON users
+  FOR EACH STATEMENT EXECUTE FUNCTION notify_table_change();
+
+CREATE TRIGGER testimonies_notify_change
+  AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON testimonies
+  FOR EACH STATEMENT EXECUTE FUNCTION notify_table_change();
+
+CREATE TRIGGER faqs_notify_change
+  AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON faqs
+  FOR EACH STATEMENT EXECUTE FUNCTION notify_table_change();
+
+CREATE TRIGGER faq_answers_notify_change
+  AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON faq_answers
+  FOR EACH STATEMENT EXECUTE FUNCTION notify_table_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS faq_answers_notify_change ON faq_answers;
+DROP TRIGGER IF EXISTS faqs_notify_change ON faqs;
+DROP TRIGGER IF EXISTS testimonies_notify_change ON testimonies;
+DROP TRIGGER IF EXISTS users_notify_change ON users;
+DROP FUNCTION IF EXISTS notify_table_change();
+-- +goose StatementEnd
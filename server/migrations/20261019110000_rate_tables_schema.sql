@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A rate table prices Robux in rupiah from effective_from until the next
+-- table takes effect. Amounts are in sen, 100 to the rupiah.
+CREATE TABLE rate_tables (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  effective_from TIMESTAMP WITH TIME ZONE NOT NULL UNIQUE,
+  rounding_step BIGINT NOT NULL CHECK (rounding_step > 0),
+  rounding_mode TEXT NOT NULL CHECK (rounding_mode IN ('nearest', 'up', 'down')),
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Every Robux of an order of at least min_robux costs idr_per_robux.
+CREATE TABLE rate_tiers (
+  rate_table_id UUID NOT NULL REFERENCES rate_tables(id) ON DELETE CASCADE,
+  min_robux INTEGER NOT NULL CHECK (min_robux > 0),
+  idr_per_robux BIGINT NOT NULL CHECK (idr_per_robux > 0),
+
+  PRIMARY KEY (rate_table_id, min_robux)
+);
+
+INSERT INTO rate_tables (id, effective_from, rounding_step, rounding_mode) VALUES
+('7d3f5a10-1c2b-4e8f-9a6d-5b4c3a2e1f01', '2026-01-01 00:00:00+07', 100, 'nearest');
+
+INSERT INTO rate_tiers (rate_table_id, min_robux, idr_per_robux) VALUES
+('7d3f5a10-1c2b-4e8f-9a6d-5b4c3a2e1f01', 1, 14000),
+('7d3f5a10-1c2b-4e8f-9a6d-5b4c3a2e1f01', 1000, 13700),
+('7d3f5a10-1c2b-4e8f-9a6d-5b4c3a2e1f01', 10000, 13500);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_tiers;
+DROP TABLE IF EXISTS rate_tables;
+-- +goose StatementEnd
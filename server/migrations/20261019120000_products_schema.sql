@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE products (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  name TEXT NOT NULL,
+  category TEXT NOT NULL CHECK (category IN ('robux', 'gamepass')),
+  -- delivered amount of a robux package, item price of a gamepass
+  robux INTEGER NOT NULL CHECK (robux > 0),
+  icon_url TEXT NOT NULL DEFAULT '',
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  -- relative chance of being drawn as Product of the Day, 0 for never
+  featured_weight INTEGER NOT NULL DEFAULT 1 CHECK (featured_weight >= 0),
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Products editors fixed as Product of the Day, dates in Asia/Jakarta.
+CREATE TABLE featured_calendar (
+  date DATE NOT NULL,
+  position INTEGER NOT NULL CHECK (position > 0),
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (date, position)
+);
+
+-- The Product of the Day set served on each date, written once by the
+-- scheduler of whichever replica gets there first.
+CREATE TABLE featured_picks (
+  date DATE NOT NULL,
+  position INTEGER NOT NULL CHECK (position > 0),
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  source TEXT NOT NULL CHECK (source IN ('calendar', 'random')),
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (date, position)
+);
+
+CREATE INDEX featured_picks_product_id_idx ON featured_picks (product_id, date);
+
+INSERT INTO products (id, name, category, robux, icon_url, featured_weight) VALUES
+('990e8400-e29b-41d4-a716-446655440001', '80 Robux', 'robux', 80, '/products/mayo-with-glass.png', 1),
+('990e8400-e29b-41d4-a716-446655440002', '400 Robux', 'robux', 400, '/products/mayo-with-glass.png', 2),
+('990e8400-e29b-41d4-a716-446655440003', '1053 Robux', 'robux', 1053, '/products/mayo-with-glass.png', 3),
+('990e8400-e29b-41d4-a716-446655440004', '2200 Robux', 'robux', 2200, '/products/mayo-with-glass.png', 2),
+('990e8400-e29b-41d4-a716-446655440005', '4500 Robux', 'robux', 4500, '/products/mayo-with-glass.png', 1),
+('990e8400-e29b-41d4-a716-446655440006', 'Search & Rescue', 'gamepass', 850, '/products/sky-people.png', 3),
+('990e8400-e29b-41d4-a716-446655440007', 'VIP Server Pass', 'gamepass', 499, '/products/sky-people.png', 2),
+('990e8400-e29b-41d4-a716-446655440008', 'Double Coins', 'gamepass', 199, '/products/sky-people.png', 2),
+('990e8400-e29b-41d4-a716-446655440009', 'Speed Coil', 'gamepass', 150, '/products/sky-people.png', 1),
+('990e8400-e29b-41d4-a716-446655440010', 'Pet Slot Expansion', 'gamepass', 350, '/products/sky-people.png', 1),
+('990e8400-e29b-41d4-a716-446655440011', 'Radio Pass', 'gamepass', 100, '/products/sky-people.png', 1),
+('990e8400-e29b-41d4-a716-446655440012', 'Legendary Crate', 'gamepass', 1200, '/products/sky-people.png', 2);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS featured_picks;
+DROP TABLE IF EXISTS featured_calendar;
+DROP TABLE IF EXISTS products;
+-- +goose StatementEnd
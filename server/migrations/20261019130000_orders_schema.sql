@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE orders (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  product_id UUID NOT NULL REFERENCES products(id),
+  user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  -- the Roblox account the Robux are delivered to
+  roblox_username TEXT NOT NULL,
+  quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+  -- Robux delivered for the whole order
+  robux INTEGER NOT NULL CHECK (robux > 0),
+  -- in sen, 100 to the rupiah
+  total_idr BIGINT NOT NULL CHECK (total_idr >= 0),
+  status TEXT NOT NULL DEFAULT 'pending_payment'
+    CHECK (status IN ('pending_payment', 'paid', 'delivering', 'delivered', 'failed', 'refunded')),
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  delivered_at TIMESTAMP WITH TIME ZONE,
+
+  CHECK ((status = 'delivered') = (delivered_at IS NOT NULL))
+);
+
+CREATE INDEX orders_delivered_at_idx ON orders (delivered_at) WHERE status = 'delivered';
+
+-- Units of each product delivered per day in Asia/Jakarta. Rolled up
+-- incrementally from orders, up to the watermark in rollup_watermarks.
+CREATE TABLE product_sales_daily (
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  date DATE NOT NULL,
+  sold INTEGER NOT NULL CHECK (sold >= 0),
+
+  PRIMARY KEY (product_id, date)
+);
+
+CREATE INDEX product_sales_daily_date_idx ON product_sales_daily (date);
+
+-- How far each rollup has read its source table.
+CREATE TABLE rollup_watermarks (
+  name TEXT PRIMARY KEY,
+  through TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO rollup_watermarks (name, through) VALUES ('product_sales', '-infinity');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rollup_watermarks;
+DROP TABLE IF EXISTS product_sales_daily;
+DROP TABLE IF EXISTS orders;
+-- +goose StatementEnd
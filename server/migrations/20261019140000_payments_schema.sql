@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The charge created at the payment provider for an order.
+CREATE TABLE payments (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  order_id UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  -- the provider's ID of the charge
+  charge_id TEXT NOT NULL,
+  method TEXT NOT NULL,
+  -- in sen, 100 to the rupiah
+  amount_idr BIGINT NOT NULL CHECK (amount_idr >= 0),
+  status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'paid', 'expired', 'failed', 'refunded')),
+  -- QR string, virtual account or checkout URL shown to the customer
+  instructions JSONB NOT NULL DEFAULT '{}',
+
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  paid_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  UNIQUE (provider, charge_id)
+);
+
+CREATE INDEX payments_pending_idx ON payments (created_at) WHERE status = 'pending';
+
+-- Webhook events already processed. Providers retry deliveries, so an event
+-- is applied only the first time its ID is seen.
+CREATE TABLE payment_events (
+  provider TEXT NOT NULL,
+  event_id TEXT NOT NULL,
+  charge_id TEXT NOT NULL,
+  status TEXT NOT NULL,
+
+  occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (provider, event_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_events;
+DROP TABLE IF EXISTS payments;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders
+  -- delivery attempts made since the order was paid or last retried by hand
+  ADD COLUMN delivery_attempts INTEGER NOT NULL DEFAULT 0 CHECK (delivery_attempts >= 0),
+  -- when a paid order is next tried, NULL for at once
+  ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN last_error TEXT,
+  -- the delivery at Roblox, e.g. the gamepass purchase
+  ADD COLUMN delivery_reference TEXT;
+
+CREATE INDEX orders_due_idx ON orders (next_attempt_at) WHERE status = 'paid';
+CREATE INDEX orders_status_idx ON orders (status, updated_at);
+
+-- Every change of status of an order. from_status is NULL for the
+-- creation of the order.
+CREATE TABLE order_transitions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  from_status TEXT,
+  to_status TEXT NOT NULL,
+  actor TEXT NOT NULL CHECK (actor IN ('customer', 'payment', 'system', 'admin')),
+  reason TEXT,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX order_transitions_order_idx ON order_transitions (order_id, created_at);
+
+INSERT INTO order_transitions (order_id, to_status, actor, created_at)
+SELECT id, 'pending_payment', 'customer', created_at FROM orders;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_transitions;
+DROP INDEX IF EXISTS orders_status_idx;
+DROP INDEX IF EXISTS orders_due_idx;
+ALTER TABLE orders
+  DROP COLUMN delivery_reference,
+  DROP COLUMN last_error,
+  DROP COLUMN next_attempt_at,
+  DROP COLUMN delivery_attempts;
+-- +goose StatementEnd
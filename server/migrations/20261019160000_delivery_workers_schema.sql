@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The Roblox accounts Robux are delivered from. Roblox throttles each
+-- account, so deliveries from one run at most max_concurrency at a time.
+CREATE TABLE supplier_accounts (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  name TEXT NOT NULL,
+  max_concurrency INTEGER NOT NULL DEFAULT 1 CHECK (max_concurrency BETWEEN 1 AND 50),
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO supplier_accounts (name) VALUES ('Main');
+
+-- the account of the latest delivery attempt
+ALTER TABLE orders ADD COLUMN supplier_account_id UUID REFERENCES supplier_accounts(id) ON DELETE SET NULL;
+
+-- Orders whose delivery attempts ran out. A dead letter is resolved when
+-- its order leaves failed, e.g. an admin retries or refunds it.
+CREATE TABLE delivery_dead_letters (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  supplier_account_id UUID REFERENCES supplier_accounts(id) ON DELETE SET NULL,
+  attempts INTEGER NOT NULL,
+  error TEXT NOT NULL,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX delivery_dead_letters_open_idx ON delivery_dead_letters (created_at) WHERE resolved_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS delivery_dead_letters;
+ALTER TABLE orders DROP COLUMN supplier_account_id;
+DROP TABLE IF EXISTS supplier_accounts;
+-- +goose StatementEnd
@@ -0,0 +1,105 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Double-entry ledger of the Robux of every supplier account. Each row
+-- moves amount from its credit bucket to its debit bucket, so the buckets
+-- of an account always sum to zero:
+--
+--   supplier    Robux bought from Roblox, negative
+--   available   on hand and free to sell
+--   reserved    on hand and held for an order
+--   delivered   sent to buyers
+--   adjustment  corrections from reconciling with the Roblox balance
+CREATE TABLE robux_ledger (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  supplier_account_id UUID NOT NULL REFERENCES supplier_accounts(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK (kind IN ('purchase', 'reservation', 'release', 'consumption', 'adjustment')),
+  debit TEXT NOT NULL CHECK (debit IN ('supplier', 'available', 'reserved', 'delivered', 'adjustment')),
+  credit TEXT NOT NULL CHECK (credit IN ('supplier', 'available', 'reserved', 'delivered', 'adjustment')),
+  amount INTEGER NOT NULL CHECK (amount > 0),
+  order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+  note TEXT,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CHECK (debit <> credit)
+);
+
+CREATE INDEX robux_ledger_account_idx ON robux_ledger (supplier_account_id, created_at);
+
+-- The Robux held for an order from checkout until it is delivered, or
+-- released when it fails or is not paid by expires_at. Orders placed
+-- before stock was tracked have none.
+CREATE TABLE stock_reservations (
+  order_id UUID PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+
+  supplier_account_id UUID NOT NULL REFERENCES supplier_accounts(id) ON DELETE CASCADE,
+  robux INTEGER NOT NULL CHECK (robux > 0),
+  status TEXT NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'released', 'consumed')),
+  -- NULL once the order is paid
+  expires_at TIMESTAMP WITH TIME ZONE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX stock_reservations_expiry_idx ON stock_reservations (expires_at) WHERE status = 'held';
+
+-- The buckets of every supplier account summed over its ledger, one row
+-- per account kept up to date with each entry. Checkouts lock the row of
+-- one account instead of summing the ledger. available may go negative
+-- when a paid order is reserved again or an adjustment is posted.
+CREATE TABLE robux_balances (
+  supplier_account_id UUID PRIMARY KEY REFERENCES supplier_accounts(id) ON DELETE CASCADE,
+
+  supplier INTEGER NOT NULL DEFAULT 0,
+  available INTEGER NOT NULL DEFAULT 0,
+  reserved INTEGER NOT NULL DEFAULT 0,
+  delivered INTEGER NOT NULL DEFAULT 0,
+  adjustment INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO robux_balances (supplier_account_id)
+SELECT id FROM supplier_accounts;
+
+CREATE OR REPLACE FUNCTION robux_balances_open() RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO robux_balances (supplier_account_id) VALUES (NEW.id);
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER supplier_accounts_open_balance
+  AFTER INSERT ON supplier_accounts
+  FOR EACH ROW EXECUTE FUNCTION robux_balances_open();
+
+-- Moves the amount of a ledger entry from its credit bucket to its debit
+-- bucket.
+CREATE OR REPLACE FUNCTION robux_balances_post() RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE robux_balances SET
+    supplier = supplier + CASE 'supplier' WHEN NEW.debit THEN NEW.amount WHEN NEW.credit THEN -NEW.amount ELSE 0 END,
+    available = available + CASE 'available' WHEN NEW.debit THEN NEW.amount WHEN NEW.credit THEN -NEW.amount ELSE 0 END,
+    reserved = reserved + CASE 'reserved' WHEN NEW.debit THEN NEW.amount WHEN NEW.credit THEN -NEW.amount ELSE 0 END,
+    delivered = delivered + CASE 'delivered' WHEN NEW.debit THEN NEW.amount WHEN NEW.credit THEN -NEW.amount ELSE 0 END,
+    adjustment = adjustment + CASE 'adjustment' WHEN NEW.debit THEN NEW.amount WHEN NEW.credit THEN -NEW.amount ELSE 0 END
+  WHERE supplier_account_id = NEW.supplier_account_id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER robux_ledger_post
+  AFTER INSERT ON robux_ledger
+  FOR EACH ROW EXECUTE FUNCTION robux_balances_post();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS robux_ledger_post ON robux_ledger;
+DROP TRIGGER IF EXISTS supplier_accounts_open_balance ON supplier_accounts;
+DROP FUNCTION IF EXISTS robux_balances_post();
+DROP FUNCTION IF EXISTS robux_balances_open();
+DROP TABLE IF EXISTS robux_balances;
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS robux_ledger;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Promo codes. A voucher takes percent_off percent, up to
+-- max_discount_idr, or amount_off_idr off the subtotal of an order.
+CREATE TABLE vouchers (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  -- upper-case, matched case-insensitively
+  code TEXT NOT NULL UNIQUE CHECK (code = upper(code)),
+  description TEXT NOT NULL DEFAULT '',
+  percent_off INTEGER CHECK (percent_off BETWEEN 1 AND 100),
+  -- in sen, 100 to the rupiah
+  amount_off_idr BIGINT CHECK (amount_off_idr > 0),
+  max_discount_idr BIGINT CHECK (max_discount_idr > 0),
+  min_spend_idr BIGINT NOT NULL DEFAULT 0 CHECK (min_spend_idr >= 0),
+  -- only products of the category when set
+  category TEXT CHECK (category IN ('robux', 'gamepass')),
+  -- orders using the voucher, in total and per Roblox username; failed
+  -- and refunded orders do not count
+  usage_limit INTEGER CHECK (usage_limit > 0),
+  per_user_limit INTEGER CHECK (per_user_limit > 0),
+  starts_at TIMESTAMP WITH TIME ZONE,
+  ends_at TIMESTAMP WITH TIME ZONE,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CHECK ((percent_off IS NULL) <> (amount_off_idr IS NULL)),
+  CHECK (max_discount_idr IS NULL OR percent_off IS NOT NULL),
+  CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+);
+
+-- Restricts a voucher to the products listed, when it has any.
+CREATE TABLE voucher_products (
+  voucher_id UUID NOT NULL REFERENCES vouchers(id) ON DELETE CASCADE,
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+
+  PRIMARY KEY (voucher_id, product_id)
+);
+
+-- Existing orders had no discount, so their subtotal is their total.
+ALTER TABLE orders
+  ADD COLUMN subtotal_idr BIGINT CHECK (subtotal_idr >= 0),
+  ADD COLUMN discount_idr BIGINT NOT NULL DEFAULT 0 CHECK (discount_idr >= 0),
+  ADD COLUMN voucher_id UUID REFERENCES vouchers(id);
+
+UPDATE orders SET subtotal_idr = total_idr;
+
+ALTER TABLE orders
+  ALTER COLUMN subtotal_idr SET NOT NULL,
+  ADD CHECK (total_idr = subtotal_idr - discount_idr);
+
+CREATE INDEX orders_voucher_idx ON orders (voucher_id, lower(roblox_username)) WHERE voucher_id IS NOT NULL;
+
+INSERT INTO vouchers (code, description, percent_off, max_discount_idr, min_spend_idr, per_user_limit)
+VALUES ('MAYO10', '10% off, up to Rp20.000', 10, 2000000, 5000000, 1);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+  DROP COLUMN IF EXISTS voucher_id,
+  DROP COLUMN IF EXISTS discount_idr,
+  DROP COLUMN IF EXISTS subtotal_idr;
+DROP TABLE IF EXISTS voucher_products;
+DROP TABLE IF EXISTS vouchers;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Time-boxed campaigns selling products below their rate table price. A
+-- flash sale is live from starts_at until ends_at while it is active.
+CREATE TABLE flash_sales (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+  name TEXT NOT NULL,
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+  CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX flash_sales_window_idx ON flash_sales (ends_at, starts_at) WHERE active;
+
+-- The products of a flash sale. Checkout adds the units of an order to
+-- sold, only while sold stays within quantity. Failed and refunded orders
+-- give their units back.
+CREATE TABLE flash_sale_items (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  flash_sale_id UUID NOT NULL REFERENCES flash_sales(id) ON DELETE CASCADE,
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+
+  -- per unit, in sen, 100 to the rupiah
+  price_idr BIGINT NOT NULL CHECK (price_idr > 0),
+  quantity INTEGER NOT NULL CHECK (quantity > 0),
+  -- may exceed quantity when an admin pays a failed order again
+  sold INTEGER NOT NULL DEFAULT 0 CHECK (sold >= 0),
+
+  UNIQUE (flash_sale_id, product_id)
+);
+
+ALTER TABLE orders
+  ADD COLUMN flash_sale_item_id UUID REFERENCES flash_sale_items(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN IF EXISTS flash_sale_item_id;
+DROP TABLE IF EXISTS flash_sale_items;
+DROP TABLE IF EXISTS flash_sales;
+-- +goose StatementEnd
@@ -0,0 +1,217 @@
+// Package client is a Go client for the Mayobox API.
+//
+//	c, err := client.New("http://localhost:4000")
+//	if err != nil {
+//		return err
+//	}
+//	for t, err := range c.Testimonies(ctx, 50) {
+//		...
+//	}
+//
+// Responses are decoded from the {"data": ..., "metadata": ...} envelope.
+// Non-2xx responses are returned as *Error with a stable error code. Requests rejected with 429 or
+// 503 are retried with backoff, honouring Retry-After.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultUserAgent = "mayobox-go-client"
+	mimeProblemJSON  = "application/problem+json"
+)
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	adminToken string
+	userAgent  string
+	language   string
+	retry      RetryPolicy
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client. The default has a 10
+// second timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithAdminToken sets the bearer token sent to the /v1/admin endpoints.
+func WithAdminToken(token string) Option {
+	return func(c *Client) { c.adminToken = token }
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithLanguage sets Accept-Language, e.g. "id", for the title and message
+// of returned errors.
+func WithLanguage(lang string) Option {
+	return func(c *Client) { c.language = lang }
+}
+
+// WithRetry replaces DefaultRetryPolicy. Use RetryPolicy{} to disable
+// retries.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// New returns a client for the API at baseURL, e.g. "http://localhost:4000".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: base url %q must be http or https", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		userAgent:  defaultUserAgent,
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// envelope is the success body of every endpoint.
+type envelope[T any] struct {
+	Data     T         `json:"data"`
+	Metadata *Metadata `json:"metadata"`
+}
+
+// request describes one API call.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	admin  bool
+	// raw is sent as is with contentType instead of body as JSON.
+	raw         []byte
+	contentType string
+}
+
+// do sends the request, retrying when allowed, and decodes the JSON
+// response into out unless out is nil. An io.Writer out receives the raw
+// body instead.
+func (c *Client) do(ctx context.Context, r request, out any) error {
+	payload := r.raw
+	if r.body != nil {
+		var err error
+		payload, err = json.Marshal(r.body)
+		if err != nil {
+			return fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+
+	// One key per call, reused by every retry, so a retried mutation is
+	// applied at most once.
+	idempotencyKey := ""
+	if r.method != http.MethodGet && r.method != http.MethodHead {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	for attempt := 0; ; attempt++ {
+		res, err := c.send(ctx, r, payload, idempotencyKey)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode >= 200 && res.StatusCode < 300 {
+			defer res.Body.Close()
+			if out == nil {
+				return nil
+			}
+			if w, ok := out.(io.Writer); ok {
+				if _, err := io.Copy(w, res.Body); err != nil {
+					return fmt.Errorf("client: read %s %s response: %w", r.method, r.path, err)
+				}
+				return nil
+			}
+			if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+				return fmt.Errorf("client: decode %s %s response: %w", r.method, r.path, err)
+			}
+			return nil
+		}
+
+		apiErr := decodeError(res)
+		res.Body.Close()
+
+		wait, ok := c.retry.next(attempt, r.method, apiErr)
+		if !ok {
+			return apiErr
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(apiErr, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, r request, payload []byte, idempotencyKey string) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += r.path
+	u.RawQuery = r.query.Encode()
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("client: build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json, "+mimeProblemJSON)
+	req.Header.Set("User-Agent", c.userAgent)
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	if payload != nil {
+		contentType := r.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	if r.admin && c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("client: %s %s: %w", r.method, r.path, err)
+	}
+	return res, nil
+}
+
+func newIdempotencyKey() string {
+	return rand.Text()
+}
@@ -0,0 +1,425 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	opts = append([]Option{WithRetry(RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})}, opts...)
+	c, err := New(srv.URL, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew(t *testing.T) {
+	t.Run("rejects non http urls", func(t *testing.T) {
+		_, err := New("ftp://example.com")
+		assert.Error(t, err)
+	})
+
+	t.Run("keeps base path", func(t *testing.T) {
+		var path string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			fmt.Fprint(w, `{"data":[],"metadata":null}`)
+		}))
+		defer srv.Close()
+
+		c, err := New(srv.URL + "/api/")
+		require.NoError(t, err)
+		_, err = c.ListFAQs(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "/api/v1/faqs", path)
+	})
+}
+
+func TestListTestimonies(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/testimonies", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		assert.Equal(t, "5", r.URL.Query().Get("page_size"))
+		fmt.Fprint(w, `{
+			"data": [{"id": "t-1", "userId": "u-1", "testimoni": "Fast", "user": {"id": "u-1", "username": "RobloxMaster99"}}],
+			"metadata": {"current_page": 2, "page_size": 5, "first_page": 1, "last_page": 2, "total_records": 6}
+		}`)
+	})
+
+	page, err := c.ListTestimonies(context.Background(), ListParams{Page: 2, PageSize: 5})
+
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, "RobloxMaster99", page.Data[0].User.Username)
+	assert.Equal(t, Metadata{CurrentPage: 2, PageSize: 5, FirstPage: 1, LastPage: 2, TotalRecords: 6}, page.Metadata)
+}
+
+func TestTestimoniesIterator(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"data":[{"id":"t-1"},{"id":"t-2"}],"metadata":{"current_page":1,"page_size":2,"first_page":1,"last_page":2,"total_records":3}}`)
+		case "2":
+			fmt.Fprint(w, `{"data":[{"id":"t-3"}],"metadata":{"current_page":2,"page_size":2,"first_page":1,"last_page":2,"total_records":3}}`)
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("page"))
+		}
+	})
+
+	t.Run("walks every page", func(t *testing.T) {
+		requests.Store(0)
+
+		var ids []string
+		for item, err := range c.Testimonies(context.Background(), 2) {
+			require.NoError(t, err)
+			ids = append(ids, item.ID)
+		}
+
+		assert.Equal(t, []string{"t-1", "t-2", "t-3"}, ids)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("stops when the caller breaks", func(t *testing.T) {
+		requests.Store(0)
+
+		for range c.Testimonies(context.Background(), 2) {
+			break
+		}
+
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("stops on an empty first page", func(t *testing.T) {
+		empty := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data":null,"metadata":{}}`)
+		})
+
+		count := 0
+		for _, err := range empty.Testimonies(context.Background(), 0) {
+			require.NoError(t, err)
+			count++
+		}
+		assert.Zero(t, count)
+	})
+
+	t.Run("yields the error and stops", func(t *testing.T) {
+		failing := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":{"code":"Internal Server Error","message":"the server encountered a problem"}}`)
+		})
+
+		var errs []error
+		for _, err := range failing.Testimonies(context.Background(), 0) {
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		assert.True(t, IsStatus(errs[0], http.StatusInternalServerError))
+	})
+}
+
+func TestErrors(t *testing.T) {
+	t.Run("decodes validation errors", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"error":{"code":"Unprocessable Entity","message":"unable to proccess request because some malformed input","details":{"pagination.page":"page must be 1 or greater"}}}`)
+		})
+
+		_, err := c.ListTestimonies(context.Background(), ListParams{Page: 1})
+
+		require.Error(t, err)
+		assert.True(t, IsValidation(err))
+
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "Unprocessable Entity", apiErr.Code)
+		assert.Equal(t, map[string]string{"pagination.page": "page must be 1 or greater"}, apiErr.Details)
+	})
+
+	t.Run("decodes problem details", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Contains(t, r.Header.Get("Accept"), "application/problem+json")
+			assert.Equal(t, "id", r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"type":"urn:mayobox:error:log_buffer_disabled","title":"Buffer log dinonaktifkan","status":404,"detail":"buffer log di memori dinonaktifkan","code":"log_buffer_disabled"}`)
+		}, WithLanguage("id"))
+
+		_, err := c.RecentLogs(context.Background(), 0)
+
+		assert.True(t, IsNotFound(err))
+		assert.True(t, IsCode(err, "log_buffer_disabled"))
+
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "Buffer log dinonaktifkan", apiErr.Title)
+		assert.Equal(t, "buffer log di memori dinonaktifkan", apiErr.Message)
+	})
+
+	t.Run("ignores non map details", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":"Not Found","message":"the requested resource could not be found","details":"nope"}}`)
+		})
+
+		_, err := c.ListFAQs(context.Background())
+
+		assert.True(t, IsNotFound(err))
+	})
+
+	t.Run("keeps non json bodies", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		})
+
+		_, err := c.ListFAQs(context.Background())
+
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+		assert.Equal(t, "Bad Gateway", apiErr.Code)
+		assert.Contains(t, apiErr.Message, "bad gateway")
+	})
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("retries 429 and 503 until success", func(t *testing.T) {
+		var attempts atomic.Int32
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch attempts.Add(1) {
+			case 1:
+				w.WriteHeader(http.StatusTooManyRequests)
+			case 2:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			default:
+				fmt.Fprint(w, `{"data":[],"metadata":null}`)
+			}
+		})
+
+		_, err := c.ListFAQs(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		var attempts atomic.Int32
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"code":"Too Many Requests","message":"rate limit exceeded"}}`)
+		})
+
+		_, err := c.ListFAQs(context.Background())
+
+		assert.True(t, IsRateLimited(err))
+		assert.Equal(t, int32(4), attempts.Load())
+	})
+
+	t.Run("retries idempotent requests in progress", func(t *testing.T) {
+		var attempts atomic.Int32
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) == 1 {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"type":"urn:mayobox:error:idempotency_request_in_progress","title":"Request in progress","status":409,"detail":"still running","code":"idempotency_request_in_progress"}`)
+				return
+			}
+			fmt.Fprint(w, `{"data":{"level":"warn"}}`)
+		})
+
+		_, err := c.SetLogLevel(context.Background(), "warn")
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		var attempts atomic.Int32
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		_, err := c.ListFAQs(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("replays the request body with the same idempotency key", func(t *testing.T) {
+		var bodies, keys []string
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			if len(bodies) == 1 {
+				writeUnavailable(w, "service_unavailable")
+				return
+			}
+			fmt.Fprint(w, `{"data":{"level":"warn"}}`)
+		})
+
+		level, err := c.SetLogLevel(context.Background(), "warn")
+
+		require.NoError(t, err)
+		assert.Equal(t, "warn", level)
+		assert.Equal(t, []string{`{"level":"warn"}`, `{"level":"warn"}`}, bodies)
+		require.Len(t, keys, 2)
+		assert.NotEmpty(t, keys[0])
+		assert.Equal(t, keys[0], keys[1])
+	})
+
+	t.Run("does not retry mutations the server may have processed", func(t *testing.T) {
+		for _, code := range []string{"payment_unavailable", ""} {
+			var attempts atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				if code == "" {
+					// e.g. from a proxy in front of the API
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				writeUnavailable(w, code)
+			})
+
+			_, err := c.CreateOrder(context.Background(), NewOrder{ProductID: "p-1", RobloxUsername: "builderman", PaymentMethod: "qris"})
+
+			assert.Error(t, err)
+			assert.Equal(t, int32(1), attempts.Load(), code)
+		}
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, WithRetry(RetryPolicy{MaxRetries: 1}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := c.ListFAQs(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, IsRateLimited(err))
+	})
+}
+
+func TestRetryPolicyNext(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	t.Run("honours retry after", func(t *testing.T) {
+		wait, ok := policy.next(0, http.MethodGet, &Error{StatusCode: http.StatusTooManyRequests, RetryAfter: 700 * time.Millisecond})
+
+		assert.True(t, ok)
+		assert.Equal(t, 700*time.Millisecond, wait)
+	})
+
+	t.Run("caps retry after at max backoff", func(t *testing.T) {
+		wait, _ := policy.next(0, http.MethodGet, &Error{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Hour})
+
+		assert.Equal(t, time.Second, wait)
+	})
+
+	t.Run("backs off exponentially with jitter", func(t *testing.T) {
+		wait, ok := policy.next(2, http.MethodGet, &Error{StatusCode: http.StatusServiceUnavailable})
+
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, wait, 200*time.Millisecond)
+		assert.LessOrEqual(t, wait, 400*time.Millisecond)
+	})
+
+	t.Run("retries 503 mutations only when unprocessed", func(t *testing.T) {
+		_, ok := policy.next(0, http.MethodPost, &Error{StatusCode: http.StatusServiceUnavailable, Code: "service_unavailable"})
+		assert.True(t, ok)
+
+		_, ok = policy.next(0, http.MethodPost, &Error{StatusCode: http.StatusServiceUnavailable, Code: "payment_unavailable"})
+		assert.False(t, ok)
+
+		_, ok = policy.next(0, http.MethodPost, &Error{StatusCode: http.StatusTooManyRequests})
+		assert.True(t, ok)
+	})
+
+	t.Run("stops after max retries", func(t *testing.T) {
+		_, ok := policy.next(3, http.MethodGet, &Error{StatusCode: http.StatusTooManyRequests})
+
+		assert.False(t, ok)
+	})
+}
+
+// writeUnavailable writes a 503 problem with code.
+func writeUnavailable(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprintf(w, `{"type":"urn:mayobox:error:%s","title":"Service unavailable","status":503,"detail":"try again later","code":%q}`, code, code)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 20, 4, 41, 27, 0, time.UTC)
+
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+	assert.Zero(t, parseRetryAfter("", now))
+}
+
+func TestAdminToken(t *testing.T) {
+	var auth []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"data":[{"level":"info","msg":"started"}]}`)
+	}, WithAdminToken("secret-admin-token"))
+
+	entries, err := c.RecentLogs(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	_, err = c.ListFAQs(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Bearer secret-admin-token", ""}, auth)
+}
+
+func TestUploadUserAvatar(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/admin/users/u-1/avatar", r.URL.Path)
+
+		file, header, err := r.FormFile("avatar")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, "me.png", header.Filename)
+		assert.Equal(t, "png bytes", string(data))
+
+		if attempts.Add(1) == 1 {
+			writeUnavailable(w, "service_unavailable")
+			return
+		}
+		fmt.Fprint(w, `{"data":{"url":"http://media/a.png","width":4,"height":2,"thumbnails":[{"size":64,"url":"http://media/a_64.png"}]}}`)
+	}, WithAdminToken("secret-admin-token"))
+
+	image, err := c.UploadUserAvatar(context.Background(), "u-1", "me.png", strings.NewReader("png bytes"))
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), attempts.Load(), "the form is sent again on retry")
+	assert.Equal(t, "http://media/a.png", image.URL)
+	assert.Equal(t, []UploadedThumbnail{{Size: 64, URL: "http://media/a_64.png"}}, image.Thumbnails)
+}
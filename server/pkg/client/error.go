@@ -0,0 +1,135 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error is a non-2xx API response. The client asks for RFC 7807 problem
+// details, so Code is a stable error code such as "validation_failed" that
+// is safe to branch on. Servers that answer with the older
+// {"error": {"code", "message", "details"}} envelope fill Code with the
+// status text instead. When the body is in neither shape Message holds the
+// raw body.
+type Error struct {
+	StatusCode int
+	Code       string
+	Title      string
+	Message    string
+	// Details holds per-field messages of a 422 response, keyed by the
+	// field path, e.g. "pagination.page".
+	Details map[string]string
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("mayobox: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	for field, detail := range e.Details {
+		msg += fmt.Sprintf("; %s: %s", field, detail)
+	}
+	return msg
+}
+
+// IsStatus reports whether err is an *Error with the given status code.
+func IsStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// IsCode reports whether err is an *Error with the given error code.
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound)
+}
+
+// IsValidation reports whether the request failed input validation. The
+// per-field messages are in (*Error).Details.
+func IsValidation(err error) bool {
+	return IsStatus(err, http.StatusUnprocessableEntity)
+}
+
+func IsUnauthorized(err error) bool {
+	return IsStatus(err, http.StatusUnauthorized) || IsStatus(err, http.StatusForbidden)
+}
+
+func IsRateLimited(err error) bool {
+	return IsStatus(err, http.StatusTooManyRequests)
+}
+
+func decodeError(res *http.Response) *Error {
+	apiErr := &Error{
+		StatusCode: res.StatusCode,
+		Code:       http.StatusText(res.StatusCode),
+		RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType == mimeProblemJSON {
+		var problem struct {
+			Code    string          `json:"code"`
+			Title   string          `json:"title"`
+			Detail  string          `json:"detail"`
+			Details json.RawMessage `json:"details"`
+		}
+		if err := json.Unmarshal(raw, &problem); err == nil && problem.Code != "" {
+			apiErr.Code = problem.Code
+			apiErr.Title = problem.Title
+			apiErr.Message = problem.Detail
+			decodeDetails(problem.Details, apiErr)
+			return apiErr
+		}
+	}
+
+	var body struct {
+		Error *struct {
+			Code    string          `json:"code"`
+			Message string          `json:"message"`
+			Details json.RawMessage `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil || body.Error == nil {
+		apiErr.Message = string(raw)
+		return apiErr
+	}
+
+	if body.Error.Code != "" {
+		apiErr.Code = body.Error.Code
+	}
+	apiErr.Message = body.Error.Message
+	decodeDetails(body.Error.Details, apiErr)
+	return apiErr
+}
+
+// decodeDetails keeps details only when it is a field map, which is the
+// case for validation errors.
+func decodeDetails(raw json.RawMessage, apiErr *Error) {
+	if len(raw) > 0 {
+		json.Unmarshal(raw, &apiErr.Details)
+	}
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date values.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
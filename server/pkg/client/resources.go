@@ -0,0 +1,570 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) Healthcheck(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/"}, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+/* ------------------------- TESTIMONIES -------------------------- */
+
+// ListParams selects a page. Zero values use the server defaults (page 1,
+// 10 per page).
+type ListParams struct {
+	Page     int
+	PageSize int
+}
+
+func (p ListParams) values() url.Values {
+	q := url.Values{}
+	if p.Page > 0 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+	if p.PageSize > 0 {
+		q.Set("page_size", strconv.Itoa(p.PageSize))
+	}
+	return q
+}
+
+type TestimoniPage struct {
+	Data     []Testimoni
+	Metadata Metadata
+}
+
+func (c *Client) ListTestimonies(ctx context.Context, params ListParams) (*TestimoniPage, error) {
+	var env envelope[[]Testimoni]
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/testimonies", query: params.values()}, &env)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TestimoniPage{Data: env.Data}
+	if env.Metadata != nil {
+		page.Metadata = *env.Metadata
+	}
+	return page, nil
+}
+
+// Testimonies iterates over every testimoni, fetching pageSize (0 for the
+// server default) per request. Iteration stops after the first error.
+func (c *Client) Testimonies(ctx context.Context, pageSize int) iter.Seq2[Testimoni, error] {
+	return paginate(func(page int) ([]Testimoni, Metadata, error) {
+		p, err := c.ListTestimonies(ctx, ListParams{Page: page, PageSize: pageSize})
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		return p.Data, p.Metadata, nil
+	})
+}
+
+// paginate walks pages from the first until the last page reported by the
+// metadata, or until a page comes back empty.
+func paginate[T any](fetch func(page int) ([]T, Metadata, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page := 1; ; page++ {
+			items, metadata, err := fetch(page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if len(items) == 0 || page >= metadata.LastPage {
+				return
+			}
+		}
+	}
+}
+
+/* ----------------------------- FAQS ----------------------------- */
+
+// ListFAQs returns every FAQ with its answers, both in display order.
+func (c *Client) ListFAQs(ctx context.Context) ([]FAQ, error) {
+	var env envelope[[]FAQ]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/faqs"}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+/* --------------------------- PRODUCTS --------------------------- */
+
+// FeaturedProducts returns today's Products of the Day by position.
+func (c *Client) FeaturedProducts(ctx context.Context) ([]FeaturedProduct, error) {
+	var env envelope[[]FeaturedProduct]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/products/featured"}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// BestSellers returns the products ranked by units delivered in window,
+// "7d", "30d" or "all". Zero values use the server defaults, 30d and 10
+// products.
+func (c *Client) BestSellers(ctx context.Context, window string, limit int) ([]BestSellerProduct, error) {
+	q := url.Values{}
+	if window != "" {
+		q.Set("window", window)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var env envelope[[]BestSellerProduct]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/products/best-sellers", query: q}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// ActiveFlashSales returns the live flash sales with the server time.
+func (c *Client) ActiveFlashSales(ctx context.Context) (*ActiveFlashSales, error) {
+	var env envelope[ActiveFlashSales]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/flash-sales/active"}, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+/* ---------------------------- ORDERS ---------------------------- */
+
+// RecentOrders returns the latest completed orders, newest first, with the
+// buyer masked. A zero limit uses the server default, 10.
+//
+// New orders are also pushed as Server-Sent Events by
+// /v1/orders/recent/stream, which is left to an EventSource client.
+func (c *Client) RecentOrders(ctx context.Context, limit int) ([]RecentOrder, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var env envelope[[]RecentOrder]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/orders/recent", query: q}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// CreateOrder places an order and creates the charge that pays it. Show
+// Payment.Instructions to the buyer and poll Order until the status leaves
+// pending_payment.
+func (c *Client) CreateOrder(ctx context.Context, order NewOrder) (*Order, error) {
+	var env envelope[Order]
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/v1/orders", body: order}, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// ValidateVoucher prices an order with a voucher for the checkout page.
+// Nothing is redeemed. A voucher that does not apply returns an error with
+// code invalid_voucher and the reason in its details.
+func (c *Client) ValidateVoucher(ctx context.Context, check VoucherCheck) (*VoucherQuote, error) {
+	var env envelope[VoucherQuote]
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/v1/vouchers/validate", body: check}, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// Order returns an order with its product and payment.
+func (c *Client) Order(ctx context.Context, id string) (*Order, error) {
+	var env envelope[Order]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/orders/" + url.PathEscape(id)}, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+/* --------------------------- PAYMENTS --------------------------- */
+
+type simulatedPayment struct {
+	Status string `json:"status"`
+}
+
+// SimulatePayment pays, expires or fails a charge ("paid", "expired" or
+// "failed") when the server runs the fake payment provider.
+func (c *Client) SimulatePayment(ctx context.Context, chargeID, status string) (*PaymentEvent, error) {
+	var env envelope[PaymentEvent]
+	r := request{method: http.MethodPost, path: "/v1/payments/simulator/" + url.PathEscape(chargeID), body: simulatedPayment{Status: status}}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+/* --------------------------- PRICING ---------------------------- */
+
+// Quote prices robux with the rate table in effect.
+func (c *Client) Quote(ctx context.Context, robux int) (*Quote, error) {
+	q := url.Values{}
+	q.Set("robux", strconv.Itoa(robux))
+
+	var env envelope[Quote]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/pricing/quote", query: q}, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+/* ---------------------------- ADMIN ----------------------------- */
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+// LogLevel returns the server log level. Requires WithAdminToken.
+func (c *Client) LogLevel(ctx context.Context) (string, error) {
+	var env envelope[logLevel]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/log-level", admin: true}, &env); err != nil {
+		return "", err
+	}
+	return env.Data.Level, nil
+}
+
+// SetLogLevel changes the server log level (debug, info, warn, error or
+// off) until the next restart or config reload. Requires WithAdminToken.
+func (c *Client) SetLogLevel(ctx context.Context, level string) (string, error) {
+	var env envelope[logLevel]
+	r := request{method: http.MethodPut, path: "/v1/admin/log-level", body: logLevel{Level: level}, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return "", err
+	}
+	return env.Data.Level, nil
+}
+
+// RecentLogs returns up to limit (0 for the server default) of the newest
+// log entries, oldest first. Requires WithAdminToken.
+func (c *Client) RecentLogs(ctx context.Context, limit int) ([]json.RawMessage, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var env envelope[[]json.RawMessage]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/logs", query: q, admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// FeaturedCalendar returns the editor picked Products of the Day between
+// from and to inclusive, both formatted as 2006-01-02. Empty bounds default
+// to today and 30 days ahead. Requires WithAdminToken.
+func (c *Client) FeaturedCalendar(ctx context.Context, from, to string) ([]FeaturedCalendarEntry, error) {
+	q := url.Values{}
+	if from != "" {
+		q.Set("from", from)
+	}
+	if to != "" {
+		q.Set("to", to)
+	}
+
+	var env envelope[[]FeaturedCalendarEntry]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/featured-calendar", query: q, admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+type featuredCalendarDay struct {
+	ProductIDs []string `json:"productIds"`
+}
+
+// SetFeaturedCalendar replaces the editor picks of date, formatted as
+// 2006-01-02. Requires WithAdminToken.
+func (c *Client) SetFeaturedCalendar(ctx context.Context, date string, productIDs []string) ([]FeaturedCalendarEntry, error) {
+	if productIDs == nil {
+		productIDs = []string{}
+	}
+
+	var env envelope[[]FeaturedCalendarEntry]
+	r := request{method: http.MethodPut, path: "/v1/admin/featured-calendar/" + url.PathEscape(date), body: featuredCalendarDay{ProductIDs: productIDs}, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// AdminOrders returns up to limit (0 for the server default, 50) orders in
+// status, or in any status when it is empty, those stuck the longest
+// first. Requires WithAdminToken.
+func (c *Client) AdminOrders(ctx context.Context, status string, limit int) ([]AdminOrder, error) {
+	q := url.Values{}
+	if status != "" {
+		q.Set("status", status)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var env envelope[[]AdminOrder]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/orders", query: q, admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// AdminOrder returns an order with its payment and status history.
+// Requires WithAdminToken.
+func (c *Client) AdminOrder(ctx context.Context, id string) (*AdminOrderDetail, error) {
+	var env envelope[AdminOrderDetail]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/orders/" + url.PathEscape(id), admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+type orderRetry struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// RetryOrder delivers a paid, stuck or failed order again, with a fresh set
+// of attempts. Requires WithAdminToken.
+func (c *Client) RetryOrder(ctx context.Context, id, reason string) (*AdminOrderDetail, error) {
+	var env envelope[AdminOrderDetail]
+	r := request{method: http.MethodPost, path: "/v1/admin/orders/" + url.PathEscape(id) + "/retry", body: orderRetry{Reason: reason}, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// ResolveOrder settles an order by hand. Refunds return the payment at the
+// provider first. Requires WithAdminToken.
+func (c *Client) ResolveOrder(ctx context.Context, id string, resolution OrderResolution) (*AdminOrderDetail, error) {
+	var env envelope[AdminOrderDetail]
+	r := request{method: http.MethodPost, path: "/v1/admin/orders/" + url.PathEscape(id) + "/resolve", body: resolution, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// DeadLetters returns up to limit (0 for the server default, 50) orders
+// whose delivery attempts ran out, newest first, the resolved ones too
+// when all is set. Requires WithAdminToken.
+func (c *Client) DeadLetters(ctx context.Context, all bool, limit int) ([]DeadLetter, error) {
+	q := url.Values{}
+	if all {
+		q.Set("all", "true")
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var env envelope[[]DeadLetter]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/dead-letters", query: q, admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// SupplierAccounts returns every supplier account by name. Requires
+// WithAdminToken.
+func (c *Client) SupplierAccounts(ctx context.Context) ([]SupplierAccount, error) {
+	var env envelope[[]SupplierAccount]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/supplier-accounts", admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// CreateSupplierAccount adds a supplier account. Requires WithAdminToken.
+func (c *Client) CreateSupplierAccount(ctx context.Context, account SupplierAccountChange) (*SupplierAccount, error) {
+	var env envelope[SupplierAccount]
+	r := request{method: http.MethodPost, path: "/v1/admin/supplier-accounts", body: account, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// UpdateSupplierAccount changes the fields set in change. Requires
+// WithAdminToken.
+func (c *Client) UpdateSupplierAccount(ctx context.Context, id string, change SupplierAccountChange) (*SupplierAccount, error) {
+	var env envelope[SupplierAccount]
+	r := request{method: http.MethodPut, path: "/v1/admin/supplier-accounts/" + url.PathEscape(id), body: change, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// Vouchers returns every voucher with its redemptions, newest first.
+// Requires WithAdminToken.
+func (c *Client) Vouchers(ctx context.Context) ([]Voucher, error) {
+	var env envelope[[]Voucher]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/vouchers", admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// CreateVoucher adds a voucher. Requires WithAdminToken.
+func (c *Client) CreateVoucher(ctx context.Context, voucher VoucherChange) (*Voucher, error) {
+	var env envelope[Voucher]
+	r := request{method: http.MethodPost, path: "/v1/admin/vouchers", body: voucher, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// UpdateVoucher replaces every field of a voucher. Requires
+// WithAdminToken.
+func (c *Client) UpdateVoucher(ctx context.Context, id string, voucher VoucherChange) (*Voucher, error) {
+	var env envelope[Voucher]
+	r := request{method: http.MethodPut, path: "/v1/admin/vouchers/" + url.PathEscape(id), body: voucher, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// FlashSales returns every flash sale with its items, the latest to start
+// first. Requires WithAdminToken.
+func (c *Client) FlashSales(ctx context.Context) ([]FlashSale, error) {
+	var env envelope[[]FlashSale]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/flash-sales", admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// CreateFlashSale adds a flash sale. Requires WithAdminToken.
+func (c *Client) CreateFlashSale(ctx context.Context, sale FlashSaleChange) (*FlashSale, error) {
+	var env envelope[FlashSale]
+	r := request{method: http.MethodPost, path: "/v1/admin/flash-sales", body: sale, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// UpdateFlashSale replaces every field of a flash sale. Its items keep the
+// units they sold. Requires WithAdminToken.
+func (c *Client) UpdateFlashSale(ctx context.Context, id string, sale FlashSaleChange) (*FlashSale, error) {
+	var env envelope[FlashSale]
+	r := request{method: http.MethodPut, path: "/v1/admin/flash-sales/" + url.PathEscape(id), body: sale, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// StockBalances returns the Robux of every supplier account by ledger
+// bucket. Requires WithAdminToken.
+func (c *Client) StockBalances(ctx context.Context) ([]StockBalance, error) {
+	var env envelope[[]StockBalance]
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/stock", admin: true}, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// StockLedger returns up to limit (0 for the server default, 100) Robux
+// movements of a supplier account, newest first. Requires WithAdminToken.
+func (c *Client) StockLedger(ctx context.Context, accountID string, limit int) ([]LedgerEntry, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var env envelope[[]LedgerEntry]
+	r := request{method: http.MethodGet, path: "/v1/admin/supplier-accounts/" + url.PathEscape(accountID) + "/ledger", query: q, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+// PurchaseStock records robux bought for a supplier account. Requires
+// WithAdminToken.
+func (c *Client) PurchaseStock(ctx context.Context, accountID string, robux int, note string) (*LedgerEntry, error) {
+	var env envelope[LedgerEntry]
+	r := request{method: http.MethodPost, path: "/v1/admin/supplier-accounts/" + url.PathEscape(accountID) + "/purchases", body: stockChange{Robux: robux, Note: note}, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// ReconcileStock records robux as the balance of a supplier account at
+// Roblox, adjusting the available Robux by the difference. Requires
+// WithAdminToken.
+func (c *Client) ReconcileStock(ctx context.Context, accountID string, robux int, note string) (*Reconciliation, error) {
+	var env envelope[Reconciliation]
+	r := request{method: http.MethodPost, path: "/v1/admin/supplier-accounts/" + url.PathEscape(accountID) + "/reconcile", body: stockChange{Robux: robux, Note: note}, admin: true}
+	if err := c.do(ctx, r, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
+
+// ExportTestimonies streams every testimoni to w as "json" or "csv", as
+// served by the admin export. Requires WithAdminToken.
+func (c *Client) ExportTestimonies(ctx context.Context, format string, w io.Writer) error {
+	q := url.Values{}
+	if format != "" {
+		q.Set("format", format)
+	}
+	return c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/exports/testimonies", query: q, admin: true}, w)
+}
+
+// UploadUserAvatar uploads a PNG, JPEG or GIF read from r as the avatar of
+// a user and returns the stored image. Requires WithAdminToken.
+func (c *Client) UploadUserAvatar(ctx context.Context, userID, filename string, r io.Reader) (*UploadedImage, error) {
+	return c.uploadImage(ctx, "/v1/admin/users/"+url.PathEscape(userID)+"/avatar", "avatar", filename, r)
+}
+
+// UploadTestimoniIcon uploads a PNG, JPEG or GIF read from r as the icon of
+// a testimoni and returns the stored image. Requires WithAdminToken.
+func (c *Client) UploadTestimoniIcon(ctx context.Context, testimoniID, filename string, r io.Reader) (*UploadedImage, error) {
+	return c.uploadImage(ctx, "/v1/admin/testimonies/"+url.PathEscape(testimoniID)+"/icon", "icon", filename, r)
+}
+
+func (c *Client) uploadImage(ctx context.Context, path, field, filename string, r io.Reader) (*UploadedImage, error) {
+	// The form is buffered so a retry can send it again.
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		return nil, fmt.Errorf("client: encode request: %w", err)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, fmt.Errorf("client: read %s: %w", filename, err)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("client: encode request: %w", err)
+	}
+
+	var env envelope[UploadedImage]
+	req := request{method: http.MethodPost, path: path, raw: buf.Bytes(), contentType: mw.FormDataContentType(), admin: true}
+	if err := c.do(ctx, req, &env); err != nil {
+		return nil, err
+	}
+	return &env.Data, nil
+}
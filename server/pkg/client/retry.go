@@ -0,0 +1,74 @@
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls retries of 429 and 503 responses, and of 409
+// responses to a duplicate that arrived while the first request with the
+// same Idempotency-Key was still running. 429 and 409 never processed the
+// request, so every method is retried. A 503 may come after the request
+// was processed, e.g. payment_unavailable after the order was stored as
+// failed, and the server does not keep 5xx responses for the
+// Idempotency-Key, so other methods than GET and HEAD are retried only
+// on the codes in unprocessedCodes.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt. Zero
+	// disables retries.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled on each
+	// following retry up to MaxBackoff. Half of it is randomised.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 10 * time.Second,
+}
+
+// next returns the delay before retrying the given failed attempt, or false
+// if it must not be retried. A Retry-After header wins over the backoff, but
+// is still capped at MaxBackoff.
+func (p RetryPolicy) next(attempt int, method string, err *Error) (time.Duration, bool) {
+	if attempt >= p.MaxRetries {
+		return 0, false
+	}
+	if !retryable(method, err) {
+		return 0, false
+	}
+
+	wait := err.RetryAfter
+	if wait == 0 {
+		wait = p.MinBackoff << attempt
+		if wait > 0 {
+			wait = wait/2 + rand.N(wait/2+1)
+		}
+	}
+	if p.MaxBackoff > 0 && (wait > p.MaxBackoff || wait < 0) {
+		wait = p.MaxBackoff
+	}
+	return wait, true
+}
+
+// unprocessedCodes are the 503 codes the server returns before it has
+// changed anything.
+var unprocessedCodes = map[string]bool{
+	"service_unavailable": true,
+	"refund_unavailable":  true,
+}
+
+func retryable(method string, err *Error) bool {
+	switch err.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		return method == http.MethodGet || method == http.MethodHead || unprocessedCodes[err.Code]
+	case http.StatusConflict:
+		return err.Code == "idempotency_request_in_progress"
+	}
+	return false
+}
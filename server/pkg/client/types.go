@@ -0,0 +1,496 @@
+package client
+
+import "time"
+
+// Metadata describes the page of a paginated response. It is zero when
+// the requested page has no records.
+type Metadata struct {
+	CurrentPage  int `json:"current_page"`
+	PageSize     int `json:"page_size"`
+	FirstPage    int `json:"first_page"`
+	LastPage     int `json:"last_page"`
+	TotalRecords int `json:"total_records"`
+}
+
+type Health struct {
+	Status     string `json:"status"`
+	SystemInfo struct {
+		Environment string `json:"environment"`
+		Version     string `json:"version"`
+	} `json:"system_info"`
+}
+
+type User struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	ImageURL    string    `json:"imageUrl"`
+	AddressLine string    `json:"addressLine,omitempty"`
+	City        string    `json:"city,omitempty"`
+	Province    string    `json:"province,omitempty"`
+	PostalCode  string    `json:"postalCode,omitempty"`
+	Country     string    `json:"country,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type Testimoni struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Testimoni string    `json:"testimoni"`
+	IconURL   string    `json:"iconUrl"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	User      User      `json:"user"`
+}
+
+type FAQ struct {
+	ID           string      `json:"id"`
+	Question     string      `json:"question"`
+	DisplayOrder int         `json:"displayOrder"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	UpdatedAt    time.Time   `json:"updatedAt"`
+	Answers      []FAQAnswer `json:"answers"`
+}
+
+type FAQAnswer struct {
+	ID           string    `json:"id"`
+	FAQID        string    `json:"faqId"`
+	Short        string    `json:"short"`
+	Long         string    `json:"long"`
+	DisplayOrder int       `json:"displayOrder"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// UploadedImage is a stored image with its square thumbnails.
+type UploadedImage struct {
+	URL        string              `json:"url"`
+	Width      int                 `json:"width"`
+	Height     int                 `json:"height"`
+	Thumbnails []UploadedThumbnail `json:"thumbnails"`
+}
+
+type UploadedThumbnail struct {
+	Size int    `json:"size"`
+	URL  string `json:"url"`
+}
+
+// Money is an amount of rupiah. Minor is in sen, 100 to the rupiah.
+type Money struct {
+	Minor   int64  `json:"minor"`
+	Display string `json:"display"`
+}
+
+type Quote struct {
+	Robux int `json:"robux"`
+	// GamepassPrice is the price the gamepass must be put up for so Robux
+	// are paid out after Roblox's 30% cut.
+	GamepassPrice int       `json:"gamepassPrice"`
+	IDRPerRobux   Money     `json:"idrPerRobux"`
+	Total         Money     `json:"total"`
+	RateTableID   string    `json:"rateTableId"`
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+}
+
+type Product struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Category       string    `json:"category"`
+	Robux          int       `json:"robux"`
+	IconURL        string    `json:"iconUrl"`
+	Active         bool      `json:"active"`
+	FeaturedWeight int       `json:"featuredWeight"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Price is a price in Robux and rupiah.
+type Price struct {
+	Robux int   `json:"robux"`
+	IDR   Money `json:"idr"`
+}
+
+// PricedProduct is a product with its price. InStock is false while not
+// enough Robux are in stock to sell one. FlashSale is set while the price
+// is a flash sale price.
+type PricedProduct struct {
+	Product
+	Price     Price           `json:"price"`
+	InStock   bool            `json:"inStock"`
+	FlashSale *FlashSalePrice `json:"flashSale"`
+}
+
+// FlashSalePrice tells where the price of a product on a flash sale comes
+// from. RegularIDR is the rate table price.
+type FlashSalePrice struct {
+	FlashSaleID string    `json:"flashSaleId"`
+	ItemID      string    `json:"itemId"`
+	RegularIDR  Money     `json:"regularIdr"`
+	Remaining   int       `json:"remaining"`
+	EndsAt      time.Time `json:"endsAt"`
+}
+
+// ActiveFlashSales are the live flash sales, the first to end first.
+// Count down to their end from ServerTime rather than the local clock.
+type ActiveFlashSales struct {
+	ServerTime time.Time         `json:"serverTime"`
+	Sales      []ActiveFlashSale `json:"sales"`
+}
+
+// ActiveFlashSale is a live flash sale with its products, the cheapest
+// first.
+type ActiveFlashSale struct {
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	StartsAt time.Time        `json:"startsAt"`
+	EndsAt   time.Time        `json:"endsAt"`
+	Items    []FlashSaleOffer `json:"items"`
+}
+
+// FlashSaleOffer is a product of a flash sale. It sells at PriceIDR while
+// Remaining is above zero, at RegularIDR after.
+type FlashSaleOffer struct {
+	ItemID     string  `json:"itemId"`
+	Product    Product `json:"product"`
+	PriceIDR   Money   `json:"priceIdr"`
+	RegularIDR Money   `json:"regularIdr"`
+	Quantity   int     `json:"quantity"`
+	Remaining  int     `json:"remaining"`
+	InStock    bool    `json:"inStock"`
+}
+
+// FeaturedProduct is a Product of the Day. Source is "calendar" when an
+// editor picked it and "random" otherwise.
+type FeaturedProduct struct {
+	Date     string        `json:"date"`
+	Position int           `json:"position"`
+	Source   string        `json:"source"`
+	Product  PricedProduct `json:"product"`
+}
+
+// BestSellerProduct is a product ranked by the units delivered in a window.
+type BestSellerProduct struct {
+	Rank    int           `json:"rank"`
+	Sold    int           `json:"sold"`
+	Product PricedProduct `json:"product"`
+}
+
+// RecentOrder is a completed order. Buyer is the masked Roblox username.
+type RecentOrder struct {
+	ID          string    `json:"id"`
+	Buyer       string    `json:"buyer"`
+	Quantity    int       `json:"quantity"`
+	Robux       int       `json:"robux"`
+	Total       Money     `json:"total"`
+	DeliveredAt time.Time `json:"deliveredAt"`
+	Product     Product   `json:"product"`
+}
+
+// NewOrder is an order to place. Quantity defaults to 1. PaymentMethod is
+// one of qris, va_bca, va_bni, va_bri, va_mandiri, ewallet_gopay,
+// ewallet_ovo, ewallet_dana or ewallet_shopeepay.
+type NewOrder struct {
+	ProductID      string `json:"productId"`
+	RobloxUsername string `json:"robloxUsername"`
+	Quantity       int    `json:"quantity,omitempty"`
+	PaymentMethod  string `json:"paymentMethod"`
+	VoucherCode    string `json:"voucherCode,omitempty"`
+}
+
+// Order is an order with its product and payment. Payment is nil when no
+// charge could be created.
+type Order struct {
+	ID             string  `json:"id"`
+	ProductID      string  `json:"productId"`
+	UserID         *string `json:"userId"`
+	RobloxUsername string  `json:"robloxUsername"`
+	Quantity       int     `json:"quantity"`
+	Robux          int     `json:"robux"`
+	SubtotalIDR    Money   `json:"subtotalIdr"`
+	DiscountIDR    Money   `json:"discountIdr"`
+	TotalIDR       Money   `json:"totalIdr"`
+	VoucherID      *string `json:"voucherId"`
+	// FlashSaleItemID is the flash sale item the order is priced at.
+	FlashSaleItemID *string    `json:"flashSaleItemId"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeliveredAt     *time.Time `json:"deliveredAt"`
+	Product         Product    `json:"product"`
+	Payment         *Payment   `json:"payment"`
+}
+
+// Payment is the charge the buyer pays an order with. Status is pending,
+// paid, expired, failed or refunded.
+type Payment struct {
+	ID           string              `json:"id"`
+	OrderID      string              `json:"orderId"`
+	Provider     string              `json:"provider"`
+	ChargeID     string              `json:"chargeId"`
+	Method       string              `json:"method"`
+	AmountIDR    Money               `json:"amountIdr"`
+	Status       string              `json:"status"`
+	Instructions PaymentInstructions `json:"instructions"`
+	ExpiresAt    time.Time           `json:"expiresAt"`
+	PaidAt       *time.Time          `json:"paidAt"`
+	CreatedAt    time.Time           `json:"createdAt"`
+	UpdatedAt    time.Time           `json:"updatedAt"`
+}
+
+// PaymentInstructions tell the buyer how to pay, depending on the method:
+// a QRIS string, a virtual account or an e-wallet checkout URL.
+type PaymentInstructions struct {
+	QRString    string `json:"qrString,omitempty"`
+	Bank        string `json:"bank,omitempty"`
+	VANumber    string `json:"vaNumber,omitempty"`
+	CheckoutURL string `json:"checkoutUrl,omitempty"`
+}
+
+// PaymentEvent acknowledges a payment status change. Applied is false when
+// the event was seen before.
+type PaymentEvent struct {
+	EventID string `json:"eventId"`
+	Applied bool   `json:"applied"`
+}
+
+// AdminOrder is an order with its delivery attempts. Status is one of
+// pending_payment, paid, delivering, delivered, failed or refunded.
+type AdminOrder struct {
+	ID             string        `json:"id"`
+	ProductID      string        `json:"productId"`
+	UserID         *string       `json:"userId"`
+	RobloxUsername string        `json:"robloxUsername"`
+	Quantity       int           `json:"quantity"`
+	Robux          int           `json:"robux"`
+	TotalIDR       Money         `json:"totalIdr"`
+	Status         string        `json:"status"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+	DeliveredAt    *time.Time    `json:"deliveredAt"`
+	Delivery       OrderDelivery `json:"delivery"`
+}
+
+// OrderDelivery tracks the attempts to deliver an order. NextAttemptAt is
+// nil when a paid order is tried at once.
+type OrderDelivery struct {
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt"`
+	LastError     *string    `json:"lastError"`
+	Reference     *string    `json:"reference"`
+	// SupplierAccountID is the account of the latest attempt.
+	SupplierAccountID *string `json:"supplierAccountId"`
+}
+
+// DeadLetter is an order whose delivery attempts ran out. ResolvedAt is
+// set once the order leaves failed.
+type DeadLetter struct {
+	ID                string     `json:"id"`
+	OrderID           string     `json:"orderId"`
+	SupplierAccountID *string    `json:"supplierAccountId"`
+	Attempts          int        `json:"attempts"`
+	Error             string     `json:"error"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	ResolvedAt        *time.Time `json:"resolvedAt"`
+}
+
+// SupplierAccount is a Roblox account Robux are delivered from, at most
+// MaxConcurrency deliveries at a time.
+type SupplierAccount struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	MaxConcurrency int       `json:"maxConcurrency"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// StockBalance is the Robux of a supplier account by ledger bucket.
+// OnHand, Available plus Reserved, is what the Roblox account should hold.
+type StockBalance struct {
+	SupplierAccountID string `json:"supplierAccountId"`
+	Name              string `json:"name"`
+	Active            bool   `json:"active"`
+	Purchased         int    `json:"purchased"`
+	Available         int    `json:"available"`
+	Reserved          int    `json:"reserved"`
+	Delivered         int    `json:"delivered"`
+	Adjusted          int    `json:"adjusted"`
+	OnHand            int    `json:"onHand"`
+}
+
+// LedgerEntry moves Amount Robux of a supplier account from the Credit
+// bucket to the Debit bucket. Kind is purchase, reservation, release,
+// consumption or adjustment.
+type LedgerEntry struct {
+	ID                string    `json:"id"`
+	SupplierAccountID string    `json:"supplierAccountId"`
+	Kind              string    `json:"kind"`
+	Debit             string    `json:"debit"`
+	Credit            string    `json:"credit"`
+	Amount            int       `json:"amount"`
+	OrderID           *string   `json:"orderId"`
+	Note              *string   `json:"note"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// Reconciliation compares the Robux a supplier account should hold with
+// its balance at Roblox. Entry is nil when they match.
+type Reconciliation struct {
+	Expected   int          `json:"expected"`
+	Actual     int          `json:"actual"`
+	Difference int          `json:"difference"`
+	Entry      *LedgerEntry `json:"entry"`
+	Balance    StockBalance `json:"balance"`
+}
+
+type stockChange struct {
+	Robux int    `json:"robux"`
+	Note  string `json:"note,omitempty"`
+}
+
+// SupplierAccountChange adds or updates a supplier account. Nil fields
+// keep their current value, or the server default (1, active) for a new
+// account.
+type SupplierAccountChange struct {
+	Name           string `json:"name,omitempty"`
+	MaxConcurrency *int   `json:"maxConcurrency,omitempty"`
+	Active         *bool  `json:"active,omitempty"`
+}
+
+// VoucherCheck asks for the price of an order with a voucher.
+// RobloxUsername, when set, checks the per-user limit of the buyer.
+type VoucherCheck struct {
+	Code           string `json:"code"`
+	ProductID      string `json:"productId"`
+	Quantity       int    `json:"quantity,omitempty"`
+	RobloxUsername string `json:"robloxUsername,omitempty"`
+}
+
+// VoucherQuote is the price of an order with a voucher applied.
+type VoucherQuote struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	SubtotalIDR Money  `json:"subtotalIdr"`
+	DiscountIDR Money  `json:"discountIdr"`
+	TotalIDR    Money  `json:"totalIdr"`
+}
+
+// Voucher is a promo code taking PercentOff percent, up to MaxDiscountIDR,
+// or AmountOffIDR off the subtotal of an order.
+type Voucher struct {
+	ID             string     `json:"id"`
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	PercentOff     *int       `json:"percentOff"`
+	AmountOffIDR   *Money     `json:"amountOffIdr"`
+	MaxDiscountIDR *Money     `json:"maxDiscountIdr"`
+	MinSpendIDR    Money      `json:"minSpendIdr"`
+	Category       *string    `json:"category"`
+	ProductIDs     []string   `json:"productIds"`
+	UsageLimit     *int       `json:"usageLimit"`
+	PerUserLimit   *int       `json:"perUserLimit"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	Active         bool       `json:"active"`
+	Redemptions    int        `json:"redemptions"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// VoucherChange adds or replaces a voucher. Set one of PercentOff and
+// AmountOffIDR; amounts are whole rupiah. Nil fields are cleared, and
+// Active defaults to true.
+type VoucherChange struct {
+	Code           string     `json:"code"`
+	Description    string     `json:"description,omitempty"`
+	PercentOff     *int       `json:"percentOff,omitempty"`
+	AmountOffIDR   *int64     `json:"amountOffIdr,omitempty"`
+	MaxDiscountIDR *int64     `json:"maxDiscountIdr,omitempty"`
+	MinSpendIDR    *int64     `json:"minSpendIdr,omitempty"`
+	Category       string     `json:"category,omitempty"`
+	ProductIDs     []string   `json:"productIds,omitempty"`
+	UsageLimit     *int       `json:"usageLimit,omitempty"`
+	PerUserLimit   *int       `json:"perUserLimit,omitempty"`
+	StartsAt       *time.Time `json:"startsAt,omitempty"`
+	EndsAt         *time.Time `json:"endsAt,omitempty"`
+	Active         *bool      `json:"active,omitempty"`
+}
+
+// FlashSale is a time-boxed campaign selling its items below their rate
+// table price, live from StartsAt until EndsAt while Active.
+type FlashSale struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	StartsAt  time.Time       `json:"startsAt"`
+	EndsAt    time.Time       `json:"endsAt"`
+	Active    bool            `json:"active"`
+	Items     []FlashSaleItem `json:"items"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// FlashSaleItem is a product of a flash sale, sold at PriceIDR a unit
+// until Quantity units are sold.
+type FlashSaleItem struct {
+	ID          string `json:"id"`
+	FlashSaleID string `json:"flashSaleId"`
+	ProductID   string `json:"productId"`
+	PriceIDR    Money  `json:"priceIdr"`
+	Quantity    int    `json:"quantity"`
+	Sold        int    `json:"sold"`
+}
+
+// FlashSaleChange adds or replaces a flash sale. StartsAt and EndsAt are
+// Asia/Jakarta times like 2026-10-20T12:00. Active defaults to true.
+type FlashSaleChange struct {
+	Name     string                `json:"name"`
+	StartsAt string                `json:"startsAt"`
+	EndsAt   string                `json:"endsAt"`
+	Items    []FlashSaleItemChange `json:"items"`
+	Active   *bool                 `json:"active,omitempty"`
+}
+
+// FlashSaleItemChange puts Quantity units of a product on a flash sale at
+// PriceIDR whole rupiah a unit.
+type FlashSaleItemChange struct {
+	ProductID string `json:"productId"`
+	PriceIDR  int64  `json:"priceIdr"`
+	Quantity  int    `json:"quantity"`
+}
+
+// AdminOrderDetail is an order with its payment, its status history,
+// oldest first, and the statuses an admin may move it to.
+type AdminOrderDetail struct {
+	AdminOrder
+	Product     Product           `json:"product"`
+	Payment     *Payment          `json:"payment"`
+	Transitions []OrderTransition `json:"transitions"`
+	Next        []string          `json:"next"`
+}
+
+// OrderTransition is a change of status of an order. FromStatus is nil for
+// its creation. Actor is customer, payment, system or admin.
+type OrderTransition struct {
+	ID         string    `json:"id"`
+	OrderID    string    `json:"orderId"`
+	FromStatus *string   `json:"fromStatus"`
+	ToStatus   string    `json:"toStatus"`
+	Actor      string    `json:"actor"`
+	Reason     *string   `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// OrderResolution settles an order by hand. Status is delivered, failed or
+// refunded; Reference identifies a delivery made outside the API.
+type OrderResolution struct {
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference,omitempty"`
+}
+
+type FeaturedCalendarEntry struct {
+	Date      string    `json:"date"`
+	Position  int       `json:"position"`
+	ProductID string    `json:"productId"`
+	CreatedAt time.Time `json:"createdAt"`
+}